@@ -2,13 +2,21 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	App "github.com/axetroy/go-server"
+	"github.com/axetroy/go-server/core/controller/finance"
 	"github.com/axetroy/go-server/core/helper/daemon"
+	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/server/admin_server"
+	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/util"
+	"github.com/fatih/color"
 	"github.com/urfave/cli"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -41,6 +49,106 @@ func main() {
 				return daemon.Stop()
 			},
 		},
+		{
+			Name:  "verify-finance",
+			Usage: "verify the hash chain of user's finance logs",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "uid, u",
+					Usage: "the user id to verify",
+				},
+				cli.StringFlag{
+					Name:  "currency, c",
+					Usage: "the currency to verify, verify all currencies if not specified",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				uid := c.String("uid")
+
+				if uid == "" {
+					return errors.New("require flag --uid")
+				}
+
+				currencies := model.Wallets
+
+				if c.String("currency") != "" {
+					currency := strings.ToUpper(c.String("currency"))
+
+					// 流水表不存在时校验结果为空的链, 拼错的币种会被当作校验通过
+					if _, ok := model.FinanceLogMap[strings.ToLower(currency)]; !ok {
+						return fmt.Errorf("unknown currency %s, expect one of %s", currency, strings.Join(model.Wallets, ", "))
+					}
+
+					currencies = []string{currency}
+				}
+
+				broken := false
+
+				for _, currency := range currencies {
+					chain, err := finance.VerifyChain(database.Db, uid, currency)
+
+					if err != nil {
+						return err
+					}
+
+					if chain.Valid {
+						fmt.Printf("%s %s: %d logs\n", color.GreenString("[OK]"), chain.Currency, chain.Total)
+					} else {
+						broken = true
+						fmt.Printf("%s %s: %d logs, first broken link at #%d (%s) %s\n", color.RedString("[BROKEN]"), chain.Currency, chain.Total, chain.Broken.Index, chain.Broken.Id, chain.Broken.Reason)
+					}
+				}
+
+				if broken {
+					return errors.New("finance hash chain is broken")
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:  "finance-report",
+			Usage: "generate the daily finance report",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "date",
+					Usage: "the date of report, format 2006-01-02, default yesterday",
+				},
+				cli.BoolFlag{
+					Name:  "email, e",
+					Usage: "send the report to the subscribed admins",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				date := time.Now().AddDate(0, 0, -1)
+
+				if c.String("date") != "" {
+					d, err := time.ParseInLocation(finance.ReportDateFormat, c.String("date"), time.Local)
+
+					if err != nil {
+						return err
+					}
+
+					date = d
+				}
+
+				reports, err := finance.GenerateDailyReport(database.Db, date)
+
+				if err != nil {
+					return err
+				}
+
+				for _, report := range reports {
+					fmt.Printf("%s %s: balance %s, frozen %s, %d transfers\n", report.Date, report.Currency, util.FloatToStr(report.TotalBalance), util.FloatToStr(report.TotalFrozen), report.TransferCount)
+				}
+
+				if c.Bool("email") {
+					return finance.SendDailyReport(database.Db, date, reports)
+				}
+
+				return nil
+			},
+		},
 		{
 			Name:  "env",
 			Usage: "print runtime environment",
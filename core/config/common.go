@@ -14,6 +14,9 @@ type common struct {
 	Mode      string `json:"mode"`       // 运行模式, 开发模式还是生产模式
 	Signature string `json:"signature"`  // 签名密钥，主要用户签名数据
 	Exiting   bool   `json:"exiting"`    // 进程是否出于正在退出的状态，用户优雅的退出进程
+	Locale    string `json:"locale"`     // 默认的语言, 用于选择邮件和短信的模版
+	// 可信的反向代理的 IP 或者 CIDR, 只有来自这些地址的请求才会读取 X-Forwarded-For 中的客户端 IP
+	TrustedProxies []string `json:"trusted_proxies"`
 }
 
 var Common *common
@@ -23,4 +26,6 @@ func init() {
 	Common.Mode = dotenv.GetByDefault("GO_MOD", ModeProduction)
 	Common.MachineId = dotenv.GetByDefault("MACHINE_ID", "0")
 	Common.Signature = dotenv.GetByDefault("SIGNATURE_KEY", "signature key")
+	Common.Locale = dotenv.GetByDefault("LOCALE", "zh-CN")
+	Common.TrustedProxies = dotenv.GetStrArrayByDefault("TRUSTED_PROXIES", []string{})
 }
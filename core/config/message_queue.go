@@ -5,14 +5,22 @@ import (
 	"github.com/axetroy/go-server/core/service/dotenv"
 )
 
+const (
+	MessageQueueBackendNSQ    = "nsq"    // 使用 nsq
+	MessageQueueBackendRedis  = "redis"  // 使用 Redis Streams, 需要 Redis 5.0 以上
+	MessageQueueBackendMemory = "memory" // 保存在进程内, 只能由同一个进程消费, 用于测试和单进程部署
+)
+
 type messageQueue struct {
-	Host string `json:"host"`
-	Port string `json:"port"`
+	Backend string `json:"backend"` // 消息队列的实现
+	Host    string `json:"host"`
+	Port    string `json:"port"`
 }
 
 var MessageQueue messageQueue
 
 func init() {
+	MessageQueue.Backend = dotenv.GetByDefault("MSG_QUEUE_BACKEND", MessageQueueBackendNSQ)
 	MessageQueue.Host = dotenv.GetByDefault("MSG_QUEUE_SERVER", "127.0.0.1")
 	MessageQueue.Port = dotenv.GetByDefault("MSG_QUEUE_PORT", "4150")
 }
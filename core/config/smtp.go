@@ -10,21 +10,45 @@ type sender struct {
 	Email string `json:"email"`
 }
 
+const (
+	SMTPTransportSMTP   = "smtp"   // 通过 SMTP 服务器发送
+	SMTPTransportFile   = "file"   // 以 maildir 的格式保存到本地目录, 用于本地开发
+	SMTPTransportMemory = "memory" // 保存在内存中, 用于测试
+
+	SMTPSecurityTLS      = "tls"      // 直接建立 TLS 连接, 通常是 465 端口
+	SMTPSecuritySTARTTLS = "starttls" // 先建立明文连接, 再通过 STARTTLS 升级, 通常是 587 端口
+	SMTPSecurityNone     = "none"     // 服务器支持 STARTTLS 时才加密, 只应该用于本地的 SMTP 服务器
+)
+
 type smtp struct {
-	Host     string `json:"host"`
-	Port     string `json:"port"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Sender   sender `json:"sender"`
+	Transport          string `json:"transport"`            // 发送邮件的方式
+	Host               string `json:"host"`                 // SMTP 服务器
+	Port               string `json:"port"`                 // SMTP 服务器的端口
+	Security           string `json:"security"`             // SMTP 连接的加密方式
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"` // 是否跳过证书校验, 只应该用于自签名证书的测试服务器
+	Username           string `json:"username"`             // SMTP 服务器的用户名
+	Password           string `json:"password"`             // SMTP 服务器的密码
+	Sender             sender `json:"sender"`               // 发送者
+	MailDir            string `json:"mail_dir"`             // file 方式保存邮件的目录
 }
 
 var SMTP smtp
 
 func init() {
+	SMTP.Transport = dotenv.GetByDefault("SMTP_TRANSPORT", SMTPTransportSMTP)
 	SMTP.Host = dotenv.Get("SMTP_SERVER")
 	SMTP.Port = dotenv.Get("SMTP_SERVER_PORT")
+	SMTP.InsecureSkipVerify = dotenv.GetBoolByDefault("SMTP_INSECURE_SKIP_VERIFY", false)
 	SMTP.Username = dotenv.Get("SMTP_USERNAME")
 	SMTP.Password = dotenv.Get("SMTP_PASSWORD")
 	SMTP.Sender.Name = dotenv.Get("SMTP_FROM_NAME")
 	SMTP.Sender.Email = dotenv.Get("SMTP_FROM_EMAIL")
+	SMTP.MailDir = dotenv.GetByDefault("SMTP_MAIL_DIR", "mail")
+
+	// 默认 465 端口使用 TLS, 其他端口使用 STARTTLS
+	if SMTP.Port == "465" {
+		SMTP.Security = dotenv.GetByDefault("SMTP_SECURITY", SMTPSecurityTLS)
+	} else {
+		SMTP.Security = dotenv.GetByDefault("SMTP_SECURITY", SMTPSecuritySTARTTLS)
+	}
 }
@@ -10,6 +10,7 @@ type aliyunCloud struct {
 	TemplateCodeAuth          string `json:"template_code_auth"`           // 短信模版代码 - 身份验证
 	TemplateCodeResetPassword string `json:"template_code_reset_password"` // 短信模版代码 - 重置密码
 	TemplateCodeRegister      string `json:"template_code_register"`       // 短信模版代码 - 注册帐号
+	TemplateCodeNotification  string `json:"template_code_notification"`   // 短信模版代码 - 通知
 }
 
 type tencentCloud struct {
@@ -19,10 +20,13 @@ type tencentCloud struct {
 	TemplateCodeAuth          string `json:"template_code_auth"`           // 短信模版代码 - 身份验证
 	TemplateCodeResetPassword string `json:"template_code_reset_password"` // 短信模版代码 - 重置密码
 	TemplateCodeRegister      string `json:"template_code_register"`       // 短信模版代码 - 注册帐号
+	TemplateCodeNotification  string `json:"template_code_notification"`   // 短信模版代码 - 通知
 }
 
 type telephone struct {
 	Provider string       `json:"provider"` // 选用哪家短信提供商
+	Cooldown int          `json:"cooldown"` // 同一个手机号两次发送验证码的最小间隔, 单位秒
+	IPLimit  int          `json:"ip_limit"` // 同一个 IP 每小时最多发送验证码的次数
 	Aliyun   aliyunCloud  `json:"aliyun"`   // 阿里云服务商相关配置
 	Tencent  tencentCloud `json:"tencent"`  // 腾讯云服务商相关配置
 }
@@ -32,6 +36,8 @@ var Telephone telephone
 func init() {
 	Telephone = telephone{
 		Provider: dotenv.GetByDefault("TELEPHONE_PROVIDER", "aliyun"),
+		Cooldown: dotenv.GetIntByDefault("TELEPHONE_COOLDOWN", 60),
+		IPLimit:  dotenv.GetIntByDefault("TELEPHONE_IP_LIMIT", 20),
 		Aliyun: aliyunCloud{
 			AccessKeyId:               dotenv.Get("TELEPHONE_ALIYUN_ACCESS_KEY"),
 			AccessSecret:              dotenv.Get("TELEPHONE_ALIYUN_ACCESS_SECRET"),
@@ -39,6 +45,7 @@ func init() {
 			TemplateCodeAuth:          dotenv.Get("TELEPHONE_ALIYUN_TEMPLATE_CODE_AUTH"),
 			TemplateCodeResetPassword: dotenv.Get("TELEPHONE_ALIYUN_TEMPLATE_CODE_RESET_PASSWORD"),
 			TemplateCodeRegister:      dotenv.Get("TELEPHONE_ALIYUN_TEMPLATE_CODE_REGISTER"),
+			TemplateCodeNotification:  dotenv.Get("TELEPHONE_ALIYUN_TEMPLATE_CODE_NOTIFICATION"),
 		},
 		Tencent: tencentCloud{
 			AppId:                     dotenv.Get("TELEPHONE_TENCENT_APP_ID"),
@@ -47,6 +54,7 @@ func init() {
 			TemplateCodeAuth:          dotenv.Get("TELEPHONE_TENCENT_TEMPLATE_CODE_AUTH"),
 			TemplateCodeResetPassword: dotenv.Get("TELEPHONE_TENCENT_TEMPLATE_CODE_RESET_PASSWORD"),
 			TemplateCodeRegister:      dotenv.Get("TELEPHONE_TENCENT_TEMPLATE_CODE_REGISTER"),
+			TemplateCodeNotification:  dotenv.Get("TELEPHONE_TENCENT_TEMPLATE_CODE_NOTIFICATION"),
 		},
 	}
 }
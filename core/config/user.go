@@ -15,6 +15,8 @@ type user struct {
 	Port   string `json:"port"`   // 用户端 API 监听的端口
 	Secret string `json:"secret"` // 用户端密钥，用于加密/解密 token
 	TLS    *TLS   `json:"tls"`
+	// 文章的公开地址, {id} 会被替换为文章 ID, 例如 https://example.com/news/{id}
+	ArticleURL string `json:"article_url"`
 }
 
 var User user
@@ -23,6 +25,7 @@ func init() {
 	User.Port = dotenv.GetByDefault("USER_HTTP_PORT", "8080")
 	User.Domain = dotenv.GetByDefault("USER_HTTP_DOMAIN", "localhost")
 	User.Secret = dotenv.GetByDefault("USER_TOKEN_SECRET_KEY", "user")
+	User.ArticleURL = dotenv.GetByDefault("USER_ARTICLE_URL", "")
 
 	TlsCert := dotenv.GetByDefault("USER_TLS_CERT", "")
 	TlsKey := dotenv.GetByDefault("USER_TLS_KEY", "")
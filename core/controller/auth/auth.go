@@ -6,7 +6,7 @@ import (
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/schema"
-	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/dispatcher"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/telephone"
 	"github.com/axetroy/go-server/core/util"
@@ -62,10 +62,8 @@ func SendEmailAuthCode(c controller.Context, input SendEmailAuthCodeParams) (res
 		return
 	}
 
-	e := email.NewMailer()
-
 	// send email
-	if err = e.SendAuthEmail(input.Email, activationCode); err != nil {
+	if err = dispatcher.SendEmailCode(input.Email, dispatcher.CodeAuth, activationCode); err != nil {
 		// 邮件没发出去的话，删除redis的key
 		_ = redis.ClientAuthEmailCode.Del(activationCode).Err()
 		return
@@ -100,6 +98,11 @@ func SendPhoneAuthCode(c controller.Context, input SendPhoneAuthCodeParams) (res
 		return
 	}
 
+	// 检查发送频率
+	if err = telephone.Throttle(input.Phone, c.Ip); err != nil {
+		return
+	}
+
 	// 生成验证码
 	activationCode := GenerateAuthCode()
 
@@ -108,9 +111,10 @@ func SendPhoneAuthCode(c controller.Context, input SendPhoneAuthCodeParams) (res
 		return
 	}
 
-	if err = telephone.GetClient().SendAuthCode(input.Phone, activationCode); err != nil {
+	if err = dispatcher.SendSMSCode(input.Phone, dispatcher.CodeAuth, activationCode); err != nil {
 		// 如果发送失败，则删除
 		_ = redis.ClientAuthPhoneCode.Del(activationCode).Err()
+		telephone.ResetThrottle(input.Phone)
 		return
 	}
 
@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
@@ -124,9 +125,10 @@ func BindingEmail(c controller.Context, input BindingEmailParams) (res schema.Re
 // 绑定手机号
 func BindingPhone(c controller.Context, input BindingPhoneParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err    error
+		data   = &schema.ProfileWithToken{}
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -149,6 +151,10 @@ func BindingPhone(c controller.Context, input BindingPhoneParams) (res schema.Re
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -209,6 +215,10 @@ func BindingPhone(c controller.Context, input BindingPhoneParams) (res schema.Re
 		return
 	}
 
+	if err = events.Publish(tx, event.PhoneBound{Uid: c.Uid, Phone: input.Phone}); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -3,6 +3,7 @@ package auth
 
 import (
 	"errors"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
@@ -23,9 +24,10 @@ type ResetPasswordParams struct {
 
 func ResetPassword(input ResetPasswordParams) (res schema.Response) {
 	var (
-		err error
-		tx  *gorm.DB
-		uid string // 重置码对应的uid
+		err    error
+		tx     *gorm.DB
+		uid    string // 重置码对应的uid
+		events event.Batch
 	)
 
 	defer func() {
@@ -48,6 +50,10 @@ func ResetPassword(input ResetPasswordParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -75,6 +81,10 @@ func ResetPassword(input ResetPasswordParams) (res schema.Response) {
 	// 更新密码
 	tx.Model(&userInfo).Update("password", util.GeneratePassword(input.NewPassword))
 
+	if err = events.Publish(tx, event.PasswordChanged{Uid: uid, Source: event.PasswordChangedByReset}); err != nil {
+		return
+	}
+
 	// delete reset code from redis
 	if err = redis.ClientResetCode.Del(input.Code).Err(); err != nil {
 		return
@@ -4,7 +4,7 @@ package auth
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
-	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
@@ -352,9 +352,10 @@ func SignInWithPhone(c controller.Context, input SignInWithPhoneParams) (res sch
 // 使用微信小程序登陆
 func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res schema.Response) {
 	var (
-		err  error
-		data = &schema.ProfileWithToken{}
-		tx   *gorm.DB
+		err    error
+		data   = &schema.ProfileWithToken{}
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -377,6 +378,10 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -420,7 +425,7 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 			UsernameRenameRemaining: 1, // 允许微信注册的用户可以重命名一次
 		}
 
-		if err = tx.Create(userInfo).Error; err != nil {
+		if err = CreateUserTx(tx, &events, userInfo, nil); err != nil {
 			return
 		}
 
@@ -430,19 +435,6 @@ func SignInWithWechat(c controller.Context, input SignInWithWechatParams) (res s
 		}).Error; err != nil {
 			return
 		}
-
-		// 创建用户对应的钱包账号
-		for _, walletName := range model.Wallets {
-			if err = tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
-				Id:       userInfo.Id,
-				Currency: walletName,
-				Balance:  0,
-				Frozen:   0,
-			}).Error; err != nil {
-				return
-			}
-		}
-
 	} else {
 		userInfo = &wechatOpenID.User
 	}
@@ -4,13 +4,13 @@ package auth
 import (
 	"errors"
 	"fmt"
-	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/dispatcher"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/axetroy/go-server/core/validator"
@@ -46,8 +46,9 @@ type SignUpWithPhoneParams struct {
 	InviteCode *string `json:"invite_code"`                    // 邀请码
 }
 
-// 创建用户帐号，包括创建的邀请码，钱包数据等，继承到一起
-func CreateUserTx(tx *gorm.DB, userInfo *model.User, inviterCode *string) (err error) {
+// 创建用户帐号，并发布用户注册的事件, 邀请记录，钱包数据等由事件的订阅者在同一个事务中创建
+// 传入 tx 时, 调用者需要在事务提交之后调用 events.Commit()
+func CreateUserTx(tx *gorm.DB, events *event.Batch, userInfo *model.User, inviterCode *string) (err error) {
 	var (
 		newTx bool
 	)
@@ -56,6 +57,10 @@ func CreateUserTx(tx *gorm.DB, userInfo *model.User, inviterCode *string) (err e
 		newTx = true
 	}
 
+	if events == nil {
+		events = &event.Batch{}
+	}
+
 	defer func() {
 		if newTx {
 			if err != nil {
@@ -63,6 +68,10 @@ func CreateUserTx(tx *gorm.DB, userInfo *model.User, inviterCode *string) (err e
 			} else {
 				err = tx.Commit().Error
 			}
+
+			if err == nil {
+				events.Commit()
+			}
 		}
 	}()
 
@@ -70,56 +79,19 @@ func CreateUserTx(tx *gorm.DB, userInfo *model.User, inviterCode *string) (err e
 		return err
 	}
 
-	if inviterCode != nil && len(*inviterCode) > 0 {
-
-		inviter := model.User{
-			InviteCode: *inviterCode,
-		}
-
-		if err := tx.Where(&inviter).Find(&inviter).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				err = exception.InvalidInviteCode
-			}
-			return err
-		}
-
-		// 如果存在邀请者的话，写入邀请列表中
-		if inviter.Id != "" {
-			inviteHistory := model.InviteHistory{
-				Inviter:       inviter.Id,
-				Invitee:       userInfo.Id,
-				Status:        model.StatusInviteRegistered,
-				RewardSettled: false,
-			}
-
-			// 创建邀请记录
-			if err = tx.Create(&inviteHistory).Error; err != nil {
-				return err
-			}
-		}
-	}
-
-	// 创建用户对应的钱包账号
-	for _, walletName := range model.Wallets {
-		if err = tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
-			Id:       userInfo.Id,
-			Currency: walletName,
-			Balance:  0,
-			Frozen:   0,
-		}).Error; err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return events.Publish(tx, event.UserRegistered{
+		User:       *userInfo,
+		InviteCode: inviterCode,
+	})
 }
 
 // 使用用户名注册
 func SignUpWithUsername(input SignUpWithUsernameParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Profile
-		tx   *gorm.DB
+		err    error
+		data   schema.Profile
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -142,6 +114,10 @@ func SignUpWithUsername(input SignUpWithUsernameParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -180,7 +156,7 @@ func SignUpWithUsername(input SignUpWithUsernameParams) (res schema.Response) {
 		Gender:   model.GenderUnknown,
 	}
 
-	if err = CreateUserTx(tx, &userInfo, input.InviteCode); err != nil {
+	if err = CreateUserTx(tx, &events, &userInfo, input.InviteCode); err != nil {
 		return
 	}
 
@@ -198,9 +174,10 @@ func SignUpWithUsername(input SignUpWithUsernameParams) (res schema.Response) {
 // 使用邮箱注册
 func SignUpWithEmail(input SignUpWithEmailParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Profile
-		tx   *gorm.DB
+		err    error
+		data   schema.Profile
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -223,6 +200,10 @@ func SignUpWithEmail(input SignUpWithEmailParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -276,7 +257,7 @@ func SignUpWithEmail(input SignUpWithEmailParams) (res schema.Response) {
 		return
 	}
 
-	if err = CreateUserTx(tx, &userInfo, input.InviteCode); err != nil {
+	if err = CreateUserTx(tx, &events, &userInfo, input.InviteCode); err != nil {
 		return
 	}
 
@@ -341,12 +322,10 @@ func SignUpWithEmailAction(input SignUpWithEmailActionParams) (res schema.Respon
 		return
 	}
 
-	e := email.NewMailer()
-
 	link := fmt.Sprintf("%s?code=%s&email=%s", input.RedirectURL, code, input.Email)
 
 	// 发送邮件
-	if err = e.SendAuthEmail(input.Email, link); err != nil {
+	if err = dispatcher.SendEmailCode(input.Email, dispatcher.CodeRegister, link); err != nil {
 		return
 	}
 
@@ -356,9 +335,10 @@ func SignUpWithEmailAction(input SignUpWithEmailActionParams) (res schema.Respon
 // 使用手机注册
 func SignUpWithPhone(input SignUpWithPhoneParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Profile
-		tx   *gorm.DB
+		err    error
+		data   schema.Profile
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -381,6 +361,10 @@ func SignUpWithPhone(input SignUpWithPhoneParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -435,7 +419,7 @@ func SignUpWithPhone(input SignUpWithPhoneParams) (res schema.Response) {
 		return
 	}
 
-	if err = CreateUserTx(tx, &userInfo, input.InviteCode); err != nil {
+	if err = CreateUserTx(tx, &events, &userInfo, input.InviteCode); err != nil {
 		return
 	}
 
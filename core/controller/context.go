@@ -18,6 +18,6 @@ func NewContext(c *gin.Context) Context {
 	return Context{
 		Uid:       c.GetString(middleware.ContextUidField),
 		UserAgent: c.GetHeader("user-agent"),
-		Ip:        c.ClientIP(),
+		Ip:        middleware.ClientIP(c),
 	}
 }
@@ -0,0 +1,197 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package delivery
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+const ParamsIdName = "delivery_id"
+
+type Query struct {
+	schema.Query
+	Status    *model.DeliveryStatus `json:"status" form:"status"`
+	Channel   *string               `json:"channel" form:"channel"`
+	Kind      *string               `json:"kind" form:"kind"`
+	Recipient *string               `json:"recipient" form:"recipient"`
+}
+
+func DeleteDeliveryById(id string) {
+	database.DeleteRowByTable("delivery", "id", id)
+}
+
+func deliveryToSchema(d model.Delivery) (data schema.Delivery, err error) {
+	if err = mapstructure.Decode(d, &data.DeliveryPure); err != nil {
+		return
+	}
+
+	if d.SentAt != nil {
+		t := d.SentAt.Format(time.RFC3339Nano)
+		data.SentAt = &t
+	}
+
+	data.CreatedAt = d.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = d.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 获取发送记录详情
+func Get(id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Delivery
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	d := model.Delivery{Id: id}
+
+	if err = database.Db.First(&d).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.DeliveryNotExist
+		}
+		return
+	}
+
+	data, err = deliveryToSchema(d)
+
+	return
+}
+
+// 获取发送记录列表
+func GetList(input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.Delivery, 0)
+		list = make([]model.Delivery, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	filter := map[string]interface{}{}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	if input.Channel != nil {
+		filter["channel"] = *input.Channel
+	}
+
+	if input.Kind != nil {
+		filter["kind"] = *input.Kind
+	}
+
+	if input.Recipient != nil {
+		filter["recipient"] = *input.Recipient
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.Delivery{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.Delivery
+
+		if d, err = deliveryToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Get(c.Param(ParamsIdName))
+}
+
+func GetListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetList(input)
+}
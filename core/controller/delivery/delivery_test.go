@@ -0,0 +1,105 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package delivery_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/delivery"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func createDelivery(t *testing.T, recipient string) model.Delivery {
+	d := model.Delivery{
+		Kind:      string(dispatcher.KindEmailNotification),
+		Channel:   string(dispatcher.ChannelEmail),
+		Recipient: recipient,
+		Payload:   `{"title":"test","content":"test"}`,
+		Status:    model.DeliveryStatusDead,
+		Attempts:  dispatcher.MaxAttempts,
+		Error:     "timeout",
+	}
+
+	assert.Nil(t, database.Db.Create(&d).Error)
+
+	return d
+}
+
+func TestGet(t *testing.T) {
+	d := createDelivery(t, "delivery@example.com")
+
+	defer delivery.DeleteDeliveryById(d.Id)
+
+	r := delivery.Get(d.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	info := schema.Delivery{}
+
+	assert.Nil(t, tester.Decode(r.Data, &info))
+
+	assert.Equal(t, d.Recipient, info.Recipient)
+	assert.Equal(t, int(model.DeliveryStatusDead), info.Status)
+	assert.Equal(t, "timeout", info.Error)
+	assert.Nil(t, info.SentAt)
+
+	// 记录不存在
+	r2 := delivery.Get("123123")
+
+	assert.Equal(t, exception.DeliveryNotExist.Code(), r2.Status)
+}
+
+func TestGetList(t *testing.T) {
+	d := createDelivery(t, "delivery@example.com")
+
+	defer delivery.DeleteDeliveryById(d.Id)
+
+	recipient := d.Recipient
+	status := model.DeliveryStatusDead
+
+	r := delivery.GetList(delivery.Query{
+		Recipient: &recipient,
+		Status:    &status,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.Delivery, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	assert.Len(t, list, 1)
+	assert.Equal(t, d.Id, list[0].Id)
+}
+
+func TestGetListRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	d := createDelivery(t, "delivery@example.com")
+
+	defer delivery.DeleteDeliveryById(d.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/delivery?recipient="+d.Recipient, nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, int64(1), res.Meta.Total)
+}
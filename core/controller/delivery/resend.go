@@ -0,0 +1,75 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package delivery
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+// 重新发送, 重置尝试次数并加入消息队列, 不管之前是否发送成功
+func Resend(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Delivery
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	var d model.Delivery
+
+	if d, err = dispatcher.Resend(database.Db, id); err != nil {
+		return
+	}
+
+	data, err = deliveryToSchema(d)
+
+	return
+}
+
+func ResendRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Resend(controller.NewContext(c), c.Param(ParamsIdName))
+}
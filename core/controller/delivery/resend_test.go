@@ -0,0 +1,91 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package delivery_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/delivery"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestResend(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	d := createDelivery(t, "resend@example.com")
+
+	defer delivery.DeleteDeliveryById(d.Id)
+
+	// 重置为等待发送
+	{
+		r := delivery.Resend(controller.Context{Uid: adminInfo.Id}, d.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		info := schema.Delivery{}
+
+		assert.Nil(t, tester.Decode(r.Data, &info))
+
+		assert.Equal(t, int(model.DeliveryStatusPending), info.Status)
+		assert.Equal(t, 0, info.Attempts)
+	}
+
+	// 记录不存在
+	{
+		r := delivery.Resend(controller.Context{Uid: adminInfo.Id}, "123123")
+
+		assert.Equal(t, exception.DeliveryNotExist.Code(), r.Status)
+	}
+
+	// 验证码不能重新发送
+	{
+		code := model.Delivery{
+			Kind:      string(dispatcher.KindEmailCode),
+			Channel:   string(dispatcher.ChannelEmail),
+			Recipient: "resend@example.com",
+			Payload:   `{"type":"auth"}`,
+			Status:    model.DeliveryStatusDead,
+		}
+
+		assert.Nil(t, database.Db.Create(&code).Error)
+
+		defer delivery.DeleteDeliveryById(code.Id)
+
+		r := delivery.Resend(controller.Context{Uid: adminInfo.Id}, code.Id)
+
+		assert.Equal(t, exception.DeliveryCodeNotResendable.Error(), r.Message)
+	}
+}
+
+func TestResendRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	d := createDelivery(t, "resend@example.com")
+
+	defer delivery.DeleteDeliveryById(d.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Put("/v1/delivery/d/"+d.Id+"/resend", nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+}
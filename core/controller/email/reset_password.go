@@ -9,7 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/captcha"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/dispatcher"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -71,10 +71,8 @@ func SendResetPasswordEmail(input SendResetPasswordEmailParams) (res schema.Resp
 		return
 	}
 
-	e := email.NewMailer()
-
 	// send email
-	if err = e.SendForgotPasswordEmail(input.Email, code); err != nil {
+	if err = dispatcher.SendEmailCode(input.Email, dispatcher.CodeResetPassword, code); err != nil {
 		// 邮件没发出去的话，删除redis的key
 		_ = redis.ClientResetCode.Del(code).Err()
 		return
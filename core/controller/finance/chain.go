@@ -0,0 +1,148 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/jinzhu/gorm"
+	"strings"
+)
+
+var (
+	ChainBrokenPrevHash = "prev_hash_mismatch" // 流水记录的上一条哈希与链中上一条流水的哈希不一致, 说明有流水被删除/插入/调换
+	ChainBrokenHash     = "hash_mismatch"      // 流水的内容与哈希不一致, 说明流水被修改过
+)
+
+// 计算一条流水的哈希
+func GenerateHash(log model.FinanceLog) (string, error) {
+	return log.GenerateHash()
+}
+
+// 写入一条流水, 并把它接到该用户该币种哈希链的末尾
+// 必须在事务中调用
+func CreateLog(tx *gorm.DB, log *model.FinanceLog) (err error) {
+	log.Currency = strings.ToUpper(log.Currency)
+
+	tableName := GetTableName(log.Currency)
+
+	// 如果财务日志表不存在的话, 那么就生成这个表
+	if tx.HasTable(tableName) == false {
+		if err = tx.CreateTable(model.FinanceLogMap[strings.ToLower(log.Currency)]).Error; err != nil {
+			return
+		}
+	}
+
+	// 先锁住哈希链再读取链尾, 防止并发写入时哈希链分叉
+	if err = model.LockFinanceChain(tx, log.Currency, log.Uid); err != nil {
+		return
+	}
+
+	last := model.FinanceLog{}
+
+	// 软删除的流水也属于链的一部分
+	// 创建时间来自各个进程的时钟, 不能保证递增, 所以按照序号找到链尾
+	if err = tx.Unscoped().Table(tableName).Where("uid = ?", log.Uid).Order("seq DESC").Order("created_at DESC").Order("id DESC").First(&last).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return
+		}
+		err = nil
+	}
+
+	log.PrevHash = last.Hash
+	log.Seq = last.Seq + 1
+
+	if err = tx.Table(tableName).Create(log).Error; err != nil {
+		return
+	}
+
+	// 流水 ID 在写入时才生成, 所以哈希在写入之后计算
+	if log.Hash, err = GenerateHash(*log); err != nil {
+		return
+	}
+
+	if err = tx.Table(tableName).Where("id = ?", log.Id).UpdateColumn("hash", log.Hash).Error; err != nil {
+		return
+	}
+
+	return
+}
+
+// 按顺序遍历某个用户某个币种的流水, 校验哈希链是否完整
+// 如果链断开了, 则返回第一个断开的位置
+func VerifyChain(db *gorm.DB, uid string, currency string) (result schema.FinanceChain, err error) {
+	result = schema.FinanceChain{
+		Uid:      uid,
+		Currency: strings.ToUpper(currency),
+		Valid:    true,
+	}
+
+	tableName := GetTableName(currency)
+
+	if db.HasTable(tableName) == false {
+		return
+	}
+
+	// 软删除的流水也属于链的一部分, 所以直接查表
+	rows, err := db.Table(tableName).Where("uid = ?", uid).Order("seq ASC").Order("created_at ASC").Order("id ASC").Rows()
+
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	prevHash := ""
+
+	for rows.Next() {
+		log := model.FinanceLog{}
+
+		if err = db.ScanRows(rows, &log); err != nil {
+			return
+		}
+
+		index := result.Total
+
+		result.Total = result.Total + 1
+
+		if result.Broken != nil {
+			continue
+		}
+
+		if log.PrevHash != prevHash {
+			result.Valid = false
+			result.Broken = &schema.FinanceChainBroken{
+				Id:     log.Id,
+				Index:  index,
+				Reason: ChainBrokenPrevHash,
+				Expect: prevHash,
+				Actual: log.PrevHash,
+			}
+			continue
+		}
+
+		hash, er := GenerateHash(log)
+
+		if er != nil {
+			err = er
+			return
+		}
+
+		if hash != log.Hash {
+			result.Valid = false
+			result.Broken = &schema.FinanceChainBroken{
+				Id:     log.Id,
+				Index:  index,
+				Reason: ChainBrokenHash,
+				Expect: hash,
+				Actual: log.Hash,
+			}
+			continue
+		}
+
+		prevHash = log.Hash
+	}
+
+	err = rows.Err()
+
+	return
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/controller/transfer"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func transferTo(t *testing.T, from string, to string, amount string) {
+	input := transfer.ToParams{
+		Currency: model.WalletCNY,
+		To:       to,
+		Amount:   amount,
+	}
+
+	b, err := json.Marshal(input)
+
+	assert.Nil(t, err)
+
+	signature, err := util.Signature(string(b))
+
+	assert.Nil(t, err)
+
+	r := transfer.To(controller.Context{Uid: from}, input, signature)
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+}
+
+func TestGenerateHash(t *testing.T) {
+	log := model.FinanceLog{
+		Id:              "123",
+		Currency:        model.WalletCNY,
+		OrderId:         "456",
+		Uid:             "789",
+		BeforeBalance:   100,
+		BalanceMutation: -20,
+		AfterBalance:    80,
+		Type:            model.FinanceTypeTransferOut,
+	}
+
+	hash1, err := finance.GenerateHash(log)
+
+	assert.Nil(t, err)
+	assert.Len(t, hash1, 64)
+
+	// 币种不区分大小写
+	log.Currency = "cny"
+
+	hash2, err := finance.GenerateHash(log)
+
+	assert.Nil(t, err)
+	assert.Equal(t, hash1, hash2)
+
+	// 上一条哈希不同，则哈希不同
+	log.PrevHash = hash1
+
+	hash3, err := finance.GenerateHash(log)
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash1, hash3)
+}
+
+func TestVerifyChain(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+	transferTo(t, userFrom.Id, userTo.Id, "10")
+	transferTo(t, userFrom.Id, userTo.Id, "5")
+
+	// 完整的链
+	{
+		chain, err := finance.VerifyChain(database.Db, userFrom.Id, model.WalletCNY)
+
+		assert.Nil(t, err)
+		assert.True(t, chain.Valid)
+		assert.Equal(t, 3, chain.Total)
+		assert.Nil(t, chain.Broken)
+	}
+
+	logs := make([]model.FinanceLog, 0)
+
+	assert.Nil(t, database.Db.Table(finance.GetTableName(model.WalletCNY)).Where("uid = ?", userFrom.Id).Order("seq ASC").Find(&logs).Error)
+	assert.Len(t, logs, 3)
+
+	assert.Equal(t, "", logs[0].PrevHash)
+	assert.Equal(t, logs[0].Hash, logs[1].PrevHash)
+	assert.Equal(t, logs[1].Hash, logs[2].PrevHash)
+
+	for i, log := range logs {
+		assert.Equal(t, int64(i+1), log.Seq)
+	}
+
+	// 写入最后一条流水的进程时钟偏慢, 创建时间早于之前的流水, 链的顺序不受影响
+	assert.Nil(t, database.Db.Table(finance.GetTableName(model.WalletCNY)).Where("id = ?", logs[2].Id).UpdateColumn("created_at", logs[0].CreatedAt.Add(-time.Hour)).Error)
+
+	{
+		chain, err := finance.VerifyChain(database.Db, userFrom.Id, model.WalletCNY)
+
+		assert.Nil(t, err)
+		assert.True(t, chain.Valid)
+	}
+
+	// 篡改第二条流水
+	assert.Nil(t, database.Db.Table(finance.GetTableName(model.WalletCNY)).Where("id = ?", logs[1].Id).UpdateColumn("after_balance", 1000).Error)
+
+	{
+		chain, err := finance.VerifyChain(database.Db, userFrom.Id, model.WalletCNY)
+
+		assert.Nil(t, err)
+		assert.False(t, chain.Valid)
+		assert.Equal(t, 3, chain.Total)
+		assert.NotNil(t, chain.Broken)
+		assert.Equal(t, logs[1].Id, chain.Broken.Id)
+		assert.Equal(t, 1, chain.Broken.Index)
+		assert.Equal(t, finance.ChainBrokenHash, chain.Broken.Reason)
+	}
+
+	// 删除第一条流水
+	assert.Nil(t, database.Db.Exec("DELETE FROM "+finance.GetTableName(model.WalletCNY)+" WHERE id = ?", logs[0].Id).Error)
+
+	{
+		chain, err := finance.VerifyChain(database.Db, userFrom.Id, model.WalletCNY)
+
+		assert.Nil(t, err)
+		assert.False(t, chain.Valid)
+		assert.Equal(t, 2, chain.Total)
+		assert.Equal(t, logs[1].Id, chain.Broken.Id)
+		assert.Equal(t, 0, chain.Broken.Index)
+		assert.Equal(t, finance.ChainBrokenPrevHash, chain.Broken.Reason)
+	}
+
+	// 收款人的链不受影响
+	{
+		chain, err := finance.VerifyChain(database.Db, userTo.Id, model.WalletCNY)
+
+		assert.Nil(t, err)
+		assert.True(t, chain.Valid)
+		assert.Equal(t, 3, chain.Total)
+	}
+}
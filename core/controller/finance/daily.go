@@ -0,0 +1,168 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"html"
+	"strings"
+	"time"
+)
+
+var (
+	ReportDateFormat = "2006-01-02" // 报表日期的格式
+	ReportTopMovers  = 10           // 报表中记录资金变动最大的用户数
+)
+
+// 生成某一天的财务报表, 每个币种一条, 重复生成会覆盖当天已有的报表
+// 钱包的余额/冻结为生成报表时的快照, 所以应该在第二天凌晨尽早生成
+func GenerateDailyReport(db *gorm.DB, date time.Time) (reports []model.FinanceReport, err error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.AddDate(0, 0, 1)
+
+	for _, currency := range model.Wallets {
+		var report model.FinanceReport
+
+		if report, err = generateCurrencyReport(db, currency, start, end); err != nil {
+			return
+		}
+
+		reports = append(reports, report)
+	}
+
+	return
+}
+
+func generateCurrencyReport(db *gorm.DB, currency string, start time.Time, end time.Time) (report model.FinanceReport, err error) {
+	suffix := `("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00')`
+
+	report = model.FinanceReport{
+		Date:     start.Format(ReportDateFormat),
+		Currency: currency,
+	}
+
+	walletTableName := wallet.GetTableName(currency)
+	transferTableName := "transfer_log_" + strings.ToLower(currency) // 转账模块依赖了流水模块, 这里不能反过来引用
+	financeTableName := GetTableName(currency)
+
+	// 钱包的余额快照
+	if err = db.Raw(fmt.Sprintf(`SELECT COALESCE(SUM("balance"), 0), COALESCE(SUM("frozen"), 0), COUNT(*) FROM "%s" WHERE %s`, walletTableName, suffix)).Row().Scan(&report.TotalBalance, &report.TotalFrozen, &report.WalletCount); err != nil {
+		return
+	}
+
+	// 当天新增的钱包
+	if err = db.Raw(fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE "created_at" >= ? AND "created_at" < ? AND %s`, walletTableName, suffix), start, end).Row().Scan(&report.NewWallets); err != nil {
+		return
+	}
+
+	// 当天已确认的转账
+	if err = db.Raw(fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM("amount"), 0) FROM "%s" WHERE "status" = ? AND "created_at" >= ? AND "created_at" < ? AND %s`, transferTableName, suffix), model.TransferStatusConfirmed, start, end).Row().Scan(&report.TransferCount, &report.TransferVolume); err != nil {
+		return
+	}
+
+	// 当天资金变动最大的用户
+	rows, err := db.Raw(fmt.Sprintf(`SELECT "uid", SUM("balance_mutation") AS "mutation", COUNT(*) FROM "%s" WHERE "created_at" >= ? AND "created_at" < ? AND %s GROUP BY "uid" ORDER BY ABS(SUM("balance_mutation")) DESC, "uid" ASC LIMIT ?`, financeTableName, suffix), start, end, ReportTopMovers).Rows()
+
+	if err != nil {
+		return
+	}
+
+	defer rows.Close()
+
+	movers := make([]schema.FinanceReportMover, 0)
+
+	for rows.Next() {
+		var (
+			mover    schema.FinanceReportMover
+			mutation float64
+		)
+
+		if err = rows.Scan(&mover.Uid, &mutation, &mover.Count); err != nil {
+			return
+		}
+
+		mover.Mutation = util.FloatToStr(mutation)
+
+		movers = append(movers, mover)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	var b []byte
+
+	if b, err = json.Marshal(movers); err != nil {
+		return
+	}
+
+	report.TopMovers = string(b)
+
+	// 当天的报表已存在则覆盖
+	exist := model.FinanceReport{}
+
+	if err = db.Where("date = ? AND currency = ?", report.Date, report.Currency).First(&exist).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return
+		}
+
+		err = db.Create(&report).Error
+
+		return
+	}
+
+	report.Id = exist.Id
+	report.CreatedAt = exist.CreatedAt
+
+	err = db.Save(&report).Error
+
+	return
+}
+
+// 把报表发送给订阅了报表的管理员
+func SendDailyReport(db *gorm.DB, date time.Time, reports []model.FinanceReport) (err error) {
+	subscriptions := make([]model.FinanceReportSubscription, 0)
+
+	if err = db.Find(&subscriptions).Error; err != nil {
+		return
+	}
+
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	to := make([]string, 0)
+
+	for _, subscription := range subscriptions {
+		to = append(to, subscription.Email)
+	}
+
+	content := bytes.NewBufferString("<table border=\"1\" cellspacing=\"0\" cellpadding=\"4\">")
+
+	content.WriteString("<tr><th>" + strings.Join(reportHeader, "</th><th>") + "</th></tr>")
+
+	for _, report := range reports {
+		var record []string
+
+		if record, err = reportRecord(report); err != nil {
+			return
+		}
+
+		for i, v := range record {
+			record[i] = html.EscapeString(v)
+		}
+
+		content.WriteString("<tr><td>" + strings.Join(record, "</td><td>") + "</td></tr>")
+	}
+
+	content.WriteString("</table>")
+
+	return dispatcher.SendFinanceReport(to, date.Format(ReportDateFormat), content.String())
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGenerateDailyReport(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	now := time.Now()
+
+	reports, err := finance.GenerateDailyReport(database.Db, now)
+
+	assert.Nil(t, err)
+	assert.Len(t, reports, len(model.Wallets))
+
+	for _, report := range reports {
+		assert.Equal(t, now.Format(finance.ReportDateFormat), report.Date)
+		assert.NotEmpty(t, report.Id)
+		assert.True(t, report.WalletCount >= 2)
+		assert.True(t, report.NewWallets >= 2)
+
+		if report.Currency == model.WalletCNY {
+			assert.True(t, report.TotalBalance >= 100)
+			assert.True(t, report.TransferCount >= 1)
+			assert.True(t, report.TransferVolume >= 20)
+			assert.NotEqual(t, "[]", report.TopMovers)
+		}
+	}
+
+	// 重复生成会覆盖当天的报表
+	reports2, err := finance.GenerateDailyReport(database.Db, now)
+
+	assert.Nil(t, err)
+
+	for i, report := range reports2 {
+		assert.Equal(t, reports[i].Id, report.Id)
+	}
+
+	var count int
+
+	assert.Nil(t, database.Db.Model(&model.FinanceReport{}).Where("date = ?", now.Format(finance.ReportDateFormat)).Count(&count).Error)
+	assert.Equal(t, len(model.Wallets), count)
+
+	// 没有数据的日期
+	reports3, err := finance.GenerateDailyReport(database.Db, now.AddDate(-10, 0, 0))
+
+	assert.Nil(t, err)
+
+	for _, report := range reports3 {
+		assert.Equal(t, int64(0), report.TransferCount)
+		assert.Equal(t, int64(0), report.NewWallets)
+		assert.Equal(t, "[]", report.TopMovers)
+	}
+
+	assert.Nil(t, database.Db.Where("date = ?", now.AddDate(-10, 0, 0).Format(finance.ReportDateFormat)).Delete(&model.FinanceReport{}).Error)
+}
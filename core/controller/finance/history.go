@@ -2,9 +2,30 @@
 package finance
 
 import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
 )
 
+type QueryAdmin struct {
+	schema.Query
+	Currency  *string            `json:"currency" form:"currency"`     // 币种
+	Type      *model.FinanceType `json:"type" form:"type"`             // 流水类型
+	OrderId   *string            `json:"order_id" form:"order_id"`     // 对应的订单ID
+	StartTime *string            `json:"start_time" form:"start_time"` // 开始时间, RFC3339 格式
+	EndTime   *string            `json:"end_time" form:"end_time"`     // 结束时间, RFC3339 格式
+}
+
 func GetHistory(c *gin.Context) {
 	//var (
 	//	err     error
@@ -167,3 +188,138 @@ func GetHistory(c *gin.Context) {
 //	}
 //	return vsm
 //}
+
+// 管理员获取某个用户的财务流水
+func GetHistoryByAdmin(c controller.Context, userId string, input QueryAdmin) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.FinanceLog, 0)
+		meta = &schema.Meta{}
+		list = make([]model.FinanceLog, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	userInfo := model.User{
+		Id: userId,
+	}
+
+	if err = database.Db.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	tables := GetTableNames()
+	conditions := []string{`"uid" = ?`}
+	args := []interface{}{userInfo.Id}
+
+	if input.Currency != nil && len(*input.Currency) > 0 {
+		if _, ok := model.FinanceLogMap[strings.ToLower(*input.Currency)]; !ok {
+			err = exception.InvalidWallet
+			return
+		}
+		tables = []string{GetTableName(*input.Currency)}
+	}
+
+	if input.Type != nil && len(*input.Type) > 0 {
+		conditions = append(conditions, `"type" = ?`)
+		args = append(args, *input.Type)
+	}
+
+	if input.OrderId != nil && len(*input.OrderId) > 0 {
+		conditions = append(conditions, `"order_id" = ?`)
+		args = append(args, *input.OrderId)
+	}
+
+	if input.StartTime != nil && len(*input.StartTime) > 0 {
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339, *input.StartTime); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+		conditions = append(conditions, `"created_at" >= ?`)
+		args = append(args, t)
+	}
+
+	if input.EndTime != nil && len(*input.EndTime) > 0 {
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339, *input.EndTime); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+		conditions = append(conditions, `"created_at" <= ?`)
+		args = append(args, t)
+	}
+
+	sql, values := helper.UnionSQL(tables, conditions, args)
+
+	var total int64
+
+	if err = database.Db.Raw("SELECT COUNT(*) FROM ("+sql+") AS t", values...).Count(&total).Error; err != nil {
+		return
+	}
+
+	listSQL := "SELECT * FROM (" + sql + ") AS t " + helper.OrderSQL(query, "balance_mutation", "created_at") + " LIMIT ? OFFSET ?"
+
+	if err = database.Db.Raw(listSQL, append(values, query.Limit, query.Limit*query.Page)...).Scan(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		d := schema.FinanceLog{}
+		mapToSchema(v, &d)
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetHistoryByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input QueryAdmin
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetHistoryByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("user_id"), input)
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestGetHistoryByAdmin(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+	transferTo(t, userFrom.Id, userTo.Id, "10")
+
+	// 获取所有币种的流水
+	{
+		r := finance.GetHistoryByAdmin(controller.Context{}, userFrom.Id, finance.QueryAdmin{})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, int64(2), r.Meta.Total)
+
+		list := make([]schema.FinanceLog, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 2)
+
+		// 默认按时间倒序
+		assert.Equal(t, util.FloatToStr(-10), list[0].BalanceMutation)
+		assert.Equal(t, util.FloatToStr(70), list[0].AfterBalance)
+		assert.Equal(t, list[1].Hash, list[0].PrevHash)
+
+		for _, b := range list {
+			assert.Equal(t, userFrom.Id, b.Uid)
+			assert.Equal(t, model.WalletCNY, b.Currency)
+			assert.Equal(t, model.FinanceTypeTransferOut, b.Type)
+		}
+	}
+
+	// 按类型筛选
+	{
+		financeType := model.FinanceTypeTransferIn
+
+		r := finance.GetHistoryByAdmin(controller.Context{}, userFrom.Id, finance.QueryAdmin{
+			Type: &financeType,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(0), r.Meta.Total)
+	}
+
+	// 无效的币种
+	{
+		currency := "abc"
+
+		r := finance.GetHistoryByAdmin(controller.Context{}, userFrom.Id, finance.QueryAdmin{
+			Currency: &currency,
+		})
+
+		assert.Equal(t, exception.InvalidWallet.Error(), r.Message)
+	}
+
+	// 无效的时间
+	{
+		startTime := "2019-01-01"
+
+		r := finance.GetHistoryByAdmin(controller.Context{}, userFrom.Id, finance.QueryAdmin{
+			StartTime: &startTime,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 用户不存在
+	{
+		r := finance.GetHistoryByAdmin(controller.Context{}, "123123", finance.QueryAdmin{})
+
+		assert.Equal(t, exception.UserNotExist.Error(), r.Message)
+	}
+}
+
+func TestGetHistoryByAdminRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/finance/u/"+userTo.Id+"?currency=CNY", nil, &header)
+
+	res := schema.List{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	list := make([]schema.FinanceLog, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &list))
+	assert.Len(t, list, 1)
+	assert.Equal(t, util.FloatToStr(20), list[0].BalanceMutation)
+	assert.Equal(t, model.FinanceTypeTransferIn, list[0].Type)
+}
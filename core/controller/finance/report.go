@@ -0,0 +1,277 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ReportDefaultDays = 30  // 默认获取最近 30 天的报表
+	ReportMaxDays     = 366 // 一次最多获取一年的报表
+
+	// 导出 CSV 的表头
+	reportHeader = []string{"日期", "币种", "可用余额", "冻结余额", "钱包总数", "新增钱包", "转账笔数", "转账总量", "资金变动最大的用户"}
+)
+
+type ReportQuery struct {
+	Currency  *string `json:"currency" form:"currency"`     // 币种
+	StartDate *string `json:"start_date" form:"start_date"` // 开始日期, 格式 2006-01-02
+	EndDate   *string `json:"end_date" form:"end_date"`     // 结束日期, 格式 2006-01-02
+}
+
+func reportToSchema(report model.FinanceReport) (d schema.FinanceReport, err error) {
+	d.Date = report.Date
+	d.Currency = report.Currency
+	d.TotalBalance = util.FloatToStr(report.TotalBalance)
+	d.TotalFrozen = util.FloatToStr(report.TotalFrozen)
+	d.WalletCount = report.WalletCount
+	d.NewWallets = report.NewWallets
+	d.TransferCount = report.TransferCount
+	d.TransferVolume = util.FloatToStr(report.TransferVolume)
+	d.TopMovers = make([]schema.FinanceReportMover, 0)
+
+	if len(report.TopMovers) > 0 {
+		if err = json.Unmarshal([]byte(report.TopMovers), &d.TopMovers); err != nil {
+			return
+		}
+	}
+
+	d.CreatedAt = report.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = report.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 报表的一行记录, 用于导出 CSV 和发送邮件
+func reportRecord(report model.FinanceReport) (record []string, err error) {
+	d, err := reportToSchema(report)
+
+	if err != nil {
+		return
+	}
+
+	movers := make([]string, 0)
+
+	for _, mover := range d.TopMovers {
+		movers = append(movers, mover.Uid+":"+mover.Mutation)
+	}
+
+	record = []string{
+		d.Date,
+		d.Currency,
+		d.TotalBalance,
+		d.TotalFrozen,
+		strconv.FormatInt(d.WalletCount, 10),
+		strconv.FormatInt(d.NewWallets, 10),
+		strconv.FormatInt(d.TransferCount, 10),
+		d.TransferVolume,
+		strings.Join(movers, " "),
+	}
+
+	return
+}
+
+func getReports(input ReportQuery) (list []model.FinanceReport, err error) {
+	list = make([]model.FinanceReport, 0)
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -ReportDefaultDays)
+
+	if input.StartDate != nil && len(*input.StartDate) > 0 {
+		if start, err = time.ParseInLocation(ReportDateFormat, *input.StartDate, time.Local); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+	}
+
+	if input.EndDate != nil && len(*input.EndDate) > 0 {
+		if end, err = time.ParseInLocation(ReportDateFormat, *input.EndDate, time.Local); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+	}
+
+	if end.Before(start) || end.Sub(start) > time.Duration(ReportMaxDays)*24*time.Hour {
+		err = exception.InvalidParams
+		return
+	}
+
+	query := database.Db.Where("date >= ? AND date <= ?", start.Format(ReportDateFormat), end.Format(ReportDateFormat))
+
+	if input.Currency != nil && len(*input.Currency) > 0 {
+		currency := strings.ToUpper(*input.Currency)
+
+		if _, ok := model.FinanceLogMap[strings.ToLower(currency)]; !ok {
+			err = exception.InvalidWallet
+			return
+		}
+
+		query = query.Where("currency = ?", currency)
+	}
+
+	err = query.Order("date ASC").Order("currency ASC").Find(&list).Error
+
+	return
+}
+
+// 获取每日财务报表的时间序列
+func GetReportList(c controller.Context, input ReportQuery) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.FinanceReport, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	list, err := getReports(input)
+
+	if err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.FinanceReport
+
+		if d, err = reportToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	return
+}
+
+// 把每日财务报表导出为 CSV
+func ExportReport(c controller.Context, input ReportQuery) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+	}()
+
+	list, err := getReports(input)
+
+	if err != nil {
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	// 写入 BOM, 防止 Excel 打开时中文乱码
+	buf.WriteString("\xEF\xBB\xBF")
+
+	w := csv.NewWriter(buf)
+
+	if err = w.Write(reportHeader); err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var record []string
+
+		if record, err = reportRecord(v); err != nil {
+			return
+		}
+
+		if err = w.Write(record); err != nil {
+			return
+		}
+	}
+
+	w.Flush()
+
+	if err = w.Error(); err != nil {
+		return
+	}
+
+	data = buf.Bytes()
+
+	return
+}
+
+func GetReportListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input ReportQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetReportList(controller.NewContext(c), input)
+}
+
+func ExportReportRouter(c *gin.Context) {
+	var (
+		err   error
+		data  []byte
+		input ReportQuery
+	)
+
+	defer func() {
+		if err != nil {
+			c.JSON(http.StatusOK, schema.Response{
+				Status:  exception.GetCodeFromError(err),
+				Message: err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=finance_report.csv")
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	data, err = ExportReport(controller.NewContext(c), input)
+}
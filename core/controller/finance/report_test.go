@@ -0,0 +1,167 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetReportList(t *testing.T) {
+	now := time.Now()
+
+	_, err := finance.GenerateDailyReport(database.Db, now)
+
+	assert.Nil(t, err)
+
+	// 默认获取最近的报表
+	{
+		r := finance.GetReportList(controller.Context{}, finance.ReportQuery{})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		list := make([]schema.FinanceReport, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.True(t, len(list) >= len(model.Wallets))
+
+		// 按日期正序排列
+		assert.Equal(t, now.Format(finance.ReportDateFormat), list[len(list)-1].Date)
+	}
+
+	// 按币种和日期筛选
+	{
+		currency := "cny"
+		date := now.Format(finance.ReportDateFormat)
+
+		r := finance.GetReportList(controller.Context{}, finance.ReportQuery{
+			Currency:  &currency,
+			StartDate: &date,
+			EndDate:   &date,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.FinanceReport, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, model.WalletCNY, list[0].Currency)
+		assert.Equal(t, date, list[0].Date)
+	}
+
+	// 无效的日期
+	{
+		startDate := "2019-10-01"
+		endDate := "2018-10-01"
+
+		r := finance.GetReportList(controller.Context{}, finance.ReportQuery{
+			StartDate: &startDate,
+			EndDate:   &endDate,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 无效的币种
+	{
+		currency := "abc"
+
+		r := finance.GetReportList(controller.Context{}, finance.ReportQuery{
+			Currency: &currency,
+		})
+
+		assert.Equal(t, exception.InvalidWallet.Error(), r.Message)
+	}
+}
+
+func TestExportReport(t *testing.T) {
+	now := time.Now()
+
+	_, err := finance.GenerateDailyReport(database.Db, now)
+
+	assert.Nil(t, err)
+
+	date := now.Format(finance.ReportDateFormat)
+
+	data, err := finance.ExportReport(controller.Context{}, finance.ReportQuery{
+		StartDate: &date,
+		EndDate:   &date,
+	})
+
+	assert.Nil(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\xEF\xBB\xBF"))).ReadAll()
+
+	assert.Nil(t, err)
+
+	// 表头 + 每个币种一行
+	assert.Len(t, records, len(model.Wallets)+1)
+	assert.Equal(t, "日期", records[0][0])
+
+	for _, record := range records[1:] {
+		assert.Equal(t, date, record[0])
+	}
+}
+
+func TestGetReportListRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	_, err := finance.GenerateDailyReport(database.Db, time.Now())
+
+	assert.Nil(t, err)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/finance/report?currency=CNY", nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	list := make([]schema.FinanceReport, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &list))
+	assert.True(t, len(list) >= 1)
+
+	for _, b := range list {
+		assert.Equal(t, model.WalletCNY, b.Currency)
+	}
+}
+
+func TestExportReportRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	_, err := finance.GenerateDailyReport(database.Db, time.Now())
+
+	assert.Nil(t, err)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/finance/report/export", nil, &header)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Header().Get("Content-Disposition"), "finance_report.csv")
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+type SubscribeParams struct {
+	Email string `json:"email" valid:"required~请输入邮箱"` // 接收报表的邮箱
+}
+
+func subscriptionToSchema(subscription model.FinanceReportSubscription) *schema.FinanceReportSubscription {
+	return &schema.FinanceReportSubscription{
+		Id:        subscription.Id,
+		Email:     subscription.Email,
+		CreatedAt: subscription.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt: subscription.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+// 获取当前管理员对每日财务报表的订阅, 没有订阅时返回 null
+func GetSubscription(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data *schema.FinanceReportSubscription
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	subscription := model.FinanceReportSubscription{}
+
+	if err = database.Db.Where("id = ?", c.Uid).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+		}
+		return
+	}
+
+	data = subscriptionToSchema(subscription)
+
+	return
+}
+
+// 订阅每日财务报表, 报表生成后会发送到指定的邮箱
+func Subscribe(c controller.Context, input SubscribeParams) (res schema.Response) {
+	var (
+		err  error
+		data *schema.FinanceReportSubscription
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if err = validator.ValidateEmail(input.Email); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	subscription := model.FinanceReportSubscription{
+		Id: adminInfo.Id,
+	}
+
+	if err = tx.Where("id = ?", adminInfo.Id).FirstOrInit(&subscription).Error; err != nil {
+		return
+	}
+
+	subscription.Email = input.Email
+
+	if err = tx.Save(&subscription).Error; err != nil {
+		return
+	}
+
+	data = subscriptionToSchema(subscription)
+
+	return
+}
+
+// 取消订阅每日财务报表
+func Unsubscribe(c controller.Context) (res schema.Response) {
+	var (
+		err error
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	err = database.Db.Where("id = ?", c.Uid).Delete(&model.FinanceReportSubscription{}).Error
+
+	return
+}
+
+func GetSubscriptionRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetSubscription(controller.NewContext(c))
+}
+
+func SubscribeRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input SubscribeParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Subscribe(controller.NewContext(c), input)
+}
+
+func UnsubscribeRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Unsubscribe(controller.NewContext(c))
+}
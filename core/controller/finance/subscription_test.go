@@ -0,0 +1,105 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	context := controller.Context{Uid: adminInfo.Id}
+
+	defer finance.Unsubscribe(context)
+
+	// 订阅
+	{
+		r := finance.Subscribe(context, finance.SubscribeParams{
+			Email: "finance@example.com",
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		subscription := schema.FinanceReportSubscription{}
+
+		assert.Nil(t, tester.Decode(r.Data, &subscription))
+		assert.Equal(t, adminInfo.Id, subscription.Id)
+		assert.Equal(t, "finance@example.com", subscription.Email)
+	}
+
+	// 更换邮箱
+	{
+		r := finance.Subscribe(context, finance.SubscribeParams{
+			Email: "report@example.com",
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		r2 := finance.GetSubscription(context)
+
+		subscription := schema.FinanceReportSubscription{}
+
+		assert.Nil(t, tester.Decode(r2.Data, &subscription))
+		assert.Equal(t, "report@example.com", subscription.Email)
+	}
+
+	// 无效的邮箱
+	{
+		r := finance.Subscribe(context, finance.SubscribeParams{
+			Email: "abc",
+		})
+
+		assert.Equal(t, exception.InvalidFormat.Error(), r.Message)
+	}
+
+	// 取消订阅
+	{
+		r := finance.Unsubscribe(context)
+
+		assert.Equal(t, "", r.Message)
+
+		r2 := finance.GetSubscription(context)
+
+		assert.Equal(t, schema.StatusSuccess, r2.Status)
+		assert.Nil(t, r2.Data)
+	}
+}
+
+func TestSubscribeRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	defer finance.Unsubscribe(controller.Context{Uid: adminInfo.Id})
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	body, _ := json.Marshal(&finance.SubscribeParams{
+		Email: "finance@example.com",
+	})
+
+	r := tester.HttpAdmin.Put("/v1/finance/report/subscription", body, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	subscription := schema.FinanceReportSubscription{}
+
+	assert.Nil(t, tester.Decode(res.Data, &subscription))
+	assert.Equal(t, "finance@example.com", subscription.Email)
+}
@@ -1,8 +1,41 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package finance
 
-import "strings"
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/util"
+	"strings"
+	"time"
+)
 
 func GetTableName(currency string) string {
 	return "finance_log_" + strings.ToLower(currency)
 }
+
+// 获取所有的流水表名
+func GetTableNames() (list []string) {
+	for _, currency := range model.Wallets {
+		list = append(list, GetTableName(currency))
+	}
+	return
+}
+
+func mapToSchema(model model.FinanceLog, d *schema.FinanceLog) {
+	d.Id = model.Id
+	d.Currency = strings.ToUpper(model.Currency)
+	d.OrderId = model.OrderId
+	d.Uid = model.Uid
+	d.BeforeBalance = util.FloatToStr(model.BeforeBalance)
+	d.BalanceMutation = util.FloatToStr(model.BalanceMutation)
+	d.AfterBalance = util.FloatToStr(model.AfterBalance)
+	d.BeforeFrozen = util.FloatToStr(model.BeforeFrozen)
+	d.FrozenMutation = util.FloatToStr(model.FrozenMutation)
+	d.AfterFrozen = util.FloatToStr(model.AfterFrozen)
+	d.Type = model.Type
+	d.Note = model.Note
+	d.PrevHash = model.PrevHash
+	d.Hash = model.Hash
+	d.CreatedAt = model.CreatedAt.Format(time.RFC3339Nano)
+	d.UpdatedAt = model.UpdatedAt.Format(time.RFC3339Nano)
+}
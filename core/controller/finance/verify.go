@@ -0,0 +1,116 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+)
+
+type VerifyParams struct {
+	Currency *string `json:"currency" form:"currency"` // 要校验的币种, 不填则校验所有币种
+}
+
+// 管理员校验某个用户的流水哈希链
+func Verify(c controller.Context, userId string, input VerifyParams) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.FinanceChain, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	userInfo := model.User{
+		Id: userId,
+	}
+
+	if err = database.Db.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	currencies := model.Wallets
+
+	if input.Currency != nil && len(*input.Currency) > 0 {
+		currency := strings.ToUpper(*input.Currency)
+
+		if _, ok := model.FinanceLogMap[strings.ToLower(currency)]; !ok {
+			err = exception.InvalidWallet
+			return
+		}
+
+		currencies = []string{currency}
+	}
+
+	for _, currency := range currencies {
+		var chain schema.FinanceChain
+
+		if chain, err = VerifyChain(database.Db, userInfo.Id, currency); err != nil {
+			return
+		}
+
+		data = append(data, chain)
+	}
+
+	return
+}
+
+func VerifyRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input VerifyParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Verify(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("user_id"), input)
+}
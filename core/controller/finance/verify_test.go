@@ -0,0 +1,118 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package finance_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	// 校验所有币种
+	{
+		r := finance.Verify(controller.Context{Uid: adminInfo.Id}, userFrom.Id, finance.VerifyParams{})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		list := make([]schema.FinanceChain, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, len(model.Wallets))
+
+		for _, chain := range list {
+			assert.True(t, chain.Valid)
+			assert.Equal(t, userFrom.Id, chain.Uid)
+		}
+	}
+
+	// 校验指定币种
+	{
+		currency := "cny"
+
+		r := finance.Verify(controller.Context{Uid: adminInfo.Id}, userFrom.Id, finance.VerifyParams{Currency: &currency})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		list := make([]schema.FinanceChain, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, model.WalletCNY, list[0].Currency)
+		assert.Equal(t, 1, list[0].Total)
+		assert.True(t, list[0].Valid)
+	}
+
+	// 无效的币种
+	{
+		currency := "abc"
+
+		r := finance.Verify(controller.Context{Uid: adminInfo.Id}, userFrom.Id, finance.VerifyParams{Currency: &currency})
+
+		assert.Equal(t, exception.InvalidWallet.Error(), r.Message)
+	}
+
+	// 用户不存在
+	{
+		r := finance.Verify(controller.Context{Uid: adminInfo.Id}, "123123", finance.VerifyParams{})
+
+		assert.Equal(t, exception.UserNotExist.Error(), r.Message)
+	}
+}
+
+func TestVerifyRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/finance/u/"+userInfo.Id+"/verify?currency=CNY", nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	list := make([]schema.FinanceChain, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &list))
+	assert.Len(t, list, 1)
+	assert.True(t, list[0].Valid)
+	assert.Equal(t, 0, list[0].Total)
+}
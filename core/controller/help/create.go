@@ -3,10 +3,12 @@ package help
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
@@ -17,19 +19,21 @@ import (
 )
 
 type CreateParams struct {
-	Title    string           `json:"title" valid:"required~请填写标题"`
-	Content  string           `json:"content" valid:"required~请填写内容"`
-	Tags     []string         `json:"tags"`
-	Status   model.HelpStatus `json:"status" valid:"required~请填写状态"`
-	Type     model.HelpType   `json:"type" valid:"required~请填写类型"`
-	ParentId *string          `json:"parent_id"`
+	Title    string               `json:"title" valid:"required~请填写标题"`
+	Content  string               `json:"content" valid:"required~请填写内容"`
+	Format   *model.ContentFormat `json:"format"` // 内容的格式, 默认为 html
+	Tags     []string             `json:"tags"`
+	Status   model.HelpStatus     `json:"status" valid:"required~请填写状态"`
+	Type     model.HelpType       `json:"type" valid:"required~请填写类型"`
+	ParentId *string              `json:"parent_id"`
 }
 
 func Create(c controller.Context, input CreateParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Help
-		tx   *gorm.DB
+		err    error
+		data   schema.Help
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -52,6 +56,10 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -83,20 +91,31 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 		ParentId: input.ParentId,
 	}
 
-	// checkout parent id is exist or not
-	if input.ParentId != nil {
-		if err = tx.Where(&model.Help{Id: *input.ParentId}).First(&model.Help{}).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				err = exception.HelpParentNotExist
-			}
-			return
-		}
+	if helpInfo.Format, err = content.ParseFormat(input.Format); err != nil {
+		return
+	}
+
+	helpInfo.Excerpt = content.Excerpt(helpInfo.Format, helpInfo.Content, content.ExcerptLength)
+	helpInfo.ParentId = normalizeParentId(helpInfo.ParentId)
+
+	// 父级必须是存在的分类
+	if err = checkParent(tx, "", helpInfo.ParentId); err != nil {
+		return
+	}
+
+	// 放在同级的最后面
+	if helpInfo.Sort, err = nextSort(tx, helpInfo.ParentId); err != nil {
+		return
 	}
 
 	if err = tx.Create(&helpInfo).Error; err != nil {
 		return
 	}
 
+	if err = events.Publish(tx, event.HelpSaved{Help: helpInfo}); err != nil {
+		return
+	}
+
 	if er := mapstructure.Decode(helpInfo, &data.HelpPure); er != nil {
 		err = er
 		return
@@ -7,6 +7,7 @@ import (
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -15,10 +16,11 @@ import (
 	"time"
 )
 
+// 获取帮助详情, 以及从根分类到父级的面包屑
 func GetHelp(id string) (res schema.Response) {
 	var (
 		err  error
-		data = schema.Help{}
+		data = schema.HelpDetail{}
 	)
 
 	defer func() {
@@ -53,6 +55,23 @@ func GetHelp(id string) (res schema.Response) {
 
 	data.CreatedAt = helpInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = helpInfo.UpdatedAt.Format(time.RFC3339Nano)
+	data.Html = content.Cached("help", helpInfo.Id, content.RevisionOf(helpInfo.UpdatedAt), helpInfo.Format, helpInfo.Content)
+
+	var list []model.Help
+
+	if list, err = ancestors(database.Db, helpInfo); err != nil {
+		return
+	}
+
+	data.Breadcrumbs = make([]schema.HelpBreadcrumb, 0)
+
+	for _, v := range list {
+		data.Breadcrumbs = append(data.Breadcrumbs, schema.HelpBreadcrumb{
+			Id:    v.Id,
+			Title: v.Title,
+			Type:  v.Type,
+		})
+	}
 
 	return
 }
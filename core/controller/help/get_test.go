@@ -75,6 +75,66 @@ func TestGetHelp(t *testing.T) {
 	}
 }
 
+func TestGetHelpFormat(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	plain := model.ContentFormatPlain
+
+	r := help.Create(controller.Context{
+		Uid: adminInfo.Id,
+	}, help.CreateParams{
+		Title:   "TestGetHelpFormat",
+		Content: "<b>1</b>\n2",
+		Format:  &plain,
+		Tags:    []string{},
+		Status:  model.HelpStatusActive,
+		Type:    model.HelpTypeArticle,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Help{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer help.DeleteHelpById(n.Id)
+
+	assert.Equal(t, "<b>1</b> 2", n.Excerpt)
+
+	// 纯文本会被转义
+	{
+		r := help.GetHelp(n.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		helpInfo := schema.HelpDetail{}
+
+		assert.Nil(t, tester.Decode(r.Data, &helpInfo))
+
+		assert.Equal(t, "<p>&lt;b&gt;1&lt;/b&gt;<br>2</p>", helpInfo.Html)
+	}
+
+	// 修改格式之后重新生成摘要
+	{
+		html := model.ContentFormatHTML
+
+		r := help.Update(controller.Context{
+			Uid: adminInfo.Id,
+		}, n.Id, help.UpdateParams{
+			Format: &html,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		helpInfo := schema.Help{}
+
+		assert.Nil(t, tester.Decode(r.Data, &helpInfo))
+
+		assert.Equal(t, model.ContentFormatHTML, helpInfo.Format)
+		assert.Equal(t, "1 2", helpInfo.Excerpt)
+	}
+}
+
 func TestGetHelpRouter(t *testing.T) {
 	var (
 		helpId  = ""
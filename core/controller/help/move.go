@@ -0,0 +1,175 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package help
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+type MoveParams struct {
+	ParentId *string `json:"parent_id"` // 移动到的父级, 为空则移动到根目录
+	Index    *int    `json:"index"`     // 在新的父级下的位置, 从 0 开始, 为空则放在最后
+}
+
+// 移动到某个分类下的某个位置, 父级不变时只调整顺序
+// 同时重新设置新旧父级下所有内容的排序
+func Move(c controller.Context, id string, input MoveParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.Help
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	helpInfo := model.Help{Id: id}
+
+	if err = tx.First(&helpInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NoData
+		}
+		return
+	}
+
+	parentId := normalizeParentId(input.ParentId)
+
+	if err = checkParent(tx, id, parentId); err != nil {
+		return
+	}
+
+	oldParentId := normalizeParentId(helpInfo.ParentId)
+
+	// 从原来的父级中移除
+	if !sameParent(oldParentId, parentId) {
+		var list []model.Help
+
+		if list, err = siblings(tx, oldParentId); err != nil {
+			return
+		}
+
+		if err = reorder(tx, idsWithout(list, id)); err != nil {
+			return
+		}
+	}
+
+	var list []model.Help
+
+	if list, err = siblings(tx, parentId); err != nil {
+		return
+	}
+
+	ids := idsWithout(list, id)
+
+	index := len(ids)
+
+	if input.Index != nil && *input.Index >= 0 && *input.Index < index {
+		index = *input.Index
+	}
+
+	ids = append(ids[:index], append([]string{id}, ids[index:]...)...)
+
+	if err = tx.Model(&helpInfo).UpdateColumn("parent_id", parentId).Error; err != nil {
+		return
+	}
+
+	if err = reorder(tx, ids); err != nil {
+		return
+	}
+
+	helpInfo.ParentId = parentId
+	helpInfo.Sort = index
+
+	if err = mapstructure.Decode(helpInfo, &data.HelpPure); err != nil {
+		return
+	}
+
+	data.CreatedAt = helpInfo.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = helpInfo.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+func sameParent(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return *a == *b
+}
+
+func idsWithout(list []model.Help, id string) []string {
+	ids := make([]string, 0, len(list))
+
+	for _, v := range list {
+		if v.Id != id {
+			ids = append(ids, v.Id)
+		}
+	}
+
+	return ids
+}
+
+func MoveRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input MoveParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Move(controller.NewContext(c), c.Param("help_id"), input)
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package help_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/help"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	c := controller.Context{Uid: adminInfo.Id}
+
+	root := createHelp(t, adminInfo.Id, "root", model.HelpTypeClass, nil)
+	defer help.DeleteHelpById(root.Id)
+
+	sub := createHelp(t, adminInfo.Id, "sub", model.HelpTypeClass, &root.Id)
+	defer help.DeleteHelpById(sub.Id)
+
+	a1 := createHelp(t, adminInfo.Id, "a1", model.HelpTypeArticle, &root.Id)
+	defer help.DeleteHelpById(a1.Id)
+
+	a2 := createHelp(t, adminInfo.Id, "a2", model.HelpTypeArticle, &root.Id)
+	defer help.DeleteHelpById(a2.Id)
+
+	// 调整顺序, 把 a2 放到第一个
+	{
+		index := 0
+
+		r := help.Move(c, a2.Id, help.MoveParams{ParentId: &root.Id, Index: &index})
+
+		assert.Equal(t, "", r.Message)
+
+		nodes := make([]schema.HelpNode, 0)
+
+		assert.Nil(t, tester.Decode(help.GetTree(help.TreeQuery{}).Data, &nodes))
+
+		children := findNode(nodes, root.Id).Children
+
+		if assert.Len(t, children, 3) {
+			assert.Equal(t, a2.Id, children[0].Id)
+			assert.Equal(t, sub.Id, children[1].Id)
+			assert.Equal(t, a1.Id, children[2].Id)
+		}
+	}
+
+	// 移动到其他分类下, 原来的分类重新排序
+	{
+		r := help.Move(c, a2.Id, help.MoveParams{ParentId: &sub.Id})
+
+		assert.Equal(t, "", r.Message)
+
+		nodes := make([]schema.HelpNode, 0)
+
+		assert.Nil(t, tester.Decode(help.GetTree(help.TreeQuery{}).Data, &nodes))
+
+		children := findNode(nodes, root.Id).Children
+
+		if assert.Len(t, children, 2) {
+			assert.Equal(t, 0, children[0].Sort)
+			assert.Equal(t, 1, children[1].Sort)
+		}
+
+		assert.Equal(t, a2.Id, findNode(nodes, sub.Id).Children[0].Id)
+	}
+
+	// 不能移动到自己或者自己的子级下面
+	{
+		r := help.Move(c, root.Id, help.MoveParams{ParentId: &root.Id})
+
+		assert.Equal(t, exception.HelpMoveCycle.Code(), r.Status)
+
+		r = help.Move(c, root.Id, help.MoveParams{ParentId: &sub.Id})
+
+		assert.Equal(t, exception.HelpMoveCycle.Code(), r.Status)
+	}
+
+	// 文章不能作为父级
+	{
+		r := help.Move(c, sub.Id, help.MoveParams{ParentId: &a1.Id})
+
+		assert.Equal(t, exception.HelpParentNotClass.Code(), r.Status)
+
+		article := model.HelpTypeArticle
+
+		r = help.Update(c, sub.Id, help.UpdateParams{Type: &article})
+
+		assert.Equal(t, exception.HelpHasChildren.Code(), r.Status)
+	}
+
+	// 移动到根目录
+	{
+		r := help.Move(c, sub.Id, help.MoveParams{})
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.Help{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Nil(t, d.ParentId)
+	}
+}
+
+func TestMoveRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	root := createHelp(t, adminInfo.Id, "root", model.HelpTypeClass, nil)
+	defer help.DeleteHelpById(root.Id)
+
+	a1 := createHelp(t, adminInfo.Id, "a1", model.HelpTypeArticle, nil)
+	defer help.DeleteHelpById(a1.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	body, _ := json.Marshal(&help.MoveParams{ParentId: &root.Id})
+
+	r := tester.HttpAdmin.Put("/v1/help/h/"+a1.Id+"/move", body, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	d := schema.Help{}
+
+	assert.Nil(t, tester.Decode(res.Data, &d))
+
+	assert.Equal(t, root.Id, *d.ParentId)
+}
@@ -0,0 +1,290 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package help
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+// 目录的最大层级, 防止错误的数据导致死循环
+const maxDepth = 32
+
+type TreeQuery struct {
+	Status *model.HelpStatus `json:"status" form:"status"` // 根据状态筛选
+}
+
+// 空字符串和 nil 都表示根目录
+func normalizeParentId(parentId *string) *string {
+	if parentId == nil || *parentId == "" {
+		return nil
+	}
+
+	return parentId
+}
+
+// 筛选同一个父级下的内容
+func siblingScope(db *gorm.DB, parentId *string) *gorm.DB {
+	if parentId = normalizeParentId(parentId); parentId == nil {
+		return db.Where("parent_id IS NULL OR parent_id = ''")
+	}
+
+	return db.Where("parent_id = ?", *parentId)
+}
+
+// 同一个父级下的内容, 按照排序
+func siblings(tx *gorm.DB, parentId *string) (list []model.Help, err error) {
+	list = make([]model.Help, 0)
+
+	err = siblingScope(tx, parentId).Order("sort ASC").Order("created_at ASC").Find(&list).Error
+
+	return
+}
+
+// 放到同一个父级的最后面
+func nextSort(tx *gorm.DB, parentId *string) (sort int, err error) {
+	err = siblingScope(tx.Model(&model.Help{}), parentId).Select("COALESCE(MAX(sort), -1) + 1").Row().Scan(&sort)
+
+	return
+}
+
+// 按照顺序重新设置排序
+func reorder(tx *gorm.DB, ids []string) error {
+	for i, id := range ids {
+		if err := tx.Model(&model.Help{}).Where("id = ?", id).UpdateColumn("sort", i).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 从根分类到父级的所有祖先
+func ancestors(tx *gorm.DB, h model.Help) (list []model.Help, err error) {
+	var (
+		parentId = normalizeParentId(h.ParentId)
+		visited  = map[string]bool{h.Id: true}
+	)
+
+	for parentId != nil && !visited[*parentId] && len(list) < maxDepth {
+		parent := model.Help{Id: *parentId}
+
+		if err = tx.First(&parent).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = nil
+				break
+			}
+			return
+		}
+
+		visited[parent.Id] = true
+		list = append([]model.Help{parent}, list...)
+		parentId = normalizeParentId(parent.ParentId)
+	}
+
+	return
+}
+
+// 校验父级, 父级必须存在并且是分类, 并且不能是自己或者自己的子级
+// id 为空表示新建的内容
+func checkParent(tx *gorm.DB, id string, parentId *string) (err error) {
+	if parentId = normalizeParentId(parentId); parentId == nil {
+		return
+	}
+
+	if *parentId == id {
+		return exception.HelpMoveCycle
+	}
+
+	parent := model.Help{Id: *parentId}
+
+	if err = tx.First(&parent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.HelpParentNotExist
+		}
+		return
+	}
+
+	if parent.Type != model.HelpTypeClass {
+		return exception.HelpParentNotClass
+	}
+
+	if id == "" {
+		return
+	}
+
+	var list []model.Help
+
+	if list, err = ancestors(tx, parent); err != nil {
+		return
+	}
+
+	for _, v := range list {
+		if v.Id == id {
+			return exception.HelpMoveCycle
+		}
+	}
+
+	return
+}
+
+func toNode(h model.Help) schema.HelpNode {
+	return schema.HelpNode{
+		Id:       h.Id,
+		Title:    h.Title,
+		Tags:     h.Tags,
+		Status:   h.Status,
+		Type:     h.Type,
+		ParentId: normalizeParentId(h.ParentId),
+		Sort:     h.Sort,
+		Children: []schema.HelpNode{},
+	}
+}
+
+// 把列表组装成树, list 已经按照排序
+// keepOrphans 为 true 时, 父级不在列表中的内容放在根目录, 否则丢弃
+func buildTree(list []model.Help, keepOrphans bool) []schema.HelpNode {
+	var (
+		children = map[string][]model.Help{}
+		exist    = map[string]bool{}
+		roots    = make([]model.Help, 0)
+		visited  = map[string]bool{}
+	)
+
+	for _, v := range list {
+		exist[v.Id] = true
+	}
+
+	for _, v := range list {
+		parentId := normalizeParentId(v.ParentId)
+
+		switch {
+		case parentId == nil:
+			roots = append(roots, v)
+		case exist[*parentId]:
+			children[*parentId] = append(children[*parentId], v)
+		case keepOrphans:
+			roots = append(roots, v)
+		}
+	}
+
+	var build func(list []model.Help, depth int) []schema.HelpNode
+
+	build = func(list []model.Help, depth int) []schema.HelpNode {
+		nodes := make([]schema.HelpNode, 0)
+
+		for _, v := range list {
+			if visited[v.Id] {
+				continue
+			}
+
+			visited[v.Id] = true
+
+			node := toNode(v)
+
+			if depth < maxDepth {
+				node.Children = build(children[v.Id], depth+1)
+			}
+
+			nodes = append(nodes, node)
+		}
+
+		return nodes
+	}
+
+	return build(roots, 0)
+}
+
+func getTree(filter map[string]interface{}, keepOrphans bool) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.HelpNode, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	list := make([]model.Help, 0)
+
+	if err = database.Db.Where(filter).Order("sort ASC").Order("created_at ASC").Find(&list).Error; err != nil {
+		return
+	}
+
+	data = buildTree(list, keepOrphans)
+
+	return
+}
+
+// 管理员获取帮助中心的目录树, 父级已经删除或者被筛选掉的内容放在根目录
+func GetTree(input TreeQuery) (res schema.Response) {
+	filter := map[string]interface{}{}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	return getTree(filter, true)
+}
+
+// 用户获取帮助中心的目录树, 只包括启用的内容, 未启用的分类下的内容也不显示
+func GetTreeByUser() (res schema.Response) {
+	return getTree(map[string]interface{}{"status": model.HelpStatusActive}, false)
+}
+
+func GetTreeRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input TreeQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetTree(input)
+}
+
+func GetTreeByUserRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetTreeByUser()
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package help_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/help"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func createHelp(t *testing.T, uid string, title string, helpType model.HelpType, parentId *string) schema.Help {
+	r := help.Create(controller.Context{Uid: uid}, help.CreateParams{
+		Title:    title,
+		Content:  title,
+		Tags:     []string{},
+		Status:   model.HelpStatusActive,
+		Type:     helpType,
+		ParentId: parentId,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Help{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n
+}
+
+func findNode(nodes []schema.HelpNode, id string) *schema.HelpNode {
+	for i := range nodes {
+		if nodes[i].Id == id {
+			return &nodes[i]
+		}
+
+		if n := findNode(nodes[i].Children, id); n != nil {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func TestGetTree(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	root := createHelp(t, adminInfo.Id, "root", model.HelpTypeClass, nil)
+	defer help.DeleteHelpById(root.Id)
+
+	sub := createHelp(t, adminInfo.Id, "sub", model.HelpTypeClass, &root.Id)
+	defer help.DeleteHelpById(sub.Id)
+
+	a1 := createHelp(t, adminInfo.Id, "a1", model.HelpTypeArticle, &sub.Id)
+	defer help.DeleteHelpById(a1.Id)
+
+	a2 := createHelp(t, adminInfo.Id, "a2", model.HelpTypeArticle, &sub.Id)
+	defer help.DeleteHelpById(a2.Id)
+
+	// 新建的内容放在同级的最后面
+	assert.Equal(t, 0, a1.Sort)
+	assert.Equal(t, 1, a2.Sort)
+
+	// 目录树
+	{
+		r := help.GetTreeByUser()
+
+		assert.Equal(t, "", r.Message)
+
+		nodes := make([]schema.HelpNode, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &nodes))
+
+		n := findNode(nodes, sub.Id)
+
+		if !assert.NotNil(t, n) {
+			return
+		}
+
+		assert.Len(t, n.Children, 2)
+		assert.Equal(t, a1.Id, n.Children[0].Id)
+		assert.Equal(t, a2.Id, n.Children[1].Id)
+		assert.Equal(t, sub.Id, findNode(nodes, root.Id).Children[0].Id)
+	}
+
+	// 面包屑
+	{
+		r := help.GetHelp(a1.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.HelpDetail{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Equal(t, []schema.HelpBreadcrumb{
+			{Id: root.Id, Title: root.Title, Type: model.HelpTypeClass},
+			{Id: sub.Id, Title: sub.Title, Type: model.HelpTypeClass},
+		}, d.Breadcrumbs)
+	}
+
+	// 未启用的分类下的内容, 用户看不到, 管理员可以看到
+	{
+		inactive := model.HelpStatusInActive
+
+		r := help.Update(controller.Context{Uid: adminInfo.Id}, sub.Id, help.UpdateParams{Status: &inactive})
+
+		assert.Equal(t, "", r.Message)
+
+		nodes := make([]schema.HelpNode, 0)
+
+		assert.Nil(t, tester.Decode(help.GetTreeByUser().Data, &nodes))
+
+		assert.Nil(t, findNode(nodes, sub.Id))
+		assert.Nil(t, findNode(nodes, a1.Id))
+
+		assert.Nil(t, tester.Decode(help.GetTree(help.TreeQuery{}).Data, &nodes))
+
+		assert.NotNil(t, findNode(nodes, a1.Id))
+	}
+}
+
+func TestGetTreeRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	root := createHelp(t, adminInfo.Id, "root", model.HelpTypeClass, nil)
+	defer help.DeleteHelpById(root.Id)
+
+	r := tester.HttpUser.Get("/v1/help/tree", nil, nil)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	nodes := make([]schema.HelpNode, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &nodes))
+
+	assert.NotNil(t, findNode(nodes, root.Id))
+}
@@ -4,10 +4,12 @@ package help
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
@@ -18,12 +20,13 @@ import (
 )
 
 type UpdateParams struct {
-	Title    *string           `json:"title"`
-	Content  *string           `json:"content"`
-	Tags     *[]string         `json:"tags"`
-	Status   *model.HelpStatus `json:"status"`
-	Type     *model.HelpType   `json:"type"`
-	ParentId *string           `json:"parent_id"`
+	Title    *string              `json:"title"`
+	Content  *string              `json:"content"`
+	Format   *model.ContentFormat `json:"format"`
+	Tags     *[]string            `json:"tags"`
+	Status   *model.HelpStatus    `json:"status"`
+	Type     *model.HelpType      `json:"type"`
+	ParentId *string              `json:"parent_id"`
 }
 
 func Update(c controller.Context, helpId string, input UpdateParams) (res schema.Response) {
@@ -32,6 +35,7 @@ func Update(c controller.Context, helpId string, input UpdateParams) (res schema
 		data         schema.Help
 		tx           *gorm.DB
 		shouldUpdate bool
+		events       event.Batch
 	)
 
 	defer func() {
@@ -54,6 +58,10 @@ func Update(c controller.Context, helpId string, input UpdateParams) (res schema
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -100,6 +108,29 @@ func Update(c controller.Context, helpId string, input UpdateParams) (res schema
 		updateModel.Content = *input.Content
 	}
 
+	if input.Format != nil {
+		shouldUpdate = true
+
+		if updateModel.Format, err = content.ParseFormat(input.Format); err != nil {
+			return
+		}
+	}
+
+	// 内容或者格式修改之后重新生成摘要
+	if input.Content != nil || input.Format != nil {
+		format, source := helpInfo.Format, helpInfo.Content
+
+		if input.Format != nil {
+			format = updateModel.Format
+		}
+
+		if input.Content != nil {
+			source = *input.Content
+		}
+
+		updateModel.Excerpt = content.Excerpt(format, source, content.ExcerptLength)
+	}
+
 	if input.Tags != nil {
 		shouldUpdate = true
 		updateModel.Tags = *input.Tags
@@ -113,14 +144,40 @@ func Update(c controller.Context, helpId string, input UpdateParams) (res schema
 	if input.Type != nil {
 		shouldUpdate = true
 		updateModel.Type = *input.Type
+
+		// 分类下还有内容时不能修改为文章, 文章不能作为父级
+		if helpInfo.Type == model.HelpTypeClass && *input.Type != model.HelpTypeClass {
+			var children int
+
+			if err = siblingScope(tx.Model(&model.Help{}), &helpInfo.Id).Count(&children).Error; err != nil {
+				return
+			}
+
+			if children > 0 {
+				err = exception.HelpHasChildren
+				return
+			}
+		}
 	}
 
-	if input.ParentId != nil {
+	// 修改父级时放在新的父级的最后面, 移动到根目录或者调整顺序使用 Move
+	if parentId := normalizeParentId(input.ParentId); parentId != nil && !sameParent(parentId, helpInfo.ParentId) {
 		shouldUpdate = true
-		updateModel.ParentId = input.ParentId
-		// check parent id exist or not
-		if er := tx.Model(&helpInfo).Where(model.Help{Id: *input.ParentId}).First(&model.Help{}).Error; er != nil {
-			err = er
+
+		if err = checkParent(tx, helpInfo.Id, parentId); err != nil {
+			return
+		}
+
+		updateModel.ParentId = parentId
+
+		var sort int
+
+		if sort, err = nextSort(tx, parentId); err != nil {
+			return
+		}
+
+		// 排序可能为 0, 不能通过 Updates 更新
+		if err = tx.Model(&helpInfo).UpdateColumn("sort", sort).Error; err != nil {
 			return
 		}
 	}
@@ -133,6 +190,10 @@ func Update(c controller.Context, helpId string, input UpdateParams) (res schema
 			}
 			return
 		}
+
+		if err = events.Publish(tx, event.HelpSaved{Help: helpInfo}); err != nil {
+			return
+		}
 	}
 
 	if err = mapstructure.Decode(helpInfo, &data.HelpPure); err != nil {
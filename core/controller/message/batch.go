@@ -0,0 +1,650 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/mitchellh/mapstructure"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func DeleteBatchById(id string) {
+	database.DeleteRowByTable("message_batch", "id", id)
+}
+
+// 群发消息的发送对象, 时间为 RFC3339 格式, 不传则不限制
+type BatchTarget struct {
+	TargetRoles         []string `json:"target_roles"`          // 发送给拥有其中任意一个角色的用户
+	TargetLevelMin      *int32   `json:"target_level_min"`      // 发送给不低于该等级的用户
+	TargetLevelMax      *int32   `json:"target_level_max"`      // 发送给不高于该等级的用户
+	TargetRegisterStart *string  `json:"target_register_start"` // 发送给在这之后注册的用户
+	TargetRegisterEnd   *string  `json:"target_register_end"`   // 发送给在这之前注册的用户
+	TargetUsers         []string `json:"target_users"`          // 发送给指定的用户
+}
+
+type CreateBatchParams struct {
+	Title   string               `json:"title" form:"title" valid:"required~请填写消息标题"`
+	Content string               `json:"content" form:"content" valid:"required~请填写消息内容"`
+	Format  *model.ContentFormat `json:"format" form:"format"` // 消息内容的格式, 默认为 html
+	BatchTarget
+}
+
+type BatchQuery struct {
+	schema.Query
+	Status *model.MessageBatchStatus `json:"status" form:"status"`
+}
+
+func parseBatchTime(s *string) (*time.Time, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, *s)
+
+	if err != nil {
+		return nil, exception.InvalidParams
+	}
+
+	return &t, nil
+}
+
+// 去掉重复和空的值
+func uniqueIds(list []string) pq.StringArray {
+	result := pq.StringArray{}
+	exist := map[string]bool{}
+
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+
+		if v == "" || exist[v] {
+			continue
+		}
+		exist[v] = true
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// 筛选群发任务的发送对象
+func batchScope(db *gorm.DB, batch model.MessageBatch) *gorm.DB {
+	db = db.Model(&model.User{})
+
+	if len(batch.TargetRoles) > 0 {
+		db = db.Where("role && ?::varchar[]", batch.TargetRoles)
+	}
+
+	if batch.TargetLevelMin != nil {
+		db = db.Where("level >= ?", *batch.TargetLevelMin)
+	}
+
+	if batch.TargetLevelMax != nil {
+		db = db.Where("level <= ?", *batch.TargetLevelMax)
+	}
+
+	if batch.TargetRegisterStart != nil {
+		db = db.Where("created_at >= ?", *batch.TargetRegisterStart)
+	}
+
+	if batch.TargetRegisterEnd != nil {
+		db = db.Where("created_at < ?", *batch.TargetRegisterEnd)
+	}
+
+	if len(batch.TargetUsers) > 0 {
+		db = db.Where("id IN (?)", []string(batch.TargetUsers))
+	}
+
+	return db
+}
+
+func batchToSchema(batch model.MessageBatch) (data schema.MessageBatch, err error) {
+	if err = mapstructure.Decode(batch, &data.MessageBatchPure); err != nil {
+		return
+	}
+
+	if batch.TargetRegisterStart != nil {
+		t := batch.TargetRegisterStart.Format(time.RFC3339Nano)
+		data.TargetRegisterStart = &t
+	}
+
+	if batch.TargetRegisterEnd != nil {
+		t := batch.TargetRegisterEnd.Format(time.RFC3339Nano)
+		data.TargetRegisterEnd = &t
+	}
+
+	if batch.FinishedAt != nil {
+		t := batch.FinishedAt.Format(time.RFC3339Nano)
+		data.FinishedAt = &t
+	}
+
+	if batch.Status == model.MessageBatchStatusFinished {
+		data.Progress = 1
+	} else if batch.Total > 0 {
+		data.Progress = math.Min(math.Round(float64(batch.Delivered)/float64(batch.Total)*10000)/10000, 1)
+	}
+
+	data.TargetUsers = len(batch.TargetUsers)
+	data.CreatedAt = batch.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = batch.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 把群发任务的下一批加入消息队列
+func enqueueBatch(id string) error {
+	return message_queue.Enqueue(context.Background(), message_queue.SendMessageBatchBody{Id: id})
+}
+
+// 从 CSV 中读取用户, 每行的第一列为用户 ID 或者用户名
+func readUsersFromCSV(reader io.Reader) (list []string, err error) {
+	r := csv.NewReader(reader)
+
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var records [][]string
+
+	if records, err = r.ReadAll(); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	for _, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+
+		list = append(list, record[0])
+	}
+
+	return
+}
+
+// 创建群发任务, 任务会在消息队列中分批发送
+// 如果指定了用户, 则 ID 或者用户名无法识别的用户会被忽略, 并记录在 invalid 中
+func CreateBatch(c controller.Context, input CreateBatchParams) (res schema.Response) {
+	var (
+		err   error
+		data  schema.MessageBatch
+		tx    *gorm.DB
+		batch model.MessageBatch
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		// 加入消息队列失败, 则任务不会被执行, 直接删除
+		if err == nil {
+			if err = enqueueBatch(batch.Id); err != nil {
+				_ = database.Db.Unscoped().Delete(&batch).Error
+				data = schema.MessageBatch{}
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		// 没有找到管理员
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		err = exception.AdminNotSuper
+		return
+	}
+
+	batch = model.MessageBatch{
+		Author:         c.Uid,
+		Title:          input.Title,
+		Content:        input.Content,
+		Status:         model.MessageBatchStatusPending,
+		TargetRoles:    uniqueIds(input.TargetRoles),
+		TargetLevelMin: input.TargetLevelMin,
+		TargetLevelMax: input.TargetLevelMax,
+	}
+
+	if batch.Format, err = content.ParseFormat(input.Format); err != nil {
+		return
+	}
+
+	if batch.TargetRegisterStart, err = parseBatchTime(input.TargetRegisterStart); err != nil {
+		return
+	}
+
+	if batch.TargetRegisterEnd, err = parseBatchTime(input.TargetRegisterEnd); err != nil {
+		return
+	}
+
+	if input.TargetUsers != nil {
+		users := uniqueIds(input.TargetUsers)
+
+		if len(users) == 0 {
+			err = exception.MessageBatchNoTarget
+			return
+		}
+
+		ids := make([]string, 0)
+
+		if err = tx.Model(&model.User{}).Where("id IN (?) OR username IN (?)", []string(users), []string(users)).Pluck("id", &ids).Error; err != nil {
+			return
+		}
+
+		batch.TargetUsers = ids
+
+		batch.Invalid = int64(len(users) - len(batch.TargetUsers))
+
+		if batch.Invalid < 0 {
+			batch.Invalid = 0
+		}
+
+		if len(batch.TargetUsers) == 0 {
+			err = exception.MessageBatchNoTarget
+			return
+		}
+	}
+
+	if err = batchScope(tx, batch).Count(&batch.Total).Error; err != nil {
+		return
+	}
+
+	if batch.Total == 0 {
+		err = exception.MessageBatchNoTarget
+		return
+	}
+
+	if err = tx.Create(&batch).Error; err != nil {
+		return
+	}
+
+	data, err = batchToSchema(batch)
+
+	return
+}
+
+// 导入 CSV 创建群发任务, 每行的第一列为用户 ID 或者用户名
+func CreateBatchByCSV(c controller.Context, input CreateBatchParams, file io.Reader) (res schema.Response) {
+	var (
+		err   error
+		users []string
+	)
+
+	if users, err = readUsersFromCSV(file); err != nil {
+		helper.Response(&res, nil, err)
+		return
+	}
+
+	// 没有读到任何用户时, 也不能变成不限制发送对象
+	if users == nil {
+		users = []string{}
+	}
+
+	input.TargetUsers = users
+
+	return CreateBatch(c, input)
+}
+
+// 获取群发任务的详情和进度
+func GetBatch(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageBatch
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	batch := model.MessageBatch{}
+
+	if err = database.Db.Where("id = ?", id).First(&batch).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.MessageBatchNotExist
+		}
+		return
+	}
+
+	data, err = batchToSchema(batch)
+
+	return
+}
+
+// 获取群发任务列表
+func GetBatchList(c controller.Context, input BatchQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.MessageBatch, 0)
+		list = make([]model.MessageBatch, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	filter := map[string]interface{}{}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	var total int64
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	if err = database.Db.Model(model.MessageBatch{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.MessageBatch
+
+		if d, err = batchToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 取消群发任务, 已经发送的消息不会撤回
+func CancelBatch(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageBatch
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	batch := model.MessageBatch{}
+
+	if err = tx.Where("id = ?", id).First(&batch).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.MessageBatchNotExist
+		}
+		return
+	}
+
+	now := time.Now()
+
+	// 只有未结束的任务才能取消, 正在发送的批次会在提交时发现任务已经取消
+	result := tx.Model(&batch).
+		Where("status IN (?)", []model.MessageBatchStatus{model.MessageBatchStatusPending, model.MessageBatchStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      model.MessageBatchStatusCancelled,
+			"finished_at": now,
+		})
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		err = exception.MessageBatchIsFinished
+		return
+	}
+
+	if err = tx.Where("id = ?", id).First(&batch).Error; err != nil {
+		return
+	}
+
+	data, err = batchToSchema(batch)
+
+	return
+}
+
+func CreateBatchRouter(c *gin.Context) {
+	var (
+		input CreateBatchParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = CreateBatch(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func CreateBatchByCSVRouter(c *gin.Context) {
+	var (
+		input CreateBatchParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBind(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	header, er := c.FormFile("file")
+
+	if er != nil {
+		err = exception.RequireFile
+		return
+	}
+
+	file, er := header.Open()
+
+	if er != nil {
+		err = er
+		return
+	}
+
+	defer file.Close()
+
+	res = CreateBatchByCSV(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input, file)
+}
+
+func GetBatchRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetBatch(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param(ParamsBatchIdName))
+}
+
+func GetBatchListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input BatchQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetBatchList(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
+
+func CancelBatchRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = CancelBatch(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param(ParamsBatchIdName))
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"log"
+	"time"
+)
+
+var (
+	BatchSize = 500 // 群发任务每一批发送的用户数量
+)
+
+// 发送群发任务的下一批消息, 返回任务是否已经结束
+// 发送的消息和任务的进度在同一个事务中提交, 并以进度作为条件更新
+// 所以重复投递的队列消息不会重复发送, 取消的任务也不会继续发送
+func SendBatch(db *gorm.DB, id string) (done bool, err error) {
+	var (
+		tx       *gorm.DB
+		batch    model.MessageBatch
+		messages = make([]model.Message, 0)
+		aborted  bool // 任务已经被取消, 或者这一批已经被其他的消费者发送
+		fields   = map[string]interface{}{}
+	)
+
+	defer func() {
+		if tx != nil {
+			if err != nil || aborted {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		// 推送给在线的用户, 推送失败不影响发送
+		if err == nil && !aborted {
+			for _, v := range messages {
+				resetUnread(v.Uid)
+
+				data := schema.Message{}
+
+				if er := mapstructure.Decode(v, &data.MessagePure); er != nil {
+					continue
+				}
+
+				data.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
+				data.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+
+				_ = push.Publish(push.EventMessage, v.Uid, data)
+			}
+
+			if done && tx != nil {
+				log.Printf("群发任务 %s 发送完成, 共发送 %d 条消息\n", batch.Id, fields["delivered"])
+			}
+		}
+	}()
+
+	if err = db.Where("id = ?", id).First(&batch).Error; err != nil {
+		// 任务已经被删除
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+			done = true
+		}
+		return
+	}
+
+	if batch.Status == model.MessageBatchStatusFinished || batch.Status == model.MessageBatchStatusCancelled {
+		done = true
+		return
+	}
+
+	tx = db.Begin()
+
+	ids := make([]string, 0)
+
+	if err = batchScope(tx, batch).Where("id > ?", batch.Cursor).Order("id ASC").Limit(BatchSize).Pluck("id", &ids).Error; err != nil {
+		return
+	}
+
+	// 同一批消息的内容相同, 摘要只需要生成一次
+	excerpt := content.Excerpt(batch.Format, batch.Content, content.ExcerptLength)
+
+	for _, uid := range ids {
+		messageInfo := model.Message{
+			Uid:     uid,
+			Title:   batch.Title,
+			Content: batch.Content,
+			Format:  batch.Format,
+			Excerpt: excerpt,
+			Status:  model.MessageStatusActive,
+		}
+
+		if err = tx.Create(&messageInfo).Error; err != nil {
+			return
+		}
+
+		messages = append(messages, messageInfo)
+	}
+
+	fields["status"] = model.MessageBatchStatusRunning
+	fields["delivered"] = batch.Delivered + int64(len(ids))
+
+	if len(ids) > 0 {
+		fields["cursor"] = ids[len(ids)-1]
+	}
+
+	// 不足一批, 说明已经是最后一批了
+	if len(ids) < BatchSize {
+		now := time.Now()
+
+		fields["status"] = model.MessageBatchStatusFinished
+		fields["finished_at"] = now
+		done = true
+	}
+
+	result := tx.Model(&batch).
+		Where("status IN (?)", []model.MessageBatchStatus{model.MessageBatchStatusPending, model.MessageBatchStatusRunning}).
+		Where("cursor = ?", batch.Cursor).
+		Updates(fields)
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		aborted = true
+		done = true
+		return
+	}
+
+	return
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSendBatch(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	user1, _ := tester.CreateUser()
+	user2, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(user1.Username)
+	defer auth.DeleteUserByUserName(user2.Username)
+
+	batchSize := message.BatchSize
+
+	message.BatchSize = 1
+
+	defer func() {
+		message.BatchSize = batchSize
+	}()
+
+	n := createBatch(t, adminInfo.Id, []string{user1.Id, user2.Id})
+
+	defer message.DeleteBatchById(n.Id)
+
+	defer database.Db.Unscoped().Where("uid IN (?)", []string{user1.Id, user2.Id}).Delete(model.Message{})
+
+	// 每次只发送一批
+	done, err := message.SendBatch(database.Db, n.Id)
+
+	assert.Nil(t, err)
+	assert.False(t, done)
+
+	r := message.GetBatch(controller.Context{Uid: adminInfo.Id}, n.Id)
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+	assert.Equal(t, int(model.MessageBatchStatusRunning), n.Status)
+	assert.Equal(t, int64(1), n.Delivered)
+	assert.Equal(t, 0.5, n.Progress)
+
+	for {
+		if done, err = message.SendBatch(database.Db, n.Id); err != nil || done {
+			break
+		}
+	}
+
+	assert.Nil(t, err)
+
+	r = message.GetBatch(controller.Context{Uid: adminInfo.Id}, n.Id)
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+	assert.Equal(t, int(model.MessageBatchStatusFinished), n.Status)
+	assert.Equal(t, int64(2), n.Delivered)
+	assert.Equal(t, float64(1), n.Progress)
+	assert.NotNil(t, n.FinishedAt)
+
+	// 每个用户都收到了一条消息
+	for _, uid := range []string{user1.Id, user2.Id} {
+		list := message.GetMessageListByUser(controller.Context{Uid: uid}, message.Query{})
+
+		assert.Equal(t, schema.StatusSuccess, list.Status)
+		assert.Equal(t, int64(1), list.Meta.Total)
+	}
+
+	// 重复投递不会重复发送
+	done, err = message.SendBatch(database.Db, n.Id)
+
+	assert.Nil(t, err)
+	assert.True(t, done)
+
+	count, err := message.CountUnread(database.Db, user1.Id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
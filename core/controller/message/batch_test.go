@@ -0,0 +1,192 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func createBatch(t *testing.T, adminId string, users []string) schema.MessageBatch {
+	r := message.CreateBatch(controller.Context{Uid: adminId}, message.CreateBatchParams{
+		Title:   "batch",
+		Content: "batch",
+		BatchTarget: message.BatchTarget{
+			TargetUsers: users,
+		},
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	n := schema.MessageBatch{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n
+}
+
+func TestCreateBatch(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	user1, _ := tester.CreateUser()
+	user2, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(user1.Username)
+	defer auth.DeleteUserByUserName(user2.Username)
+
+	// 通过用户 ID 或者用户名指定用户, 无法识别的用户会被忽略
+	{
+		n := createBatch(t, adminInfo.Id, []string{user1.Id, user2.Username, user2.Id, "not_exist_user"})
+
+		defer message.DeleteBatchById(n.Id)
+
+		assert.Equal(t, int(model.MessageBatchStatusPending), n.Status)
+		assert.Equal(t, int64(2), n.Total)
+		assert.Equal(t, int64(1), n.Invalid)
+		assert.Equal(t, 2, n.TargetUsers)
+	}
+
+	// 导入 CSV
+	{
+		csv := strings.Join([]string{user1.Username, user2.Id + ",other column", "", "not_exist_user"}, "\n")
+
+		r := message.CreateBatchByCSV(controller.Context{Uid: adminInfo.Id}, message.CreateBatchParams{
+			Title:   "batch",
+			Content: "batch",
+		}, strings.NewReader(csv))
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.MessageBatch{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		defer message.DeleteBatchById(n.Id)
+
+		assert.Equal(t, int64(2), n.Total)
+		assert.Equal(t, int64(1), n.Invalid)
+	}
+
+	// 没有符合条件的用户
+	{
+		var level int32 = 100
+
+		r := message.CreateBatch(controller.Context{Uid: adminInfo.Id}, message.CreateBatchParams{
+			Title:   "batch",
+			Content: "batch",
+			BatchTarget: message.BatchTarget{
+				TargetUsers:    []string{user1.Id},
+				TargetLevelMin: &level,
+			},
+		})
+
+		assert.Equal(t, exception.MessageBatchNoTarget.Error(), r.Message)
+
+		r = message.CreateBatchByCSV(controller.Context{Uid: adminInfo.Id}, message.CreateBatchParams{
+			Title:   "batch",
+			Content: "batch",
+		}, strings.NewReader(""))
+
+		assert.Equal(t, exception.MessageBatchNoTarget.Error(), r.Message)
+	}
+
+	// 非管理员不能创建
+	{
+		r := message.CreateBatch(controller.Context{Uid: user1.Id}, message.CreateBatchParams{
+			Title:   "batch",
+			Content: "batch",
+		})
+
+		assert.Equal(t, exception.AdminNotExist.Error(), r.Message)
+	}
+}
+
+func TestCancelBatch(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := createBatch(t, adminInfo.Id, []string{userInfo.Id})
+
+	defer message.DeleteBatchById(n.Id)
+
+	r := message.CancelBatch(controller.Context{Uid: adminInfo.Id}, n.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+	assert.Equal(t, int(model.MessageBatchStatusCancelled), n.Status)
+	assert.NotNil(t, n.FinishedAt)
+
+	// 取消之后不会再发送
+	done, err := message.SendBatch(database.Db, n.Id)
+
+	assert.Nil(t, err)
+	assert.True(t, done)
+
+	r = message.GetBatch(controller.Context{Uid: adminInfo.Id}, n.Id)
+
+	assert.Equal(t, "", r.Message)
+	assert.Nil(t, tester.Decode(r.Data, &n))
+	assert.Equal(t, int64(0), n.Delivered)
+
+	// 不能重复取消
+	r = message.CancelBatch(controller.Context{Uid: adminInfo.Id}, n.Id)
+
+	assert.Equal(t, exception.MessageBatchIsFinished.Error(), r.Message)
+}
+
+func TestCreateBatchRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	body, _ := json.Marshal(&message.CreateBatchParams{
+		Title:   "batch",
+		Content: "batch",
+		BatchTarget: message.BatchTarget{
+			TargetUsers: []string{userInfo.Id},
+		},
+	})
+
+	r := tester.HttpAdmin.Post("/v1/message/batch", body, &header)
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	n := schema.MessageBatch{}
+
+	assert.Nil(t, tester.Decode(res.Data, &n))
+
+	defer message.DeleteBatchById(n.Id)
+
+	assert.Equal(t, int64(1), n.Total)
+
+	// 获取进度
+	r = tester.HttpAdmin.Get("/v1/message/batch/"+n.Id, nil, &header)
+	res = schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+}
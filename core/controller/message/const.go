@@ -2,5 +2,6 @@
 package message
 
 var (
-	ParamsIdName = "message_id"
+	ParamsIdName      = "message_id"
+	ParamsBatchIdName = "batch_id"
 )
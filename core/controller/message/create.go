@@ -9,7 +9,9 @@ import (
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -19,9 +21,10 @@ import (
 )
 
 type CreateMessageParams struct {
-	Uid     string `json:"uid" valid:"required~请添加用户ID"`
-	Title   string `json:"title" valid:"required~请填写消息标题"`
-	Content string `json:"content" valid:"required~请填写消息内容"`
+	Uid     string               `json:"uid" valid:"required~请添加用户ID"`
+	Title   string               `json:"title" valid:"required~请填写消息标题"`
+	Content string               `json:"content" valid:"required~请填写消息内容"`
+	Format  *model.ContentFormat `json:"format"` // 消息内容的格式, 默认为 html
 }
 
 func Create(c controller.Context, input CreateMessageParams) (res schema.Response) {
@@ -51,6 +54,12 @@ func Create(c controller.Context, input CreateMessageParams) (res schema.Respons
 			}
 		}
 
+		// 推送给在线的用户, 推送失败不影响创建
+		if err == nil {
+			resetUnread(input.Uid)
+			_ = push.Publish(push.EventMessage, input.Uid, data)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -97,6 +106,12 @@ func Create(c controller.Context, input CreateMessageParams) (res schema.Respons
 		Status:  model.MessageStatusActive,
 	}
 
+	if MessageInfo.Format, err = content.ParseFormat(input.Format); err != nil {
+		return
+	}
+
+	MessageInfo.Excerpt = content.Excerpt(MessageInfo.Format, MessageInfo.Content, content.ExcerptLength)
+
 	if err = tx.Create(&MessageInfo).Error; err != nil {
 		return
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"github.com/mitchellh/mapstructure"
@@ -23,9 +24,10 @@ func DeleteMessageById(id string) {
 
 func DeleteByAdmin(c controller.Context, messageId string) (res schema.Response) {
 	var (
-		err  error
-		data schema.Message
-		tx   *gorm.DB
+		err         error
+		data        schema.Message
+		tx          *gorm.DB
+		messageInfo model.Message
 	)
 
 	defer func() {
@@ -48,6 +50,10 @@ func DeleteByAdmin(c controller.Context, messageId string) (res schema.Response)
 			}
 		}
 
+		if err == nil {
+			resetUnread(messageInfo.Uid)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -62,7 +68,7 @@ func DeleteByAdmin(c controller.Context, messageId string) (res schema.Response)
 		return
 	}
 
-	messageInfo := model.Message{
+	messageInfo = model.Message{
 		Id: messageId,
 	}
 
@@ -111,9 +117,10 @@ func DeleteByAdminRouter(c *gin.Context) {
 
 func DeleteByUser(c controller.Context, messageId string) (res schema.Response) {
 	var (
-		err  error
-		data schema.Message
-		tx   *gorm.DB
+		err         error
+		data        schema.Message
+		tx          *gorm.DB
+		messageInfo model.Message
 	)
 
 	defer func() {
@@ -136,12 +143,16 @@ func DeleteByUser(c controller.Context, messageId string) (res schema.Response)
 			}
 		}
 
+		if err == nil {
+			resetUnread(messageInfo.Uid)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
 	tx = database.Db.Begin()
 
-	messageInfo := model.Message{
+	messageInfo = model.Message{
 		Id:  messageId,
 		Uid: c.Uid,
 	}
@@ -188,3 +199,109 @@ func DeleteByUserRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	}, id)
 }
+
+type BatchDeleteParams struct {
+	Ids []string `json:"ids" valid:"required~请选择要删除的消息"` // 要删除的消息 ID
+}
+
+// 用户批量删除自己的消息, 返回被删除的消息
+func BatchDeleteByUser(c controller.Context, input BatchDeleteParams) (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.Message, 0)
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			resetUnread(c.Uid)
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	list := make([]model.Message, 0)
+
+	// 只能删除自己的消息, 不存在的消息会被忽略
+	if err = tx.Where("uid = ?", c.Uid).Where("id IN (?)", input.Ids).Find(&list).Error; err != nil {
+		return
+	}
+
+	if len(list) == 0 {
+		err = exception.MessageNotExist
+		return
+	}
+
+	ids := make([]string, 0)
+
+	for _, v := range list {
+		ids = append(ids, v.Id)
+	}
+
+	if err = tx.Where("id IN (?)", ids).Delete(model.Message{}).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		d := schema.Message{}
+
+		if err = mapstructure.Decode(v, &d.MessagePure); err != nil {
+			return
+		}
+
+		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
+		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+
+		data = append(data, d)
+	}
+
+	return
+}
+
+func BatchDeleteByUserRouter(c *gin.Context) {
+	var (
+		input BatchDeleteParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = BatchDeleteByUser(controller.NewContext(c), input)
+}
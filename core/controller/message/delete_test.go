@@ -6,6 +6,7 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
@@ -300,3 +301,119 @@ func TestDeleteByUserRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestBatchDeleteByUser(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+	otherInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+	defer auth.DeleteUserByUserName(otherInfo.Username)
+
+	createMessage := func(uid string) schema.Message {
+		r := message.Create(controller.Context{
+			Uid: adminInfo.Id,
+		}, message.CreateMessageParams{
+			Uid:     uid,
+			Title:   "TestBatchDelete",
+			Content: "TestBatchDelete",
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		n := schema.Message{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		return n
+	}
+
+	m1 := createMessage(userInfo.Id)
+	m2 := createMessage(userInfo.Id)
+	m3 := createMessage(otherInfo.Id)
+
+	defer message.DeleteMessageById(m1.Id)
+	defer message.DeleteMessageById(m2.Id)
+	defer message.DeleteMessageById(m3.Id)
+
+	// 参数不正确
+	{
+		r := message.BatchDeleteByUser(controller.Context{Uid: userInfo.Id}, message.BatchDeleteParams{})
+
+		assert.Equal(t, schema.StatusFail, r.Status)
+	}
+
+	// 别人的消息不会被删除
+	{
+		r := message.BatchDeleteByUser(controller.Context{Uid: userInfo.Id}, message.BatchDeleteParams{
+			Ids: []string{m1.Id, m2.Id, m3.Id},
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		list := make([]schema.Message, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 2)
+
+		for _, id := range []string{m1.Id, m2.Id} {
+			assert.Equal(t, gorm.ErrRecordNotFound, database.Db.Where("id = ?", id).First(&model.Message{}).Error)
+		}
+
+		assert.Nil(t, database.Db.Where("id = ?", m3.Id).First(&model.Message{}).Error)
+	}
+
+	// 没有可以删除的消息
+	{
+		r := message.BatchDeleteByUser(controller.Context{Uid: userInfo.Id}, message.BatchDeleteParams{
+			Ids: []string{m3.Id},
+		})
+
+		assert.Equal(t, exception.MessageNotExist.Error(), r.Message)
+	}
+}
+
+func TestBatchDeleteByUserRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	r := message.Create(controller.Context{
+		Uid: adminInfo.Id,
+	}, message.CreateMessageParams{
+		Uid:     userInfo.Id,
+		Title:   "TestBatchDelete",
+		Content: "TestBatchDelete",
+	})
+
+	messageInfo := schema.Message{}
+
+	assert.Nil(t, tester.Decode(r.Data, &messageInfo))
+
+	defer message.DeleteMessageById(messageInfo.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	body, _ := json.Marshal(&message.BatchDeleteParams{
+		Ids: []string{messageInfo.Id},
+	})
+
+	res := schema.Response{}
+
+	r2 := tester.HttpUser.Delete("/v1/message", body, &header)
+
+	assert.Equal(t, http.StatusOK, r2.Code)
+	assert.Nil(t, json.Unmarshal(r2.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	list := make([]schema.Message, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &list))
+	assert.Len(t, list, 1)
+	assert.Equal(t, messageInfo.Id, list[0].Id)
+}
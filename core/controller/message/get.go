@@ -9,6 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -72,6 +73,7 @@ func Get(c controller.Context, id string) (res schema.Response) {
 		data.ReadAt = &readAt
 	}
 
+	data.Html = render(MessageInfo)
 	data.CreatedAt = MessageInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = MessageInfo.UpdatedAt.Format(time.RFC3339Nano)
 
@@ -144,12 +146,18 @@ func GetByAdmin(c controller.Context, id string) (res schema.Response) {
 		data.ReadAt = &readAt
 	}
 
+	data.Html = render(MessageInfo)
 	data.CreatedAt = MessageInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = MessageInfo.UpdatedAt.Format(time.RFC3339Nano)
 
 	return
 }
 
+// 渲染消息的内容, 按更新时间缓存
+func render(m model.Message) string {
+	return content.Cached("message", m.Id, content.RevisionOf(m.UpdatedAt), m.Format, m.Content)
+}
+
 // GetRouter get Message detail router
 func GetRouter(c *gin.Context) {
 	var (
@@ -6,6 +6,7 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
@@ -70,6 +71,44 @@ func TestGetMessage(t *testing.T) {
 
 }
 
+func TestGetMessageFormat(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	markdown := model.ContentFormatMarkdown
+
+	r := message.Create(controller.Context{
+		Uid: adminInfo.Id,
+	}, message.CreateMessageParams{
+		Uid:     userInfo.Id,
+		Title:   "TestGetMessageFormat",
+		Content: "[link](https://example.com)",
+		Format:  &markdown,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Message{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer message.DeleteMessageById(n.Id)
+
+	assert.Equal(t, "link", n.Excerpt)
+
+	r = message.Get(controller.Context{
+		Uid: userInfo.Id,
+	}, n.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	messageInfo := r.Data.(schema.Message)
+
+	assert.Equal(t, `<p><a href="https://example.com" rel="nofollow noopener noreferrer">link</a></p>`+"\n", messageInfo.Html)
+}
+
 func TestGetAdmin(t *testing.T) {
 	var (
 		messageId string
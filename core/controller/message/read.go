@@ -43,6 +43,10 @@ func MarkRead(c controller.Context, id string) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			resetUnread(c.Uid)
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -98,3 +102,68 @@ func ReadRouter(c *gin.Context) {
 
 	res = MarkRead(controller.NewContext(c), id)
 }
+
+// 把用户所有未读的消息标记为已读
+func MarkReadAll(c controller.Context) (res schema.Response) {
+	var (
+		err error
+		tx  *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			resetUnread(c.Uid)
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	now := time.Now()
+
+	if err = tx.Model(&model.Message{}).Where("uid = ?", c.Uid).Where("read = ?", false).UpdateColumns(map[string]interface{}{
+		"read":    true,
+		"read_at": now,
+	}).Error; err != nil {
+		return
+	}
+
+	return
+}
+
+func ReadAllRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = MarkReadAll(controller.NewContext(c))
+}
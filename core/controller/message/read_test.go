@@ -7,6 +7,7 @@ import (
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/message"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
 	"github.com/axetroy/mocker"
@@ -142,3 +143,64 @@ func TestReadRouter(t *testing.T) {
 		assert.Nil(t, tester.Decode(res.Data, &n))
 	}
 }
+
+func TestMarkReadAll(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	for i := 0; i < 3; i++ {
+		r := message.Create(controller.Context{
+			Uid: adminInfo.Id,
+		}, message.CreateMessageParams{
+			Uid:     userInfo.Id,
+			Title:   "TestMarkReadAll",
+			Content: "TestMarkReadAll",
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		n := schema.Message{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		defer message.DeleteMessageById(n.Id)
+	}
+
+	count, err := message.CountUnread(database.Db, userInfo.Id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	r := message.MarkReadAll(controller.Context{
+		Uid: userInfo.Id,
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	count, err = message.CountUnread(database.Db, userInfo.Id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestReadAllRouter(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	r := tester.HttpUser.Put("/v1/message/read", nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+}
@@ -0,0 +1,20 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/jinzhu/gorm"
+)
+
+// 获取用户未读的消息数量
+func CountUnread(db *gorm.DB, uid string) (count int64, err error) {
+	err = db.Model(&model.Message{}).Where("uid = ?", uid).Where("read = ?", false).Count(&count).Error
+
+	return
+}
+
+// 消息有变动, 清除用户未读数的缓存
+func resetUnread(uid string) {
+	_ = redis.ClientUnread.Del(uid).Err()
+}
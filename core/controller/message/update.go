@@ -9,6 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
@@ -19,8 +20,9 @@ import (
 )
 
 type UpdateParams struct {
-	Title   *string `json:"title"`   // 消息标题
-	Content *string `json:"content"` // 消息内容
+	Title   *string              `json:"title"`   // 消息标题
+	Content *string              `json:"content"` // 消息内容
+	Format  *model.ContentFormat `json:"format"`  // 消息内容的格式
 }
 
 func Update(c controller.Context, messageId string, input UpdateParams) (res schema.Response) {
@@ -102,6 +104,29 @@ func Update(c controller.Context, messageId string, input UpdateParams) (res sch
 		updateModel.Content = *input.Content
 	}
 
+	if input.Format != nil {
+		shouldUpdate = true
+
+		if updateModel.Format, err = content.ParseFormat(input.Format); err != nil {
+			return
+		}
+	}
+
+	// 内容或者格式修改之后重新生成摘要
+	if input.Content != nil || input.Format != nil {
+		format, source := messageInfo.Format, messageInfo.Content
+
+		if input.Format != nil {
+			format = updateModel.Format
+		}
+
+		if input.Content != nil {
+			source = *input.Content
+		}
+
+		updateModel.Excerpt = content.Excerpt(format, source, content.ExcerptLength)
+	}
+
 	if shouldUpdate {
 		if err = tx.Model(&messageInfo).Updates(&updateModel).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
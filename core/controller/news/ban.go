@@ -0,0 +1,299 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+const ParamsUidName = "uid"
+
+type BanParams struct {
+	Uid          string  `json:"uid" valid:"required~请输入用户ID"`
+	Reason       string  `json:"reason" valid:"required~请输入禁言原因,stringlength(1|255)~禁言原因不能超过 255 个字"`
+	ExpiredAt    *string `json:"expired_at"`    // 解除的时间, 格式为 RFC3339, 不填则永久禁言
+	HideComments bool    `json:"hide_comments"` // 是否同时隐藏该用户所有的评论
+}
+
+func banToSchema(b model.NewsCommentBan) (data schema.NewsCommentBan, err error) {
+	if err = mapstructure.Decode(b, &data.NewsCommentBanPure); err != nil {
+		return
+	}
+
+	if b.ExpiredAt != nil {
+		t := b.ExpiredAt.Format(time.RFC3339Nano)
+		data.ExpiredAt = &t
+	}
+
+	data.CreatedAt = b.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = b.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 禁止用户评论, 重复禁言会覆盖之前的记录
+func Ban(c controller.Context, input BanParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsCommentBan
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	var expiredAt *time.Time
+
+	if input.ExpiredAt != nil && *input.ExpiredAt != "" {
+		t, er := time.Parse(time.RFC3339, *input.ExpiredAt)
+
+		if er != nil || !t.After(time.Now()) {
+			err = exception.InvalidParams
+			return
+		}
+
+		expiredAt = &t
+	}
+
+	tx = database.Db.Begin()
+
+	if _, err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	if err = tx.Where("id = ?", input.Uid).First(&model.User{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	banInfo := model.NewsCommentBan{Uid: input.Uid}
+
+	if err = tx.Where("uid = ?", input.Uid).FirstOrInit(&banInfo).Error; err != nil {
+		return
+	}
+
+	banInfo.Admin = c.Uid
+	banInfo.Reason = input.Reason
+	banInfo.ExpiredAt = expiredAt
+
+	if err = tx.Save(&banInfo).Error; err != nil {
+		return
+	}
+
+	if input.HideComments {
+		var ids []string
+
+		if err = tx.Model(&model.NewsComment{}).Where("uid = ? AND status = ?", input.Uid, model.NewsCommentStatusActive).Pluck("DISTINCT news_id", &ids).Error; err != nil {
+			return
+		}
+
+		if err = tx.Model(&model.NewsComment{}).Where("uid = ?", input.Uid).UpdateColumn("status", model.NewsCommentStatusHidden).Error; err != nil {
+			return
+		}
+
+		if err = recountComments(tx, ids...); err != nil {
+			return
+		}
+	}
+
+	data, err = banToSchema(banInfo)
+
+	return
+}
+
+// 解除禁言
+func Unban(c controller.Context, uid string) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsCommentBan
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	if _, err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	banInfo := model.NewsCommentBan{}
+
+	if err = tx.Where("uid = ?", uid).First(&banInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NewsCommentBanNotExist
+		}
+		return
+	}
+
+	if err = tx.Delete(&banInfo).Error; err != nil {
+		return
+	}
+
+	data, err = banToSchema(banInfo)
+
+	return
+}
+
+// 获取禁言列表
+func GetBanList(query schema.Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.NewsCommentBan, 0)
+		list = make([]model.NewsCommentBan, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query.Normalize()
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.NewsCommentBan{}).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.NewsCommentBan
+
+		if d, err = banToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func BanRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input BanParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Ban(controller.NewContext(c), input)
+}
+
+func UnbanRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, Unban(controller.NewContext(c), c.Param(ParamsUidName)))
+}
+
+func GetBanListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input schema.Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetBanList(input)
+}
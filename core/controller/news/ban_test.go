@@ -0,0 +1,91 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBan(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	createComment(t, userInfo.Id, n.Id, nil)
+
+	adminContext := controller.Context{Uid: adminInfo.Id}
+
+	// 解除的时间必须晚于当前时间
+	{
+		expiredAt := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+		r := news.Ban(adminContext, news.BanParams{
+			Uid:       userInfo.Id,
+			Reason:    "spam",
+			ExpiredAt: &expiredAt,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	// 禁言并隐藏所有的评论
+	{
+		r := news.Ban(adminContext, news.BanParams{
+			Uid:          userInfo.Id,
+			Reason:       "spam",
+			HideComments: true,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.NewsCommentBan{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Equal(t, adminInfo.Id, d.Admin)
+		assert.Nil(t, d.ExpiredAt)
+		assert.Equal(t, int64(0), getNewsComments(t, n.Id))
+	}
+
+	{
+		r := news.CreateComment(controller.Context{Uid: userInfo.Id}, n.Id, news.CreateCommentParams{
+			Content: "comment",
+		})
+
+		assert.Equal(t, exception.NewsCommentBanned.Error(), r.Message)
+	}
+
+	{
+		r := news.GetBanList(schema.Query{})
+
+		assert.Equal(t, "", r.Message)
+		assert.True(t, r.Meta.Total >= 1)
+	}
+
+	// 解除之后可以继续评论
+	{
+		r := news.Unban(adminContext, userInfo.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		createComment(t, userInfo.Id, n.Id, nil)
+	}
+
+	{
+		r := news.Unban(adminContext, userInfo.Id)
+
+		assert.Equal(t, exception.NewsCommentBanNotExist.Error(), r.Message)
+	}
+}
@@ -0,0 +1,621 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+const ParamsCommentIdName = "comment_id"
+
+type CommentQuery struct {
+	schema.Query
+	ParentId *string `json:"parent_id" form:"parent_id"` // 获取某条评论的回复, 不填则获取评论
+}
+
+type CommentAdminQuery struct {
+	schema.Query
+	NewsId   *string                  `json:"news_id" form:"news_id"`     // 新闻公告ID
+	Uid      *string                  `json:"uid" form:"uid"`             // 评论的用户
+	ParentId *string                  `json:"parent_id" form:"parent_id"` // 所属的评论
+	Status   *model.NewsCommentStatus `json:"status" form:"status"`       // 评论状态
+}
+
+type CreateCommentParams struct {
+	Content  string  `json:"content" valid:"required~请输入评论内容,stringlength(1|500)~评论内容不能超过 500 个字"`
+	ParentId *string `json:"parent_id"` // 回复的评论或者回复, 不填则为评论
+}
+
+type UpdateCommentParams struct {
+	Status model.NewsCommentStatus `json:"status"` // 评论状态, 0 正常, -1 隐藏
+}
+
+// 用户能看到的文章, 已经发布并且到了发布时间
+func getPublishedNews(db *gorm.DB, id string) (n model.News, err error) {
+	if err = db.Where("id = ? AND status = ? AND published_at <= ?", id, model.NewsStatusActive, time.Now()).First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NewsNotExist
+		}
+	}
+
+	return
+}
+
+func getComment(db *gorm.DB, id string) (c model.NewsComment, err error) {
+	if err = db.Where("id = ?", id).First(&c).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NewsCommentNotExist
+		}
+	}
+
+	return
+}
+
+// 获取某篇文章下正常显示的评论或者回复, 已经隐藏的视为不存在
+func getVisibleComment(db *gorm.DB, newsId string, id string) (c model.NewsComment, err error) {
+	if c, err = getComment(db, id); err != nil {
+		return
+	}
+
+	if c.NewsId != newsId || c.Status != model.NewsCommentStatusActive {
+		err = exception.NewsCommentNotExist
+	}
+
+	return
+}
+
+// 是否被禁止评论
+func checkBanned(db *gorm.DB, uid string) error {
+	ban := model.NewsCommentBan{}
+
+	if err := db.Where("uid = ?", uid).First(&ban).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if ban.IsActive(time.Now()) {
+		return exception.NewsCommentBanned
+	}
+
+	return nil
+}
+
+// 转换成接口输出的格式, 带上评论用户的公开资料和可见的回复数量
+func commentsToSchema(db *gorm.DB, list []model.NewsComment) (data []schema.NewsComment, err error) {
+	data = make([]schema.NewsComment, 0, len(list))
+
+	if len(list) == 0 {
+		return
+	}
+
+	var (
+		uids    = make([]string, 0, len(list))
+		roots   = make([]string, 0, len(list))
+		users   = make([]model.User, 0)
+		profile = map[string]schema.ProfilePublic{}
+		replies = map[string]int64{}
+	)
+
+	for _, v := range list {
+		uids = append(uids, v.Uid)
+
+		if v.ParentId == nil {
+			roots = append(roots, v.Id)
+		}
+	}
+
+	if err = db.Where("id IN (?)", uids).Find(&users).Error; err != nil {
+		return
+	}
+
+	for _, u := range users {
+		profile[u.Id] = schema.ProfilePublic{
+			Id:       u.Id,
+			Username: u.Username,
+			Nickname: u.Nickname,
+			Avatar:   u.Avatar,
+		}
+	}
+
+	if len(roots) > 0 {
+		rows, er := db.Model(&model.NewsComment{}).
+			Select("parent_id, COUNT(*)").
+			Where("parent_id IN (?) AND status = ?", roots, model.NewsCommentStatusActive).
+			Group("parent_id").
+			Rows()
+
+		if er != nil {
+			err = er
+			return
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				id    string
+				count int64
+			)
+
+			if err = rows.Scan(&id, &count); err != nil {
+				return
+			}
+
+			replies[id] = count
+		}
+	}
+
+	for _, v := range list {
+		d := schema.NewsComment{}
+
+		if err = mapstructure.Decode(v, &d.NewsCommentPure); err != nil {
+			return
+		}
+
+		d.User = profile[v.Uid]
+		d.Replies = replies[v.Id]
+		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
+		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+
+		data = append(data, d)
+	}
+
+	return
+}
+
+func getCommentList(db *gorm.DB, query schema.Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.NewsComment, 0)
+		list = make([]model.NewsComment, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query.Normalize()
+
+	if err = query.Order(db.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = db.Model(model.NewsComment{}).Count(&total).Error; err != nil {
+		return
+	}
+
+	if data, err = commentsToSchema(database.Db, list); err != nil {
+		return
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 用户获取文章的评论, 或者某条评论的回复, 只返回正常显示的内容
+// 评论默认按照时间倒序, 回复默认按照时间正序
+func GetCommentList(newsId string, input CommentQuery) (res schema.List) {
+	if _, err := getPublishedNews(database.Db, newsId); err != nil {
+		helper.ResponseList(&res, nil, nil, err)
+		return
+	}
+
+	db := database.Db.Where("news_id = ? AND status = ?", newsId, model.NewsCommentStatusActive)
+
+	query := input.Query
+
+	if input.ParentId != nil && *input.ParentId != "" {
+		// 评论被隐藏之后, 下面的回复也不再显示
+		if _, err := getVisibleComment(database.Db, newsId, *input.ParentId); err != nil {
+			helper.ResponseList(&res, nil, nil, err)
+			return
+		}
+
+		db = db.Where("parent_id = ?", *input.ParentId)
+
+		if len(query.Sort) == 0 {
+			query.Sort = "created_at"
+		}
+	} else {
+		db = db.Where("parent_id IS NULL")
+	}
+
+	return getCommentList(db, query)
+}
+
+// 管理员获取评论列表, 包括已经隐藏的评论
+func GetCommentListByAdmin(input CommentAdminQuery) (res schema.List) {
+	filter := map[string]interface{}{}
+
+	if input.NewsId != nil {
+		filter["news_id"] = *input.NewsId
+	}
+
+	if input.Uid != nil {
+		filter["uid"] = *input.Uid
+	}
+
+	if input.ParentId != nil {
+		filter["parent_id"] = *input.ParentId
+	}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	return getCommentList(database.Db.Where(filter), input.Query)
+}
+
+// 发表评论或者回复, 回复的回复也属于同一条评论
+func CreateComment(c controller.Context, newsId string, input CreateCommentParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsComment
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	if err = checkBanned(tx, c.Uid); err != nil {
+		return
+	}
+
+	if _, err = getPublishedNews(tx, newsId); err != nil {
+		return
+	}
+
+	commentInfo := model.NewsComment{
+		NewsId:  newsId,
+		Uid:     c.Uid,
+		Content: input.Content,
+		Status:  model.NewsCommentStatusActive,
+	}
+
+	if input.ParentId != nil && *input.ParentId != "" {
+		var parent model.NewsComment
+
+		if parent, err = getVisibleComment(tx, newsId, *input.ParentId); err != nil {
+			return
+		}
+
+		rootId := parent.Id
+
+		// 回复所属的评论被隐藏之后也不能再回复
+		if parent.ParentId != nil {
+			rootId = *parent.ParentId
+
+			if _, err = getVisibleComment(tx, newsId, rootId); err != nil {
+				return
+			}
+		}
+
+		commentInfo.ParentId = &rootId
+		commentInfo.ReplyUid = &parent.Uid
+	}
+
+	if err = tx.Create(&commentInfo).Error; err != nil {
+		return
+	}
+
+	if err = recountComments(tx, newsId); err != nil {
+		return
+	}
+
+	var list []schema.NewsComment
+
+	if list, err = commentsToSchema(tx, []model.NewsComment{commentInfo}); err != nil {
+		return
+	}
+
+	data = list[0]
+
+	return
+}
+
+// 删除评论, 用户只能删除自己的评论, 删除评论时也会删除下面的回复
+func DeleteComment(c controller.Context, id string) (res schema.Response) {
+	return deleteComment(id, func(tx *gorm.DB, commentInfo model.NewsComment) error {
+		if commentInfo.Uid != c.Uid {
+			return exception.NoPermission
+		}
+
+		return nil
+	})
+}
+
+// 管理员删除评论
+func DeleteCommentByAdmin(c controller.Context, id string) (res schema.Response) {
+	return deleteComment(id, func(tx *gorm.DB, commentInfo model.NewsComment) error {
+		_, err := getAdmin(tx, c.Uid)
+
+		return err
+	})
+}
+
+func deleteComment(id string, check func(tx *gorm.DB, commentInfo model.NewsComment) error) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsComment
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	var commentInfo model.NewsComment
+
+	if commentInfo, err = getComment(tx, id); err != nil {
+		return
+	}
+
+	if err = check(tx, commentInfo); err != nil {
+		return
+	}
+
+	if err = tx.Where("id = ? OR parent_id = ?", commentInfo.Id, commentInfo.Id).Delete(model.NewsComment{}).Error; err != nil {
+		return
+	}
+
+	if err = recountComments(tx, commentInfo.NewsId); err != nil {
+		return
+	}
+
+	var list []schema.NewsComment
+
+	if list, err = commentsToSchema(tx, []model.NewsComment{commentInfo}); err != nil {
+		return
+	}
+
+	data = list[0]
+
+	return
+}
+
+// 管理员隐藏或者恢复评论, 隐藏之后用户看不到这条评论, 也不计入评论数
+func UpdateComment(c controller.Context, id string, input UpdateCommentParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsComment
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if !model.IsValidNewsCommentStatus(input.Status) {
+		err = exception.NewsCommentInvalidStatus
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	if _, err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	var commentInfo model.NewsComment
+
+	if commentInfo, err = getComment(tx, id); err != nil {
+		return
+	}
+
+	// 状态可能为 0, 不能通过 Updates 更新
+	if err = tx.Model(&commentInfo).Update("status", input.Status).Error; err != nil {
+		return
+	}
+
+	if err = recountComments(tx, commentInfo.NewsId); err != nil {
+		return
+	}
+
+	var list []schema.NewsComment
+
+	if list, err = commentsToSchema(tx, []model.NewsComment{commentInfo}); err != nil {
+		return
+	}
+
+	data = list[0]
+
+	return
+}
+
+func GetCommentListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input CommentQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetCommentList(c.Param(ParamsIdName), input)
+}
+
+func GetCommentListByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input CommentAdminQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetCommentListByAdmin(input)
+}
+
+func CreateCommentRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input CreateCommentParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = CreateComment(controller.NewContext(c), c.Param(ParamsIdName), input)
+}
+
+func DeleteCommentRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, DeleteComment(controller.NewContext(c), c.Param(ParamsCommentIdName)))
+}
+
+func DeleteCommentByAdminRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, DeleteCommentByAdmin(controller.NewContext(c), c.Param(ParamsCommentIdName)))
+}
+
+func UpdateCommentRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input UpdateCommentParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = UpdateComment(controller.NewContext(c), c.Param(ParamsCommentIdName), input)
+}
@@ -0,0 +1,269 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func createComment(t *testing.T, uid string, newsId string, parentId *string) schema.NewsComment {
+	r := news.CreateComment(controller.Context{Uid: uid}, newsId, news.CreateCommentParams{
+		Content:  "comment",
+		ParentId: parentId,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	c := schema.NewsComment{}
+
+	assert.Nil(t, tester.Decode(r.Data, &c))
+
+	return c
+}
+
+func getNewsComments(t *testing.T, id string) int64 {
+	r := news.GetNews(id)
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n.Comments
+}
+
+func TestComment(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+	otherInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+	defer auth.DeleteUserByUserName(otherInfo.Username)
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	// 评论内容不能为空
+	{
+		r := news.CreateComment(controller.Context{Uid: userInfo.Id}, n.Id, news.CreateCommentParams{})
+
+		assert.Equal(t, schema.StatusFail, r.Status)
+	}
+
+	c := createComment(t, userInfo.Id, n.Id, nil)
+
+	assert.Equal(t, userInfo.Id, c.Uid)
+	assert.Equal(t, userInfo.Id, c.User.Id)
+	assert.Nil(t, c.ParentId)
+
+	// 回复的回复也属于同一条评论
+	reply := createComment(t, otherInfo.Id, n.Id, &c.Id)
+	replyOfReply := createComment(t, userInfo.Id, n.Id, &reply.Id)
+
+	assert.Equal(t, c.Id, *reply.ParentId)
+	assert.Equal(t, c.Id, *replyOfReply.ParentId)
+	assert.Equal(t, otherInfo.Id, *replyOfReply.ReplyUid)
+
+	assert.Equal(t, int64(3), getNewsComments(t, n.Id))
+
+	// 评论列表只包含评论, 带上回复数量
+	{
+		r := news.GetCommentList(n.Id, news.CommentQuery{})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.NewsComment, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, c.Id, list[0].Id)
+			assert.Equal(t, int64(2), list[0].Replies)
+		}
+	}
+
+	// 回复按照时间正序
+	{
+		r := news.GetCommentList(n.Id, news.CommentQuery{ParentId: &c.Id})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.NewsComment, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		if assert.Len(t, list, 2) {
+			assert.Equal(t, reply.Id, list[0].Id)
+			assert.Equal(t, replyOfReply.Id, list[1].Id)
+		}
+	}
+
+	// 隐藏之后用户看不到, 也不计入评论数
+	{
+		r := news.UpdateComment(controller.Context{Uid: adminInfo.Id}, reply.Id, news.UpdateCommentParams{
+			Status: model.NewsCommentStatusHidden,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), getNewsComments(t, n.Id))
+
+		list := news.GetCommentList(n.Id, news.CommentQuery{ParentId: &c.Id})
+
+		assert.Equal(t, int64(1), list.Meta.Total)
+	}
+
+	// 不能回复已经隐藏的评论
+	{
+		r := news.CreateComment(controller.Context{Uid: userInfo.Id}, n.Id, news.CreateCommentParams{
+			Content:  "comment",
+			ParentId: &reply.Id,
+		})
+
+		assert.Equal(t, exception.NewsCommentNotExist.Error(), r.Message)
+	}
+
+	// 错误的状态
+	{
+		r := news.UpdateComment(controller.Context{Uid: adminInfo.Id}, reply.Id, news.UpdateCommentParams{
+			Status: model.NewsCommentStatus(100),
+		})
+
+		assert.Equal(t, exception.NewsCommentInvalidStatus.Error(), r.Message)
+	}
+
+	// 隐藏评论之后, 下面的回复也看不到, 不计入评论数, 也不能回复
+	{
+		r := news.UpdateComment(controller.Context{Uid: adminInfo.Id}, c.Id, news.UpdateCommentParams{
+			Status: model.NewsCommentStatusHidden,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(0), getNewsComments(t, n.Id))
+
+		list := news.GetCommentList(n.Id, news.CommentQuery{ParentId: &c.Id})
+
+		assert.Equal(t, exception.NewsCommentNotExist.Error(), list.Message)
+
+		r2 := news.CreateComment(controller.Context{Uid: otherInfo.Id}, n.Id, news.CreateCommentParams{
+			Content:  "comment",
+			ParentId: &replyOfReply.Id,
+		})
+
+		assert.Equal(t, exception.NewsCommentNotExist.Error(), r2.Message)
+
+		r3 := news.UpdateComment(controller.Context{Uid: adminInfo.Id}, c.Id, news.UpdateCommentParams{
+			Status: model.NewsCommentStatusActive,
+		})
+
+		assert.Equal(t, "", r3.Message)
+		assert.Equal(t, int64(2), getNewsComments(t, n.Id))
+	}
+
+	// 只有管理员可以通过管理接口删除
+	{
+		r := news.DeleteCommentByAdmin(controller.Context{Uid: userInfo.Id}, c.Id)
+
+		assert.Equal(t, exception.AdminNotExist.Error(), r.Message)
+	}
+
+	// 只能删除自己的评论
+	{
+		r := news.DeleteComment(controller.Context{Uid: otherInfo.Id}, c.Id)
+
+		assert.Equal(t, exception.NoPermission.Error(), r.Message)
+	}
+
+	// 删除评论会连同回复一起删除
+	{
+		r := news.DeleteComment(controller.Context{Uid: userInfo.Id}, c.Id)
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(0), getNewsComments(t, n.Id))
+
+		list := news.GetCommentListByAdmin(news.CommentAdminQuery{NewsId: &n.Id})
+
+		assert.Equal(t, "", list.Message)
+		assert.Equal(t, int64(0), list.Meta.Total)
+	}
+}
+
+func TestCommentRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	body, _ := json.Marshal(&news.CreateCommentParams{
+		Content: "comment",
+	})
+
+	// 没有登陆不能评论
+	{
+		r := tester.HttpUser.Post("/v1/news/n/"+n.Id+"/comment", body, nil)
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, schema.StatusFail, res.Status)
+	}
+
+	c := schema.NewsComment{}
+
+	{
+		r := tester.HttpUser.Post("/v1/news/n/"+n.Id+"/comment", body, &header)
+
+		if !assert.Equal(t, http.StatusOK, r.Code) {
+			return
+		}
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+		assert.Nil(t, tester.Decode(res.Data, &c))
+		assert.Equal(t, "comment", c.Content)
+	}
+
+	// 不需要登陆也可以看评论
+	{
+		r := tester.HttpUser.Get("/v1/news/n/"+n.Id+"/comment", nil, nil)
+
+		res := schema.List{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, int64(1), res.Meta.Total)
+	}
+
+	{
+		r := tester.HttpAdmin.Delete("/v1/news/comment/c/"+c.Id, nil, &mocker.Header{
+			"Authorization": token.Prefix + " " + adminInfo.Token,
+		})
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/counter"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const (
+	counterViews = "news:views" // 还没有写入数据库的浏览量
+	counterLikes = "news:likes" // 还没有写入数据库的点赞数
+
+	// 热度的计算方式, 评论和点赞比浏览的权重更高
+	popularityExpr = "views + likes * 10 + comments * 20"
+)
+
+// 同一个用户在这段时间内多次浏览只计算一次
+var ViewDuration = time.Hour * 24
+
+// 记录一次浏览, viewer 为用户 ID, 没有登陆则为 IP 地址
+func RecordView(id string, viewer string) error {
+	first, err := counter.Once("news:view:"+id+":"+viewer, ViewDuration)
+
+	if err != nil || !first {
+		return err
+	}
+
+	return counter.Incr(counterViews, id, 1)
+}
+
+// 把累加在 redis 中的浏览量和点赞数写入数据库, 由定时任务调用, 返回更新的文章数量
+func FlushCounters(db *gorm.DB) (count int, err error) {
+	var n int
+
+	if n, err = counter.Flush(counterViews, func(id string, delta int64) error {
+		return updateCounter(db, id, "views", gorm.Expr("views + ?", delta))
+	}); err != nil {
+		return
+	}
+
+	count = count + n
+
+	// 点赞数以点赞记录为准, 增量只用来找出需要重新统计的文章
+	if n, err = counter.Flush(counterLikes, func(id string, delta int64) error {
+		return updateCounter(db, id, "likes", gorm.Expr("(SELECT COUNT(*) FROM news_like WHERE news_like.news_id = news.id)"))
+	}); err != nil {
+		return
+	}
+
+	count = count + n
+
+	return
+}
+
+// 在同一个事务中更新统计的列和热度, 提交之后 redis 中的增量才会被删除
+// 如果分开执行, 热度更新失败时增量会保留到下次, 已经写入的浏览量就会被重复累加
+func updateCounter(db *gorm.DB, id string, column string, value interface{}) (err error) {
+	tx := db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	if err = tx.Model(&model.News{}).Where("id = ?", id).UpdateColumn(column, value).Error; err != nil {
+		return
+	}
+
+	err = refreshPopularity(tx, id)
+
+	return
+}
+
+// 重新统计可见的评论和回复数, 评论被隐藏之后下面的回复也不计入
+func recountComments(db *gorm.DB, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := db.Model(&model.News{}).Where("id IN (?)", ids).UpdateColumn("comments", gorm.Expr(`(SELECT COUNT(*) FROM news_comment AS c WHERE c.news_id = news.id AND c.status = ? AND c.deleted_at IS NULL AND (c.parent_id IS NULL OR EXISTS (SELECT 1 FROM news_comment AS p WHERE p.id = c.parent_id AND p.status = ? AND p.deleted_at IS NULL)))`, model.NewsCommentStatusActive, model.NewsCommentStatusActive)).Error; err != nil {
+		return err
+	}
+
+	return refreshPopularity(db, ids...)
+}
+
+func refreshPopularity(db *gorm.DB, ids ...string) error {
+	return db.Model(&model.News{}).Where("id IN (?)", ids).UpdateColumn("popularity", gorm.Expr(popularityExpr)).Error
+}
+
+// 加上还没有写入数据库的浏览量和点赞数
+func withPending(list []schema.News) {
+	ids := make([]string, 0, len(list))
+
+	for _, v := range list {
+		ids = append(ids, v.Id)
+	}
+
+	views := counter.Pending(counterViews, ids...)
+	likes := counter.Pending(counterLikes, ids...)
+
+	for i := range list {
+		list[i].Views = list[i].Views + views[list[i].Id]
+		list[i].Likes = list[i].Likes + likes[list[i].Id]
+	}
+}
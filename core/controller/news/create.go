@@ -4,30 +4,34 @@ package news
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
-	"time"
 )
 
 type CreateNewParams struct {
-	Title   string         `json:"title"`
-	Content string         `json:"content"`
-	Type    model.NewsType `json:"type"`
-	Tags    []string       `json:"tags"`
+	Title       string               `json:"title"`
+	Content     string               `json:"content"`
+	Format      *model.ContentFormat `json:"format"` // 内容的格式, 默认为 html
+	Type        model.NewsType       `json:"type"`
+	Tags        []string             `json:"tags"`
+	Status      *model.NewsStatus    `json:"status"`       // 默认直接发布, 也可以保存为草稿
+	PublishedAt *string              `json:"published_at"` // 发布时间, 不填则立即发布, 填写将来的时间则定时发布
 }
 
 func Create(c controller.Context, input CreateNewParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.News
-		tx   *gorm.DB
+		err    error
+		data   schema.News
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -50,6 +54,11 @@ func Create(c controller.Context, input CreateNewParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+			expireFeedVersion()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -87,17 +96,31 @@ func Create(c controller.Context, input CreateNewParams) (res schema.Response) {
 		Status:  model.NewsStatusActive,
 	}
 
+	if input.Status != nil {
+		NewsInfo.Status = *input.Status
+	}
+
+	if NewsInfo.Format, err = content.ParseFormat(input.Format); err != nil {
+		return
+	}
+
+	if input.PublishedAt != nil {
+		if NewsInfo.PublishedAt, err = parsePublishedAt(*input.PublishedAt); err != nil {
+			return
+		}
+	}
+
 	if err = tx.Create(&NewsInfo).Error; err != nil {
 		return
 	}
 
-	if er := mapstructure.Decode(NewsInfo, &data.NewsPure); er != nil {
-		err = er
+	// 创建的内容作为第一个版本
+	if NewsInfo, err = saveNews(tx, &events, c.Uid, NewsInfo, nil); err != nil {
 		return
 	}
 
-	data.CreatedAt = NewsInfo.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = NewsInfo.UpdatedAt.Format(time.RFC3339Nano)
+	data, err = newsToSchema(NewsInfo)
+
 	return
 }
 
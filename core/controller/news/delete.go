@@ -16,10 +16,6 @@ import (
 	"time"
 )
 
-func DeleteNewsById(id string) {
-	database.DeleteRowByTable("news", "id", id)
-}
-
 func Delete(c controller.Context, addressId string) (res schema.Response) {
 	var (
 		err  error
@@ -47,6 +43,10 @@ func Delete(c controller.Context, addressId string) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			expireFeedVersion()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -103,7 +103,7 @@ func DeleteRouter(c *gin.Context) {
 		c.JSON(http.StatusOK, res)
 	}()
 
-	id := c.Param("news_id")
+	id := c.Param(ParamsIdName)
 
 	res = Delete(controller.NewContext(c), id)
 }
@@ -0,0 +1,312 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/feed"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 订阅源最多输出的文章数量
+var FeedLimit = 20
+
+// 订阅源版本的缓存时间, 文章保存或者删除之后会立即清除缓存
+var FeedVersionTTL = time.Minute
+
+const feedVersionKey = "news:feed:version"
+
+type FeedQuery struct {
+	Format feed.Format     `json:"format" form:"format"` // 订阅格式, rss, atom 或者 json, 默认为 rss
+	Type   *model.NewsType `json:"type" form:"type"`     // 文章类型, 不填则包括所有类型
+	Tag    *string         `json:"tag" form:"tag"`       // 只输出带有该标签的文章
+}
+
+func checkFeedQuery(input FeedQuery) (FeedQuery, error) {
+	if input.Format == "" {
+		input.Format = feed.FormatRSS
+	}
+
+	if !feed.IsValidFormat(input.Format) {
+		return input, exception.NewsInvalidFeedFormat
+	}
+
+	if input.Type != nil && !model.IsValidNewsType(*input.Type) {
+		return input, exception.NewsInvalidType
+	}
+
+	return input, nil
+}
+
+// 用户端的地址, 配置的域名没有协议时根据是否启用 TLS 补全, 没有端口时补上监听的端口
+func siteURL() string {
+	domain := strings.TrimRight(config.User.Domain, "/")
+
+	if strings.Contains(domain, "://") {
+		return domain
+	}
+
+	scheme, defaultPort := "http", "80"
+
+	if config.User.TLS != nil {
+		scheme, defaultPort = "https", "443"
+	}
+
+	if _, _, err := net.SplitHostPort(domain); err != nil && config.User.Port != "" && config.User.Port != defaultPort {
+		domain = net.JoinHostPort(strings.Trim(domain, "[]"), config.User.Port)
+	}
+
+	return scheme + "://" + domain
+}
+
+// 文章的公开地址, 没有配置时指向获取文章详情的接口
+func articleURL(id string) string {
+	if config.User.ArticleURL == "" {
+		return siteURL() + "/v1/news/n/" + id
+	}
+
+	return strings.Replace(config.User.ArticleURL, "{id}", url.PathEscape(id), -1)
+}
+
+func feedURL(input FeedQuery) string {
+	values := url.Values{}
+
+	values.Set("format", string(input.Format))
+
+	if input.Type != nil {
+		values.Set("type", string(*input.Type))
+	}
+
+	if input.Tag != nil && *input.Tag != "" {
+		values.Set("tag", *input.Tag)
+	}
+
+	return siteURL() + "/v1/news/feed?" + values.Encode()
+}
+
+// 订阅源的版本, 用于 ETag 和 Last-Modified
+// 修改时间取所有文章(包括已删除的)最后一次修改, 删除和到达发布时间的时间, 这样文章下线或者删除之后订阅源也会更新
+func GetFeedVersion(input FeedQuery) (etag string, lastModified time.Time, err error) {
+	if input, err = checkFeedQuery(input); err != nil {
+		return
+	}
+
+	if lastModified, err = feedModified(); err != nil {
+		return
+	}
+
+	var newsType, tag string
+
+	if input.Type != nil {
+		newsType = string(*input.Type)
+	}
+
+	if input.Tag != nil {
+		tag = *input.Tag
+	}
+
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%s|%d|%d", input.Format, newsType, tag, FeedLimit, lastModified.UnixNano())))
+
+	etag = `"` + hex.EncodeToString(hash[:]) + `"`
+
+	return
+}
+
+// 订阅源最后修改的时间, 需要扫描整个表, 所以缓存在 redis 中
+// 缓存在下一篇定时发布的文章到达发布时间之前过期
+func feedModified() (lastModified time.Time, err error) {
+	if raw, er := redis.ClientContent.Get(feedVersionKey).Int64(); er == nil {
+		if raw > 0 {
+			lastModified = time.Unix(0, raw)
+		}
+		return
+	}
+
+	now := time.Now()
+
+	var t, next *time.Time
+
+	if err = database.Db.Unscoped().Model(&model.News{}).
+		Select("MAX(GREATEST(updated_at, deleted_at, CASE WHEN published_at <= ? THEN published_at END)), MIN(CASE WHEN published_at > ? AND deleted_at IS NULL THEN published_at END)", now, now).
+		Row().
+		Scan(&t, &next); err != nil {
+		return
+	}
+
+	if t != nil {
+		lastModified = *t
+	}
+
+	ttl := FeedVersionTTL
+
+	if next != nil && next.Sub(now) < ttl {
+		ttl = next.Sub(now)
+	}
+
+	if ttl > 0 {
+		var raw int64
+
+		if !lastModified.IsZero() {
+			raw = lastModified.UnixNano()
+		}
+
+		_ = redis.ClientContent.Set(feedVersionKey, raw, ttl).Err()
+	}
+
+	return
+}
+
+// 文章保存或者删除之后清除订阅源版本的缓存, 在事务提交之后调用
+func expireFeedVersion() {
+	_ = redis.ClientContent.Del(feedVersionKey).Err()
+}
+
+// 输出已经发布并且到了发布时间的文章, 按照发布时间倒序
+func GetFeed(input FeedQuery) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+	}()
+
+	if input, err = checkFeedQuery(input); err != nil {
+		return
+	}
+
+	db := database.Db.Where("status = ? AND published_at <= ?", model.NewsStatusActive, time.Now())
+
+	title := "新闻公告"
+
+	if input.Type != nil {
+		db = db.Where("type = ?", *input.Type)
+
+		if *input.Type == model.NewsTypeAnnouncement {
+			title = "官方公告"
+		} else {
+			title = "新闻资讯"
+		}
+	}
+
+	if input.Tag != nil && *input.Tag != "" {
+		db = db.Where("? = ANY(tags)", *input.Tag)
+		title = title + " - " + *input.Tag
+	}
+
+	list := make([]model.News, 0)
+
+	if err = db.Order("published_at DESC").Limit(FeedLimit).Find(&list).Error; err != nil {
+		return
+	}
+
+	f := feed.Feed{
+		Title:       title,
+		Description: title,
+		Link:        siteURL(),
+		FeedURL:     feedURL(input),
+		Items:       make([]feed.Item, 0, len(list)),
+	}
+
+	for _, n := range list {
+		link := articleURL(n.Id)
+
+		item := feed.Item{
+			Id:         link,
+			Title:      n.Title,
+			Link:       link,
+			Summary:    n.Excerpt,
+			Content:    content.Cached(renderKind, n.Id, strconv.Itoa(n.Version), n.Format, n.Content),
+			Categories: append([]string{string(n.Type)}, n.Tags...),
+			Published:  *n.PublishedAt,
+			Updated:    n.UpdatedAt,
+		}
+
+		// 定时发布的文章, 到了发布时间才算更新
+		if item.Published.After(item.Updated) {
+			item.Updated = item.Published
+		}
+
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+
+		f.Items = append(f.Items, item)
+	}
+
+	return feed.Render(input.Format, f)
+}
+
+func GetFeedRouter(c *gin.Context) {
+	var (
+		err   error
+		data  []byte
+		input FeedQuery
+	)
+
+	defer func() {
+		if err != nil {
+			c.Writer.Header().Del("ETag")
+			c.Writer.Header().Del("Last-Modified")
+			c.JSON(http.StatusOK, schema.Response{
+				Status:  exception.GetCodeFromError(err),
+				Message: err.Error(),
+				Data:    nil,
+			})
+		}
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	if input, err = checkFeedQuery(input); err != nil {
+		return
+	}
+
+	etag, lastModified, err := GetFeedVersion(input)
+
+	if err != nil {
+		return
+	}
+
+	// 允许客户端缓存, 但是每次都需要校验
+	c.Header("Cache-Control", "no-cache")
+	c.Header("ETag", etag)
+
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if feed.NotModified(c.Request.Header, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if data, err = GetFeed(input); err != nil {
+		return
+	}
+
+	c.Data(http.StatusOK, feed.ContentType(input.Format), data)
+}
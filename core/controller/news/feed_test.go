@@ -0,0 +1,192 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/feed"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetFeed(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tag := "feed-" + util.RandomString(6)
+	format := model.ContentFormatMarkdown
+
+	r := news.Create(controller.Context{Uid: adminInfo.Id}, news.CreateNewParams{
+		Title:   "title",
+		Content: "**bold**",
+		Format:  &format,
+		Type:    model.NewsTypeAnnouncement,
+		Tags:    []string{tag},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer news.DeleteNewsById(n.Id)
+
+	// 草稿不会出现在订阅源中
+	draftStatus := model.NewsStatusDraft
+
+	r = news.Create(controller.Context{Uid: adminInfo.Id}, news.CreateNewParams{
+		Title:   "draft",
+		Content: "draft",
+		Type:    model.NewsTypeAnnouncement,
+		Tags:    []string{tag},
+		Status:  &draftStatus,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	draft := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &draft))
+
+	defer news.DeleteNewsById(draft.Id)
+
+	newsType := model.NewsTypeAnnouncement
+
+	{
+		data, err := news.GetFeed(news.FeedQuery{Format: feed.FormatJSON, Type: &newsType, Tag: &tag})
+
+		assert.Nil(t, err)
+
+		res := struct {
+			Items []struct {
+				Title       string   `json:"title"`
+				ContentHTML string   `json:"content_html"`
+				Tags        []string `json:"tags"`
+			} `json:"items"`
+		}{}
+
+		assert.Nil(t, json.Unmarshal(data, &res))
+
+		if assert.Len(t, res.Items, 1) {
+			assert.Equal(t, "title", res.Items[0].Title)
+			assert.Equal(t, "<p><strong>bold</strong></p>\n", res.Items[0].ContentHTML)
+			assert.Equal(t, []string{string(model.NewsTypeAnnouncement), tag}, res.Items[0].Tags)
+		}
+	}
+
+	// 配置了文章的公开地址时, 链接指向该地址
+	{
+		config.User.ArticleURL = "https://example.com/news/{id}"
+
+		defer func() {
+			config.User.ArticleURL = ""
+		}()
+
+		data, err := news.GetFeed(news.FeedQuery{Format: feed.FormatJSON, Type: &newsType, Tag: &tag})
+
+		assert.Nil(t, err)
+
+		res := struct {
+			Items []struct {
+				URL string `json:"url"`
+			} `json:"items"`
+		}{}
+
+		assert.Nil(t, json.Unmarshal(data, &res))
+
+		if assert.Len(t, res.Items, 1) {
+			assert.Equal(t, "https://example.com/news/"+n.Id, res.Items[0].URL)
+		}
+	}
+
+	{
+		t1 := model.NewsTypeNews
+
+		data, err := news.GetFeed(news.FeedQuery{Format: feed.FormatRSS, Type: &t1, Tag: &tag})
+
+		assert.Nil(t, err)
+		assert.False(t, strings.Contains(string(data), "<item>"))
+	}
+
+	{
+		_, err := news.GetFeed(news.FeedQuery{Format: "xml"})
+
+		assert.Equal(t, exception.NewsInvalidFeedFormat, err)
+	}
+}
+
+func TestGetFeedRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	r := tester.HttpUser.Get("/v1/news/feed?format=atom", nil, nil)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	assert.Equal(t, feed.ContentType(feed.FormatAtom), r.Header().Get("Content-Type"))
+	assert.True(t, strings.Contains(r.Body.String(), "/v1/news/n/"+n.Id))
+
+	etag := r.Header().Get("ETag")
+	lastModified := r.Header().Get("Last-Modified")
+
+	assert.NotEqual(t, "", etag)
+	assert.NotEqual(t, "", lastModified)
+
+	// 没有修改时返回 304
+	{
+		r := tester.HttpUser.Get("/v1/news/feed?format=atom", nil, &mocker.Header{
+			"If-None-Match": etag,
+		})
+
+		assert.Equal(t, http.StatusNotModified, r.Code)
+		assert.Equal(t, 0, r.Body.Len())
+	}
+
+	{
+		r := tester.HttpUser.Get("/v1/news/feed?format=atom", nil, &mocker.Header{
+			"If-Modified-Since": lastModified,
+		})
+
+		assert.Equal(t, http.StatusNotModified, r.Code)
+	}
+
+	// 不同的格式 ETag 不一样
+	{
+		r := tester.HttpUser.Get("/v1/news/feed?format=rss", nil, &mocker.Header{
+			"If-None-Match": etag,
+		})
+
+		assert.Equal(t, http.StatusOK, r.Code)
+	}
+
+	// 文章修改之后订阅源也会更新
+	{
+		title := "new title"
+
+		res := news.Update(controller.Context{Uid: adminInfo.Id}, n.Id, news.UpdateParams{Title: &title})
+
+		assert.Equal(t, "", res.Message)
+
+		r := tester.HttpUser.Get("/v1/news/feed?format=atom", nil, &mocker.Header{
+			"If-None-Match": etag,
+		})
+
+		assert.Equal(t, http.StatusOK, r.Code)
+		assert.NotEqual(t, etag, r.Header().Get("ETag"))
+	}
+}
@@ -5,16 +5,18 @@ import (
 	"errors"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
 	"time"
 )
 
+// 获取文章详情, 包括草稿和还没有到发布时间的文章
 func GetNews(id string) (res schema.Response) {
 	var (
 		err  error
@@ -36,23 +38,49 @@ func GetNews(id string) (res schema.Response) {
 		helper.Response(&res, data, err)
 	}()
 
-	newsInfo := model.News{
-		Id: id,
+	var newsInfo model.News
+
+	if newsInfo, err = getNews(database.Db, id); err != nil {
+		return
 	}
 
-	if err = database.Db.First(&newsInfo).Error; err != nil {
+	data, err = newsToDetail(newsInfo)
+
+	return
+}
+
+// 用户获取文章详情, 只能获取已经发布并且到了发布时间的文章
+func GetNewsByUser(id string) (res schema.Response) {
+	var (
+		err  error
+		data = schema.News{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	newsInfo := model.News{}
+
+	if err = database.Db.Where("id = ? AND status = ? AND published_at <= ?", id, model.NewsStatusActive, time.Now()).First(&newsInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			err = exception.NewsNotExist
 		}
 		return
 	}
 
-	if err = mapstructure.Decode(newsInfo, &data.NewsPure); err != nil {
-		return
-	}
-
-	data.CreatedAt = newsInfo.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = newsInfo.UpdatedAt.Format(time.RFC3339Nano)
+	data, err = newsToDetail(newsInfo)
 
 	return
 }
@@ -71,7 +99,39 @@ func GetNewsRouter(c *gin.Context) {
 		c.JSON(http.StatusOK, res)
 	}()
 
-	id := c.Param("news_id")
+	id := c.Param(ParamsIdName)
 
 	res = GetNews(id)
 }
+
+func GetNewsByUserRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	id := c.Param(ParamsIdName)
+
+	res = GetNewsByUser(id)
+
+	if res.Status != schema.StatusSuccess {
+		return
+	}
+
+	// 登陆是可选的, 没有登陆的用户按照 IP 地址去重
+	viewer := "ip:" + middleware.ClientIP(c)
+
+	if claims, er := token.Parse(c.GetHeader(token.AuthField), false); er == nil {
+		viewer = claims.Uid
+	}
+
+	_ = RecordView(id, viewer)
+}
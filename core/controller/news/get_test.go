@@ -79,6 +79,59 @@ func TestGetNews(t *testing.T) {
 	}
 }
 
+func TestGetNewsFormat(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	markdown := model.ContentFormatMarkdown
+
+	r := news.Create(controller.Context{
+		Uid: adminInfo.Id,
+	}, news.CreateNewParams{
+		Title:   "TestGetNewsFormat",
+		Content: "**bold** <script>alert(1)</script>",
+		Format:  &markdown,
+		Type:    model.NewsTypeNews,
+		Tags:    []string{},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer news.DeleteNewsById(n.Id)
+
+	assert.Equal(t, model.ContentFormatMarkdown, n.Format)
+	assert.Equal(t, "bold", n.Excerpt)
+
+	// 详情中返回渲染并过滤之后的内容
+	{
+		r := news.GetNewsByUser(n.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		newsInfo := schema.News{}
+
+		assert.Nil(t, tester.Decode(r.Data, &newsInfo))
+
+		assert.Equal(t, "<p><strong>bold</strong> </p>\n", newsInfo.Html)
+	}
+
+	// 不支持的格式
+	{
+		invalid := model.ContentFormat("rich")
+
+		r := news.Update(controller.Context{
+			Uid: adminInfo.Id,
+		}, n.Id, news.UpdateParams{
+			Format: &invalid,
+		})
+
+		assert.Equal(t, exception.InvalidContentFormat.Error(), r.Message)
+	}
+}
+
 func TestGetNewsRouter(t *testing.T) {
 	var (
 		newsId string
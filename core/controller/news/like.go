@@ -0,0 +1,151 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/counter"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+// 获取当前用户对文章的点赞状态
+func GetLike(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsLike
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	data, err = likeStatus(database.Db, id, c.Uid)
+
+	return
+}
+
+// 点赞
+func Like(c controller.Context, id string) (res schema.Response) {
+	return toggleLike(c, id, true)
+}
+
+// 取消点赞
+func Unlike(c controller.Context, id string) (res schema.Response) {
+	return toggleLike(c, id, false)
+}
+
+func toggleLike(c controller.Context, id string, like bool) (res schema.Response) {
+	var (
+		err   error
+		data  schema.NewsLike
+		tx    *gorm.DB
+		delta int64
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		// 点赞数先累加在 redis 中, 由定时任务写入数据库
+		if err == nil && delta != 0 {
+			if er := counter.Incr(counterLikes, id, delta); er == nil {
+				data.Likes = data.Likes + delta
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	if data, err = likeStatus(tx, id, c.Uid); err != nil {
+		return
+	}
+
+	if data.Liked == like {
+		return
+	}
+
+	if like {
+		err = tx.Create(&model.NewsLike{NewsId: id, Uid: c.Uid}).Error
+		delta = 1
+	} else {
+		err = tx.Where("news_id = ? AND uid = ?", id, c.Uid).Delete(model.NewsLike{}).Error
+		delta = -1
+	}
+
+	if err != nil {
+		delta = 0
+		return
+	}
+
+	data.Liked = like
+
+	return
+}
+
+func likeStatus(db *gorm.DB, id string, uid string) (data schema.NewsLike, err error) {
+	var newsInfo model.News
+
+	if newsInfo, err = getPublishedNews(db, id); err != nil {
+		return
+	}
+
+	var count int
+
+	if err = db.Model(model.NewsLike{}).Where("news_id = ? AND uid = ?", id, uid).Count(&count).Error; err != nil {
+		return
+	}
+
+	data.NewsId = id
+	data.Liked = count > 0
+	data.Likes = newsInfo.Likes + counter.Pending(counterLikes, id)[id]
+
+	return
+}
+
+func GetLikeRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, GetLike(controller.NewContext(c), c.Param(ParamsIdName)))
+}
+
+func LikeRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, Like(controller.NewContext(c), c.Param(ParamsIdName)))
+}
+
+func UnlikeRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, Unlike(controller.NewContext(c), c.Param(ParamsIdName)))
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestLike(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	context := controller.Context{Uid: userInfo.Id}
+
+	{
+		r := news.Like(context, n.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.NewsLike{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.True(t, d.Liked)
+		assert.Equal(t, int64(1), d.Likes)
+	}
+
+	// 重复点赞不会重复计数
+	{
+		r := news.Like(context, n.Id)
+
+		d := schema.NewsLike{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Equal(t, int64(1), d.Likes)
+	}
+
+	// 写入数据库之后点赞数以点赞记录为准
+	{
+		_, err := news.FlushCounters(database.Db)
+
+		assert.Nil(t, err)
+
+		r := news.GetNews(n.Id)
+
+		d := schema.News{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Equal(t, int64(1), d.Likes)
+		assert.Equal(t, int64(10), d.Popularity)
+	}
+
+	{
+		r := news.Unlike(context, n.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.NewsLike{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.False(t, d.Liked)
+		assert.Equal(t, int64(0), d.Likes)
+	}
+}
+
+func TestLikeRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	r := tester.HttpUser.Post("/v1/news/n/"+n.Id+"/like", nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	d := schema.NewsLike{}
+
+	assert.Nil(t, tester.Decode(res.Data, &d))
+
+	assert.True(t, d.Liked)
+}
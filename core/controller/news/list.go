@@ -9,7 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
-	"github.com/mitchellh/mapstructure"
+	"github.com/jinzhu/gorm"
 	"net/http"
 	"time"
 )
@@ -20,7 +20,41 @@ type Query struct {
 	Type   *model.NewsType   `json:"type" form:"type"`
 }
 
+// 管理员获取文章列表, 包括草稿, 已下线和定时发布的文章
+func GetNewsList(input Query) (res schema.List) {
+	filter := map[string]interface{}{}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	if input.Type != nil {
+		filter["type"] = *input.Type
+	}
+
+	return getNewsList(database.Db.Where(filter), input.Query)
+}
+
+// 用户获取文章列表, 只返回已经发布并且到了发布时间的文章, 默认按照发布时间倒序
 func GetNewsListByUser(input Query) (res schema.List) {
+	filter := map[string]interface{}{
+		"status": model.NewsStatusActive,
+	}
+
+	if input.Type != nil {
+		filter["type"] = *input.Type
+	}
+
+	query := input.Query
+
+	if len(query.Sort) == 0 {
+		query.Sort = "-published_at"
+	}
+
+	return getNewsList(database.Db.Where(filter).Where("published_at <= ?", time.Now()), query)
+}
+
+func getNewsList(db *gorm.DB, query schema.Query) (res schema.List) {
 	var (
 		err  error
 		data = make([]schema.News, 0) // 接口输出的数据
@@ -43,41 +77,30 @@ func GetNewsListByUser(input Query) (res schema.List) {
 		helper.ResponseList(&res, data, meta, err)
 	}()
 
-	query := input.Query
-
 	query.Normalize()
 
-	filter := map[string]interface{}{}
-
-	if input.Status != nil {
-		filter["status"] = *input.Status
-	}
-
-	if input.Type != nil {
-		filter["type"] = *input.Type
-	}
-
-	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+	if err = query.Order(db.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
 		return
 	}
 
 	var total int64
 
-	if err = database.Db.Model(model.News{}).Where(filter).Count(&total).Error; err != nil {
+	if err = db.Model(model.News{}).Count(&total).Error; err != nil {
 		return
 	}
 
 	for _, v := range list {
-		d := schema.News{}
-		if er := mapstructure.Decode(v, &d.NewsPure); er != nil {
-			err = er
+		var d schema.News
+
+		if d, err = newsToSchema(v); err != nil {
 			return
 		}
-		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
-		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+
 		data = append(data, d)
 	}
 
+	withPending(data)
+
 	meta.Total = total
 	meta.Num = len(data)
 	meta.Page = query.Page
@@ -87,6 +110,29 @@ func GetNewsListByUser(input Query) (res schema.List) {
 	return
 }
 
+func GetNewsListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetNewsList(input)
+}
+
 func GetNewsListByUserRouter(c *gin.Context) {
 	var (
 		err   error
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/tester"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestGetList(t *testing.T) {
@@ -147,3 +149,74 @@ func TestGetListRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestGetListByUserOnlyPublished(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	var (
+		draft     = model.NewsStatusDraft
+		active    = model.NewsStatusActive
+		scheduled = time.Now().Add(time.Hour).Format(time.RFC3339)
+		ids       = make([]string, 0)
+	)
+
+	// 草稿和定时发布的文章
+	for _, input := range []news.CreateNewParams{
+		{Title: "draft", Content: "draft", Type: model.NewsTypeNews, Status: &draft},
+		{Title: "scheduled", Content: "scheduled", Type: model.NewsTypeNews, Status: &active, PublishedAt: &scheduled},
+	} {
+		r := news.Create(controller.Context{Uid: adminInfo.Id}, input)
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.News{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		ids = append(ids, n.Id)
+
+		defer news.DeleteNewsById(n.Id)
+	}
+
+	// 用户看不到
+	{
+		r := news.GetNewsListByUser(news.Query{Query: schema.Query{Limit: 100}})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.News, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		for _, n := range list {
+			assert.NotContains(t, ids, n.Id)
+		}
+
+		for _, id := range ids {
+			assert.Equal(t, exception.NewsNotExist.Code(), news.GetNewsByUser(id).Status)
+		}
+	}
+
+	// 管理员可以看到
+	{
+		r := news.GetNewsList(news.Query{Status: &draft})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.News, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		found := false
+
+		for _, n := range list {
+			assert.Equal(t, draft, n.Status)
+
+			if n.Id == ids[0] {
+				found = true
+			}
+		}
+
+		assert.True(t, found)
+	}
+}
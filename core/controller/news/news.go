@@ -0,0 +1,237 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const (
+	ParamsIdName      = "news_id"
+	ParamsVersionName = "version"
+
+	renderKind = "news" // 渲染缓存的类型
+)
+
+func DeleteNewsById(id string) {
+	database.DeleteRowByTable("news_revision", "news_id", id)
+	database.DeleteRowByTable("news_comment", "news_id", id)
+	database.DeleteRowByTable("news_like", "news_id", id)
+	database.DeleteRowByTable("news", "id", id)
+	expireFeedVersion()
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	s := t.Format(time.RFC3339Nano)
+
+	return &s
+}
+
+func newsToSchema(n model.News) (data schema.News, err error) {
+	if err = mapstructure.Decode(n, &data.NewsPure); err != nil {
+		return
+	}
+
+	data.PublishedAt = formatTime(n.PublishedAt)
+	data.CreatedAt = n.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = n.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 详情中带上渲染之后的内容, 按版本缓存
+func newsToDetail(n model.News) (data schema.News, err error) {
+	if data, err = newsToSchema(n); err != nil {
+		return
+	}
+
+	data.Html = content.Cached(renderKind, n.Id, strconv.Itoa(n.Version), n.Format, n.Content)
+
+	list := []schema.News{data}
+
+	withPending(list)
+
+	data = list[0]
+
+	return
+}
+
+func revisionToSchema(r model.NewsRevision) (data schema.NewsRevision, err error) {
+	if err = mapstructure.Decode(r, &data.NewsRevisionPure); err != nil {
+		return
+	}
+
+	data.PublishedAt = formatTime(r.PublishedAt)
+	data.CreatedAt = r.CreatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 版本详情和文章详情共用同一个版本的缓存
+func revisionToDetail(r model.NewsRevision) (data schema.NewsRevision, err error) {
+	if data, err = revisionToSchema(r); err != nil {
+		return
+	}
+
+	data.Html = content.Cached(renderKind, r.NewsId, strconv.Itoa(r.Version), r.Format, r.Content)
+
+	return
+}
+
+// 解析发布时间, 格式为 RFC3339
+func parsePublishedAt(s string) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+
+	if err != nil {
+		return nil, exception.NewsInvalidPublishTime
+	}
+
+	return &t, nil
+}
+
+// 两个版本之间修改的字段, 顺序固定
+func changedFields(prev model.News, next model.News) []string {
+	fields := make([]string, 0)
+
+	if prev.Title != next.Title {
+		fields = append(fields, "title")
+	}
+
+	if prev.Content != next.Content {
+		fields = append(fields, "content")
+	}
+
+	if prev.Format != next.Format {
+		fields = append(fields, "format")
+	}
+
+	if prev.Type != next.Type {
+		fields = append(fields, "type")
+	}
+
+	if !reflect.DeepEqual([]string(prev.Tags), []string(next.Tags)) && (len(prev.Tags) > 0 || len(next.Tags) > 0) {
+		fields = append(fields, "tags")
+	}
+
+	if prev.Status != next.Status {
+		fields = append(fields, "status")
+	}
+
+	if !samePublishTime(prev.PublishedAt, next.PublishedAt) {
+		fields = append(fields, "published_at")
+	}
+
+	return fields
+}
+
+func samePublishTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return a.Equal(*b)
+}
+
+// 校验并保存文章, 版本号加 1, 记录这个版本, 并发布 NewsSaved 事件
+func saveNews(tx *gorm.DB, events *event.Batch, editor string, n model.News, changes []string) (model.News, error) {
+	if !model.IsValidNewsType(n.Type) {
+		return n, exception.NewsInvalidType
+	}
+
+	if !model.IsValidNewsStatus(n.Status) {
+		return n, exception.NewsInvalidStatus
+	}
+
+	if !model.IsValidContentFormat(n.Format) {
+		return n, exception.InvalidContentFormat
+	}
+
+	// 发布时没有指定发布时间则立即发布
+	if n.Status == model.NewsStatusActive && n.PublishedAt == nil {
+		now := time.Now()
+		n.PublishedAt = &now
+	}
+
+	n.Version = n.Version + 1
+	n.Editor = editor
+	n.Excerpt = content.Excerpt(n.Format, n.Content, content.ExcerptLength)
+
+	// 计数由评论, 点赞和定时任务单独更新, 保存文章时不能覆盖
+	if err := tx.Omit("views", "likes", "comments", "popularity").Save(&n).Error; err != nil {
+		return n, err
+	}
+
+	if err := tx.Create(&model.NewsRevision{
+		NewsId:      n.Id,
+		Version:     n.Version,
+		Title:       n.Title,
+		Content:     n.Content,
+		Format:      n.Format,
+		Type:        n.Type,
+		Tags:        n.Tags,
+		Status:      n.Status,
+		PublishedAt: n.PublishedAt,
+		Changes:     changes,
+		Editor:      editor,
+	}).Error; err != nil {
+		return n, err
+	}
+
+	if err := events.Publish(tx, event.NewsSaved{News: n}); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+func getAdmin(tx *gorm.DB, uid string) (adminInfo model.Admin, err error) {
+	adminInfo = model.Admin{Id: uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+	}
+
+	return
+}
+
+func getNews(tx *gorm.DB, id string) (n model.News, err error) {
+	n = model.News{Id: id}
+
+	if err = tx.First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NewsNotExist
+		}
+	}
+
+	return
+}
+
+// 修改文章之前锁住这一行, 避免并发修改时基于同一个版本号保存, 产生相同版本号的历史版本
+func lockNews(tx *gorm.DB, id string) (model.News, error) {
+	return getNews(tx.Set("gorm:query_option", "FOR UPDATE"), id)
+}
+
+func getRevision(tx *gorm.DB, id string, version int) (r model.NewsRevision, err error) {
+	if err = tx.Where("news_id = ? AND version = ?", id, version).First(&r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NewsRevisionNotExist
+		}
+	}
+
+	return
+}
@@ -0,0 +1,369 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strconv"
+)
+
+type DiffQuery struct {
+	From int `json:"from" form:"from" binding:"required"` // 旧的版本号
+	To   int `json:"to" form:"to" binding:"required"`     // 新的版本号
+}
+
+// 获取文章的历史版本, 默认按照版本号倒序
+func GetRevisionList(id string, input schema.Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.NewsRevision, 0)
+		list = make([]model.NewsRevision, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	if _, err = getNews(database.Db, id); err != nil {
+		return
+	}
+
+	query := input
+
+	query.Normalize()
+
+	if len(input.Sort) == 0 {
+		query.Sort = "-version"
+	}
+
+	filter := map[string]interface{}{"news_id": id}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.NewsRevision{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.NewsRevision
+
+		if d, err = revisionToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 获取文章的某个历史版本
+func GetRevision(id string, version int) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsRevision
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	var r model.NewsRevision
+
+	if r, err = getRevision(database.Db, id, version); err != nil {
+		return
+	}
+
+	data, err = revisionToDetail(r)
+
+	return
+}
+
+// 对比文章的两个版本, 内容逐行对比, 其他字段列出修改前后的值
+func DiffRevision(id string, input DiffQuery) (res schema.Response) {
+	var (
+		err  error
+		data schema.NewsDiff
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	var from, to model.NewsRevision
+
+	if from, err = getRevision(database.Db, id, input.From); err != nil {
+		return
+	}
+
+	if to, err = getRevision(database.Db, id, input.To); err != nil {
+		return
+	}
+
+	data.From = from.Version
+	data.To = to.Version
+	data.Changes = make([]schema.NewsFieldChange, 0)
+	data.Content = util.DiffLines(from.Content, to.Content)
+
+	prev := revisionToNews(from)
+	next := revisionToNews(to)
+
+	for _, field := range changedFields(prev, next) {
+		var change = schema.NewsFieldChange{Field: field}
+
+		switch field {
+		case "content":
+			continue
+		case "title":
+			change.From, change.To = prev.Title, next.Title
+		case "format":
+			change.From, change.To = prev.Format, next.Format
+		case "type":
+			change.From, change.To = prev.Type, next.Type
+		case "tags":
+			change.From, change.To = prev.Tags, next.Tags
+		case "status":
+			change.From, change.To = prev.Status, next.Status
+		case "published_at":
+			change.From, change.To = formatTime(prev.PublishedAt), formatTime(next.PublishedAt)
+		}
+
+		data.Changes = append(data.Changes, change)
+	}
+
+	return
+}
+
+// 回滚到历史版本, 只恢复标题, 内容, 格式, 类型和标签, 不改变发布状态, 恢复的内容会作为一个新的版本保存
+func Rollback(c controller.Context, id string, version int) (res schema.Response) {
+	var (
+		err    error
+		data   schema.News
+		tx     *gorm.DB
+		events event.Batch
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			events.Commit()
+			expireFeedVersion()
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	if _, err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	var (
+		newsInfo model.News
+		r        model.NewsRevision
+	)
+
+	if newsInfo, err = lockNews(tx, id); err != nil {
+		return
+	}
+
+	if r, err = getRevision(tx, id, version); err != nil {
+		return
+	}
+
+	next := newsInfo
+
+	next.Title = r.Title
+	next.Content = r.Content
+	next.Format = r.Format
+	next.Type = r.Type
+	next.Tags = r.Tags
+
+	if newsInfo, err = saveNews(tx, &events, c.Uid, next, changedFields(newsInfo, next)); err != nil {
+		return
+	}
+
+	data, err = newsToSchema(newsInfo)
+
+	return
+}
+
+func revisionToNews(r model.NewsRevision) model.News {
+	return model.News{
+		Id:          r.NewsId,
+		Title:       r.Title,
+		Content:     r.Content,
+		Format:      r.Format,
+		Type:        r.Type,
+		Tags:        r.Tags,
+		Status:      r.Status,
+		PublishedAt: r.PublishedAt,
+	}
+}
+
+func GetRevisionListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input schema.Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetRevisionList(c.Param(ParamsIdName), input)
+}
+
+func GetRevisionRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	version, err := strconv.Atoi(c.Param(ParamsVersionName))
+
+	if err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetRevision(c.Param(ParamsIdName), version)
+}
+
+func DiffRevisionRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input DiffQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = DiffRevision(c.Param(ParamsIdName), input)
+}
+
+func RollbackRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	version, err := strconv.Atoi(c.Param(ParamsVersionName))
+
+	if err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Rollback(controller.NewContext(c), c.Param(ParamsIdName), version)
+}
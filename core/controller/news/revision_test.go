@@ -0,0 +1,232 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package news_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func createNews(t *testing.T, uid string) schema.News {
+	r := news.Create(controller.Context{Uid: uid}, news.CreateNewParams{
+		Title:   "title",
+		Content: "line 1\nline 2",
+		Type:    model.NewsTypeNews,
+		Tags:    []string{},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n
+}
+
+func TestRevision(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	assert.Equal(t, 1, n.Version)
+	assert.Equal(t, adminInfo.Id, n.Editor)
+	assert.NotNil(t, n.PublishedAt)
+
+	var (
+		title   = "new title"
+		content = "line 1\nline 3"
+	)
+
+	r := news.Update(controller.Context{Uid: adminInfo.Id}, n.Id, news.UpdateParams{
+		Title:   &title,
+		Content: &content,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	// 没有修改则不会产生新的版本
+	{
+		r := news.Update(controller.Context{Uid: adminInfo.Id}, n.Id, news.UpdateParams{
+			Title: &title,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.News{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Equal(t, 2, d.Version)
+	}
+
+	// 历史版本按照版本号倒序
+	{
+		r := news.GetRevisionList(n.Id, schema.Query{})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.NewsRevision, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		assert.Len(t, list, 2)
+		assert.Equal(t, 2, list[0].Version)
+		assert.Equal(t, []string{"title", "content"}, list[0].Changes)
+		assert.Equal(t, adminInfo.Id, list[0].Editor)
+		assert.Equal(t, 1, list[1].Version)
+		assert.Equal(t, n.Content, list[1].Content)
+	}
+
+	// 对比两个版本
+	{
+		r := news.DiffRevision(n.Id, news.DiffQuery{From: 1, To: 2})
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.NewsDiff{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Len(t, d.Changes, 1)
+		assert.Equal(t, "title", d.Changes[0].Field)
+		assert.Equal(t, "title", d.Changes[0].From)
+		assert.Equal(t, title, d.Changes[0].To)
+		assert.Equal(t, []util.DiffLine{
+			{Op: util.DiffEqual, Text: "line 1"},
+			{Op: util.DiffDelete, Text: "line 2"},
+			{Op: util.DiffInsert, Text: "line 3"},
+		}, d.Content)
+	}
+
+	// 回滚到第一个版本, 会生成第三个版本
+	{
+		r := news.Rollback(controller.Context{Uid: adminInfo.Id}, n.Id, 1)
+
+		assert.Equal(t, "", r.Message)
+
+		d := schema.News{}
+
+		assert.Nil(t, tester.Decode(r.Data, &d))
+
+		assert.Equal(t, 3, d.Version)
+		assert.Equal(t, n.Title, d.Title)
+		assert.Equal(t, n.Content, d.Content)
+	}
+
+	// 版本不存在
+	{
+		r := news.Rollback(controller.Context{Uid: adminInfo.Id}, n.Id, 100)
+
+		assert.Equal(t, exception.NewsRevisionNotExist.Code(), r.Status)
+	}
+}
+
+func TestRevisionConcurrent(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	const total = 5
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			title := "title " + strconv.Itoa(i)
+
+			r := news.Update(controller.Context{Uid: adminInfo.Id}, n.Id, news.UpdateParams{
+				Title: &title,
+			})
+
+			assert.Equal(t, "", r.Message)
+		}(i)
+	}
+
+	wg.Wait()
+
+	// 并发修改时每次修改都会基于上一个版本, 版本号不会重复
+	r := news.GetRevisionList(n.Id, schema.Query{})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.NewsRevision, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	assert.Len(t, list, total+1)
+
+	for i, revision := range list {
+		assert.Equal(t, total+1-i, revision.Version)
+	}
+}
+
+func TestRevisionRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	n := createNews(t, adminInfo.Id)
+
+	defer news.DeleteNewsById(n.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	{
+		r := tester.HttpAdmin.Get("/v1/news/n/"+n.Id+"/revision/1", nil, &header)
+
+		if !assert.Equal(t, http.StatusOK, r.Code) {
+			return
+		}
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+
+		d := schema.NewsRevision{}
+
+		assert.Nil(t, tester.Decode(res.Data, &d))
+
+		assert.Equal(t, n.Content, d.Content)
+	}
+
+	{
+		r := tester.HttpAdmin.Put("/v1/news/n/"+n.Id+"/revision/1", nil, &header)
+
+		if !assert.Equal(t, http.StatusOK, r.Code) {
+			return
+		}
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+
+		d := schema.News{}
+
+		assert.Nil(t, tester.Decode(res.Data, &d))
+
+		assert.Equal(t, 2, d.Version)
+	}
+}
@@ -4,6 +4,7 @@ package news
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
@@ -11,25 +12,27 @@ import (
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
-	"time"
 )
 
 type UpdateParams struct {
-	Title   *string           `json:"title"`
-	Content *string           `json:"content"`
-	Type    *model.NewsType   `json:"type"`
-	Tags    *[]string         `json:"tags"`
-	Status  *model.NewsStatus `json:"status"`
+	Title       *string              `json:"title"`
+	Content     *string              `json:"content"`
+	Format      *model.ContentFormat `json:"format"`
+	Type        *model.NewsType      `json:"type"`
+	Tags        *[]string            `json:"tags"`
+	Status      *model.NewsStatus    `json:"status"`
+	PublishedAt *string              `json:"published_at"` // 发布时间, 格式为 RFC3339
 }
 
+// 更新文章, 每次更新都会保存为一个新的版本, 没有任何修改则不保存
 func Update(c controller.Context, newsId string, input UpdateParams) (res schema.Response) {
 	var (
 		err          error
 		data         schema.News
 		tx           *gorm.DB
 		shouldUpdate bool
+		events       event.Batch
 	)
 
 	defer func() {
@@ -53,72 +56,70 @@ func Update(c controller.Context, newsId string, input UpdateParams) (res schema
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+			expireFeedVersion()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
 	tx = database.Db.Begin()
 
-	adminInfo := model.Admin{Id: c.Uid}
-
 	// 判断管理员是否存在
-	if err = tx.First(&adminInfo).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			err = exception.AdminNotExist
-			return
-		}
+	if _, err = getAdmin(tx, c.Uid); err != nil {
+		return
 	}
 
-	newsInfo := model.News{
-		Id: newsId,
-	}
+	var newsInfo model.News
 
-	if err = tx.First(&newsInfo).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			err = exception.NewsNotExist
-			return
-		}
+	if newsInfo, err = lockNews(tx, newsId); err != nil {
 		return
 	}
 
+	next := newsInfo
+
 	if input.Title != nil {
-		shouldUpdate = true
-		newsInfo.Title = *input.Title
+		next.Title = *input.Title
 	}
 
 	if input.Content != nil {
-		shouldUpdate = true
-		newsInfo.Content = *input.Content
+		next.Content = *input.Content
+	}
+
+	if input.Format != nil {
+		next.Format = *input.Format
 	}
 
 	if input.Type != nil {
-		shouldUpdate = true
-		newsInfo.Type = *input.Type
+		next.Type = *input.Type
 	}
 
 	if input.Status != nil {
-		shouldUpdate = true
-		newsInfo.Status = *input.Status
+		next.Status = *input.Status
 	}
 
 	if input.Tags != nil {
-		shouldUpdate = true
-		newsInfo.Tags = *input.Tags
+		next.Tags = *input.Tags
 	}
 
-	if err = tx.Save(&newsInfo).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			err = exception.NewsNotExist
+	if input.PublishedAt != nil {
+		if next.PublishedAt, err = parsePublishedAt(*input.PublishedAt); err != nil {
 			return
 		}
-		return
 	}
 
-	if err = mapstructure.Decode(newsInfo, &data.NewsPure); err != nil {
-		return
+	changes := changedFields(newsInfo, next)
+
+	if len(changes) > 0 {
+		shouldUpdate = true
+
+		if newsInfo, err = saveNews(tx, &events, c.Uid, next, changes); err != nil {
+			return
+		}
 	}
 
-	data.CreatedAt = newsInfo.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = newsInfo.UpdatedAt.Format(time.RFC3339Nano)
+	data, err = newsToSchema(newsInfo)
 
 	return
 }
@@ -138,7 +139,7 @@ func UpdateRouter(c *gin.Context) {
 		c.JSON(http.StatusOK, res)
 	}()
 
-	id := c.Param("news_id")
+	id := c.Param(ParamsIdName)
 
 	if err = c.ShouldBindJSON(&input); err != nil {
 		err = exception.InvalidParams
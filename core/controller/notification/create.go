@@ -4,31 +4,36 @@ package notification
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
 	"time"
 )
 
 type CreateParams struct {
-	Title   string  `json:"title" valid:"required~请输入公告标题"`   // 公告标题
-	Content string  `json:"content" valid:"required~请输入公告内容"` // 公告内容
-	Note    *string `json:"note"`                             // 备注
+	Title   string               `json:"title" valid:"required~请输入公告标题"`   // 公告标题
+	Content string               `json:"content" valid:"required~请输入公告内容"` // 公告内容
+	Format  *model.ContentFormat `json:"format"`                           // 内容的格式, 默认为 html
+	Note    *string              `json:"note"`                             // 备注
+	TargetParams
 }
 
 func Create(c controller.Context, input CreateParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Notification
-		tx   *gorm.DB
+		err              error
+		data             schema.Notification
+		tx               *gorm.DB
+		notificationInfo model.Notification
+		events           event.Batch
 	)
 
 	defer func() {
@@ -51,6 +56,17 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 			}
 		}
 
+		// 已经发布的通知推送给在线的用户, 推送失败不影响创建
+		// 定时发布的通知, 由定时任务在发布时推送
+		if err == nil {
+			events.Commit()
+			resetAllUnread()
+
+			if notificationInfo.Pushed {
+				_ = publish(notificationInfo)
+			}
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -73,24 +89,40 @@ func Create(c controller.Context, input CreateParams) (res schema.Response) {
 		return
 	}
 
-	notificationInfo := model.Notification{
+	notificationInfo = model.Notification{
 		Author:  adminInfo.Id,
 		Title:   input.Title,
 		Content: input.Content,
 		Note:    input.Note,
+		Status:  model.NotificationStatusActive,
+	}
+
+	if notificationInfo.Format, err = content.ParseFormat(input.Format); err != nil {
+		return
+	}
+
+	notificationInfo.Excerpt = content.Excerpt(notificationInfo.Format, notificationInfo.Content, content.ExcerptLength)
+
+	if err = input.TargetParams.apply(&notificationInfo); err != nil {
+		return
+	}
+
+	if err = validateTarget(tx, notificationInfo); err != nil {
+		return
 	}
 
+	notificationInfo.Pushed = isLive(notificationInfo, time.Now())
+
 	if err = tx.Create(&notificationInfo).Error; err != nil {
 		return
 	}
 
-	if er := mapstructure.Decode(notificationInfo, &data.NotificationPure); er != nil {
-		err = er
+	if err = events.Publish(tx, event.NotificationSaved{Notification: notificationInfo}); err != nil {
 		return
 	}
 
-	data.CreatedAt = notificationInfo.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = notificationInfo.UpdatedAt.Format(time.RFC3339Nano)
+	data, err = toSchema(notificationInfo)
+
 	return
 }
 
@@ -52,6 +52,10 @@ func Delete(c controller.Context, notificationId string) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			resetAllUnread()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -3,22 +3,22 @@ package notification
 
 import (
 	"errors"
-	"fmt"
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
 	"time"
 )
 
 // Get notification detail
+// 用户只能获取已经发布, 未过期, 并且推送给自己的通知
 func Get(c controller.Context, id string) (res schema.Response) {
 	var (
 		err  error
@@ -51,23 +51,37 @@ func Get(c controller.Context, id string) (res schema.Response) {
 
 	tx = database.Db.Begin()
 
-	notificationInfo := model.Notification{}
-	NotificationMark := model.NotificationMark{Id: id}
+	userInfo := model.User{
+		Id: c.Uid,
+	}
 
-	sql := fmt.Sprintf("LEFT JOIN notification_mark ON notification_mark.id = notification.id AND notification.id = '%s'", notificationInfo.Id)
+	if err = tx.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	notificationInfo := model.Notification{}
 
-	if err = tx.Table(notificationInfo.TableName()).Joins(sql).Last(&notificationInfo).Error; err != nil {
+	if err = userScope(tx.Where("id = ?", id), userInfo, time.Now()).First(&notificationInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			err = exception.NoData
 		}
 		return
 	}
 
-	if er := mapstructure.Decode(notificationInfo, &data.NotificationPure); er != nil {
-		err = er
+	if data, err = toSchema(notificationInfo); err != nil {
 		return
 	}
 
+	data.Html = render(notificationInfo)
+
+	NotificationMark := model.NotificationMark{
+		Id:  notificationInfo.Id,
+		Uid: userInfo.Id,
+	}
+
 	if err = tx.Where(&NotificationMark).Last(&NotificationMark).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			data.NotificationPure.Read = false
@@ -80,12 +94,65 @@ func Get(c controller.Context, id string) (res schema.Response) {
 		data.ReadAt = NotificationMark.CreatedAt.Format(time.RFC3339Nano)
 	}
 
-	data.CreatedAt = notificationInfo.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = notificationInfo.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+// 管理员获取通知详情, 包括推送对象和有效期
+func GetByAdmin(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.NotificationAdmin
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	notificationInfo := model.Notification{}
+
+	if err = database.Db.Where("id = ?", id).First(&notificationInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NotificationNotExist
+		}
+		return
+	}
+
+	if data, err = toSchemaAdmin(notificationInfo); err != nil {
+		return
+	}
+
+	data.Html = render(notificationInfo)
 
 	return
 }
 
+// 渲染通知的内容, 按更新时间缓存
+func render(n model.Notification) string {
+	return content.Cached("notification", n.Id, content.RevisionOf(n.UpdatedAt), n.Format, n.Content)
+}
+
 // GetRouter get notification detail router
 func GetRouter(c *gin.Context) {
 	var (
@@ -107,3 +174,22 @@ func GetRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	}, id)
 }
+
+func GetByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("id"))
+}
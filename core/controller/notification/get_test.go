@@ -6,6 +6,7 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/tester"
@@ -17,6 +18,9 @@ import (
 
 func TestGet(t *testing.T) {
 	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
 
 	context := controller.Context{
 		Uid: adminInfo.Id,
@@ -51,7 +55,9 @@ func TestGet(t *testing.T) {
 
 	// 获取详情
 	{
-		r := notification.Get(context, testNotification.Id)
+		r := notification.Get(controller.Context{
+			Uid: userInfo.Id,
+		}, testNotification.Id)
 
 		assert.Equal(t, schema.StatusSuccess, r.Status)
 		assert.Equal(t, "", r.Message)
@@ -65,6 +71,44 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetFormat(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	r := notification.Create(controller.Context{
+		Uid: adminInfo.Id,
+	}, notification.CreateParams{
+		Title:   "TestGetFormat",
+		Content: `<p onclick="alert(1)">hello</p><img src="javascript:alert(1)">`,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Notification{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer notification.DeleteNotificationById(n.Id)
+
+	// 默认为 HTML, 过滤掉不安全的属性
+	assert.Equal(t, model.ContentFormatHTML, n.Format)
+	assert.Equal(t, "hello", n.Excerpt)
+
+	r = notification.Get(controller.Context{
+		Uid: userInfo.Id,
+	}, n.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	detail := schema.Notification{}
+
+	assert.Nil(t, tester.Decode(r.Data, &detail))
+
+	assert.Equal(t, "<p>hello</p><img>", detail.Html)
+}
+
 func TestGetRouter(t *testing.T) {
 	var notificationId string
 	adminInfo, _ := tester.LoginAdmin()
@@ -12,7 +12,6 @@ import (
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
 	"time"
 )
@@ -60,28 +59,38 @@ func GetNotificationListByUser(c controller.Context, input Query) (res schema.Li
 
 	tx = database.Db.Begin()
 
-	list := make([]model.Notification, 0)
+	userInfo := model.User{
+		Id: c.Uid,
+	}
 
-	filter := map[string]interface{}{}
+	if err = tx.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
 
-	if err = query.Order(tx.Limit(query.Limit).Offset(query.Limit * query.Page).Where(filter)).Find(&list).Error; err != nil {
+	now := time.Now()
+
+	list := make([]model.Notification, 0)
+
+	// 只获取已经发布, 未过期, 并且推送给该用户的通知
+	if err = query.Order(userScope(tx.Limit(query.Limit).Offset(query.Limit*query.Page), userInfo, now)).Find(&list).Error; err != nil {
 		return
 	}
 
 	var total int64
 
-	if err = tx.Model(&model.Notification{}).Count(&total).Error; err != nil {
+	if err = userScope(tx.Model(&model.Notification{}), userInfo, now).Count(&total).Error; err != nil {
 		return
 	}
 
 	for _, v := range list {
-		d := schema.Notification{}
-		if er := mapstructure.Decode(v, &d.NotificationPure); er != nil {
-			err = er
+		var d schema.Notification
+
+		if d, err = toSchema(v); err != nil {
 			return
 		}
-		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
-		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
 
 		// 查询用户是否已读通知
 		mark := model.NotificationMark{
@@ -89,7 +98,7 @@ func GetNotificationListByUser(c controller.Context, input Query) (res schema.Li
 			Uid: c.Uid,
 		}
 
-		if err = tx.Last(&mark).Error; err != nil {
+		if err = tx.Where(&mark).Last(&mark).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				d.Read = false
 				d.ReadAt = ""
@@ -167,13 +176,12 @@ func GetNotificationListByAdmin(c controller.Context, input Query) (res schema.L
 	}
 
 	for _, v := range list {
-		d := schema.NotificationAdmin{}
-		if er := mapstructure.Decode(v, &d.NotificationPureAdmin); er != nil {
-			err = er
+		var d schema.NotificationAdmin
+
+		if d, err = toSchemaAdmin(v); err != nil {
 			return
 		}
-		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
-		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+
 		data = append(data, d)
 	}
 
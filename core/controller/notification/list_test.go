@@ -16,6 +16,10 @@ import (
 )
 
 func TestGetNotificationListByUser(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
 	{
 		var (
 			adminUid string
@@ -73,7 +77,9 @@ func TestGetNotificationListByUser(t *testing.T) {
 			query := schema.Query{
 				Limit: 20,
 			}
-			r := notification.GetNotificationListByUser(controller.Context{}, notification.Query{
+			r := notification.GetNotificationListByUser(controller.Context{
+				Uid: userInfo.Id,
+			}, notification.Query{
 				Query: query,
 			})
 
@@ -13,8 +13,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"net/http"
+	"time"
 )
 
+// 标记通知已读, 已经读过的不会修改已读时间
+// 被删除的已读记录仍然占用主键, 所以用 upsert 恢复, 同时标记时也不会因为主键冲突而失败
+func markRead(tx *gorm.DB, id string, uid string) error {
+	now := time.Now()
+
+	return tx.Exec(`INSERT INTO notification_mark (id, uid, read, created_at, updated_at) VALUES (?, ?, true, ?, ?)
+ON CONFLICT (id, uid) DO UPDATE SET read = true, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at, deleted_at = NULL
+WHERE notification_mark.deleted_at IS NOT NULL OR notification_mark.read = false`, id, uid, now, now).Error
+}
+
 // MarkRead mark notification as read
 func MarkRead(c controller.Context, notificationID string) (res schema.Response) {
 	var (
@@ -42,6 +53,10 @@ func MarkRead(c controller.Context, notificationID string) (res schema.Response)
 			}
 		}
 
+		if err == nil {
+			resetUnread(c.Uid)
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -59,46 +74,108 @@ func MarkRead(c controller.Context, notificationID string) (res schema.Response)
 		return
 	}
 
-	notificationInfo := model.Notification{
-		Id: notificationID,
-	}
+	notificationInfo := model.Notification{}
 
-	// 先获取通知
-	if err = tx.Where(&notificationInfo).Last(&notificationInfo).Error; err != nil {
+	// 先获取通知, 只能标记推送给自己的通知
+	if err = userScope(tx.Where("id = ?", notificationID), userInfo, time.Now()).Last(&notificationInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			err = exception.NoData
 		}
 		return
 	}
 
-	mark := model.NotificationMark{
-		Id:  notificationInfo.Id,
-		Uid: c.Uid,
+	if err = markRead(tx, notificationInfo.Id, c.Uid); err != nil {
+		return
 	}
 
-	// 再确认以读表有没有这个用户的已读记录
-	if err = tx.Where(&mark).Last(&mark).Error; err != nil {
-		// 如果没找到这条记录，则说明没有创建
-		// 继续下面的页面
+	return
+}
+
+// ReadRouter read this notification router
+func ReadRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	notificationID := c.Param("id")
+
+	res = MarkRead(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, notificationID)
+}
+
+// 把用户所有未读的通知标记为已读
+func MarkReadAll(c controller.Context) (res schema.Response) {
+	var (
+		err error
+		tx  *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		if err == nil {
+			resetUnread(c.Uid)
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	userInfo := model.User{
+		Id: c.Uid,
+	}
+
+	if err = tx.First(&userInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			err = nil
-		} else {
-			return
+			err = exception.UserNotExist
 		}
-	} else {
-		// 通知已读
 		return
 	}
 
-	if err = tx.Create(&mark).Error; err != nil {
+	ids := make([]string, 0)
+
+	if err = unreadScope(tx.Model(&model.Notification{}), userInfo).Pluck("id", &ids).Error; err != nil {
 		return
 	}
 
+	for _, id := range ids {
+		if err = markRead(tx, id, userInfo.Id); err != nil {
+			return
+		}
+	}
+
 	return
 }
 
-// ReadRouter read this notification router
-func ReadRouter(c *gin.Context) {
+func ReadAllRouter(c *gin.Context) {
 	var (
 		err error
 		res = schema.Response{}
@@ -112,9 +189,7 @@ func ReadRouter(c *gin.Context) {
 		c.JSON(http.StatusOK, res)
 	}()
 
-	notificationID := c.Param("id")
-
-	res = MarkRead(controller.Context{
+	res = MarkReadAll(controller.Context{
 		Uid: c.GetString(middleware.ContextUidField),
-	}, notificationID)
+	})
 }
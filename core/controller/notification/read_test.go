@@ -14,6 +14,7 @@ import (
 	"github.com/axetroy/go-server/tester"
 	"github.com/axetroy/mocker"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 )
 
@@ -87,7 +88,9 @@ func TestMarkRead(t *testing.T) {
 
 	{
 		// 获取详情
-		r := notification.Get(context, testNotification.Id)
+		r := notification.Get(controller.Context{
+			Uid: userInfo.Id,
+		}, testNotification.Id)
 
 		assert.Equal(t, schema.StatusSuccess, r.Status)
 		assert.Equal(t, "", r.Message)
@@ -171,3 +174,93 @@ func TestReadRouter(t *testing.T) {
 		assert.Equal(t, userInfo.Id, notificationMarkInfo.Uid)
 	}
 }
+
+func TestMarkReadAll(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := createNotification(t, adminInfo.Id, notification.TargetParams{
+		TargetUsers: []string{userInfo.Id},
+	})
+
+	defer notification.DeleteNotificationById(n.Id)
+	defer notification.DeleteNotificationMarkById(n.Id)
+
+	user := model.User{Id: userInfo.Id}
+
+	assert.Nil(t, database.Db.First(&user).Error)
+
+	count, err := notification.CountUnread(database.Db, user)
+
+	assert.Nil(t, err)
+	assert.True(t, count >= 1)
+
+	r := notification.MarkReadAll(controller.Context{
+		Uid: userInfo.Id,
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	count, err = notification.CountUnread(database.Db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// 重复标记不会报错
+	r = notification.MarkReadAll(controller.Context{
+		Uid: userInfo.Id,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	// 已读状态
+	r = notification.Get(controller.Context{
+		Uid: userInfo.Id,
+	}, n.Id)
+
+	detail := schema.Notification{}
+
+	assert.Nil(t, tester.Decode(r.Data, &detail))
+	assert.True(t, detail.Read)
+
+	// 已读记录被删除之后可以重新标记
+	assert.Nil(t, database.Db.Where("id = ? AND uid = ?", n.Id, userInfo.Id).Delete(&model.NotificationMark{}).Error)
+
+	count, err = notification.CountUnread(database.Db, user)
+
+	assert.Nil(t, err)
+	assert.True(t, count >= 1)
+
+	r = notification.MarkReadAll(controller.Context{
+		Uid: userInfo.Id,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	count, err = notification.CountUnread(database.Db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestReadAllRouter(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	r := tester.HttpUser.Put("/v1/notification/read", nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 推送已经到了发布时间, 但是还没有推送过的通知
+// 多个进程同时执行时, 通过更新 pushed 字段保证每条通知只推送一次
+func PublishScheduled(db *gorm.DB) (count int, err error) {
+	list := make([]model.Notification, 0)
+
+	defer func() {
+		if count > 0 {
+			resetAllUnread()
+		}
+	}()
+
+	if err = liveScope(db.Where("pushed = ?", false), time.Now()).Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, n := range list {
+		result := db.Model(&model.Notification{}).Where("id = ?", n.Id).Where("pushed = ?", false).UpdateColumn("pushed", true)
+
+		if err = result.Error; err != nil {
+			return
+		}
+
+		// 已经被其他进程推送了
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		// 加入队列失败则恢复为未推送, 下次再试
+		if err = publish(n); err != nil {
+			_ = db.Model(&model.Notification{}).Where("id = ?", n.Id).UpdateColumn("pushed", false).Error
+			return
+		}
+
+		count = count + 1
+	}
+
+	return
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPublishScheduled(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	publishAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	n := createNotification(t, adminInfo.Id, notification.TargetParams{
+		TargetUsers: []string{userInfo.Id},
+		PublishAt:   &publishAt,
+	})
+
+	defer notification.DeleteNotificationById(n.Id)
+
+	notificationInfo := model.Notification{}
+
+	// 还没到发布时间, 不会推送
+	assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+	assert.False(t, notificationInfo.Pushed)
+
+	_, err := notification.PublishScheduled(database.Db)
+
+	assert.Nil(t, err)
+	assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+	assert.False(t, notificationInfo.Pushed)
+
+	// 到了发布时间
+	assert.Nil(t, database.Db.Model(&notificationInfo).UpdateColumn("publish_at", time.Now().Add(-time.Minute)).Error)
+
+	count, err := notification.PublishScheduled(database.Db)
+
+	assert.Nil(t, err)
+	assert.True(t, count >= 1)
+	assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+	assert.True(t, notificationInfo.Pushed)
+
+	// 由消息队列推送给在线的用户
+	assert.Nil(t, notification.Push(database.Db, n.Id))
+
+	// 推送给了指定的用户
+	events, err := push.History(userInfo.Id, 0)
+
+	assert.Nil(t, err)
+
+	found := 0
+
+	for _, event := range events {
+		data := schema.Notification{}
+
+		if event.Type == push.EventNotification && json.Unmarshal(event.Data, &data) == nil && data.Id == n.Id {
+			assert.Equal(t, userInfo.Id, event.Uid)
+			found = found + 1
+		}
+	}
+
+	assert.Equal(t, 1, found)
+
+	// 不会重复推送
+	_, err = notification.PublishScheduled(database.Db)
+
+	assert.Nil(t, err)
+
+	events, err = push.History(userInfo.Id, 0)
+
+	assert.Nil(t, err)
+
+	found = 0
+
+	for _, event := range events {
+		data := schema.Notification{}
+
+		if event.Type == push.EventNotification && json.Unmarshal(event.Data, &data) == nil && data.Id == n.Id {
+			found = found + 1
+		}
+	}
+
+	assert.Equal(t, 1, found)
+}
+
+func TestRescheduleNotification(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	n := createNotification(t, adminInfo.Id, notification.TargetParams{})
+
+	defer notification.DeleteNotificationById(n.Id)
+
+	notificationInfo := model.Notification{}
+
+	// 立即发布的通知已经推送
+	assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+	assert.True(t, notificationInfo.Pushed)
+
+	// 改为以后发布, 到了发布时间需要重新推送
+	publishAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	r := notification.Update(controller.Context{Uid: adminInfo.Id}, n.Id, notification.UpdateParams{
+		TargetParams: notification.TargetParams{
+			PublishAt: &publishAt,
+		},
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Nil(t, database.Db.Where("id = ?", n.Id).First(&notificationInfo).Error)
+	assert.False(t, notificationInfo.Pushed)
+
+	// 还没到发布时间, 队列中的任务不会推送
+	assert.Nil(t, notification.Push(database.Db, n.Id))
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"math"
+	"net/http"
+)
+
+// 管理员获取某条通知的阅读统计
+func GetStats(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.NotificationStats
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	notificationInfo := model.Notification{}
+
+	if err = database.Db.Where("id = ?", id).First(&notificationInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.NotificationNotExist
+		}
+		return
+	}
+
+	data.Id = notificationInfo.Id
+
+	// 没有限制推送对象的通知, 推送给所有的用户
+	if err = targetScope(database.Db, notificationInfo).Count(&data.Target).Error; err != nil {
+		return
+	}
+
+	if err = database.Db.Model(&model.NotificationMark{}).Where("id = ?", notificationInfo.Id).Count(&data.Read).Error; err != nil {
+		return
+	}
+
+	if data.Target > 0 {
+		data.ReadRate = math.Round(float64(data.Read)/float64(data.Target)*10000) / 10000
+	}
+
+	return
+}
+
+func GetStatsRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetStats(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("id"))
+}
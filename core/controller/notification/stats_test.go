@@ -0,0 +1,82 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestGetStats(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	user1, _ := tester.CreateUser()
+	user2, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(user1.Username)
+	defer auth.DeleteUserByUserName(user2.Username)
+
+	n := createNotification(t, adminInfo.Id, notification.TargetParams{
+		TargetUsers: []string{user1.Id, user2.Id},
+	})
+
+	defer notification.DeleteNotificationById(n.Id)
+	defer notification.DeleteNotificationMarkById(n.Id)
+
+	r := notification.MarkRead(controller.Context{Uid: user1.Id}, n.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	r = notification.GetStats(controller.Context{Uid: adminInfo.Id}, n.Id)
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	stats := schema.NotificationStats{}
+
+	assert.Nil(t, tester.Decode(r.Data, &stats))
+	assert.Equal(t, n.Id, stats.Id)
+	assert.Equal(t, int64(2), stats.Target)
+	assert.Equal(t, int64(1), stats.Read)
+	assert.Equal(t, 0.5, stats.ReadRate)
+
+	// 通知不存在
+	r = notification.GetStats(controller.Context{Uid: adminInfo.Id}, "123123")
+
+	assert.Equal(t, exception.NotificationNotExist.Error(), r.Message)
+}
+
+func TestGetStatsRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	n := createNotification(t, adminInfo.Id, notification.TargetParams{})
+
+	defer notification.DeleteNotificationById(n.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/notification/n/"+n.Id+"/stats", nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	stats := schema.NotificationStats{}
+
+	assert.Nil(t, tester.Decode(res.Data, &stats))
+	assert.True(t, stats.Target > 0)
+	assert.Equal(t, int64(0), stats.Read)
+}
@@ -0,0 +1,359 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/mitchellh/mapstructure"
+	"log"
+	"time"
+)
+
+// 通知的推送对象和有效期, 时间为 RFC3339 格式
+// 不传则不修改, 数组传空数组/时间传空字符串/等级传 0 则清除该条件
+type TargetParams struct {
+	TargetRoles         []string `json:"target_roles"`          // 推送给拥有其中任意一个角色的用户
+	TargetLevelMin      *int32   `json:"target_level_min"`      // 推送给不低于该等级的用户
+	TargetLevelMax      *int32   `json:"target_level_max"`      // 推送给不高于该等级的用户
+	TargetRegisterStart *string  `json:"target_register_start"` // 推送给在这之后注册的用户
+	TargetRegisterEnd   *string  `json:"target_register_end"`   // 推送给在这之前注册的用户
+	TargetUsers         []string `json:"target_users"`          // 推送给指定的用户
+	PublishAt           *string  `json:"publish_at"`            // 发布时间, 为空则立即发布
+	ExpireAt            *string  `json:"expire_at"`             // 过期时间, 为空则永不过期
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+
+	if err != nil {
+		return nil, exception.InvalidParams
+	}
+
+	return &t, nil
+}
+
+func parseLevel(level int32) *int32 {
+	if level == 0 {
+		return nil
+	}
+
+	return &level
+}
+
+// 去掉重复和空的值
+func unique(list []string) pq.StringArray {
+	result := pq.StringArray{}
+	exist := map[string]bool{}
+
+	for _, v := range list {
+		if v == "" || exist[v] {
+			continue
+		}
+		exist[v] = true
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// 把参数设置到通知上
+func (input TargetParams) apply(n *model.Notification) (err error) {
+	if input.TargetRoles != nil {
+		n.TargetRoles = unique(input.TargetRoles)
+	}
+
+	if input.TargetLevelMin != nil {
+		n.TargetLevelMin = parseLevel(*input.TargetLevelMin)
+	}
+
+	if input.TargetLevelMax != nil {
+		n.TargetLevelMax = parseLevel(*input.TargetLevelMax)
+	}
+
+	if input.TargetRegisterStart != nil {
+		if n.TargetRegisterStart, err = parseTime(*input.TargetRegisterStart); err != nil {
+			return
+		}
+	}
+
+	if input.TargetRegisterEnd != nil {
+		if n.TargetRegisterEnd, err = parseTime(*input.TargetRegisterEnd); err != nil {
+			return
+		}
+	}
+
+	if input.TargetUsers != nil {
+		n.TargetUsers = unique(input.TargetUsers)
+	}
+
+	if input.PublishAt != nil {
+		if n.PublishAt, err = parseTime(*input.PublishAt); err != nil {
+			return
+		}
+	}
+
+	if input.ExpireAt != nil {
+		if n.ExpireAt, err = parseTime(*input.ExpireAt); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// 校验通知的推送对象和有效期
+func validateTarget(tx *gorm.DB, n model.Notification) (err error) {
+	if n.TargetLevelMin != nil && n.TargetLevelMax != nil && *n.TargetLevelMin > *n.TargetLevelMax {
+		return exception.NotificationInvalidLevel
+	}
+
+	if n.TargetRegisterStart != nil && n.TargetRegisterEnd != nil && !n.TargetRegisterStart.Before(*n.TargetRegisterEnd) {
+		return exception.NotificationInvalidRegister
+	}
+
+	if n.ExpireAt != nil {
+		publishAt := time.Now()
+
+		if n.PublishAt != nil {
+			publishAt = *n.PublishAt
+		}
+
+		if !publishAt.Before(*n.ExpireAt) {
+			return exception.NotificationInvalidTime
+		}
+	}
+
+	if len(n.TargetRoles) > 0 {
+		var count int
+
+		if err = tx.Model(&model.Role{}).Where("name IN (?)", []string(n.TargetRoles)).Count(&count).Error; err != nil {
+			return
+		}
+
+		if count != len(n.TargetRoles) {
+			return exception.RoleNotExist
+		}
+	}
+
+	if len(n.TargetUsers) > 0 {
+		var count int
+
+		if err = tx.Model(&model.User{}).Where("id IN (?)", []string(n.TargetUsers)).Count(&count).Error; err != nil {
+			return
+		}
+
+		if count != len(n.TargetUsers) {
+			return exception.UserNotExist
+		}
+	}
+
+	return
+}
+
+// 通知是否已经发布并且未过期
+func isLive(n model.Notification, now time.Time) bool {
+	if n.Status != model.NotificationStatusActive {
+		return false
+	}
+
+	if n.PublishAt != nil && n.PublishAt.After(now) {
+		return false
+	}
+
+	if n.ExpireAt != nil && !n.ExpireAt.After(now) {
+		return false
+	}
+
+	return true
+}
+
+// 通知是否限制了推送对象
+func isTargeted(n model.Notification) bool {
+	return len(n.TargetRoles) > 0 ||
+		n.TargetLevelMin != nil ||
+		n.TargetLevelMax != nil ||
+		n.TargetRegisterStart != nil ||
+		n.TargetRegisterEnd != nil ||
+		len(n.TargetUsers) > 0
+}
+
+// 筛选已经发布并且未过期的通知
+func liveScope(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.
+		Where("status = ?", model.NotificationStatusActive).
+		Where("publish_at IS NULL OR publish_at <= ?", now).
+		Where("expire_at IS NULL OR expire_at > ?", now)
+}
+
+// 筛选用户能看到的通知
+func userScope(db *gorm.DB, userInfo model.User, now time.Time) *gorm.DB {
+	return liveScope(db, now).
+		Where("target_roles IS NULL OR cardinality(target_roles) = 0 OR target_roles && ?::varchar[]", userInfo.Role).
+		Where("target_level_min IS NULL OR target_level_min <= ?", userInfo.Level).
+		Where("target_level_max IS NULL OR target_level_max >= ?", userInfo.Level).
+		Where("target_register_start IS NULL OR target_register_start <= ?", userInfo.CreatedAt).
+		Where("target_register_end IS NULL OR target_register_end > ?", userInfo.CreatedAt).
+		Where("target_users IS NULL OR cardinality(target_users) = 0 OR ? = ANY(target_users)", userInfo.Id)
+}
+
+// 筛选用户当前能看到的通知, 用于其他模块查询通知, 例如搜索
+func VisibleScope(db *gorm.DB, userInfo model.User) *gorm.DB {
+	return userScope(db, userInfo, time.Now())
+}
+
+// 筛选通知推送的用户
+func targetScope(db *gorm.DB, n model.Notification) *gorm.DB {
+	db = db.Model(&model.User{})
+
+	if len(n.TargetRoles) > 0 {
+		db = db.Where("role && ?::varchar[]", n.TargetRoles)
+	}
+
+	if n.TargetLevelMin != nil {
+		db = db.Where("level >= ?", *n.TargetLevelMin)
+	}
+
+	if n.TargetLevelMax != nil {
+		db = db.Where("level <= ?", *n.TargetLevelMax)
+	}
+
+	if n.TargetRegisterStart != nil {
+		db = db.Where("created_at >= ?", *n.TargetRegisterStart)
+	}
+
+	if n.TargetRegisterEnd != nil {
+		db = db.Where("created_at < ?", *n.TargetRegisterEnd)
+	}
+
+	if len(n.TargetUsers) > 0 {
+		db = db.Where("id IN (?)", []string(n.TargetUsers))
+	}
+
+	return db
+}
+
+// 把通知加入推送的队列, 逐个用户推送可能很慢, 不在请求中进行
+func publish(n model.Notification) error {
+	return message_queue.Enqueue(context.Background(), message_queue.PushNotificationBody{Id: n.Id})
+}
+
+// 把通知推送给在线的用户, 没有限制推送对象则广播, 由消息队列调用
+// 通知已经被删除, 下线或者改为以后发布时不再推送
+func Push(db *gorm.DB, id string) (err error) {
+	n := model.Notification{}
+
+	if err = db.Where("id = ?", id).First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+		}
+		return
+	}
+
+	if !n.Pushed || !isLive(n, time.Now()) {
+		return
+	}
+
+	var data schema.Notification
+
+	if data, err = toSchema(n); err != nil {
+		return
+	}
+
+	if !isTargeted(n) {
+		if err = push.Publish(push.EventNotification, "", data); err != nil {
+			return
+		}
+	} else {
+		ids := make([]string, 0)
+
+		if err = targetScope(db, n).Pluck("id", &ids).Error; err != nil {
+			return
+		}
+
+		for _, uid := range ids {
+			if err = push.Publish(push.EventNotification, uid, data); err != nil {
+				return
+			}
+		}
+	}
+
+	// 通知本身就是站内的, 另外按照用户的偏好发送邮件和短信
+	// 推送成功之后才发送, 推送失败重试时不会重复发送
+	announce(db, n)
+
+	return
+}
+
+// 把通知通过邮件和短信发送给开启了这些渠道的用户
+func announce(db *gorm.DB, n model.Notification) {
+	ids := make([]string, 0)
+
+	if err := dispatcher.SubscriberScope(targetScope(db, n), dispatcher.CategoryAnnouncement, dispatcher.ChannelEmail, dispatcher.ChannelSMS).Pluck("id", &ids).Error; err != nil {
+		log.Printf("查询通知 %s 的订阅用户失败: %v\n", n.Id, err)
+		return
+	}
+
+	for _, id := range ids {
+		_, _ = dispatcher.Dispatch(dispatcher.Notification{
+			Uid:      id,
+			Category: dispatcher.CategoryAnnouncement,
+			Title:    n.Title,
+			Content:  n.Content,
+			Channels: []dispatcher.Channel{dispatcher.ChannelEmail, dispatcher.ChannelSMS},
+		})
+	}
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	s := t.Format(time.RFC3339Nano)
+
+	return &s
+}
+
+func toSchema(n model.Notification) (data schema.Notification, err error) {
+	if err = mapstructure.Decode(n, &data.NotificationPure); err != nil {
+		return
+	}
+
+	data.PublishAt = n.CreatedAt.Format(time.RFC3339Nano)
+
+	if n.PublishAt != nil {
+		data.PublishAt = n.PublishAt.Format(time.RFC3339Nano)
+	}
+
+	data.ExpireAt = formatTime(n.ExpireAt)
+	data.CreatedAt = n.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = n.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+func toSchemaAdmin(n model.Notification) (data schema.NotificationAdmin, err error) {
+	if err = mapstructure.Decode(n, &data.NotificationPureAdmin); err != nil {
+		return
+	}
+
+	data.TargetRegisterStart = formatTime(n.TargetRegisterStart)
+	data.TargetRegisterEnd = formatTime(n.TargetRegisterEnd)
+	data.PublishAt = formatTime(n.PublishAt)
+	data.ExpireAt = formatTime(n.ExpireAt)
+	data.CreatedAt = n.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = n.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
@@ -0,0 +1,207 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// 用户是否能看到某条通知
+func isVisible(t *testing.T, uid string, notificationId string) bool {
+	r := notification.Get(controller.Context{Uid: uid}, notificationId)
+
+	if r.Status == schema.StatusSuccess {
+		return true
+	}
+
+	assert.Equal(t, exception.NoData.Error(), r.Message)
+
+	return false
+}
+
+func createNotification(t *testing.T, adminId string, target notification.TargetParams) schema.Notification {
+	r := notification.Create(controller.Context{Uid: adminId}, notification.CreateParams{
+		Title:        "target",
+		Content:      "target",
+		TargetParams: target,
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	n := schema.Notification{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n
+}
+
+func TestTarget(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	user1, _ := tester.CreateUser()
+	user2, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(user1.Username)
+	defer auth.DeleteUserByUserName(user2.Username)
+
+	// 指定用户
+	{
+		n := createNotification(t, adminInfo.Id, notification.TargetParams{
+			TargetUsers: []string{user1.Id, user1.Id},
+		})
+
+		defer notification.DeleteNotificationById(n.Id)
+
+		assert.True(t, isVisible(t, user1.Id, n.Id))
+		assert.False(t, isVisible(t, user2.Id, n.Id))
+
+		// 列表中也只有指定的用户能看到
+		r := notification.GetNotificationListByUser(controller.Context{Uid: user2.Id}, notification.Query{})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		list := make([]schema.Notification, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		for _, v := range list {
+			assert.NotEqual(t, n.Id, v.Id)
+		}
+
+		// 清除推送对象之后, 所有人都能看到
+		r2 := notification.Update(controller.Context{Uid: adminInfo.Id}, n.Id, notification.UpdateParams{
+			TargetParams: notification.TargetParams{
+				TargetUsers: []string{},
+			},
+		})
+
+		assert.Equal(t, "", r2.Message)
+		assert.True(t, isVisible(t, user2.Id, n.Id))
+	}
+
+	// 指定角色
+	{
+		n := createNotification(t, adminInfo.Id, notification.TargetParams{
+			TargetRoles: []string{model.DefaultUser.Name},
+		})
+
+		defer notification.DeleteNotificationById(n.Id)
+
+		assert.True(t, isVisible(t, user1.Id, n.Id))
+		assert.True(t, isVisible(t, user2.Id, n.Id))
+	}
+
+	// 指定等级, 新用户的等级为 1
+	{
+		var level int32 = 2
+
+		n := createNotification(t, adminInfo.Id, notification.TargetParams{
+			TargetLevelMin: &level,
+		})
+
+		defer notification.DeleteNotificationById(n.Id)
+
+		assert.False(t, isVisible(t, user1.Id, n.Id))
+	}
+
+	// 指定注册时间
+	{
+		start := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		end := time.Now().Add(-time.Minute * 30).Format(time.RFC3339)
+
+		n := createNotification(t, adminInfo.Id, notification.TargetParams{
+			TargetRegisterStart: &start,
+		})
+
+		defer notification.DeleteNotificationById(n.Id)
+
+		assert.True(t, isVisible(t, user1.Id, n.Id))
+
+		n2 := createNotification(t, adminInfo.Id, notification.TargetParams{
+			TargetRegisterStart: &start,
+			TargetRegisterEnd:   &end,
+		})
+
+		defer notification.DeleteNotificationById(n2.Id)
+
+		assert.False(t, isVisible(t, user1.Id, n2.Id))
+	}
+
+	// 定时发布
+	{
+		publishAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+		n := createNotification(t, adminInfo.Id, notification.TargetParams{
+			PublishAt: &publishAt,
+		})
+
+		defer notification.DeleteNotificationById(n.Id)
+
+		assert.False(t, isVisible(t, user1.Id, n.Id))
+
+		// 立即发布, 但是一个小时后过期
+		now := ""
+		expireAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+		r := notification.Update(controller.Context{Uid: adminInfo.Id}, n.Id, notification.UpdateParams{
+			TargetParams: notification.TargetParams{
+				PublishAt: &now,
+				ExpireAt:  &expireAt,
+			},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		data := schema.NotificationAdmin{}
+
+		assert.Nil(t, tester.Decode(r.Data, &data))
+		assert.Nil(t, data.PublishAt)
+		assert.NotNil(t, data.ExpireAt)
+
+		assert.True(t, isVisible(t, user1.Id, n.Id))
+	}
+}
+
+func TestTargetInvalid(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	context := controller.Context{Uid: adminInfo.Id}
+
+	var (
+		min       int32 = 5
+		max       int32 = 3
+		invalid         = "2019-01-01"
+		publishAt       = time.Now().Add(time.Hour).Format(time.RFC3339)
+		expireAt        = time.Now().Format(time.RFC3339)
+	)
+
+	cases := []struct {
+		target notification.TargetParams
+		err    error
+	}{
+		{notification.TargetParams{TargetLevelMin: &min, TargetLevelMax: &max}, exception.NotificationInvalidLevel},
+		{notification.TargetParams{PublishAt: &invalid}, exception.InvalidParams},
+		{notification.TargetParams{PublishAt: &publishAt, ExpireAt: &expireAt}, exception.NotificationInvalidTime},
+		{notification.TargetParams{TargetRegisterStart: &publishAt, TargetRegisterEnd: &expireAt}, exception.NotificationInvalidRegister},
+		{notification.TargetParams{TargetUsers: []string{"123123"}}, exception.UserNotExist},
+		{notification.TargetParams{TargetRoles: []string{"not_exist_role"}}, exception.RoleNotExist},
+	}
+
+	for _, c := range cases {
+		r := notification.Create(context, notification.CreateParams{
+			Title:        "target",
+			Content:      "target",
+			TargetParams: c.target,
+		})
+
+		assert.Equal(t, c.err.Error(), r.Message)
+	}
+}
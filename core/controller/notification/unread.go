@@ -0,0 +1,32 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package notification
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 筛选用户还没有读过的通知
+func unreadScope(db *gorm.DB, userInfo model.User) *gorm.DB {
+	return userScope(db, userInfo, time.Now()).
+		Where("NOT EXISTS (SELECT 1 FROM notification_mark WHERE notification_mark.id = notification.id AND notification_mark.uid = ? AND notification_mark.deleted_at IS NULL)", userInfo.Id)
+}
+
+// 获取用户未读的通知数量
+func CountUnread(db *gorm.DB, userInfo model.User) (count int64, err error) {
+	err = unreadScope(db.Model(&model.Notification{}), userInfo).Count(&count).Error
+
+	return
+}
+
+// 用户读了通知, 清除该用户未读数的缓存
+func resetUnread(uid string) {
+	_ = redis.ClientUnread.Del(uid).Err()
+}
+
+// 通知有变动会影响所有用户的未读数, 清除所有的缓存
+func resetAllUnread() {
+	_ = redis.ClientUnread.FlushDB().Err()
+}
@@ -4,31 +4,35 @@ package notification
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/middleware"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/database"
 	"github.com/axetroy/go-server/core/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
-	"github.com/mitchellh/mapstructure"
 	"net/http"
 	"time"
 )
 
 type UpdateParams struct {
-	Title   *string `json:"title"`   // 公告标题
-	Content *string `json:"content"` // 公告内容
-	Note    *string `json:"note"`    // 备注
+	Title   *string              `json:"title"`   // 公告标题
+	Content *string              `json:"content"` // 公告内容
+	Format  *model.ContentFormat `json:"format"`  // 内容的格式
+	Note    *string              `json:"note"`    // 备注
+	TargetParams
 }
 
 func Update(c controller.Context, notificationId string, input UpdateParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Notification
-		tx   *gorm.DB
+		err    error
+		data   schema.NotificationAdmin
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -51,6 +55,11 @@ func Update(c controller.Context, notificationId string, input UpdateParams) (re
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+			resetAllUnread()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -94,6 +103,27 @@ func Update(c controller.Context, notificationId string, input UpdateParams) (re
 		updateModel.Content = *input.Content
 	}
 
+	if input.Format != nil {
+		if updateModel.Format, err = content.ParseFormat(input.Format); err != nil {
+			return
+		}
+	}
+
+	// 内容或者格式修改之后重新生成摘要
+	if updateModel.Content != "" || updateModel.Format != "" {
+		format, source := notificationInfo.Format, notificationInfo.Content
+
+		if updateModel.Format != "" {
+			format = updateModel.Format
+		}
+
+		if updateModel.Content != "" {
+			source = updateModel.Content
+		}
+
+		updateModel.Excerpt = content.Excerpt(format, source, content.ExcerptLength)
+	}
+
 	if input.Note != nil {
 		updateModel.Note = input.Note
 	}
@@ -102,12 +132,39 @@ func Update(c controller.Context, notificationId string, input UpdateParams) (re
 		return
 	}
 
-	if err = mapstructure.Decode(notificationInfo, &data.NotificationPure); err != nil {
+	if err = input.TargetParams.apply(&notificationInfo); err != nil {
+		return
+	}
+
+	if err = validateTarget(tx, notificationInfo); err != nil {
+		return
+	}
+
+	// 发布时间改到了以后, 到了新的发布时间再由定时任务推送
+	if notificationInfo.PublishAt != nil && notificationInfo.PublishAt.After(time.Now()) {
+		notificationInfo.Pushed = false
+	}
+
+	// 推送对象和有效期可以被清除, 所以用 map 更新空值
+	if err = tx.Model(&notificationInfo).Updates(map[string]interface{}{
+		"target_roles":          notificationInfo.TargetRoles,
+		"target_level_min":      notificationInfo.TargetLevelMin,
+		"target_level_max":      notificationInfo.TargetLevelMax,
+		"target_register_start": notificationInfo.TargetRegisterStart,
+		"target_register_end":   notificationInfo.TargetRegisterEnd,
+		"target_users":          notificationInfo.TargetUsers,
+		"publish_at":            notificationInfo.PublishAt,
+		"expire_at":             notificationInfo.ExpireAt,
+		"pushed":                notificationInfo.Pushed,
+	}).Error; err != nil {
+		return
+	}
+
+	if err = events.Publish(tx, event.NotificationSaved{Notification: notificationInfo}); err != nil {
 		return
 	}
 
-	data.CreatedAt = notificationInfo.CreatedAt.Format(time.RFC3339Nano)
-	data.UpdatedAt = notificationInfo.UpdatedAt.Format(time.RFC3339Nano)
+	data, err = toSchemaAdmin(notificationInfo)
 
 	return
 }
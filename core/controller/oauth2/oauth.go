@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/service/database"
@@ -26,6 +27,7 @@ func redirectToClient(c *gin.Context, user *goth.User) {
 		err       error
 		tx        *gorm.DB
 		finallURL string
+		events    event.Batch
 	)
 
 	fontendURL := dotenv.Get("OAUTH_REDIRECT_URL")
@@ -50,6 +52,10 @@ func redirectToClient(c *gin.Context, user *goth.User) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		if err != nil {
 			c.String(http.StatusBadRequest, err.Error())
 		} else {
@@ -99,7 +105,7 @@ func redirectToClient(c *gin.Context, user *goth.User) {
 			}
 
 			// 创建一个用户
-			if err = auth.CreateUserTx(tx, &userInfo, nil); err != nil {
+			if err = auth.CreateUserTx(tx, &events, &userInfo, nil); err != nil {
 				return
 			}
 
@@ -159,7 +165,7 @@ func redirectToClient(c *gin.Context, user *goth.User) {
 		}
 
 		// 创建一个用户
-		if err = auth.CreateUserTx(tx, &userInfo, nil); err != nil {
+		if err = auth.CreateUserTx(tx, &events, &userInfo, nil); err != nil {
 			return
 		}
 	}
@@ -4,6 +4,7 @@ package report
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/middleware"
@@ -27,12 +28,31 @@ type UpdateByAdminParams struct {
 	Locked *bool `json:"locked"` // 是否锁定
 }
 
+// 状态改变时发布事件, 改变为已解决时同时发布 ReportResolved
+func publishStatusChanged(tx *gorm.DB, events *event.Batch, data schema.Report, previousStatus model.ReportStatus) error {
+	if data.Status == previousStatus {
+		return nil
+	}
+
+	if err := events.Publish(tx, event.ReportStatusChanged{Report: data, PreviousStatus: previousStatus}); err != nil {
+		return err
+	}
+
+	if data.Status == model.ReportStatusResolve {
+		return events.Publish(tx, event.ReportResolved{Report: data})
+	}
+
+	return nil
+}
+
 func Update(c controller.Context, reportId string, input UpdateParams) (res schema.Response) {
 	var (
-		err          error
-		data         schema.Report
-		tx           *gorm.DB
-		shouldUpdate bool
+		err            error
+		data           schema.Report
+		tx             *gorm.DB
+		shouldUpdate   bool
+		previousStatus model.ReportStatus
+		events         event.Batch
 	)
 
 	defer func() {
@@ -55,6 +75,10 @@ func Update(c controller.Context, reportId string, input UpdateParams) (res sche
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -78,6 +102,8 @@ func Update(c controller.Context, reportId string, input UpdateParams) (res sche
 		return
 	}
 
+	previousStatus = reportInfo.Status
+
 	// 如果已被锁定，则无法更新状态
 	if reportInfo.Locked {
 		err = errors.New("该反馈已被锁定, 无法更新")
@@ -113,6 +139,8 @@ func Update(c controller.Context, reportId string, input UpdateParams) (res sche
 	data.CreatedAt = reportInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = reportInfo.UpdatedAt.Format(time.RFC3339Nano)
 
+	err = publishStatusChanged(tx, &events, data, previousStatus)
+
 	return
 }
 
@@ -145,10 +173,12 @@ func UpdateRouter(c *gin.Context) {
 
 func UpdateByAdmin(c controller.Context, reportId string, input UpdateByAdminParams) (res schema.Response) {
 	var (
-		err          error
-		data         schema.Report
-		tx           *gorm.DB
-		shouldUpdate bool
+		err            error
+		data           schema.Report
+		tx             *gorm.DB
+		shouldUpdate   bool
+		previousStatus model.ReportStatus
+		events         event.Batch
 	)
 
 	defer func() {
@@ -171,6 +201,10 @@ func UpdateByAdmin(c controller.Context, reportId string, input UpdateByAdminPar
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -193,6 +227,8 @@ func UpdateByAdmin(c controller.Context, reportId string, input UpdateByAdminPar
 		return
 	}
 
+	previousStatus = reportInfo.Status
+
 	updatedModel := model.Report{}
 
 	if input.Status != nil {
@@ -222,6 +258,8 @@ func UpdateByAdmin(c controller.Context, reportId string, input UpdateByAdminPar
 	data.CreatedAt = reportInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = reportInfo.UpdatedAt.Format(time.RFC3339Nano)
 
+	err = publishStatusChanged(tx, &events, data, previousStatus)
+
 	return
 }
 
@@ -0,0 +1,198 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schedule
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+const ParamsRunIdName = "run_id"
+
+type Query struct {
+	schema.Query
+	Task    *string                  `json:"task" form:"task"`
+	Trigger *string                  `json:"trigger" form:"trigger"`
+	Status  *model.ScheduleRunStatus `json:"status" form:"status"`
+}
+
+func DeleteRunById(id string) {
+	database.DeleteRowByTable("schedule_run", "id", id)
+}
+
+func runToSchema(run model.ScheduleRun) (data schema.ScheduleRun, err error) {
+	if err = mapstructure.Decode(run, &data.ScheduleRunPure); err != nil {
+		return
+	}
+
+	data.StartedAt = run.StartedAt.Format(time.RFC3339Nano)
+
+	if run.FinishedAt != nil {
+		finishedAt := run.FinishedAt.Format(time.RFC3339Nano)
+		data.FinishedAt = &finishedAt
+	}
+
+	data.CreatedAt = run.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = run.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 获取运行记录详情
+func GetRun(id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.ScheduleRun
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	run := model.ScheduleRun{Id: id}
+
+	if err = database.Db.First(&run).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.ScheduleRunNotExist
+		}
+		return
+	}
+
+	data, err = runToSchema(run)
+
+	return
+}
+
+// 获取运行记录列表, 默认按开始时间倒序
+func GetRunList(input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.ScheduleRun, 0)
+		list = make([]model.ScheduleRun, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	if len(input.Sort) == 0 {
+		query.Sort = "-started_at"
+	}
+
+	filter := map[string]interface{}{}
+
+	if input.Task != nil {
+		filter["task"] = *input.Task
+	}
+
+	if input.Trigger != nil {
+		filter["trigger"] = *input.Trigger
+	}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.ScheduleRun{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.ScheduleRun
+
+		if d, err = runToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetRunRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetRun(c.Param(ParamsRunIdName))
+}
+
+func GetRunListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetRunList(input)
+}
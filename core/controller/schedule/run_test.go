@@ -0,0 +1,122 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schedule_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/schedule"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func createRun(t *testing.T) model.ScheduleRun {
+	finishedAt := time.Now()
+
+	run := model.ScheduleRun{
+		Task:       testTaskName,
+		Trigger:    scheduler.TriggerManual,
+		Instance:   "test",
+		Status:     model.ScheduleRunStatusFail,
+		Error:      "fail",
+		Duration:   10,
+		StartedAt:  finishedAt.Add(-time.Millisecond * 10),
+		FinishedAt: &finishedAt,
+	}
+
+	assert.Nil(t, database.Db.Create(&run).Error)
+
+	return run
+}
+
+func TestGetRun(t *testing.T) {
+	run := createRun(t)
+
+	defer schedule.DeleteRunById(run.Id)
+
+	r := schedule.GetRun(run.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	info := schema.ScheduleRun{}
+
+	assert.Nil(t, tester.Decode(r.Data, &info))
+
+	assert.Equal(t, testTaskName, info.Task)
+	assert.Equal(t, int(model.ScheduleRunStatusFail), info.Status)
+	assert.Equal(t, "fail", info.Error)
+	assert.NotNil(t, info.FinishedAt)
+
+	// 记录不存在
+	r2 := schedule.GetRun("123123")
+
+	assert.Equal(t, exception.ScheduleRunNotExist.Code(), r2.Status)
+}
+
+func TestGetRunList(t *testing.T) {
+	run := createRun(t)
+
+	defer schedule.DeleteRunById(run.Id)
+
+	task := testTaskName
+	status := model.ScheduleRunStatusFail
+
+	r := schedule.GetRunList(schedule.Query{Task: &task, Status: &status})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.ScheduleRun, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	if assert.True(t, len(list) > 0) {
+		assert.Equal(t, run.Id, list[0].Id)
+	}
+
+	// 最近一次的运行记录
+	{
+		r := schedule.GetTaskList()
+
+		tasks := make([]schema.ScheduleTask, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &tasks))
+
+		for _, v := range tasks {
+			if v.Name == testTaskName && assert.NotNil(t, v.LastRun) {
+				assert.Equal(t, run.Id, v.LastRun.Id)
+			}
+		}
+	}
+}
+
+func TestGetRunListRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	run := createRun(t)
+
+	defer schedule.DeleteRunById(run.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/schedule/run?task="+testTaskName, nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.True(t, res.Meta.Total > 0)
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+const ParamsTaskName = "task_name"
+
+func taskToSchema(task scheduler.Task) (data schema.ScheduleTask, err error) {
+	data = schema.ScheduleTask{
+		Name:        task.Name,
+		Spec:        task.Spec,
+		Description: task.Description,
+		Timeout:     int64(task.Timeout / time.Second),
+	}
+
+	if next := task.Next(time.Now()); !next.IsZero() {
+		s := next.Format(time.RFC3339Nano)
+		data.NextRunAt = &s
+	}
+
+	run := model.ScheduleRun{}
+
+	if err = database.Db.Where("task = ?", task.Name).Order("started_at DESC").First(&run).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+		}
+		return
+	}
+
+	var last schema.ScheduleRun
+
+	if last, err = runToSchema(run); err != nil {
+		return
+	}
+
+	data.LastRun = &last
+
+	return
+}
+
+// 获取所有的定时任务, 以及各个任务最近一次的运行记录
+func GetTaskList() (res schema.Response) {
+	var (
+		err  error
+		data = make([]schema.ScheduleTask, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	for _, task := range scheduler.Tasks() {
+		var d schema.ScheduleTask
+
+		if d, err = taskToSchema(task); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	return
+}
+
+// 手动触发定时任务, 加入消息队列之后立即返回, 运行的结果在运行记录中查看
+func Trigger(c controller.Context, name string) (res schema.Response) {
+	var (
+		err  error
+		data schema.ScheduleTask
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	task, ok := scheduler.GetTask(name)
+
+	if !ok {
+		err = exception.ScheduleTaskNotExist
+		return
+	}
+
+	if err = message_queue.Enqueue(context.Background(), message_queue.ScheduleTriggerBody{Name: task.Name}); err != nil {
+		return
+	}
+
+	data, err = taskToSchema(task)
+
+	return
+}
+
+func GetTaskListRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetTaskList()
+}
+
+func TriggerRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Trigger(controller.NewContext(c), c.Param(ParamsTaskName))
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schedule_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/schedule"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const testTaskName = "test_schedule_task"
+
+func init() {
+	scheduler.Register(scheduler.Task{
+		Name:        testTaskName,
+		Spec:        "0 3 * * *",
+		Description: "测试",
+		Run: func(ctx context.Context) error {
+			return nil
+		},
+	})
+}
+
+func TestGetTaskList(t *testing.T) {
+	r := schedule.GetTaskList()
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.ScheduleTask, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	found := false
+
+	for _, task := range list {
+		if task.Name == testTaskName {
+			found = true
+			assert.Equal(t, "0 3 * * *", task.Spec)
+			assert.NotNil(t, task.NextRunAt)
+		}
+	}
+
+	assert.True(t, found)
+}
+
+func TestTrigger(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	// 触发任务
+	{
+		r := schedule.Trigger(controller.Context{Uid: adminInfo.Id}, testTaskName)
+
+		assert.Equal(t, "", r.Message)
+
+		info := schema.ScheduleTask{}
+
+		assert.Nil(t, tester.Decode(r.Data, &info))
+
+		assert.Equal(t, testTaskName, info.Name)
+	}
+
+	// 任务不存在
+	{
+		r := schedule.Trigger(controller.Context{Uid: adminInfo.Id}, "not_exist")
+
+		assert.Equal(t, exception.ScheduleTaskNotExist.Code(), r.Status)
+	}
+}
+
+func TestTriggerRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Put("/v1/schedule/t/"+testTaskName+"/trigger", nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/search"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type ReindexParams struct {
+	Type string `json:"type"` // 重建索引的类型, 默认全部
+}
+
+// 重建全文搜索的索引, 返回每个类型处理的数量
+// 新增的内容在保存时就会建立索引, 只有分词的规则改变之后才需要重建
+func Reindex(c controller.Context, input ReindexParams) (res schema.Response) {
+	var (
+		err  error
+		data = map[string]int{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		err = exception.AdminNotSuper
+		return
+	}
+
+	types := search.Types
+
+	if input.Type != "" {
+		if !search.IsValidType(input.Type) {
+			err = exception.InvalidParams
+			return
+		}
+
+		types = []string{input.Type}
+	}
+
+	for _, t := range types {
+		if data[t], err = search.Reindex(database.Db, t); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func ReindexRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input ReindexParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Reindex(controller.NewContext(c), input)
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/search"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestReindex(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	{
+		r := search.Reindex(controller.Context{Uid: adminInfo.Id}, search.ReindexParams{Type: "help"})
+
+		assert.Equal(t, "", r.Message)
+
+		data := map[string]int{}
+
+		assert.Nil(t, tester.Decode(r.Data, &data))
+
+		assert.Len(t, data, 1)
+		assert.Contains(t, data, "help")
+	}
+
+	// 错误的类型
+	{
+		r := search.Reindex(controller.Context{Uid: adminInfo.Id}, search.ReindexParams{Type: "user"})
+
+		assert.Equal(t, exception.InvalidParams.Code(), r.Status)
+	}
+
+	// 用户不能重建索引
+	{
+		userInfo, _ := tester.CreateUser()
+
+		defer auth.DeleteUserByUserName(userInfo.Username)
+
+		r := search.Reindex(controller.Context{Uid: userInfo.Id}, search.ReindexParams{})
+
+		assert.Equal(t, exception.AdminNotExist.Code(), r.Status)
+	}
+}
+
+func TestReindexRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	body, _ := json.Marshal(&search.ReindexParams{})
+
+	r := tester.HttpAdmin.Put("/v1/search/reindex", body, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	data := map[string]int{}
+
+	assert.Nil(t, tester.Decode(res.Data, &data))
+
+	assert.Len(t, data, 3)
+}
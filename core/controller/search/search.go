@@ -0,0 +1,218 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/search"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Query struct {
+	schema.Query
+	Q    string `json:"q" form:"q"`       // 搜索的关键词
+	Type string `json:"type" form:"type"` // 搜索的类型, 多个用逗号分隔, 默认搜索全部
+}
+
+type row struct {
+	Type      string
+	Id        string
+	Title     string
+	Format    model.ContentFormat
+	Content   string
+	Rank      float64
+	CreatedAt time.Time
+}
+
+// 解析搜索的类型, 没有登陆时不能搜索系统通知
+func parseTypes(s string, uid string) (types []string, err error) {
+	if s == "" {
+		for _, t := range search.Types {
+			if t == search.TypeNotification && uid == "" {
+				continue
+			}
+			types = append(types, t)
+		}
+		return
+	}
+
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+
+		if !search.IsValidType(t) {
+			err = exception.InvalidParams
+			return
+		}
+
+		if t == search.TypeNotification && uid == "" {
+			err = exception.InvalidToken
+			return
+		}
+
+		types = append(types, t)
+	}
+
+	return
+}
+
+// 每个类型的查询, 只查询用户能看到的内容
+func subQuery(t string, tsquery string, userInfo model.User) *gorm.DB {
+	var db *gorm.DB
+
+	switch t {
+	case search.TypeNews:
+		db = database.Db.Model(&model.News{}).
+			Where("status = ? AND published_at <= ?", model.NewsStatusActive, time.Now())
+	case search.TypeHelp:
+		db = database.Db.Model(&model.Help{}).
+			Where("status = ?", model.HelpStatusActive)
+	case search.TypeNotification:
+		db = notification.VisibleScope(database.Db.Model(&model.Notification{}), userInfo)
+	}
+
+	return db.
+		Select("?::varchar AS type, id, title, format, content, ts_rank("+search.Column+", ?::tsquery) AS rank, created_at", t, tsquery).
+		Where(search.Column+" @@ ?::tsquery", tsquery)
+}
+
+// 搜索新闻公告, 帮助文章和系统通知的标题和内容, 按照相关度排序
+// 登陆的用户可以搜索推送给自己的系统通知
+func Search(c controller.Context, input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.SearchResult, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	query.Sort = "-rank"
+
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	var types []string
+
+	if types, err = parseTypes(input.Type, c.Uid); err != nil {
+		return
+	}
+
+	tsquery := search.Query(input.Q)
+
+	// 没有可以搜索的词
+	if tsquery == "" {
+		return
+	}
+
+	userInfo := model.User{Id: c.Uid}
+
+	if c.Uid != "" {
+		if err = database.Db.First(&userInfo).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = exception.UserNotExist
+			}
+			return
+		}
+	}
+
+	var (
+		parts = make([]string, 0)
+		args  = make([]interface{}, 0)
+	)
+
+	for _, t := range types {
+		parts = append(parts, "?")
+		args = append(args, subQuery(t, tsquery, userInfo).QueryExpr())
+	}
+
+	union := "(" + strings.Join(parts, " UNION ALL ") + ") AS result"
+
+	var total int64
+
+	if err = database.Db.Raw("SELECT count(*) FROM "+union, args...).Row().Scan(&total); err != nil {
+		return
+	}
+
+	rows := make([]row, 0)
+
+	if err = database.Db.Raw("SELECT * FROM "+union+" ORDER BY rank DESC, created_at DESC LIMIT ? OFFSET ?", append(args, query.Limit, query.Limit*query.Page)...).Scan(&rows).Error; err != nil {
+		return
+	}
+
+	keywords := search.Keywords(input.Q)
+
+	for _, r := range rows {
+		data = append(data, schema.SearchResult{
+			Type:      r.Type,
+			Id:        r.Id,
+			Title:     search.Highlight(r.Title, keywords),
+			Snippet:   search.Snippet(search.Text(r.Format, r.Content), keywords),
+			Rank:      r.Rank,
+			CreatedAt: r.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+
+	return
+}
+
+func SearchRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	ctx := controller.Context{}
+
+	// 登陆是可选的, 带了有效的 Token 才能搜索系统通知
+	if claims, er := token.Parse(c.GetHeader(token.AuthField), false); er == nil {
+		ctx.Uid = claims.Uid
+	}
+
+	res = Search(ctx, input)
+}
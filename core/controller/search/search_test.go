@@ -0,0 +1,278 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/help"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/controller/search"
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// 创建标题和内容包含关键词的新闻, 帮助文章和系统通知, 返回它们的ID
+func createContents(t *testing.T, keyword string) (newsId string, helpId string, notificationId string) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	{
+		r := news.Create(controller.Context{Uid: adminInfo.Id}, news.CreateNewParams{
+			Title:   keyword,
+			Content: "新闻内容",
+			Type:    model.NewsTypeNews,
+			Tags:    []string{},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.News{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		newsId = n.Id
+	}
+
+	{
+		r := help.Create(controller.Context{Uid: adminInfo.Id}, help.CreateParams{
+			Title:   "帮助",
+			Content: "这篇文章介绍" + keyword + "的方法",
+			Tags:    []string{},
+			Status:  model.HelpStatusActive,
+			Type:    model.HelpTypeArticle,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.Help{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		helpId = n.Id
+	}
+
+	{
+		r := notification.Create(controller.Context{Uid: adminInfo.Id}, notification.CreateParams{
+			Title:   "通知",
+			Content: keyword,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.Notification{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		notificationId = n.Id
+	}
+
+	// 索引在事务提交之后异步建立
+	event.Wait()
+
+	return
+}
+
+func TestSearch(t *testing.T) {
+	// 随机的关键词, 避免搜索到其他测试的数据
+	keyword := "重置" + util.RandomNumeric(6) + "密码"
+
+	newsId, helpId, notificationId := createContents(t, keyword)
+
+	defer news.DeleteNewsById(newsId)
+	defer help.DeleteHelpById(helpId)
+	defer notification.DeleteNotificationById(notificationId)
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	// 登陆的用户可以搜索到所有类型, 标题中匹配的新闻排在前面
+	{
+		r := search.Search(controller.Context{Uid: userInfo.Id}, search.Query{Q: keyword})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.SearchResult, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		if !assert.Len(t, list, 3) {
+			return
+		}
+
+		assert.Equal(t, "news", list[0].Type)
+		assert.Equal(t, newsId, list[0].Id)
+		assert.Equal(t, "<mark>"+keyword+"</mark>", list[0].Title)
+		assert.Equal(t, int64(3), r.Meta.Total)
+
+		for _, v := range list {
+			if v.Type == "help" {
+				assert.Equal(t, helpId, v.Id)
+				assert.Equal(t, "这篇文章介绍<mark>"+keyword+"</mark>的方法", v.Snippet)
+			}
+		}
+	}
+
+	// 按照类型筛选
+	{
+		r := search.Search(controller.Context{Uid: userInfo.Id}, search.Query{Q: keyword, Type: "help,notification"})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Meta.Total)
+	}
+
+	// 没有登陆时搜索不到系统通知
+	{
+		r := search.Search(controller.Context{}, search.Query{Q: keyword})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Meta.Total)
+
+		r2 := search.Search(controller.Context{}, search.Query{Q: keyword, Type: "notification"})
+
+		assert.Equal(t, exception.InvalidToken.Code(), r2.Status)
+	}
+
+	// 草稿搜索不到
+	{
+		draft := model.NewsStatusDraft
+
+		adminInfo, _ := tester.LoginAdmin()
+
+		r := news.Update(controller.Context{Uid: adminInfo.Id}, newsId, news.UpdateParams{Status: &draft})
+
+		assert.Equal(t, "", r.Message)
+
+		r2 := search.Search(controller.Context{}, search.Query{Q: keyword, Type: "news"})
+
+		assert.Equal(t, int64(0), r2.Meta.Total)
+	}
+
+	// 错误的类型
+	{
+		r := search.Search(controller.Context{}, search.Query{Q: keyword, Type: "user"})
+
+		assert.Equal(t, exception.InvalidParams.Code(), r.Status)
+	}
+}
+
+func TestSearchMarkup(t *testing.T) {
+	keyword := util.RandomNumeric(8)
+
+	adminInfo, _ := tester.LoginAdmin()
+
+	format := model.ContentFormatMarkdown
+
+	r := news.Create(controller.Context{Uid: adminInfo.Id}, news.CreateNewParams{
+		Title:   "链接",
+		Content: "[查看文档](https://example.com/" + keyword + ")",
+		Format:  &format,
+		Type:    model.NewsTypeNews,
+		Tags:    []string{},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer news.DeleteNewsById(n.Id)
+
+	event.Wait()
+
+	// 链接地址不是正文, 搜索不到
+	r2 := search.Search(controller.Context{}, search.Query{Q: keyword, Type: "news"})
+
+	assert.Equal(t, "", r2.Message)
+	assert.Equal(t, int64(0), r2.Meta.Total)
+
+	// 链接的文字可以搜索到
+	r3 := search.Search(controller.Context{}, search.Query{Q: "查看文档", Type: "news"})
+
+	assert.Equal(t, "", r3.Message)
+	assert.True(t, r3.Meta.Total >= 1)
+}
+
+func TestSearchSnippet(t *testing.T) {
+	keyword := util.RandomNumeric(8)
+
+	adminInfo, _ := tester.LoginAdmin()
+
+	format := model.ContentFormatMarkdown
+
+	r := news.Create(controller.Context{Uid: adminInfo.Id}, news.CreateNewParams{
+		Title:   "说明",
+		Content: "# 标题\n\n这是 **" + keyword + "** 的说明",
+		Format:  &format,
+		Type:    model.NewsTypeNews,
+		Tags:    []string{},
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.News{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer news.DeleteNewsById(n.Id)
+
+	event.Wait()
+
+	r2 := search.Search(controller.Context{}, search.Query{Q: keyword, Type: "news"})
+
+	assert.Equal(t, "", r2.Message)
+	assert.Equal(t, int64(1), r2.Meta.Total)
+
+	list := make([]schema.SearchResult, 0)
+
+	assert.Nil(t, tester.Decode(r2.Data, &list))
+
+	assert.Len(t, list, 1)
+
+	// 摘要来自渲染之后的纯文本, 不包含 Markdown 的标记
+	assert.Contains(t, list[0].Snippet, "<mark>"+keyword+"</mark>")
+	assert.NotContains(t, list[0].Snippet, "**")
+	assert.NotContains(t, list[0].Snippet, "#")
+}
+
+func TestSearchRouter(t *testing.T) {
+	keyword := "搜索" + util.RandomNumeric(6)
+
+	newsId, helpId, notificationId := createContents(t, keyword)
+
+	defer news.DeleteNewsById(newsId)
+	defer help.DeleteHelpById(helpId)
+	defer notification.DeleteNotificationById(notificationId)
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	r := tester.HttpUser.Get("/v1/search?q="+url.QueryEscape(keyword), nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, int64(3), res.Meta.Total)
+}
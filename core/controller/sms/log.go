@@ -0,0 +1,191 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package sms
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+const ParamsIdName = "log_id"
+
+type Query struct {
+	schema.Query
+	Phone     *string             `json:"phone" form:"phone"`
+	Provider  *string             `json:"provider" form:"provider"`
+	Status    *model.SMSLogStatus `json:"status" form:"status"`
+	RequestId *string             `json:"request_id" form:"request_id"`
+}
+
+func DeleteLogById(id string) {
+	database.DeleteRowByTable("sms_log", "id", id)
+}
+
+func logToSchema(l model.SMSLog) (data schema.SMSLog, err error) {
+	if err = mapstructure.Decode(l, &data.SMSLogPure); err != nil {
+		return
+	}
+
+	data.CreatedAt = l.CreatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 获取短信记录详情
+func GetLog(id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.SMSLog
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	l := model.SMSLog{Id: id}
+
+	if err = database.Db.First(&l).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.SMSLogNotExist
+		}
+		return
+	}
+
+	data, err = logToSchema(l)
+
+	return
+}
+
+// 获取短信记录列表, 使用 mock 服务商时可以在这里查看发送的验证码
+func GetLogList(input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.SMSLog, 0)
+		list = make([]model.SMSLog, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	filter := map[string]interface{}{}
+
+	if input.Phone != nil {
+		filter["phone"] = *input.Phone
+	}
+
+	if input.Provider != nil {
+		filter["provider"] = *input.Provider
+	}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	if input.RequestId != nil {
+		filter["request_id"] = *input.RequestId
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.SMSLog{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.SMSLog
+
+		if d, err = logToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetLogRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetLog(c.Param(ParamsIdName))
+}
+
+func GetLogListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetLogList(input)
+}
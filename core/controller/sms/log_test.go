@@ -0,0 +1,98 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package sms_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/sms"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func createLog(t *testing.T) model.SMSLog {
+	l := model.SMSLog{
+		Provider:  "mock",
+		Phone:     "13800000002",
+		Template:  "auth",
+		Content:   "您的验证码是 123456, 请勿泄露给他人",
+		RequestId: "mock-test",
+		Status:    model.SMSLogStatusSuccess,
+	}
+
+	assert.Nil(t, database.Db.Create(&l).Error)
+
+	return l
+}
+
+func TestGetLog(t *testing.T) {
+	l := createLog(t)
+
+	defer sms.DeleteLogById(l.Id)
+
+	r := sms.GetLog(l.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	info := schema.SMSLog{}
+
+	assert.Nil(t, tester.Decode(r.Data, &info))
+
+	assert.Equal(t, l.Phone, info.Phone)
+	assert.Equal(t, l.Content, info.Content)
+	assert.Equal(t, int(model.SMSLogStatusSuccess), info.Status)
+
+	// 记录不存在
+	r2 := sms.GetLog("123123")
+
+	assert.Equal(t, exception.SMSLogNotExist.Code(), r2.Status)
+}
+
+func TestGetLogList(t *testing.T) {
+	l := createLog(t)
+
+	defer sms.DeleteLogById(l.Id)
+
+	requestId := l.RequestId
+
+	r := sms.GetLogList(sms.Query{RequestId: &requestId})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.SMSLog, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	assert.Len(t, list, 1)
+	assert.Equal(t, l.Id, list[0].Id)
+}
+
+func TestGetLogListRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	l := createLog(t)
+
+	defer sms.DeleteLogById(l.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/sms/log?request_id="+l.RequestId, nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, int64(1), res.Meta.Total)
+}
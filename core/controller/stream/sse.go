@@ -0,0 +1,121 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// user_server 的 WriteTimeout 为 60 秒, 在此之前主动断开, 浏览器会带上 Last-Event-ID 自动重连
+	SSETimeout = time.Second * 55
+	SSERetry   = time.Second * 3 // 告诉浏览器断开后多久重连
+)
+
+func writeSSE(w io.Writer, event push.Event) error {
+	var err error
+
+	// 心跳不带 ID, 不影响浏览器记录的 Last-Event-ID
+	if event.Type == push.EventHeartbeat {
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+	} else {
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, event.Data)
+	}
+
+	return err
+}
+
+// 通过 Server-Sent Events 推送消息/通知/转账
+func SSERouter(c *gin.Context) {
+	if !requireExplicitToken(c) {
+		return
+	}
+
+	uid := c.GetString(middleware.ContextUidField)
+	lastEventId := getLastEventId(c)
+
+	subscriber, history, err := subscribe(uid, lastEventId)
+
+	if err != nil {
+		c.JSON(http.StatusOK, schema.Response{
+			Status:  schema.StatusFail,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	defer subscriber.Close()
+
+	header := c.Writer.Header()
+
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 的缓冲
+
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", SSERetry/time.Millisecond); err != nil {
+		return
+	}
+
+	for _, event := range history {
+		if err := writeSSE(c.Writer, event); err != nil {
+			return
+		}
+		lastEventId = event.Id
+	}
+
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(push.HeartbeatInterval)
+	timeout := time.NewTimer(SSETimeout)
+
+	defer ticker.Stop()
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-timeout.C:
+			return
+		case <-ticker.C:
+			// 服务器正在退出, 让客户端重连到其他实例
+			if config.Common.Exiting {
+				return
+			}
+
+			if err := writeSSE(c.Writer, heartbeat()); err != nil {
+				return
+			}
+
+			c.Writer.Flush()
+		case event, ok := <-subscriber.C:
+			if !ok {
+				return
+			}
+
+			// 已经补发过的事件
+			if event.Id <= lastEventId {
+				continue
+			}
+
+			if err := writeSSE(c.Writer, event); err != nil {
+				return
+			}
+
+			lastEventId = event.Id
+
+			c.Writer.Flush()
+		}
+	}
+}
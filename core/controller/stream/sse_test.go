@@ -0,0 +1,147 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/server/user_server"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createMessage(t *testing.T, adminId string, uid string) schema.Message {
+	r := message.Create(controller.Context{
+		Uid: adminId,
+	}, message.CreateMessageParams{
+		Uid:     uid,
+		Title:   "test",
+		Content: "test",
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	n := schema.Message{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n
+}
+
+// 找到某条消息对应的事件 ID
+func findEventId(t *testing.T, uid string, messageId string) int64 {
+	list, err := push.History(uid, 0)
+
+	assert.Nil(t, err)
+
+	for _, event := range list {
+		n := schema.Message{}
+
+		if event.Type == push.EventMessage && json.Unmarshal(event.Data, &n) == nil && n.Id == messageId {
+			return event.Id
+		}
+	}
+
+	t.Fatal("event not found")
+
+	return 0
+}
+
+// 读取 SSE 流, 直到收到某条消息
+func readSSE(t *testing.T, reader *bufio.Reader, messageId string) {
+	var eventType string
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			n := schema.Message{}
+
+			if eventType == string(push.EventMessage) && json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &n) == nil && n.Id == messageId {
+				return
+			}
+		}
+	}
+}
+
+func TestSSERouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	server := httptest.NewServer(user_server.UserRouter)
+
+	defer server.Close()
+
+	first := createMessage(t, adminInfo.Id, userInfo.Id)
+	defer message.DeleteMessageById(first.Id)
+
+	second := createMessage(t, adminInfo.Id, userInfo.Id)
+	defer message.DeleteMessageById(second.Id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/stream/sse", nil)
+
+	assert.Nil(t, err)
+
+	req = req.WithContext(ctx)
+	req.Header.Set(token.AuthField, token.Prefix+" "+userInfo.Token)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(findEventId(t, userInfo.Id, first.Id), 10))
+
+	res, err := http.DefaultClient.Do(req)
+
+	assert.Nil(t, err)
+
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+
+	// 断线期间的消息会被补发
+	readSSE(t, reader, second.Id)
+
+	// 新的消息会被实时推送
+	third := createMessage(t, adminInfo.Id, userInfo.Id)
+	defer message.DeleteMessageById(third.Id)
+
+	readSSE(t, reader, third.Id)
+}
+
+func TestSSERouterWithoutToken(t *testing.T) {
+	r := tester.HttpUser.Get("/v1/stream/sse", nil, nil)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, schema.StatusFail, res.Status)
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 推送的连接只接受通过参数或者请求头传递的 Token
+// 浏览器跨站发起的 WebSocket/EventSource 也会带上 Cookie, 只有 Cookie 的连接可能是其他网站冒充用户发起的
+func requireExplicitToken(c *gin.Context) bool {
+	if _, isExist := c.GetQuery(token.AuthField); isExist {
+		return true
+	}
+
+	if c.GetHeader(token.AuthField) != "" {
+		return true
+	}
+
+	c.JSON(http.StatusOK, schema.Response{
+		Status:  exception.InvalidAuth.Code(),
+		Message: exception.InvalidAuth.Error(),
+		Data:    nil,
+	})
+
+	return false
+}
+
+// 客户端最后收到的事件 ID
+// SSE 断线重连时浏览器会带上 Last-Event-ID 请求头, 也可以通过 last_event_id 参数指定
+func getLastEventId(c *gin.Context) int64 {
+	raw := c.GetHeader("Last-Event-ID")
+
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+
+	if err != nil || id < 0 {
+		return 0
+	}
+
+	return id
+}
+
+// 订阅用户的事件, 并取出需要补发的事件
+// 先订阅再读取记录, 保证两者之间发布的事件不会丢失, 重复的事件由 ID 去重
+func subscribe(uid string, lastEventId int64) (*push.Subscriber, []push.Event, error) {
+	subscriber := push.Subscribe(uid)
+
+	if lastEventId <= 0 {
+		return subscriber, []push.Event{}, nil
+	}
+
+	history, err := push.History(uid, lastEventId)
+
+	if err != nil {
+		subscriber.Close()
+		return nil, nil, err
+	}
+
+	return subscriber, history, nil
+}
+
+func heartbeat() push.Event {
+	return push.Event{
+		Type:      push.EventHeartbeat,
+		Data:      []byte("{}"),
+		CreatedAt: time.Now().Format(time.RFC3339Nano),
+	}
+}
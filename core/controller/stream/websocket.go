@@ -0,0 +1,117 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"time"
+)
+
+var WebSocketWriteTimeout = time.Second * 10 // 每次推送的写超时
+
+func writeWebSocket(ws *websocket.Conn, event push.Event) error {
+	if err := ws.SetWriteDeadline(time.Now().Add(WebSocketWriteTimeout)); err != nil {
+		return err
+	}
+
+	return websocket.JSON.Send(ws, event)
+}
+
+// 通过 WebSocket 推送消息/通知/转账
+// 浏览器无法设置 WebSocket 的请求头, 所以 Token 通过 Authorization 参数传递
+func WebSocketRouter(c *gin.Context) {
+	if !requireExplicitToken(c) {
+		return
+	}
+
+	uid := c.GetString(middleware.ContextUidField)
+	lastEventId := getLastEventId(c)
+
+	// 不校验 Origin, 只接受参数或者请求头中的 Token, 其他网站拿不到 Token, 无法冒充用户建立连接
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// 连接被劫持后, 仍然带着 http.Server 设置的读写超时, 需要清除
+			if err := ws.SetDeadline(time.Time{}); err != nil {
+				return
+			}
+
+			subscriber, history, err := subscribe(uid, lastEventId)
+
+			if err != nil {
+				_ = websocket.JSON.Send(ws, schema.Response{
+					Status:  schema.StatusFail,
+					Message: err.Error(),
+					Data:    nil,
+				})
+				return
+			}
+
+			defer subscriber.Close()
+
+			// 客户端发送的内容不做处理, 读取失败说明连接已断开
+			closed := make(chan struct{})
+
+			go func() {
+				defer close(closed)
+
+				var msg string
+
+				for {
+					if err := websocket.Message.Receive(ws, &msg); err != nil {
+						return
+					}
+				}
+			}()
+
+			for _, event := range history {
+				if err := writeWebSocket(ws, event); err != nil {
+					return
+				}
+				lastEventId = event.Id
+			}
+
+			ticker := time.NewTicker(push.HeartbeatInterval)
+
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-closed:
+					return
+				case <-ticker.C:
+					// 服务器正在退出, 让客户端重连到其他实例
+					if config.Common.Exiting {
+						return
+					}
+
+					if err := writeWebSocket(ws, heartbeat()); err != nil {
+						return
+					}
+				case event, ok := <-subscriber.C:
+					if !ok {
+						return
+					}
+
+					// 已经补发过的事件
+					if event.Id <= lastEventId {
+						continue
+					}
+
+					if err := writeWebSocket(ws, event); err != nil {
+						return
+					}
+
+					lastEventId = event.Id
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package stream_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/server/user_server"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 读取 WebSocket, 直到收到某条消息
+func readWebSocket(t *testing.T, ws *websocket.Conn, messageId string) {
+	for {
+		event := push.Event{}
+
+		if !assert.Nil(t, websocket.JSON.Receive(ws, &event)) {
+			return
+		}
+
+		n := schema.Message{}
+
+		if event.Type == push.EventMessage && json.Unmarshal(event.Data, &n) == nil && n.Id == messageId {
+			return
+		}
+	}
+}
+
+func TestWebSocketRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	server := httptest.NewServer(user_server.UserRouter)
+
+	defer server.Close()
+
+	first := createMessage(t, adminInfo.Id, userInfo.Id)
+	defer message.DeleteMessageById(first.Id)
+
+	second := createMessage(t, adminInfo.Id, userInfo.Id)
+	defer message.DeleteMessageById(second.Id)
+
+	query := url.Values{}
+
+	query.Set(token.AuthField, token.Prefix+" "+userInfo.Token)
+	query.Set("last_event_id", strconv.FormatInt(findEventId(t, userInfo.Id, first.Id), 10))
+
+	ws, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/v1/stream/ws?"+query.Encode(), "", server.URL)
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	defer ws.Close()
+
+	assert.Nil(t, ws.SetReadDeadline(time.Now().Add(time.Second*10)))
+
+	// 断线期间的消息会被补发
+	readWebSocket(t, ws, second.Id)
+
+	// 新的消息会被实时推送
+	third := createMessage(t, adminInfo.Id, userInfo.Id)
+	defer message.DeleteMessageById(third.Id)
+
+	readWebSocket(t, ws, third.Id)
+}
+
+func TestWebSocketRouterWithCookie(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	server := httptest.NewServer(user_server.UserRouter)
+
+	defer server.Close()
+
+	config, err := websocket.NewConfig(strings.Replace(server.URL, "http", "ws", 1)+"/v1/stream/ws", server.URL)
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// 只带 Cookie 的连接可能是其他网站发起的, 不允许建立
+	config.Header.Set("Cookie", token.AuthField+"="+url.QueryEscape(token.Prefix+" "+userInfo.Token))
+
+	ws, err := websocket.DialConfig(config)
+
+	if ws != nil {
+		_ = ws.Close()
+	}
+
+	assert.NotNil(t, err)
+}
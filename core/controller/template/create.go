@@ -0,0 +1,136 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type CreateParams struct {
+	Name       string `json:"name" valid:"required~请填写模版名称"`    // 模版名称, 只能是内置的模版名称
+	Channel    string `json:"channel" valid:"required~请选择渠道"`   // 渠道, email 或者 sms
+	Locale     string `json:"locale"`                           // 语言, 不填则为默认的语言
+	Subject    string `json:"subject"`                          // 邮件标题, 邮件必填
+	Content    string `json:"content" valid:"required~请填写模版内容"` // 模版内容
+	Text       string `json:"text"`                             // 邮件的纯文本内容
+	ExternalId string `json:"external_id"`                      // 短信服务商的模版 ID
+}
+
+// 创建模版, 覆盖对应的内置模版
+func Create(c controller.Context, input CreateParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if input.Locale == "" {
+		input.Locale = config.Common.Locale
+	}
+
+	tpl := model.MessageTemplate{
+		Name:       input.Name,
+		Channel:    input.Channel,
+		Locale:     input.Locale,
+		Subject:    input.Subject,
+		Content:    input.Content,
+		Text:       input.Text,
+		ExternalId: input.ExternalId,
+		Version:    1,
+		Author:     c.Uid,
+	}
+
+	if err = validate(tpl); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	if err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	var count int
+
+	if err = tx.Model(model.MessageTemplate{}).Where("name = ? AND channel = ? AND locale = ?", tpl.Name, tpl.Channel, tpl.Locale).Count(&count).Error; err != nil {
+		return
+	}
+
+	if count > 0 {
+		err = exception.TemplateExist
+		return
+	}
+
+	if err = tx.Create(&tpl).Error; err != nil {
+		return
+	}
+
+	if err = saveVersion(tx, tpl); err != nil {
+		return
+	}
+
+	data, err = templateToSchema(tpl)
+
+	return
+}
+
+func CreateRouter(c *gin.Context) {
+	var (
+		input CreateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Create(controller.NewContext(c), input)
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func createTemplate(t *testing.T, adminId string, locale string) schema.MessageTemplate {
+	r := template.Create(controller.Context{Uid: adminId}, template.CreateParams{
+		Name:    message_template.NameAuth,
+		Channel: message_template.ChannelEmail,
+		Locale:  locale,
+		Subject: "验证码",
+		Content: "<p>{{.code}}</p>",
+		Text:    "{{.code}}",
+	})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	tpl := schema.MessageTemplate{}
+
+	assert.Nil(t, tester.Decode(r.Data, &tpl))
+
+	return tpl
+}
+
+func TestCreate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	assert.Equal(t, message_template.NameAuth, tpl.Name)
+	assert.Equal(t, "ja-JP", tpl.Locale)
+	assert.Equal(t, 1, tpl.Version)
+	assert.Equal(t, adminInfo.Id, tpl.Author)
+
+	// 同一个名称, 渠道和语言只能有一个模版
+	{
+		r := template.Create(controller.Context{Uid: adminInfo.Id}, template.CreateParams{
+			Name:    message_template.NameAuth,
+			Channel: message_template.ChannelEmail,
+			Locale:  "ja-JP",
+			Subject: "验证码",
+			Content: "{{.code}}",
+		})
+
+		assert.Equal(t, exception.TemplateExist.Code(), r.Status)
+	}
+
+	// 使用了不存在的变量
+	{
+		r := template.Create(controller.Context{Uid: adminInfo.Id}, template.CreateParams{
+			Name:    message_template.NameAuth,
+			Channel: message_template.ChannelSMS,
+			Locale:  "ja-JP",
+			Content: "{{.not_exist}}",
+		})
+
+		assert.Equal(t, exception.InvalidTemplate.Code(), r.Status)
+	}
+
+	// 不存在的模版名称
+	{
+		r := template.Create(controller.Context{Uid: adminInfo.Id}, template.CreateParams{
+			Name:    "not_exist",
+			Channel: message_template.ChannelSMS,
+			Content: "test",
+		})
+
+		assert.Equal(t, exception.TemplateNotExist.Code(), r.Status)
+	}
+}
+
+func TestCreateRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	body, _ := json.Marshal(&template.CreateParams{
+		Name:    message_template.NameRegister,
+		Channel: message_template.ChannelSMS,
+		Locale:  "ja-JP",
+		Content: "コード {{.code}}",
+	})
+
+	r := tester.HttpAdmin.Post("/v1/template", body, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	tpl := schema.MessageTemplate{}
+
+	assert.Nil(t, tester.Decode(res.Data, &tpl))
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	assert.Equal(t, message_template.ChannelSMS, tpl.Channel)
+	assert.Equal(t, "コード {{.code}}", tpl.Content)
+}
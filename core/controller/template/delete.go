@@ -0,0 +1,88 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+// 删除模版和它的历史版本, 删除之后会使用内置的模版
+func Delete(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	if err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	var tpl model.MessageTemplate
+
+	if tpl, err = getTemplate(tx, id); err != nil {
+		return
+	}
+
+	if err = tx.Where("template_id = ?", tpl.Id).Delete(model.MessageTemplateVersion{}).Error; err != nil {
+		return
+	}
+
+	if err = tx.Delete(&tpl).Error; err != nil {
+		return
+	}
+
+	data, err = templateToSchema(tpl)
+
+	return
+}
+
+func DeleteRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Delete(controller.NewContext(c), c.Param(ParamsIdName))
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	r := template.Delete(controller.Context{Uid: adminInfo.Id}, tpl.Id)
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	// 删除之后就不存在了
+	r2 := template.Get(tpl.Id)
+
+	assert.Equal(t, exception.TemplateNotExist.Code(), r2.Status)
+}
+
+func TestDeleteRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Delete("/v1/template/t/"+tpl.Id, nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+}
@@ -0,0 +1,164 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type Query struct {
+	schema.Query
+	Name    *string `json:"name" form:"name"`
+	Channel *string `json:"channel" form:"channel"`
+	Locale  *string `json:"locale" form:"locale"`
+}
+
+// 获取模版详情
+func Get(id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	var tpl model.MessageTemplate
+
+	if tpl, err = getTemplate(database.Db, id); err != nil {
+		return
+	}
+
+	data, err = templateToSchema(tpl)
+
+	return
+}
+
+// 获取数据库中的模版列表, 不包含内置的模版
+func GetList(input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.MessageTemplate, 0)
+		list = make([]model.MessageTemplate, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	filter := map[string]interface{}{}
+
+	if input.Name != nil {
+		filter["name"] = *input.Name
+	}
+
+	if input.Channel != nil {
+		filter["channel"] = *input.Channel
+	}
+
+	if input.Locale != nil {
+		filter["locale"] = *input.Locale
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.MessageTemplate{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.MessageTemplate
+
+		if d, err = templateToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Get(c.Param(ParamsIdName))
+}
+
+func GetListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetList(input)
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	r := template.Get(tpl.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.MessageTemplate{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	assert.Equal(t, tpl.Id, n.Id)
+	assert.Equal(t, tpl.Content, n.Content)
+}
+
+func TestGetList(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl1 := createTemplate(t, adminInfo.Id, "ja-JP")
+	tpl2 := createTemplate(t, adminInfo.Id, "ko-KR")
+
+	defer template.DeleteTemplateById(tpl1.Id)
+	defer template.DeleteTemplateById(tpl2.Id)
+
+	locale := "ko-KR"
+
+	r := template.GetList(template.Query{Locale: &locale})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.MessageTemplate, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	assert.Len(t, list, 1)
+	assert.Equal(t, tpl2.Id, list[0].Id)
+}
+
+func TestGetListRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/template?locale=ja-JP", nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, int64(1), res.Meta.Total)
+}
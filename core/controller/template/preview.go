@@ -0,0 +1,106 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// 预览模版, 有三种方式:
+// 1. 传入 id, 预览数据库中的模版
+// 2. 传入 content, 预览还未保存的内容
+// 3. 只传入 name, channel 和 locale, 预览实际发送时使用的模版
+type PreviewParams struct {
+	Id        *string                `json:"id"`
+	Name      string                 `json:"name"`
+	Channel   string                 `json:"channel"`
+	Locale    string                 `json:"locale"`
+	Subject   string                 `json:"subject"`
+	Content   string                 `json:"content"`
+	Text      string                 `json:"text"`
+	Variables map[string]interface{} `json:"variables"` // 渲染的变量, 没有传入的变量使用示例值
+}
+
+func Preview(input PreviewParams) (res schema.Response) {
+	var (
+		err  error
+		data message_template.Message
+		tpl  model.MessageTemplate
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if input.Id != nil {
+		if tpl, err = getTemplate(database.Db, *input.Id); err != nil {
+			return
+		}
+	} else if input.Content != "" {
+		tpl = model.MessageTemplate{
+			Name:    input.Name,
+			Channel: input.Channel,
+			Locale:  input.Locale,
+			Subject: input.Subject,
+			Content: input.Content,
+			Text:    input.Text,
+		}
+	} else if tpl, err = message_template.Find(database.Db, input.Channel, input.Name, input.Locale); err != nil {
+		return
+	}
+
+	data, err = message_template.Preview(tpl, input.Variables)
+
+	return
+}
+
+// 获取内置的模版, 包含每个模版可用的变量
+func GetDefaults() (res schema.Response) {
+	helper.Response(&res, message_template.Defaults, nil)
+	return
+}
+
+func PreviewRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input PreviewParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Preview(input)
+}
+
+func GetDefaultsRouter(c *gin.Context) {
+	c.JSON(http.StatusOK, GetDefaults())
+}
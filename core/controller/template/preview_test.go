@@ -0,0 +1,99 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	// 预览还未保存的内容, 变量会被转义
+	{
+		r := template.Preview(template.PreviewParams{
+			Name:      message_template.NameNotification,
+			Channel:   message_template.ChannelEmail,
+			Subject:   "{{.title}}",
+			Content:   "<p>{{.content}}</p>",
+			Variables: map[string]interface{}{"content": "<script></script>"},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		msg := message_template.Message{}
+
+		assert.Nil(t, tester.Decode(r.Data, &msg))
+
+		assert.Equal(t, "标题", msg.Subject)
+		assert.Equal(t, "<p>&lt;script&gt;&lt;/script&gt;</p>", msg.Content)
+	}
+
+	// 预览实际发送时使用的模版, 没有对应语言的模版则使用默认语言
+	{
+		r := template.Preview(template.PreviewParams{
+			Name:    message_template.NameAuth,
+			Channel: message_template.ChannelSMS,
+			Locale:  "ja-JP",
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		msg := message_template.Message{}
+
+		assert.Nil(t, tester.Decode(r.Data, &msg))
+
+		assert.Equal(t, "您的验证码是 123456, 请勿泄露给他人", msg.Content)
+	}
+
+	// 模版不存在
+	{
+		id := "123123"
+
+		r := template.Preview(template.PreviewParams{Id: &id})
+
+		assert.Equal(t, exception.TemplateNotExist.Code(), r.Status)
+	}
+}
+
+func TestPreviewRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	body, _ := json.Marshal(&template.PreviewParams{
+		Id:        &tpl.Id,
+		Variables: map[string]interface{}{"code": "654321"},
+	})
+
+	r := tester.HttpAdmin.Post("/v1/template/preview", body, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	msg := message_template.Message{}
+
+	assert.Nil(t, tester.Decode(res.Data, &msg))
+
+	assert.Equal(t, "<p>654321</p>", msg.Content)
+	assert.Equal(t, "654321", msg.Text)
+}
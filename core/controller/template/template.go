@@ -0,0 +1,112 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"time"
+)
+
+const (
+	ParamsIdName      = "template_id"
+	ParamsVersionName = "version"
+)
+
+func DeleteTemplateById(id string) {
+	database.DeleteRowByTable("message_template_version", "template_id", id)
+	database.DeleteRowByTable("message_template", "id", id)
+}
+
+func templateToSchema(tpl model.MessageTemplate) (data schema.MessageTemplate, err error) {
+	if err = mapstructure.Decode(tpl, &data.MessageTemplatePure); err != nil {
+		return
+	}
+
+	data.CreatedAt = tpl.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = tpl.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+func versionToSchema(v model.MessageTemplateVersion) (data schema.MessageTemplateVersion, err error) {
+	if err = mapstructure.Decode(v, &data.MessageTemplateVersionPure); err != nil {
+		return
+	}
+
+	data.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 校验模版的渠道, 名称和内容, 内容会使用示例变量渲染一次
+func validate(tpl model.MessageTemplate) error {
+	switch tpl.Channel {
+	case message_template.ChannelEmail:
+		if tpl.Subject == "" {
+			return exception.InvalidTemplate
+		}
+	case message_template.ChannelSMS:
+	default:
+		return exception.InvalidParams
+	}
+
+	valid := false
+
+	for _, name := range message_template.Names {
+		if name == tpl.Name {
+			valid = true
+		}
+	}
+
+	if !valid {
+		return exception.TemplateNotExist
+	}
+
+	if _, err := message_template.Preview(tpl, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// 记录模版当前的版本
+func saveVersion(tx *gorm.DB, tpl model.MessageTemplate) error {
+	return tx.Create(&model.MessageTemplateVersion{
+		TemplateId: tpl.Id,
+		Version:    tpl.Version,
+		Subject:    tpl.Subject,
+		Content:    tpl.Content,
+		Text:       tpl.Text,
+		ExternalId: tpl.ExternalId,
+		Author:     tpl.Author,
+	}).Error
+}
+
+func getAdmin(tx *gorm.DB, uid string) (err error) {
+	adminInfo := model.Admin{Id: uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+	}
+
+	return
+}
+
+func getTemplate(tx *gorm.DB, id string) (tpl model.MessageTemplate, err error) {
+	tpl = model.MessageTemplate{Id: id}
+
+	if err = tx.First(&tpl).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TemplateNotExist
+		}
+	}
+
+	return
+}
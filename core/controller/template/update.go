@@ -0,0 +1,140 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type UpdateParams struct {
+	Subject    *string `json:"subject"`
+	Content    *string `json:"content"`
+	Text       *string `json:"text"`
+	ExternalId *string `json:"external_id"`
+}
+
+// 更新模版, 每次更新都会生成一个新的版本
+func Update(c controller.Context, id string, input UpdateParams) (res schema.Response) {
+	var (
+		err          error
+		data         schema.MessageTemplate
+		tx           *gorm.DB
+		shouldUpdate bool
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil || !shouldUpdate {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	if err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	var tpl model.MessageTemplate
+
+	if tpl, err = getTemplate(tx, id); err != nil {
+		return
+	}
+
+	if input.Subject != nil {
+		shouldUpdate = true
+		tpl.Subject = *input.Subject
+	}
+
+	if input.Content != nil {
+		shouldUpdate = true
+		tpl.Content = *input.Content
+	}
+
+	if input.Text != nil {
+		shouldUpdate = true
+		tpl.Text = *input.Text
+	}
+
+	if input.ExternalId != nil {
+		shouldUpdate = true
+		tpl.ExternalId = *input.ExternalId
+	}
+
+	if shouldUpdate {
+		if tpl, err = saveTemplate(tx, c.Uid, tpl); err != nil {
+			return
+		}
+	}
+
+	data, err = templateToSchema(tpl)
+
+	return
+}
+
+// 校验并保存模版的内容, 版本号加 1
+func saveTemplate(tx *gorm.DB, author string, tpl model.MessageTemplate) (model.MessageTemplate, error) {
+	if err := validate(tpl); err != nil {
+		return tpl, err
+	}
+
+	tpl.Version = tpl.Version + 1
+	tpl.Author = author
+
+	if err := tx.Save(&tpl).Error; err != nil {
+		return tpl, err
+	}
+
+	if err := saveVersion(tx, tpl); err != nil {
+		return tpl, err
+	}
+
+	return tpl, nil
+}
+
+func UpdateRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input UpdateParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Update(controller.NewContext(c), c.Param(ParamsIdName), input)
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	// 每次更新版本号加 1
+	{
+		subject := "新的标题"
+
+		r := template.Update(controller.Context{Uid: adminInfo.Id}, tpl.Id, template.UpdateParams{
+			Subject: &subject,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.MessageTemplate{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		assert.Equal(t, subject, n.Subject)
+		assert.Equal(t, tpl.Content, n.Content)
+		assert.Equal(t, 2, n.Version)
+	}
+
+	// 使用了不存在的变量
+	{
+		content := "{{.not_exist}}"
+
+		r := template.Update(controller.Context{Uid: adminInfo.Id}, tpl.Id, template.UpdateParams{
+			Content: &content,
+		})
+
+		assert.Equal(t, exception.InvalidTemplate.Code(), r.Status)
+	}
+
+	// 模版不存在
+	{
+		content := "{{.code}}"
+
+		r := template.Update(controller.Context{Uid: adminInfo.Id}, "123123", template.UpdateParams{
+			Content: &content,
+		})
+
+		assert.Equal(t, exception.TemplateNotExist.Code(), r.Status)
+	}
+}
+
+func TestUpdateRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	text := "コード {{.code}}"
+
+	body, _ := json.Marshal(&template.UpdateParams{
+		Text: &text,
+	})
+
+	r := tester.HttpAdmin.Put("/v1/template/t/"+tpl.Id, body, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	n := schema.MessageTemplate{}
+
+	assert.Nil(t, tester.Decode(res.Data, &n))
+
+	assert.Equal(t, text, n.Text)
+	assert.Equal(t, 2, n.Version)
+}
@@ -0,0 +1,196 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strconv"
+)
+
+// 获取模版的历史版本, 默认按照版本号倒序
+func GetVersionList(id string, input schema.Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.MessageTemplateVersion, 0)
+		list = make([]model.MessageTemplateVersion, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	if _, err = getTemplate(database.Db, id); err != nil {
+		return
+	}
+
+	query := input
+
+	query.Normalize()
+
+	if len(input.Sort) == 0 {
+		query.Sort = "-version"
+	}
+
+	filter := map[string]interface{}{"template_id": id}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.MessageTemplateVersion{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.MessageTemplateVersion
+
+		if d, err = versionToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 恢复到历史版本, 恢复的内容会作为一个新的版本保存
+func RestoreVersion(c controller.Context, id string, version int) (res schema.Response) {
+	var (
+		err  error
+		data schema.MessageTemplate
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	if err = getAdmin(tx, c.Uid); err != nil {
+		return
+	}
+
+	var tpl model.MessageTemplate
+
+	if tpl, err = getTemplate(tx, id); err != nil {
+		return
+	}
+
+	v := model.MessageTemplateVersion{}
+
+	if err = tx.Where("template_id = ? AND version = ?", id, version).First(&v).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TemplateNotExist
+		}
+		return
+	}
+
+	tpl.Subject = v.Subject
+	tpl.Content = v.Content
+	tpl.Text = v.Text
+	tpl.ExternalId = v.ExternalId
+
+	if tpl, err = saveTemplate(tx, c.Uid, tpl); err != nil {
+		return
+	}
+
+	data, err = templateToSchema(tpl)
+
+	return
+}
+
+func GetVersionListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input schema.Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetVersionList(c.Param(ParamsIdName), input)
+}
+
+func RestoreVersionRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	version, err := strconv.Atoi(c.Param(ParamsVersionName))
+
+	if err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = RestoreVersion(controller.NewContext(c), c.Param(ParamsIdName), version)
+}
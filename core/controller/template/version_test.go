@@ -0,0 +1,99 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package template_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	content := "<p>コード {{.code}}</p>"
+
+	r := template.Update(controller.Context{Uid: adminInfo.Id}, tpl.Id, template.UpdateParams{
+		Content: &content,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	// 历史版本按照版本号倒序
+	{
+		r := template.GetVersionList(tpl.Id, schema.Query{})
+
+		assert.Equal(t, "", r.Message)
+
+		list := make([]schema.MessageTemplateVersion, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+
+		assert.Len(t, list, 2)
+		assert.Equal(t, 2, list[0].Version)
+		assert.Equal(t, content, list[0].Content)
+		assert.Equal(t, 1, list[1].Version)
+		assert.Equal(t, tpl.Content, list[1].Content)
+	}
+
+	// 恢复到第一个版本, 会生成第三个版本
+	{
+		r := template.RestoreVersion(controller.Context{Uid: adminInfo.Id}, tpl.Id, 1)
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.MessageTemplate{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		assert.Equal(t, 3, n.Version)
+		assert.Equal(t, tpl.Content, n.Content)
+	}
+
+	// 版本不存在
+	{
+		r := template.RestoreVersion(controller.Context{Uid: adminInfo.Id}, tpl.Id, 100)
+
+		assert.Equal(t, exception.TemplateNotExist.Code(), r.Status)
+	}
+}
+
+func TestVersionRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	tpl := createTemplate(t, adminInfo.Id, "ja-JP")
+
+	defer template.DeleteTemplateById(tpl.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Put("/v1/template/t/"+tpl.Id+"/version/1", nil, &header)
+
+	if !assert.Equal(t, http.StatusOK, r.Code) {
+		return
+	}
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	n := schema.MessageTemplate{}
+
+	assert.Nil(t, tester.Decode(res.Data, &n))
+
+	assert.Equal(t, 2, n.Version)
+}
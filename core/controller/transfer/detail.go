@@ -105,3 +105,85 @@ func GetDetailRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	}, c.Param("transfer_id"))
 }
+
+// 管理员获取转账详情, 包含双方的钱包快照以及快照与流水的比对结果, 用于处理纠纷
+func GetDetailByAdmin(c controller.Context, transferId string) (res schema.Response) {
+	var (
+		err  error
+		data = schema.TransferLogDetail{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	log := model.TransferLog{}
+
+	sql := GenerateTransferLogSQL(QueryParams{
+		Id: &transferId,
+	}, 1, false)
+
+	if err = database.Db.Raw(sql).Scan(&log).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.TransferNotExist
+		}
+		return
+	}
+
+	if data.SnapshotFrom, data.CheckFrom, err = CheckSnapshot(database.Db, log, log.From, log.SnapshotFrom); err != nil {
+		return
+	}
+
+	if data.SnapshotTo, data.CheckTo, err = CheckSnapshot(database.Db, log, log.To, log.SnapshotTo); err != nil {
+		return
+	}
+
+	if err = mapstructure.Decode(log, &data.TransferLogPure); err != nil {
+		return
+	}
+
+	data.CreatedAt = log.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = log.UpdatedAt.Format(time.RFC3339Nano)
+	return
+}
+
+func GetDetailByAdminRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetDetailByAdmin(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("transfer_id"))
+}
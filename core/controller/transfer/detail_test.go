@@ -7,12 +7,16 @@ import (
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 )
 
@@ -76,3 +80,99 @@ func TestGetDetail(t *testing.T) {
 		assert.Equal(t, log.Status, detail.Status)
 	}
 }
+
+func TestGetDetailByAdmin(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	log := transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	// 获取详情
+	{
+		r := transfer.GetDetailByAdmin(controller.Context{
+			Uid: adminInfo.Id,
+		}, log.Id)
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		detail := schema.TransferLogDetail{}
+
+		assert.Nil(t, tester.Decode(r.Data, &detail))
+
+		assert.Equal(t, log.Id, detail.Id)
+		assert.NotNil(t, detail.SnapshotFrom)
+		assert.NotNil(t, detail.SnapshotTo)
+		assert.Equal(t, util.FloatToStr(80), detail.SnapshotFrom.After.Balance)
+		assert.Equal(t, util.FloatToStr(20), detail.SnapshotTo.After.Balance)
+		assert.True(t, detail.CheckFrom.Valid)
+		assert.True(t, detail.CheckTo.Valid)
+	}
+
+	// 普通用户无法调用
+	{
+		r := transfer.GetDetailByAdmin(controller.Context{
+			Uid: userFrom.Id,
+		}, log.Id)
+
+		assert.Equal(t, exception.AdminNotExist.Error(), r.Message)
+	}
+
+	// 转账记录不存在
+	{
+		r := transfer.GetDetailByAdmin(controller.Context{
+			Uid: adminInfo.Id,
+		}, "123123")
+
+		assert.Equal(t, exception.TransferNotExist.Error(), r.Message)
+	}
+}
+
+func TestGetDetailByAdminRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	log := transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/transfer/t/"+log.Id, nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	detail := schema.TransferLogDetail{}
+
+	assert.Nil(t, tester.Decode(res.Data, &detail))
+	assert.Equal(t, log.Id, detail.Id)
+	assert.True(t, detail.CheckFrom.Valid)
+	assert.True(t, detail.CheckTo.Valid)
+}
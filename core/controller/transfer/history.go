@@ -14,6 +14,8 @@ import (
 	"github.com/jinzhu/gorm"
 	"github.com/mitchellh/mapstructure"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +23,18 @@ type Query struct {
 	schema.Query
 }
 
+type QueryAdmin struct {
+	schema.Query
+	Currency  *string               `json:"currency" form:"currency"`     // 币种
+	From      *string               `json:"from" form:"from"`             // 汇款人
+	To        *string               `json:"to" form:"to"`                 // 收款人
+	Status    *model.TransferStatus `json:"status" form:"status"`         // 转账状态
+	MinAmount *string               `json:"min_amount" form:"min_amount"` // 最小转账数量
+	MaxAmount *string               `json:"max_amount" form:"max_amount"` // 最大转账数量
+	StartTime *string               `json:"start_time" form:"start_time"` // 开始时间, RFC3339 格式
+	EndTime   *string               `json:"end_time" form:"end_time"`     // 结束时间, RFC3339 格式
+}
+
 func GetHistory(c controller.Context, input Query) (res schema.List) {
 	var (
 		err  error
@@ -131,3 +145,155 @@ func GetHistoryRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	}, input)
 }
+
+// 管理员获取所有用户的转账记录
+func GetHistoryByAdmin(c controller.Context, input QueryAdmin) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.TransferLog, 0)
+		meta = &schema.Meta{}
+		list = make([]model.TransferLog, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	tables := model.TransferTableNames
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if input.Currency != nil && len(*input.Currency) > 0 {
+		if _, ok := model.TransferLogMap[strings.ToUpper(*input.Currency)]; !ok {
+			err = exception.InvalidWallet
+			return
+		}
+		tables = []string{GetTransferTableName(*input.Currency)}
+	}
+
+	if input.From != nil && len(*input.From) > 0 {
+		conditions = append(conditions, `"from" = ?`)
+		args = append(args, *input.From)
+	}
+
+	if input.To != nil && len(*input.To) > 0 {
+		conditions = append(conditions, `"to" = ?`)
+		args = append(args, *input.To)
+	}
+
+	if input.Status != nil {
+		conditions = append(conditions, `"status" = ?`)
+		args = append(args, *input.Status)
+	}
+
+	if input.MinAmount != nil && len(*input.MinAmount) > 0 {
+		var amount float64
+		if amount, err = strconv.ParseFloat(*input.MinAmount, 64); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+		conditions = append(conditions, `"amount" >= ?`)
+		args = append(args, amount)
+	}
+
+	if input.MaxAmount != nil && len(*input.MaxAmount) > 0 {
+		var amount float64
+		if amount, err = strconv.ParseFloat(*input.MaxAmount, 64); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+		conditions = append(conditions, `"amount" <= ?`)
+		args = append(args, amount)
+	}
+
+	if input.StartTime != nil && len(*input.StartTime) > 0 {
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339, *input.StartTime); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+		conditions = append(conditions, `"created_at" >= ?`)
+		args = append(args, t)
+	}
+
+	if input.EndTime != nil && len(*input.EndTime) > 0 {
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339, *input.EndTime); err != nil {
+			err = exception.InvalidParams
+			return
+		}
+		conditions = append(conditions, `"created_at" <= ?`)
+		args = append(args, t)
+	}
+
+	sql, values := helper.UnionSQL(tables, conditions, args)
+
+	var total int64
+
+	if err = database.Db.Raw("SELECT COUNT(*) FROM ("+sql+") AS t", values...).Count(&total).Error; err != nil {
+		return
+	}
+
+	listSQL := "SELECT * FROM (" + sql + ") AS t " + helper.OrderSQL(query, "amount", "status", "created_at", "updated_at") + " LIMIT ? OFFSET ?"
+
+	if err = database.Db.Raw(listSQL, append(values, query.Limit, query.Limit*query.Page)...).Scan(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		d := schema.TransferLog{}
+		if er := mapstructure.Decode(v, &d.TransferLogPure); er != nil {
+			err = er
+			return
+		}
+		d.CreatedAt = v.CreatedAt.Format(time.RFC3339Nano)
+		d.UpdatedAt = v.UpdatedAt.Format(time.RFC3339Nano)
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetHistoryByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input QueryAdmin
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetHistoryByAdmin(controller.NewContext(c), input)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
@@ -15,7 +16,9 @@ import (
 	"github.com/axetroy/go-server/tester"
 	"github.com/axetroy/mocker"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestGetHistory(t *testing.T) {
@@ -144,3 +147,133 @@ func TestGetHistoryRouter(t *testing.T) {
 		assert.IsType(t, "string", b.UpdatedAt)
 	}
 }
+
+func TestGetHistoryByAdmin(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+	transferTo(t, userFrom.Id, userTo.Id, "5")
+
+	// 按转账人筛选
+	{
+		r := transfer.GetHistoryByAdmin(controller.Context{}, transfer.QueryAdmin{
+			From: &userFrom.Id,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, int64(2), r.Meta.Total)
+
+		list := make([]schema.TransferLog, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 2)
+
+		for _, b := range list {
+			assert.Equal(t, userFrom.Id, b.From)
+			assert.Equal(t, userTo.Id, b.To)
+		}
+	}
+
+	// 按金额范围和币种筛选
+	{
+		currency := "cny"
+		minAmount := "10"
+
+		r := transfer.GetHistoryByAdmin(controller.Context{}, transfer.QueryAdmin{
+			Currency:  &currency,
+			To:        &userTo.Id,
+			MinAmount: &minAmount,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(1), r.Meta.Total)
+
+		list := make([]schema.TransferLog, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, util.FloatToStr(20), list[0].Amount)
+	}
+
+	// 按时间范围筛选
+	{
+		startTime := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+		r := transfer.GetHistoryByAdmin(controller.Context{}, transfer.QueryAdmin{
+			From:      &userFrom.Id,
+			StartTime: &startTime,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(0), r.Meta.Total)
+	}
+
+	// 无效的参数
+	{
+		maxAmount := "abc"
+
+		r := transfer.GetHistoryByAdmin(controller.Context{}, transfer.QueryAdmin{
+			MaxAmount: &maxAmount,
+		})
+
+		assert.Equal(t, exception.InvalidParams.Error(), r.Message)
+	}
+
+	{
+		currency := "abc"
+
+		r := transfer.GetHistoryByAdmin(controller.Context{}, transfer.QueryAdmin{
+			Currency: &currency,
+		})
+
+		assert.Equal(t, exception.InvalidWallet.Error(), r.Message)
+	}
+}
+
+func TestGetHistoryByAdminRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/transfer?status=1&from="+userFrom.Id, nil, &header)
+
+	res := schema.List{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	list := make([]schema.TransferLog, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &list))
+	assert.Len(t, list, 1)
+	assert.Equal(t, userFrom.Id, list[0].From)
+}
@@ -0,0 +1,481 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 收款人的类型, 即转账对象是哪一种标识
+type RecipientType string
+
+const (
+	RecipientTypeId         RecipientType = "id"          // 用户ID
+	RecipientTypeUsername   RecipientType = "username"    // 用户名
+	RecipientTypePhone      RecipientType = "phone"       // 手机号
+	RecipientTypeEmail      RecipientType = "email"       // 邮箱
+	RecipientTypeInviteCode RecipientType = "invite_code" // 邀请码
+)
+
+var RecipientTypes = []RecipientType{RecipientTypeId, RecipientTypeUsername, RecipientTypePhone, RecipientTypeEmail, RecipientTypeInviteCode}
+
+var (
+	PreviewLimit  = 20          // 每个用户在 PreviewWindow 内最多查询收款人的次数
+	PreviewWindow = time.Minute // 查询收款人的频率限制的时间窗口
+)
+
+type PreviewParams struct {
+	To string        `json:"to" form:"to" valid:"required~请输入转账对象"` // 用户ID/用户名/手机号/邮箱/邀请码
+	By RecipientType `json:"by" form:"by"`                          // 转账对象的类型, 不填则自动识别
+}
+
+type RecipientQuery struct {
+	schema.Query
+	Saved *bool `json:"saved" form:"saved"` // 只获取已保存/最近的收款人
+}
+
+type SaveRecipientParams struct {
+	To    string        `json:"to" valid:"required~请输入收款人"` // 用户ID/用户名/手机号/邮箱/邀请码
+	By    RecipientType `json:"by"`                         // 收款人的类型, 不填则自动识别
+	Alias *string       `json:"alias"`                      // 收款人的备注名
+}
+
+// 根据用户ID/用户名/手机号/邮箱/邀请码查找收款人
+// 指定了类型则只按照该类型查找, 否则在所有类型中查找, 匹配到多个不同的用户时返回错误, 防止转错人
+func ResolveRecipient(db *gorm.DB, to string, by RecipientType) (userInfo model.User, err error) {
+	to = strings.TrimSpace(to)
+
+	if len(to) == 0 {
+		err = exception.UserNotExist
+		return
+	}
+
+	var types []RecipientType
+
+	if by != "" {
+		valid := false
+
+		for _, t := range RecipientTypes {
+			if t == by {
+				valid = true
+			}
+		}
+
+		if !valid {
+			err = exception.InvalidRecipientType
+			return
+		}
+
+		types = []RecipientType{by}
+	} else {
+		types = []RecipientType{RecipientTypeId, RecipientTypeUsername}
+
+		if util.IsPhone(to) {
+			types = append(types, RecipientTypePhone)
+		}
+
+		if validator.IsEmail(to) {
+			types = append(types, RecipientTypeEmail)
+		}
+
+		types = append(types, RecipientTypeInviteCode)
+	}
+
+	conditions := make([]string, 0, len(types))
+	args := make([]interface{}, 0, len(types))
+
+	for _, t := range types {
+		conditions = append(conditions, string(t)+" = ?")
+		args = append(args, to)
+	}
+
+	list := make([]model.User, 0)
+
+	if err = db.Where(strings.Join(conditions, " OR "), args...).Limit(2).Find(&list).Error; err != nil {
+		return
+	}
+
+	switch len(list) {
+	case 0:
+		err = exception.UserNotExist
+	case 1:
+		userInfo = list[0]
+	default:
+		err = exception.RecipientAmbiguous
+	}
+
+	return
+}
+
+// 查询收款人的频率限制, 防止通过预览接口遍历用户
+func throttlePreview(uid string) error {
+	count, err := redis.IncrWindow(redis.ClientThrottle, "transfer:preview:"+uid, PreviewWindow)
+
+	if err != nil {
+		return err
+	}
+
+	if count > int64(PreviewLimit) {
+		return exception.RecipientPreviewTooOften
+	}
+
+	return nil
+}
+
+// 打码后的收款人信息, 昵称优先
+func recipientPreview(userInfo model.User) schema.TransferRecipientPreview {
+	name := userInfo.Username
+
+	if userInfo.Nickname != nil && len(*userInfo.Nickname) > 0 {
+		name = *userInfo.Nickname
+	}
+
+	return schema.TransferRecipientPreview{
+		Id:     userInfo.Id,
+		Name:   util.MaskName(name),
+		Avatar: userInfo.Avatar,
+	}
+}
+
+func recipientToSchema(recipient model.TransferRecipient) schema.TransferRecipient {
+	d := schema.TransferRecipient{
+		Id:        recipient.Id,
+		Recipient: recipientPreview(recipient.Recipient),
+		Alias:     recipient.Alias,
+		Saved:     recipient.Saved,
+		CreatedAt: recipient.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt: recipient.UpdatedAt.Format(time.RFC3339Nano),
+	}
+
+	if recipient.LastUsedAt != nil {
+		lastUsedAt := recipient.LastUsedAt.Format(time.RFC3339Nano)
+		d.LastUsedAt = &lastUsedAt
+	}
+
+	return d
+}
+
+// 转账成功后, 把收款人记录到最近的联系人
+// 使用 upsert, 并发转账给同一个人时不会因为唯一索引冲突导致转账失败
+func touchRecipient(tx *gorm.DB, uid string, recipientId string) error {
+	now := time.Now()
+
+	return tx.Exec(`INSERT INTO transfer_recipient (id, uid, recipient_id, saved, last_used_at, created_at, updated_at) VALUES (?, ?, ?, false, ?, ?, ?)
+ON CONFLICT (uid, recipient_id) DO UPDATE SET last_used_at = EXCLUDED.last_used_at`, util.GenerateId(), uid, recipientId, now, now, now).Error
+}
+
+// 转账前预览收款人, 用于确认收款人是否正确
+func Preview(c controller.Context, input PreviewParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferRecipientPreview
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	if err = throttlePreview(c.Uid); err != nil {
+		return
+	}
+
+	userInfo, err := ResolveRecipient(database.Db, input.To, input.By)
+
+	if err != nil {
+		return
+	}
+
+	if userInfo.Id == c.Uid {
+		err = exception.TransferToSelf
+		return
+	}
+
+	// 只返回打码后的昵称和头像, 不暴露用户ID
+	data = recipientPreview(userInfo)
+	data.Id = ""
+
+	return
+}
+
+// 获取我的收款人列表, 已保存的在前, 然后按最近使用的时间排序
+func GetRecipientList(c controller.Context, input RecipientQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.TransferRecipient, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	list := make([]model.TransferRecipient, 0)
+
+	db := database.Db.Model(&model.TransferRecipient{}).Where("uid = ?", c.Uid)
+
+	if input.Saved != nil {
+		db = db.Where("saved = ?", *input.Saved)
+	}
+
+	var total int64
+
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+
+	if err = db.Preload("Recipient").Order("saved DESC").Order("last_used_at DESC NULLS LAST").Order("created_at DESC").Limit(query.Limit).Offset(query.Limit * query.Page).Find(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, recipientToSchema(v))
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 保存收款人, 如果已经是最近的联系人则更新为已保存
+func SaveRecipient(c controller.Context, input SaveRecipientParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.TransferRecipient
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	userInfo, err := ResolveRecipient(tx, input.To, input.By)
+
+	if err != nil {
+		return
+	}
+
+	if userInfo.Id == c.Uid {
+		err = exception.TransferToSelf
+		return
+	}
+
+	recipient := model.TransferRecipient{}
+
+	if err = tx.Where("uid = ? AND recipient_id = ?", c.Uid, userInfo.Id).FirstOrInit(&recipient).Error; err != nil {
+		return
+	}
+
+	recipient.Uid = c.Uid
+	recipient.RecipientId = userInfo.Id
+	recipient.Saved = true
+
+	if input.Alias != nil {
+		recipient.Alias = input.Alias
+	}
+
+	if err = tx.Save(&recipient).Error; err != nil {
+		return
+	}
+
+	recipient.Recipient = userInfo
+
+	data = recipientToSchema(recipient)
+
+	return
+}
+
+// 删除收款人
+func DeleteRecipient(c controller.Context, recipientId string) (res schema.Response) {
+	var (
+		err error
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, nil, err)
+	}()
+
+	result := database.Db.Where("id = ? AND uid = ?", recipientId, c.Uid).Delete(&model.TransferRecipient{})
+
+	if err = result.Error; err != nil {
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		err = exception.RecipientNotExist
+		return
+	}
+
+	return
+}
+
+func PreviewRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input PreviewParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Preview(controller.NewContext(c), input)
+}
+
+func GetRecipientListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input RecipientQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetRecipientList(controller.NewContext(c), input)
+}
+
+func SaveRecipientRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input SaveRecipientParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = SaveRecipient(controller.NewContext(c), input)
+}
+
+func DeleteRecipientRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = DeleteRecipient(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, c.Param("recipient_id"))
+}
@@ -0,0 +1,305 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/transfer"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestResolveRecipient(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	email := "test-" + util.RandomString(6) + "@example.com"
+	phone := "1" + util.RandomNumeric(10)
+
+	assert.Nil(t, database.Db.Model(&model.User{}).Where("id = ?", userInfo.Id).Updates(map[string]interface{}{
+		"email": email,
+		"phone": phone,
+	}).Error)
+
+	for _, to := range []string{userInfo.Id, userInfo.Username, phone, email, userInfo.InviteCode, " " + userInfo.Username + " "} {
+		u, err := transfer.ResolveRecipient(database.Db, to, "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, userInfo.Id, u.Id)
+	}
+
+	_, err := transfer.ResolveRecipient(database.Db, "not-exist-user", "")
+
+	assert.Equal(t, exception.UserNotExist, err)
+
+	// 指定了类型只按照该类型查找
+	{
+		_, err := transfer.ResolveRecipient(database.Db, userInfo.Username, transfer.RecipientTypeInviteCode)
+
+		assert.Equal(t, exception.UserNotExist, err)
+
+		_, err = transfer.ResolveRecipient(database.Db, userInfo.Username, "nickname")
+
+		assert.Equal(t, exception.InvalidRecipientType, err)
+	}
+
+	// 用户名和别人的邀请码相同时不能自动识别
+	{
+		otherInfo, _ := tester.CreateUser()
+
+		defer auth.DeleteUserByUid(otherInfo.Id)
+
+		assert.Nil(t, database.Db.Model(&model.User{}).Where("id = ?", otherInfo.Id).UpdateColumn("username", userInfo.InviteCode).Error)
+
+		_, err := transfer.ResolveRecipient(database.Db, userInfo.InviteCode, "")
+
+		assert.Equal(t, exception.RecipientAmbiguous, err)
+
+		u, err := transfer.ResolveRecipient(database.Db, userInfo.InviteCode, transfer.RecipientTypeUsername)
+
+		assert.Nil(t, err)
+		assert.Equal(t, otherInfo.Id, u.Id)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 通过用户名预览
+	{
+		r := transfer.Preview(controller.Context{Uid: userFrom.Id}, transfer.PreviewParams{
+			To: userTo.Username,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+
+		preview := schema.TransferRecipientPreview{}
+
+		assert.Nil(t, tester.Decode(r.Data, &preview))
+		assert.Equal(t, "", preview.Id)
+		assert.Equal(t, util.MaskName(userTo.Username), preview.Name)
+		assert.Equal(t, userTo.Avatar, preview.Avatar)
+	}
+
+	// 不能转账给自己
+	{
+		r := transfer.Preview(controller.Context{Uid: userFrom.Id}, transfer.PreviewParams{
+			To: userFrom.InviteCode,
+		})
+
+		assert.Equal(t, exception.TransferToSelf.Error(), r.Message)
+	}
+
+	// 用户不存在
+	{
+		r := transfer.Preview(controller.Context{Uid: userFrom.Id}, transfer.PreviewParams{
+			To: "not-exist-user",
+		})
+
+		assert.Equal(t, exception.UserNotExist.Error(), r.Message)
+	}
+
+	// 查询过于频繁
+	{
+		limit := transfer.PreviewLimit
+
+		transfer.PreviewLimit = 3
+
+		defer func() {
+			transfer.PreviewLimit = limit
+		}()
+
+		r := transfer.Preview(controller.Context{Uid: userFrom.Id}, transfer.PreviewParams{
+			To: userTo.Username,
+		})
+
+		assert.Equal(t, exception.RecipientPreviewTooOften.Error(), r.Message)
+	}
+}
+
+func TestRecipient(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+	userSaved, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+	defer auth.DeleteUserByUserName(userSaved.Username)
+	defer database.Db.Where("uid = ?", userFrom.Id).Delete(&model.TransferRecipient{})
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	// 通过用户名转账, 收款人会记录到最近的联系人
+	log := transferTo(t, userFrom.Id, userTo.Username, "20")
+
+	assert.Equal(t, userTo.Id, log.To)
+
+	// 保存收款人
+	alias := "朋友"
+
+	r := transfer.SaveRecipient(controller.Context{Uid: userFrom.Id}, transfer.SaveRecipientParams{
+		To:    userSaved.InviteCode,
+		Alias: &alias,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	saved := schema.TransferRecipient{}
+
+	assert.Nil(t, tester.Decode(r.Data, &saved))
+	assert.True(t, saved.Saved)
+	assert.Equal(t, userSaved.Id, saved.Recipient.Id)
+	assert.Equal(t, alias, *saved.Alias)
+	assert.Nil(t, saved.LastUsedAt)
+
+	// 已保存的在前, 然后是最近的联系人
+	{
+		r := transfer.GetRecipientList(controller.Context{Uid: userFrom.Id}, transfer.RecipientQuery{})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(2), r.Meta.Total)
+
+		list := make([]schema.TransferRecipient, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 2)
+		assert.Equal(t, userSaved.Id, list[0].Recipient.Id)
+		assert.Equal(t, userTo.Id, list[1].Recipient.Id)
+		assert.False(t, list[1].Saved)
+		assert.NotNil(t, list[1].LastUsedAt)
+	}
+
+	// 只获取最近的联系人
+	{
+		unsaved := false
+
+		r := transfer.GetRecipientList(controller.Context{Uid: userFrom.Id}, transfer.RecipientQuery{
+			Saved: &unsaved,
+		})
+
+		list := make([]schema.TransferRecipient, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, userTo.Id, list[0].Recipient.Id)
+	}
+
+	// 删除收款人
+	{
+		r := transfer.DeleteRecipient(controller.Context{Uid: userFrom.Id}, saved.Id)
+
+		assert.Equal(t, "", r.Message)
+
+		r2 := transfer.DeleteRecipient(controller.Context{Uid: userFrom.Id}, saved.Id)
+
+		assert.Equal(t, exception.RecipientNotExist.Error(), r2.Message)
+	}
+
+	// 不能转账给自己
+	{
+		input := transfer.ToParams{
+			Currency: model.WalletCNY,
+			To:       userFrom.Username,
+			Amount:   "1",
+		}
+
+		b, err := json.Marshal(input)
+
+		assert.Nil(t, err)
+
+		signature, err := util.Signature(string(b))
+
+		assert.Nil(t, err)
+
+		r := transfer.To(controller.Context{Uid: userFrom.Id}, input, signature)
+
+		assert.Equal(t, exception.TransferToSelf.Error(), r.Message)
+	}
+}
+
+func TestRecipientRouter(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+	defer database.Db.Where("uid = ?", userFrom.Id).Delete(&model.TransferRecipient{})
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userFrom.Token,
+	}
+
+	// 预览
+	{
+		r := tester.HttpUser.Get("/v1/transfer/preview?to="+userTo.Username, nil, &header)
+
+		res := schema.Response{}
+
+		assert.Equal(t, http.StatusOK, r.Code)
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+
+		preview := schema.TransferRecipientPreview{}
+
+		assert.Nil(t, tester.Decode(res.Data, &preview))
+		assert.Equal(t, "", preview.Id)
+	}
+
+	// 保存
+	{
+		body, _ := json.Marshal(&transfer.SaveRecipientParams{
+			To: userTo.Username,
+		})
+
+		r := tester.HttpUser.Post("/v1/transfer/recipient", body, &header)
+
+		res := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+	}
+
+	// 列表
+	{
+		r := tester.HttpUser.Get("/v1/transfer/recipient", nil, &header)
+
+		res := schema.List{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+
+		list := make([]schema.TransferRecipient, 0)
+
+		assert.Nil(t, tester.Decode(res.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, userTo.Id, list[0].Recipient.Id)
+
+		r2 := tester.HttpUser.Delete("/v1/transfer/recipient/r/"+list[0].Id, nil, &header)
+
+		res2 := schema.Response{}
+
+		assert.Nil(t, json.Unmarshal(r2.Body.Bytes(), &res2))
+		assert.Equal(t, "", res2.Message)
+	}
+}
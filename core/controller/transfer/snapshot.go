@@ -0,0 +1,135 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"strings"
+)
+
+var (
+	SnapshotMissing          = "snapshot_missing"           // 没有记录钱包快照
+	SnapshotSignatureInvalid = "snapshot_signature_invalid" // 快照的签名不正确, 说明快照被修改过
+	SnapshotFinanceMissing   = "finance_log_missing"        // 找不到这笔转账对应的流水
+	SnapshotFinanceTampered  = "finance_log_tampered"       // 流水的内容与哈希不一致
+	SnapshotFinanceMismatch  = "finance_log_mismatch"       // 快照与流水记录的余额不一致
+)
+
+// 生成钱包在转账前后的快照, 并进行签名
+func GenerateSnapshot(transferId string, currency string, before model.Wallet, after model.Wallet) (string, error) {
+	snapshot := schema.TransferSnapshot{
+		TransferId: transferId,
+		Uid:        before.Id,
+		Currency:   strings.ToUpper(currency),
+		Before: schema.TransferSnapshotWallet{
+			Balance: util.FloatToStr(before.Balance),
+			Frozen:  util.FloatToStr(before.Frozen),
+		},
+		After: schema.TransferSnapshotWallet{
+			Balance: util.FloatToStr(after.Balance),
+			Frozen:  util.FloatToStr(after.Frozen),
+		},
+	}
+
+	signature, err := signSnapshot(snapshot)
+
+	if err != nil {
+		return "", err
+	}
+
+	snapshot.Signature = signature
+
+	b, err := json.Marshal(snapshot)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// 对快照签名, 签名字段本身不参与计算
+func signSnapshot(snapshot schema.TransferSnapshot) (string, error) {
+	snapshot.Signature = ""
+
+	b, err := json.Marshal(snapshot)
+
+	if err != nil {
+		return "", err
+	}
+
+	return util.Signature(string(b))
+}
+
+// 校验某一方的钱包快照, 并与其对应的财务流水进行比对
+func CheckSnapshot(db *gorm.DB, log model.TransferLog, uid string, raw *string) (snapshot *schema.TransferSnapshot, result schema.TransferSnapshotCheck, err error) {
+	result = schema.TransferSnapshotCheck{
+		Uid: uid,
+	}
+
+	reject := func(reason string) {
+		result.Valid = false
+		result.Reason = &reason
+	}
+
+	if raw == nil || len(*raw) == 0 {
+		reject(SnapshotMissing)
+		return
+	}
+
+	snapshot = &schema.TransferSnapshot{}
+
+	if err = json.Unmarshal([]byte(*raw), snapshot); err != nil {
+		return
+	}
+
+	var signature string
+
+	if signature, err = signSnapshot(*snapshot); err != nil {
+		return
+	}
+
+	if signature != snapshot.Signature || snapshot.TransferId != log.Id || snapshot.Uid != uid {
+		reject(SnapshotSignatureInvalid)
+		return
+	}
+
+	financeLog := model.FinanceLog{}
+
+	if err = db.Table(finance.GetTableName(log.Currency)).Where("order_id = ? AND uid = ?", log.Id, uid).First(&financeLog).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+			reject(SnapshotFinanceMissing)
+		}
+		return
+	}
+
+	result.FinanceLogId = &financeLog.Id
+
+	var hash string
+
+	if hash, err = finance.GenerateHash(financeLog); err != nil {
+		return
+	}
+
+	if hash != financeLog.Hash {
+		reject(SnapshotFinanceTampered)
+		return
+	}
+
+	if snapshot.Before.Balance != util.FloatToStr(financeLog.BeforeBalance) ||
+		snapshot.Before.Frozen != util.FloatToStr(financeLog.BeforeFrozen) ||
+		snapshot.After.Balance != util.FloatToStr(financeLog.AfterBalance) ||
+		snapshot.After.Frozen != util.FloatToStr(financeLog.AfterFrozen) {
+		reject(SnapshotFinanceMismatch)
+		return
+	}
+
+	result.Valid = true
+
+	return
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package transfer_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/controller/transfer"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func transferTo(t *testing.T, from string, to string, amount string) (log schema.TransferLog) {
+	input := transfer.ToParams{
+		Currency: model.WalletCNY,
+		To:       to,
+		Amount:   amount,
+	}
+
+	b, err := json.Marshal(input)
+
+	assert.Nil(t, err)
+
+	signature, err := util.Signature(string(b))
+
+	assert.Nil(t, err)
+
+	r := transfer.To(controller.Context{Uid: from}, input, signature)
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+	assert.Nil(t, tester.Decode(r.Data, &log))
+
+	return
+}
+
+func TestCheckSnapshot(t *testing.T) {
+	userFrom, _ := tester.CreateUser()
+	userTo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userFrom.Username)
+	defer auth.DeleteUserByUserName(userTo.Username)
+
+	// 给账户充钱
+	assert.Nil(t, database.Db.Table(wallet.GetTableName(model.WalletCNY)).Where("id = ?", userFrom.Id).Update(model.Wallet{
+		Balance:  100,
+		Currency: model.WalletCNY,
+	}).Error)
+
+	transferInfo := transferTo(t, userFrom.Id, userTo.Id, "20")
+
+	tableName := transfer.GetTransferTableName(model.WalletCNY)
+
+	log := model.TransferLog{}
+
+	assert.Nil(t, database.Db.Table(tableName).Where("id = ?", transferInfo.Id).First(&log).Error)
+	assert.NotNil(t, log.SnapshotFrom)
+	assert.NotNil(t, log.SnapshotTo)
+
+	// 快照与流水一致
+	{
+		snapshot, result, err := transfer.CheckSnapshot(database.Db, log, log.From, log.SnapshotFrom)
+
+		assert.Nil(t, err)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Reason)
+		assert.NotNil(t, result.FinanceLogId)
+		assert.Equal(t, userFrom.Id, snapshot.Uid)
+		assert.Equal(t, util.FloatToStr(100), snapshot.Before.Balance)
+		assert.Equal(t, util.FloatToStr(80), snapshot.After.Balance)
+	}
+
+	{
+		snapshot, result, err := transfer.CheckSnapshot(database.Db, log, log.To, log.SnapshotTo)
+
+		assert.Nil(t, err)
+		assert.True(t, result.Valid)
+		assert.Equal(t, util.FloatToStr(0), snapshot.Before.Balance)
+		assert.Equal(t, util.FloatToStr(20), snapshot.After.Balance)
+	}
+
+	// 没有快照
+	{
+		_, result, err := transfer.CheckSnapshot(database.Db, log, log.From, nil)
+
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, transfer.SnapshotMissing, *result.Reason)
+	}
+
+	// 快照被篡改
+	{
+		snapshot := schema.TransferSnapshot{}
+
+		assert.Nil(t, json.Unmarshal([]byte(*log.SnapshotFrom), &snapshot))
+
+		snapshot.Before.Balance = util.FloatToStr(1000)
+
+		b, err := json.Marshal(snapshot)
+
+		assert.Nil(t, err)
+
+		raw := string(b)
+
+		_, result, err := transfer.CheckSnapshot(database.Db, log, log.From, &raw)
+
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, transfer.SnapshotSignatureInvalid, *result.Reason)
+	}
+
+	// 把收款人的快照当作转账者的快照
+	{
+		_, result, err := transfer.CheckSnapshot(database.Db, log, log.From, log.SnapshotTo)
+
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, transfer.SnapshotSignatureInvalid, *result.Reason)
+	}
+
+	// 流水被篡改
+	{
+		assert.Nil(t, database.Db.Table(finance.GetTableName(model.WalletCNY)).Where("order_id = ? AND uid = ?", log.Id, log.To).UpdateColumn("after_balance", 1000).Error)
+
+		_, result, err := transfer.CheckSnapshot(database.Db, log, log.To, log.SnapshotTo)
+
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, transfer.SnapshotFinanceTampered, *result.Reason)
+	}
+
+	// 流水不存在
+	{
+		assert.Nil(t, database.Db.Exec("DELETE FROM "+finance.GetTableName(model.WalletCNY)+" WHERE order_id = ? AND uid = ?", log.Id, log.To).Error)
+
+		_, result, err := transfer.CheckSnapshot(database.Db, log, log.To, log.SnapshotTo)
+
+		assert.Nil(t, err)
+		assert.False(t, result.Valid)
+		assert.Nil(t, result.FinanceLogId)
+		assert.Equal(t, transfer.SnapshotFinanceMissing, *result.Reason)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/axetroy/go-server/core/controller"
 	"github.com/axetroy/go-server/core/controller/finance"
 	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/logger"
@@ -26,17 +27,20 @@ import (
 )
 
 type ToParams struct {
-	Currency string  `json:"currency" valid:"required~请选择币种"`                   // 币种
-	To       string  `json:"to" valid:"required~请输入转账对象,numeric~请输入正确的接受人ID"`   // 转账给谁
-	Amount   string  `json:"amount" valid:"required~请输入转账数量,float~请输入纯数字的转账数量"` // 转账数量
-	Note     *string `json:"note"`                                              // 转账备注
+	Currency string        `json:"currency" valid:"required~请选择币种"`                   // 币种
+	To       string        `json:"to" valid:"required~请输入转账对象"`                       // 转账给谁, 可以是用户ID/用户名/手机号/邮箱/邀请码
+	By       RecipientType `json:"by,omitempty"`                                      // 转账对象的类型, 不填则自动识别, 匹配到多个用户时需要指定. 不填时不出现在签名的内容中, 兼容旧的客户端
+	Amount   string        `json:"amount" valid:"required~请输入转账数量,float~请输入纯数字的转账数量"` // 转账数量
+	Note     *string       `json:"note"`                                              // 转账备注
 }
 
 func To(c controller.Context, input ToParams, signature string) (res schema.Response) {
 	var (
-		err  error
-		tx   *gorm.DB
-		data = schema.TransferLog{}
+		err          error
+		tx           *gorm.DB
+		data         = schema.TransferLog{}
+		fromUserInfo model.User
+		events       event.Batch
 	)
 
 	defer func() {
@@ -60,6 +64,10 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -89,8 +97,7 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 
 	tx = database.Db.Begin()
 
-	fromUserInfo := model.User{Id: c.Uid}
-	toUserInfo := model.User{Id: input.To}
+	fromUserInfo = model.User{Id: c.Uid}
 
 	if err = tx.Where(&fromUserInfo).Last(&fromUserInfo).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -99,23 +106,27 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 		return
 	}
 
-	if err = tx.Where(&toUserInfo).Last(&toUserInfo).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			err = exception.UserNotExist
-		}
+	// 根据用户ID/用户名/手机号/邮箱/邀请码找到收款人
+	toUserInfo, err := ResolveRecipient(tx, input.To, input.By)
+
+	if err != nil {
+		return
+	}
+
+	if toUserInfo.Id == fromUserInfo.Id {
+		err = exception.TransferToSelf
 		return
 	}
 
-	walletTableName := wallet.GetTableName(input.Currency)      // 对应的钱包表名
-	transferTableName := GetTransferTableName(input.Currency)   // 对应的转账记录表名
-	financeLogTableName := finance.GetTableName(input.Currency) // 对应的财务日志表名
+	walletTableName := wallet.GetTableName(input.Currency)    // 对应的钱包表名
+	transferTableName := GetTransferTableName(input.Currency) // 对应的转账记录表名
 
 	fromUserWallet := model.Wallet{
 		Id: c.Uid,
 	}
 
 	toUserWallet := model.Wallet{
-		Id: input.To,
+		Id: toUserInfo.Id,
 	}
 
 	if err = tx.Table(walletTableName).Where("id = ?", fromUserWallet.Id).FirstOrInit(&fromUserWallet).Error; err != nil {
@@ -137,6 +148,10 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 		return
 	}
 
+	// 变动前的钱包
+	fromUserWalletBefore := fromUserWallet
+	toUserWalletBefore := toUserWallet
+
 	// 变动前的余额/冻结
 	fromUserBeforeBalance := fromUserWallet.Balance
 	fromUserBeforeFrozen := fromUserWallet.Frozen
@@ -178,7 +193,7 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 	transferLog := model.TransferLog{
 		Currency: strings.ToUpper(input.Currency),
 		From:     c.Uid,
-		To:       input.To,
+		To:       toUserInfo.Id,
 		Status:   model.TransferStatusConfirmed,
 		Amount:   util.FloatToStr(amount), // 保留 8 未小数
 		Note:     input.Note,
@@ -188,6 +203,28 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 		return
 	}
 
+	// 记录双方转账前后的钱包快照, 用于处理纠纷
+	// 快照中包含转账 ID, 所以在转账记录写入之后生成
+	var snapshotFrom, snapshotTo string
+
+	if snapshotFrom, err = GenerateSnapshot(transferLog.Id, transferLog.Currency, fromUserWalletBefore, fromUserWallet); err != nil {
+		return
+	}
+
+	if snapshotTo, err = GenerateSnapshot(transferLog.Id, transferLog.Currency, toUserWalletBefore, toUserWallet); err != nil {
+		return
+	}
+
+	transferLog.SnapshotFrom = &snapshotFrom
+	transferLog.SnapshotTo = &snapshotTo
+
+	if err = tx.Table(transferTableName).Where("id = ?", transferLog.Id).UpdateColumns(map[string]interface{}{
+		"snapshot_from": snapshotFrom,
+		"snapshot_to":   snapshotTo,
+	}).Error; err != nil {
+		return
+	}
+
 	if err = mapstructure.Decode(transferLog, &data.TransferLogPure); err != nil {
 		return
 	}
@@ -195,15 +232,9 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 	data.CreatedAt = transferLog.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = transferLog.UpdatedAt.Format(time.RFC3339Nano)
 
-	// 如果财务日志表不存在的话, 那么就生成这个表
-	if tx.HasTable(financeLogTableName) == false {
-		if err = tx.CreateTable(model.FinanceLogMap[input.Currency]).Error; err != nil {
-			return
-		}
-	}
-
 	// 生成我的财务日志
 	fromUserFinanceLog := model.FinanceLog{
+		Currency:        input.Currency,
 		OrderId:         transferLog.Id, // 可用余额的变动
 		Uid:             c.Uid,
 		BeforeBalance:   fromUserBeforeBalance,
@@ -217,8 +248,9 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 
 	// 生成对方的财务日志
 	toUserFinanceLog := model.FinanceLog{
+		Currency:        input.Currency,
 		OrderId:         transferLog.Id,
-		Uid:             input.To,
+		Uid:             toUserInfo.Id,
 		BeforeBalance:   toUserBeforeBalance, // 可用余额的变动
 		BalanceMutation: amount,
 		AfterBalance:    toUserAfterBalance,
@@ -228,11 +260,21 @@ func To(c controller.Context, input ToParams, signature string) (res schema.Resp
 		Type:            model.FinanceTypeTransferIn,
 	}
 
-	if err = tx.Table(financeLogTableName).Create(&fromUserFinanceLog).Error; err != nil {
+	// 写入流水, 同时接到各自的哈希链上
+	if err = finance.CreateLog(tx, &fromUserFinanceLog); err != nil {
+		return
+	}
+
+	if err = finance.CreateLog(tx, &toUserFinanceLog); err != nil {
+		return
+	}
+
+	// 记录到最近的联系人
+	if err = touchRecipient(tx, c.Uid, toUserInfo.Id); err != nil {
 		return
 	}
 
-	if err = tx.Table(financeLogTableName).Create(&toUserFinanceLog).Error; err != nil {
+	if err = events.Publish(tx, event.TransferCompleted{Log: data, FromUsername: fromUserInfo.Username}); err != nil {
 		return
 	}
 
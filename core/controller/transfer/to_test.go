@@ -188,4 +188,29 @@ func TestToRouter(t *testing.T) {
 		assert.Equal(t, "20.00000000", toUserWallet.Balance)
 		assert.Equal(t, "0.00000000", toUserWallet.Frozen)
 	}
+
+	// 旧的客户端只签名 currency, to, amount 和 note 四个字段
+	{
+		header := mocker.Header{
+			"Authorization":  token.Prefix + " " + userFrom.Token,
+			"X-Pay-Password": "123123",
+		}
+
+		body := `{"currency":"CNY","to":"` + userTo.Id + `","amount":"10","note":null}`
+
+		signature, err := util.Signature(body)
+
+		assert.Nil(t, err)
+
+		header[middleware.SignatureHeader] = signature
+
+		r := tester.HttpUser.Post("/v1/transfer", []byte(body), &header)
+
+		res := schema.Response{}
+
+		assert.Equal(t, http.StatusOK, r.Code)
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, schema.StatusSuccess, res.Status)
+	}
 }
@@ -0,0 +1,98 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package unread
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+// 未读数缓存的有效期
+// 消息和通知有变动时会清除缓存, 通知过期不会触发变动, 所以缓存不宜太久
+var CacheExpiration = time.Minute * 5
+
+// 获取用户的未读数, 优先从缓存中读取
+func Get(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data schema.Unread
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 缓存读取失败则直接查询数据库
+	if raw, er := redis.ClientUnread.Get(c.Uid).Result(); er == nil {
+		if er := json.Unmarshal([]byte(raw), &data); er == nil {
+			return
+		}
+	}
+
+	userInfo := model.User{
+		Id: c.Uid,
+	}
+
+	if err = database.Db.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	if data.Message, err = message.CountUnread(database.Db, userInfo.Id); err != nil {
+		return
+	}
+
+	if data.Notification, err = notification.CountUnread(database.Db, userInfo); err != nil {
+		return
+	}
+
+	data.Total = data.Message + data.Notification
+
+	if raw, er := json.Marshal(data); er == nil {
+		_ = redis.ClientUnread.Set(userInfo.Id, raw, CacheExpiration).Err()
+	}
+
+	return
+}
+
+func GetRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Get(controller.NewContext(c))
+}
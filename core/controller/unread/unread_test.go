@@ -0,0 +1,115 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package unread_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/controller/unread"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func getUnread(t *testing.T, uid string) schema.Unread {
+	r := unread.Get(controller.Context{Uid: uid})
+
+	assert.Equal(t, "", r.Message)
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	data := schema.Unread{}
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+
+	return data
+}
+
+func TestGet(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	before := getUnread(t, userInfo.Id)
+
+	assert.Equal(t, int64(0), before.Message)
+	assert.Equal(t, before.Message+before.Notification, before.Total)
+
+	// 新的消息会清除缓存
+	r := message.Create(controller.Context{Uid: adminInfo.Id}, message.CreateMessageParams{
+		Uid:     userInfo.Id,
+		Title:   "TestUnread",
+		Content: "TestUnread",
+	})
+
+	messageInfo := schema.Message{}
+
+	assert.Nil(t, tester.Decode(r.Data, &messageInfo))
+
+	defer message.DeleteMessageById(messageInfo.Id)
+
+	after := getUnread(t, userInfo.Id)
+
+	assert.Equal(t, int64(1), after.Message)
+
+	// 新的通知会清除所有用户的缓存
+	r = notification.Create(controller.Context{Uid: adminInfo.Id}, notification.CreateParams{
+		Title:   "TestUnread",
+		Content: "TestUnread",
+		TargetParams: notification.TargetParams{
+			TargetUsers: []string{userInfo.Id},
+		},
+	})
+
+	notificationInfo := schema.Notification{}
+
+	assert.Nil(t, tester.Decode(r.Data, &notificationInfo))
+
+	defer notification.DeleteNotificationById(notificationInfo.Id)
+	defer notification.DeleteNotificationMarkById(notificationInfo.Id)
+
+	after = getUnread(t, userInfo.Id)
+
+	assert.Equal(t, before.Notification+1, after.Notification)
+	assert.Equal(t, after.Message+after.Notification, after.Total)
+
+	// 全部标记为已读
+	message.MarkReadAll(controller.Context{Uid: userInfo.Id})
+	notification.MarkReadAll(controller.Context{Uid: userInfo.Id})
+
+	after = getUnread(t, userInfo.Id)
+
+	assert.Equal(t, int64(0), after.Message)
+	assert.True(t, after.Notification < before.Notification+1)
+	assert.Equal(t, after.Message+after.Notification, after.Total)
+}
+
+func TestGetRouter(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	r := tester.HttpUser.Get("/v1/unread", nil, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, schema.StatusSuccess, res.Status)
+
+	data := schema.Unread{}
+
+	assert.Nil(t, tester.Decode(res.Data, &data))
+	assert.Equal(t, int64(0), data.Message)
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/captcha"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/dispatcher"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/telephone"
 	"github.com/gin-gonic/gin"
@@ -70,10 +70,8 @@ func SendAuthEmail(c controller.Context) (res schema.Response) {
 		return
 	}
 
-	e := email.NewMailer()
-
 	// send email
-	if err = e.SendAuthEmail(*userInfo.Email, activationCode); err != nil {
+	if err = dispatcher.SendEmailCode(*userInfo.Email, dispatcher.CodeAuth, activationCode); err != nil {
 		// 邮件没发出去的话，删除redis的key
 		_ = redis.ClientAuthEmailCode.Del(activationCode).Err()
 		return
@@ -126,6 +124,11 @@ func SendAuthPhone(c controller.Context) (res schema.Response) {
 		return
 	}
 
+	// 检查发送频率
+	if err = telephone.Throttle(*userInfo.Phone, c.Ip); err != nil {
+		return
+	}
+
 	// 生成验证码
 	activationCode := captcha.GeneratePhoneCaptcha()
 
@@ -134,9 +137,10 @@ func SendAuthPhone(c controller.Context) (res schema.Response) {
 		return
 	}
 
-	if err = telephone.GetClient().SendAuthCode(*userInfo.Phone, activationCode); err != nil {
+	if err = dispatcher.SendSMSCode(*userInfo.Phone, dispatcher.CodeAuth, activationCode); err != nil {
 		// 如果发送失败，则删除
 		_ = redis.ClientAuthPhoneCode.Del(activationCode).Err()
+		telephone.ResetThrottle(*userInfo.Phone)
 		return
 	}
 
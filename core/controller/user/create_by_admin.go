@@ -1,15 +1,14 @@
 package user
 
 import (
-	"encoding/json"
 	"errors"
-	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
-	"github.com/axetroy/go-server/core/message_queue"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/gin-gonic/gin"
@@ -31,9 +30,10 @@ type CreateUserParams struct {
 
 func CreateUser(input CreateUserParams) (res schema.Response) {
 	var (
-		err  error
-		data schema.Profile
-		tx   *gorm.DB
+		err    error
+		data   schema.Profile
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -56,6 +56,10 @@ func CreateUser(input CreateUserParams) (res schema.Response) {
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, data, err)
 	}()
 
@@ -160,16 +164,8 @@ func CreateUser(input CreateUserParams) (res schema.Response) {
 	data.CreatedAt = userInfo.CreatedAt.Format(time.RFC3339Nano)
 	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
 
-	// 创建用户对应的钱包账号
-	for _, walletName := range model.Wallets {
-		if err = tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
-			Id:       userInfo.Id,
-			Currency: walletName,
-			Balance:  0,
-			Frozen:   0,
-		}).Error; err != nil {
-			return
-		}
+	if err = events.Publish(tx, event.UserRegistered{User: userInfo}); err != nil {
+		return
 	}
 
 	// 如果是以邮箱注册的，那么发送激活链接
@@ -183,16 +179,7 @@ func CreateUser(input CreateUserParams) (res schema.Response) {
 		}
 
 		// 把 "发送激活码" 加入消息队列
-		var body []byte
-
-		if body, err = json.Marshal(message_queue.SendActivationEmailBody{
-			Email: *input.Email,
-			Code:  activationCode,
-		}); err != nil {
-			return
-		}
-
-		if err = message_queue.Publish(message_queue.TopicSendEmail, body); err != nil {
+		if err = dispatcher.SendEmailCode(*input.Email, dispatcher.CodeActivation, activationCode); err != nil {
 			return
 		}
 
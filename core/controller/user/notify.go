@@ -0,0 +1,17 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user
+
+import (
+	"github.com/axetroy/go-server/core/service/dispatcher"
+)
+
+// 通知用户账号安全的变动, 不受免打扰时段的限制, 通知失败不影响操作
+func notifySecurity(uid string, title string) {
+	_, _ = dispatcher.Dispatch(dispatcher.Notification{
+		Uid:      uid,
+		Category: dispatcher.CategorySecurity,
+		Title:    title,
+		Content:  title + ", 如果不是您本人的操作, 请立即修改密码并联系客服",
+		Urgent:   true,
+	})
+}
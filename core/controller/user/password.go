@@ -4,6 +4,7 @@ package user
 import (
 	"errors"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/helper"
 	"github.com/axetroy/go-server/core/model"
@@ -27,8 +28,9 @@ type UpdatePasswordByAdminParams struct {
 
 func UpdatePassword(c controller.Context, input UpdatePasswordParams) (res schema.Response) {
 	var (
-		err error
-		tx  *gorm.DB
+		err    error
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -51,6 +53,10 @@ func UpdatePassword(c controller.Context, input UpdatePasswordParams) (res schem
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -87,13 +93,16 @@ func UpdatePassword(c controller.Context, input UpdatePasswordParams) (res schem
 		return
 	}
 
+	err = events.Publish(tx, event.PasswordChanged{Uid: c.Uid, Source: event.PasswordChangedByUser})
+
 	return
 }
 
 func UpdatePasswordByAdmin(c controller.Context, userId string, input UpdatePasswordByAdminParams) (res schema.Response) {
 	var (
-		err error
-		tx  *gorm.DB
+		err    error
+		tx     *gorm.DB
+		events event.Batch
 	)
 
 	defer func() {
@@ -116,6 +125,10 @@ func UpdatePasswordByAdmin(c controller.Context, userId string, input UpdatePass
 			}
 		}
 
+		if err == nil {
+			events.Commit()
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -157,6 +170,8 @@ func UpdatePasswordByAdmin(c controller.Context, userId string, input UpdatePass
 		return
 	}
 
+	err = events.Publish(tx, event.PasswordChanged{Uid: userId, Source: event.PasswordChangedByAdmin})
+
 	return
 }
 
@@ -9,7 +9,7 @@ import (
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/dispatcher"
 	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/axetroy/go-server/core/service/telephone"
 	"github.com/axetroy/go-server/core/util"
@@ -66,6 +66,10 @@ func SetPayPassword(c controller.Context, input SetPayPasswordParams) (res schem
 			}
 		}
 
+		if err == nil {
+			notifySecurity(c.Uid, "交易密码已设置")
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -154,6 +158,10 @@ func UpdatePayPassword(c controller.Context, input UpdatePayPasswordParams) (res
 			}
 		}
 
+		if err == nil {
+			notifySecurity(c.Uid, "交易密码已修改")
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
@@ -275,18 +283,24 @@ func SendResetPayPassword(c controller.Context) (res schema.Response) {
 
 	if userInfo.Email != nil {
 		// 发送邮件
-		go func() {
-			e := email.NewMailer()
-			_ = e.SendForgotTradePasswordEmail(*userInfo.Email, resetCode)
-		}()
+		if err = dispatcher.SendEmailCode(*userInfo.Email, dispatcher.CodeResetTradePassword, resetCode); err != nil {
+			// 如果加入队列失败，则删除
+			_ = redis.ClientResetCode.Del(resetCode).Err()
+			return
+		}
 	} else if userInfo.Phone != nil {
-		go func() {
-			if err = telephone.GetClient().SendResetPasswordCode(*userInfo.Phone, resetCode); err != nil {
-				// 如果发送失败，则删除
-				_ = redis.ClientAuthPhoneCode.Del(resetCode).Err()
-				return
-			}
-		}()
+		// 检查发送频率
+		if err = telephone.Throttle(*userInfo.Phone, c.Ip); err != nil {
+			_ = redis.ClientResetCode.Del(resetCode).Err()
+			return
+		}
+
+		if err = dispatcher.SendSMSCode(*userInfo.Phone, dispatcher.CodeResetTradePassword, resetCode); err != nil {
+			// 如果加入队列失败，则删除
+			_ = redis.ClientResetCode.Del(resetCode).Err()
+			telephone.ResetThrottle(*userInfo.Phone)
+			return
+		}
 	} else {
 		// 无效的用户
 		err = exception.NoData
@@ -339,6 +353,10 @@ func ResetPayPassword(c controller.Context, input ResetPayPasswordParams) (res s
 			}
 		}
 
+		if err == nil {
+			notifySecurity(c.Uid, "交易密码已重置")
+		}
+
 		helper.Response(&res, nil, err)
 	}()
 
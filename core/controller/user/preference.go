@@ -0,0 +1,276 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+// 不传则不修改, 渠道传空数组表示不接收该类别的通知
+// 免打扰时段传空字符串则关闭
+type UpdatePreferenceParams struct {
+	Transfer     *[]string `json:"transfer"`     // 接收转账通知的渠道, 可选 in_app/email/sms
+	Security     *[]string `json:"security"`     // 接收账号安全通知的渠道
+	Announcement *[]string `json:"announcement"` // 接收公告的渠道
+	QuietStart   *string   `json:"quiet_start"`  // 免打扰的开始时间, 格式为 HH:MM
+	QuietEnd     *string   `json:"quiet_end"`    // 免打扰的结束时间, 格式为 HH:MM, 早于开始时间则表示到第二天
+}
+
+func preferenceToSchema(preference model.NotificationPreference) schema.NotificationPreference {
+	return schema.NotificationPreference{
+		Transfer:     preference.Transfer,
+		Security:     preference.Security,
+		Announcement: preference.Announcement,
+		QuietStart:   preference.QuietStart,
+		QuietEnd:     preference.QuietEnd,
+	}
+}
+
+// 校验渠道并去重
+func parseChannels(list []string) (result pq.StringArray, err error) {
+	result = pq.StringArray{}
+
+	exist := map[string]bool{}
+
+	for _, v := range list {
+		valid := false
+
+		for _, channel := range dispatcher.Channels {
+			if string(channel) == v {
+				valid = true
+			}
+		}
+
+		if !valid {
+			err = exception.InvalidNotifyChannel
+			return
+		}
+
+		if exist[v] {
+			continue
+		}
+
+		exist[v] = true
+		result = append(result, v)
+	}
+
+	return
+}
+
+func parseQuietTime(s string) (*string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(dispatcher.QuietTimeFormat, s)
+
+	if err != nil {
+		return nil, exception.InvalidQuietHours
+	}
+
+	s = t.Format(dispatcher.QuietTimeFormat)
+
+	return &s, nil
+}
+
+// 获取用户接收通知的偏好
+func GetPreference(c controller.Context) (res schema.Response) {
+	var (
+		err  error
+		data schema.NotificationPreference
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	var preference model.NotificationPreference
+
+	if preference, err = dispatcher.GetPreference(database.Db, c.Uid); err != nil {
+		return
+	}
+
+	data = preferenceToSchema(preference)
+
+	return
+}
+
+// 更新用户接收通知的偏好
+func UpdatePreference(c controller.Context, input UpdatePreferenceParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.NotificationPreference
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	userInfo := model.User{Id: c.Uid}
+
+	if err = tx.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	preference := model.NotificationPreference{}
+
+	exist := true
+
+	if err = tx.Where("uid = ?", c.Uid).First(&preference).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return
+		}
+
+		err = nil
+		exist = false
+		preference = dispatcher.NewPreference(c.Uid)
+	}
+
+	if input.Transfer != nil {
+		if preference.Transfer, err = parseChannels(*input.Transfer); err != nil {
+			return
+		}
+	}
+
+	if input.Security != nil {
+		if preference.Security, err = parseChannels(*input.Security); err != nil {
+			return
+		}
+	}
+
+	if input.Announcement != nil {
+		if preference.Announcement, err = parseChannels(*input.Announcement); err != nil {
+			return
+		}
+	}
+
+	if input.QuietStart != nil {
+		if preference.QuietStart, err = parseQuietTime(*input.QuietStart); err != nil {
+			return
+		}
+	}
+
+	if input.QuietEnd != nil {
+		if preference.QuietEnd, err = parseQuietTime(*input.QuietEnd); err != nil {
+			return
+		}
+	}
+
+	// 开始和结束时间必须同时设置
+	if (preference.QuietStart == nil) != (preference.QuietEnd == nil) {
+		err = exception.InvalidQuietHours
+		return
+	}
+
+	if exist {
+		err = tx.Model(&preference).Where("uid = ?", c.Uid).Updates(map[string]interface{}{
+			"transfer":     preference.Transfer,
+			"security":     preference.Security,
+			"announcement": preference.Announcement,
+			"quiet_start":  preference.QuietStart,
+			"quiet_end":    preference.QuietEnd,
+		}).Error
+	} else {
+		err = tx.Create(&preference).Error
+	}
+
+	if err != nil {
+		return
+	}
+
+	data = preferenceToSchema(preference)
+
+	return
+}
+
+func GetPreferenceRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetPreference(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	})
+}
+
+func UpdatePreferenceRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.Response{}
+		input UpdatePreferenceParams
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = UpdatePreference(controller.Context{
+		Uid: c.GetString(middleware.ContextUidField),
+	}, input)
+}
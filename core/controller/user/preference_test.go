@@ -0,0 +1,135 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package user_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/core/service/token"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestGetPreference(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	r := user.GetPreference(controller.Context{Uid: userInfo.Id})
+
+	assert.Equal(t, schema.StatusSuccess, r.Status)
+
+	data := schema.NotificationPreference{}
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+
+	// 没有设置过则是默认的偏好
+	assert.Equal(t, []string{string(dispatcher.ChannelInApp), string(dispatcher.ChannelEmail)}, data.Transfer)
+	assert.Nil(t, data.QuietStart)
+}
+
+func TestUpdatePreference(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	context := controller.Context{Uid: userInfo.Id}
+
+	var (
+		start = "22:00"
+		end   = "8:00"
+	)
+
+	r := user.UpdatePreference(context, user.UpdatePreferenceParams{
+		Security:   &[]string{"sms", "sms"},
+		QuietStart: &start,
+		QuietEnd:   &end,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	data := schema.NotificationPreference{}
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+	assert.Equal(t, []string{"sms"}, data.Security)
+	assert.Equal(t, []string{string(dispatcher.ChannelInApp)}, data.Announcement)
+	assert.Equal(t, "22:00", *data.QuietStart)
+	assert.Equal(t, "08:00", *data.QuietEnd)
+
+	// 再次更新
+	empty := ""
+
+	r = user.UpdatePreference(context, user.UpdatePreferenceParams{
+		Announcement: &[]string{"email"},
+		QuietStart:   &empty,
+		QuietEnd:     &empty,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	r = user.GetPreference(context)
+
+	assert.Nil(t, tester.Decode(r.Data, &data))
+	assert.Equal(t, []string{"sms"}, data.Security)
+	assert.Equal(t, []string{"email"}, data.Announcement)
+	assert.Nil(t, data.QuietStart)
+	assert.Nil(t, data.QuietEnd)
+
+	// 无效的参数
+	invalid := "25:00"
+
+	cases := []struct {
+		input user.UpdatePreferenceParams
+		err   error
+	}{
+		{user.UpdatePreferenceParams{Transfer: &[]string{"wechat"}}, exception.InvalidNotifyChannel},
+		{user.UpdatePreferenceParams{QuietStart: &invalid, QuietEnd: &start}, exception.InvalidQuietHours},
+		{user.UpdatePreferenceParams{QuietStart: &start}, exception.InvalidQuietHours},
+	}
+
+	for _, c := range cases {
+		r := user.UpdatePreference(context, c.input)
+
+		assert.Equal(t, c.err.Error(), r.Message)
+	}
+}
+
+func TestUpdatePreferenceRouter(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + userInfo.Token,
+	}
+
+	body, _ := json.Marshal(&user.UpdatePreferenceParams{
+		Transfer: &[]string{"in_app"},
+	})
+
+	r := tester.HttpUser.Put("/v1/user/preference", body, &header)
+
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	r = tester.HttpUser.Get("/v1/user/preference", nil, &header)
+
+	res = schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+
+	data := schema.NotificationPreference{}
+
+	assert.Nil(t, tester.Decode(res.Data, &data))
+	assert.Equal(t, []string{"in_app"}, data.Transfer)
+}
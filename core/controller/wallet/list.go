@@ -13,8 +13,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"net/http"
+	"strings"
 )
 
+type QueryAdmin struct {
+	schema.Query
+	UserId   *string `json:"user_id" form:"user_id"`   // 按用户 ID 筛选
+	Username *string `json:"username" form:"username"` // 按用户名筛选
+	Currency *string `json:"currency" form:"currency"` // 按币种筛选
+}
+
 func GetWallets(c controller.Context) (res schema.Response) {
 	var (
 		err  error
@@ -93,3 +101,105 @@ func GetWalletsRouter(c *gin.Context) {
 		Uid: c.GetString(middleware.ContextUidField),
 	})
 }
+
+// 管理员获取钱包列表, 可以按用户/币种搜索
+func GetListByAdmin(c controller.Context, input QueryAdmin) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.Wallet, 0)
+		meta = &schema.Meta{}
+		list = make([]model.Wallet, 0)
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	tables := model.WalletTableNames
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if input.Currency != nil && len(*input.Currency) > 0 {
+		if IsValidWallet(*input.Currency) == false {
+			err = exception.InvalidWallet
+			return
+		}
+		tables = []string{GetTableName(*input.Currency)}
+	}
+
+	if input.UserId != nil && len(*input.UserId) > 0 {
+		conditions = append(conditions, `"id" = ?`)
+		args = append(args, *input.UserId)
+	}
+
+	if input.Username != nil && len(*input.Username) > 0 {
+		conditions = append(conditions, `"id" IN (SELECT "id" FROM "user" WHERE "username" = ?)`)
+		args = append(args, strings.TrimSpace(*input.Username))
+	}
+
+	sql, values := helper.UnionSQL(tables, conditions, args)
+
+	var total int64
+
+	if err = database.Db.Raw("SELECT COUNT(*) FROM ("+sql+") AS t", values...).Count(&total).Error; err != nil {
+		return
+	}
+
+	listSQL := "SELECT * FROM (" + sql + ") AS t " + helper.OrderSQL(query, "balance", "frozen", "created_at", "updated_at") + " LIMIT ? OFFSET ?"
+
+	if err = database.Db.Raw(listSQL, append(values, query.Limit, query.Limit*query.Page)...).Scan(&list).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		wallet := schema.Wallet{}
+		mapToSchema(v, &wallet)
+		data = append(data, wallet)
+	}
+
+	meta.Total = total
+	meta.Num = len(list)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetListByAdminRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input QueryAdmin
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetListByAdmin(controller.NewContext(c), input)
+}
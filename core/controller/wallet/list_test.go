@@ -4,8 +4,10 @@ package wallet_test
 import (
 	"encoding/json"
 	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/admin"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/exception"
 	"github.com/axetroy/go-server/core/model"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/token"
@@ -74,3 +76,113 @@ func TestGetWalletsRouter(t *testing.T) {
 		assert.IsType(t, "string", b.UpdatedAt)
 	}
 }
+
+func TestGetListByAdmin(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	// 按用户 ID 筛选
+	{
+		r := wallet.GetListByAdmin(controller.Context{}, wallet.QueryAdmin{
+			UserId: &userInfo.Id,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, int64(len(model.Wallets)), r.Meta.Total)
+
+		list := make([]schema.Wallet, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, len(model.Wallets))
+
+		for _, b := range list {
+			assert.Equal(t, userInfo.Id, b.Id)
+		}
+	}
+
+	// 按用户名和币种筛选
+	{
+		currency := "usd"
+
+		r := wallet.GetListByAdmin(controller.Context{}, wallet.QueryAdmin{
+			Username: &userInfo.Username,
+			Currency: &currency,
+		})
+
+		assert.Equal(t, "", r.Message)
+		assert.Equal(t, int64(1), r.Meta.Total)
+
+		list := make([]schema.Wallet, 0)
+
+		assert.Nil(t, tester.Decode(r.Data, &list))
+		assert.Len(t, list, 1)
+		assert.Equal(t, userInfo.Id, list[0].Id)
+		assert.Equal(t, model.WalletUSD, list[0].Currency)
+	}
+
+	// 无效的币种
+	{
+		currency := "abc"
+
+		r := wallet.GetListByAdmin(controller.Context{}, wallet.QueryAdmin{
+			Currency: &currency,
+		})
+
+		assert.Equal(t, exception.InvalidWallet.Error(), r.Message)
+	}
+}
+
+func TestGetListByAdminRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	{
+		header := mocker.Header{
+			"Authorization": token.Prefix + " " + adminInfo.Token,
+		}
+
+		r := tester.HttpAdmin.Get("/v1/wallet?user_id="+userInfo.Id, nil, &header)
+
+		res := schema.List{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, schema.StatusSuccess, res.Status)
+
+		list := make([]schema.Wallet, 0)
+
+		assert.Nil(t, tester.Decode(res.Data, &list))
+		assert.Len(t, list, len(model.Wallets))
+	}
+
+	// 没有权限的管理员
+	{
+		adminRes := admin.CreateAdmin(admin.CreateAdminParams{
+			Account:  "test-wallet",
+			Password: "test-wallet",
+			Name:     "test-wallet",
+		}, false)
+
+		defer admin.DeleteAdminByAccount("test-wallet")
+
+		newAdmin := schema.AdminProfileWithToken{}
+
+		assert.Nil(t, tester.Decode(adminRes.Data, &newAdmin))
+
+		header := mocker.Header{
+			"Authorization": token.Prefix + " " + newAdmin.Token,
+		}
+
+		r := tester.HttpAdmin.Get("/v1/wallet?user_id="+userInfo.Id, nil, &header)
+
+		res := schema.List{}
+
+		assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+		assert.Equal(t, exception.NoPermission.Error(), res.Message)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/webhook"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type CreateParams struct {
+	Url         string   `json:"url" valid:"required~请输入 URL,requrl~请输入正确的 URL"`   // 接收事件的 URL
+	Events      []string `json:"events" valid:"required~请选择订阅的事件"`                 // 订阅的事件
+	Secret      *string  `json:"secret" valid:"stringlength(8|64)~密钥长度为 8 到 64 位"` // 签名使用的密钥, 不填则随机生成
+	Description *string  `json:"description"`                                      // 描述
+	Enabled     *bool    `json:"enabled"`                                          // 是否启用, 默认启用
+}
+
+// 校验订阅的事件, 并去掉重复的事件
+func normalizeEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, exception.InvalidWebhookEvent
+	}
+
+	result := make([]string, 0, len(events))
+	exist := map[string]bool{}
+
+	for _, event := range events {
+		if !webhook.IsValidEvent(event) {
+			return nil, exception.InvalidWebhookEvent
+		}
+
+		if exist[event] {
+			continue
+		}
+
+		exist[event] = true
+		result = append(result, event)
+	}
+
+	return result, nil
+}
+
+// 随机生成签名使用的密钥
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func Create(c controller.Context, input CreateParams) (res schema.Response) {
+	var (
+		err  error
+		data schema.Webhook
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	events, err := normalizeEvents(input.Events)
+
+	if err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		// 没有找到管理员
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		err = exception.AdminNotSuper
+		return
+	}
+
+	webhookInfo := model.Webhook{
+		Url:     input.Url,
+		Events:  events,
+		Enabled: true,
+	}
+
+	if input.Secret != nil {
+		webhookInfo.Secret = *input.Secret
+	}
+
+	if input.Description != nil {
+		webhookInfo.Description = *input.Description
+	}
+
+	if input.Enabled != nil {
+		webhookInfo.Enabled = *input.Enabled
+	}
+
+	if err = tx.Create(&webhookInfo).Error; err != nil {
+		return
+	}
+
+	data = webhookToSchema(webhookInfo)
+
+	return
+}
+
+func CreateRouter(c *gin.Context) {
+	var (
+		input CreateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Create(controller.NewContext(c), input)
+}
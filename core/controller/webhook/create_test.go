@@ -0,0 +1,108 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/webhook"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	webhookService "github.com/axetroy/go-server/core/service/webhook"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	// 创建一个 webhook, 不填密钥则随机生成
+	{
+		r := webhook.Create(controller.Context{
+			Uid: adminInfo.Id,
+		}, webhook.CreateParams{
+			Url:    "https://example.com/webhook",
+			Events: []string{webhookService.EventUserSignUp, webhookService.EventUserSignUp, webhookService.EventTransferSent},
+		})
+
+		assert.Equal(t, schema.StatusSuccess, r.Status)
+		assert.Equal(t, "", r.Message)
+
+		n := schema.Webhook{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		defer webhook.DeleteWebhookById(n.Id)
+
+		assert.Equal(t, "https://example.com/webhook", n.Url)
+		assert.Equal(t, []string{webhookService.EventUserSignUp, webhookService.EventTransferSent}, n.Events)
+		assert.Len(t, n.Secret, 64)
+		assert.True(t, n.Enabled)
+	}
+
+	// 不存在的事件
+	{
+		r := webhook.Create(controller.Context{
+			Uid: adminInfo.Id,
+		}, webhook.CreateParams{
+			Url:    "https://example.com/webhook",
+			Events: []string{"user.unknown"},
+		})
+
+		assert.Equal(t, schema.StatusFail, r.Status)
+		assert.Equal(t, exception.InvalidWebhookEvent.Error(), r.Message)
+	}
+
+	// 非管理员的uid去创建，应该报错
+	{
+		userInfo, _ := tester.CreateUser()
+
+		defer auth.DeleteUserByUserName(userInfo.Username)
+
+		r := webhook.Create(controller.Context{
+			Uid: userInfo.Id,
+		}, webhook.CreateParams{
+			Url:    "https://example.com/webhook",
+			Events: []string{webhookService.EventUserSignUp},
+		})
+
+		assert.Equal(t, schema.StatusFail, r.Status)
+		assert.Equal(t, exception.AdminNotExist.Error(), r.Message)
+	}
+}
+
+func TestCreateRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	secret := "my-webhook-secret"
+
+	body, _ := json.Marshal(&webhook.CreateParams{
+		Url:    "https://example.com/webhook",
+		Events: []string{webhookService.EventReportStatusChanged},
+		Secret: &secret,
+	})
+
+	r := tester.HttpAdmin.Post("/v1/webhook", body, &header)
+	res := schema.Response{}
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	n := schema.Webhook{}
+
+	assert.Nil(t, tester.Decode(res.Data, &n))
+
+	defer webhook.DeleteWebhookById(n.Id)
+
+	assert.Equal(t, secret, n.Secret)
+	assert.Equal(t, []string{webhookService.EventReportStatusChanged}, n.Events)
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+func DeleteWebhookById(id string) {
+	w := model.Webhook{}
+	database.DeleteRowByTable(w.TableName(), "id", id)
+}
+
+// 删除 webhook, 同时删除它的发送记录
+func Delete(c controller.Context, webhookId string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Webhook
+		tx   *gorm.DB
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		err = exception.AdminNotSuper
+		return
+	}
+
+	webhookInfo := model.Webhook{
+		Id: webhookId,
+	}
+
+	if err = tx.First(&webhookInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookNotExist
+		}
+		return
+	}
+
+	if err = tx.Where("webhook_id = ?", webhookInfo.Id).Delete(model.WebhookDelivery{}).Error; err != nil {
+		return
+	}
+
+	if err = tx.Delete(model.Webhook{
+		Id: webhookInfo.Id,
+	}).Error; err != nil {
+		return
+	}
+
+	data = webhookToSchema(webhookInfo)
+
+	return
+}
+
+func DeleteRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Delete(controller.NewContext(c), c.Param(ParamsWebhookIdName))
+}
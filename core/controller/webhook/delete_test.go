@@ -0,0 +1,57 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/webhook"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	webhookService "github.com/axetroy/go-server/core/service/webhook"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserSignUp)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	r := webhook.Delete(controller.Context{
+		Uid: adminInfo.Id,
+	}, w.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	// 删除之后获取不到了
+	r2 := webhook.Get(w.Id)
+
+	assert.Equal(t, exception.WebhookNotExist.Code(), r2.Status)
+}
+
+func TestDeleteRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserSignUp)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Delete("/v1/webhook/w/"+w.Id, nil, &header)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+}
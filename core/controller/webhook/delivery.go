@@ -0,0 +1,264 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"net/http"
+	"time"
+)
+
+const ParamsDeliveryIdName = "delivery_id"
+
+type DeliveryQuery struct {
+	schema.Query
+	WebhookId *string                      `json:"webhook_id" form:"webhook_id"` // 订阅ID
+	Event     *string                      `json:"event" form:"event"`           // 事件类型
+	EventId   *string                      `json:"event_id" form:"event_id"`     // 事件ID
+	Status    *model.WebhookDeliveryStatus `json:"status" form:"status"`         // 发送状态
+}
+
+func DeleteDeliveryById(id string) {
+	d := model.WebhookDelivery{}
+	database.DeleteRowByTable(d.TableName(), "id", id)
+}
+
+func deliveryToSchema(d model.WebhookDelivery) (data schema.WebhookDelivery, err error) {
+	if err = mapstructure.Decode(d, &data.WebhookDeliveryPure); err != nil {
+		return
+	}
+
+	if d.DeliveredAt != nil {
+		deliveredAt := d.DeliveredAt.Format(time.RFC3339Nano)
+		data.DeliveredAt = &deliveredAt
+	}
+
+	data.CreatedAt = d.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = d.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 获取发送记录详情
+func GetDelivery(id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.WebhookDelivery
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	d := model.WebhookDelivery{Id: id}
+
+	if err = database.Db.First(&d).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookDeliveryNotExist
+		}
+		return
+	}
+
+	data, err = deliveryToSchema(d)
+
+	return
+}
+
+// 获取发送记录列表
+func GetDeliveryList(input DeliveryQuery) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.WebhookDelivery, 0)
+		list = make([]model.WebhookDelivery, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	filter := map[string]interface{}{}
+
+	if input.WebhookId != nil {
+		filter["webhook_id"] = *input.WebhookId
+	}
+
+	if input.Event != nil {
+		filter["event"] = *input.Event
+	}
+
+	if input.EventId != nil {
+		filter["event_id"] = *input.EventId
+	}
+
+	if input.Status != nil {
+		filter["status"] = *input.Status
+	}
+
+	if err = query.Order(database.Db.Limit(query.Limit).Offset(query.Limit * query.Page)).Where(filter).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = database.Db.Model(model.WebhookDelivery{}).Where(filter).Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		var d schema.WebhookDelivery
+
+		if d, err = deliveryToSchema(v); err != nil {
+			return
+		}
+
+		data = append(data, d)
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+// 重放发送记录, 使用相同的事件ID和内容重新发送一次, 返回新的发送记录
+func Replay(c controller.Context, id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.WebhookDelivery
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	adminInfo := model.Admin{Id: c.Uid}
+
+	if err = database.Db.First(&adminInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		err = exception.AdminNotSuper
+		return
+	}
+
+	d, err := webhook.Replay(database.Db, id)
+
+	if err != nil {
+		return
+	}
+
+	data, err = deliveryToSchema(d)
+
+	return
+}
+
+func GetDeliveryRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = GetDelivery(c.Param(ParamsDeliveryIdName))
+}
+
+func GetDeliveryListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input DeliveryQuery
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetDeliveryList(input)
+}
+
+func ReplayRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Replay(controller.NewContext(c), c.Param(ParamsDeliveryIdName))
+}
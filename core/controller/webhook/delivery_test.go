@@ -0,0 +1,158 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/webhook"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/token"
+	webhookService "github.com/axetroy/go-server/core/service/webhook"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 创建一个订阅了事件的 webhook, 并触发一次事件
+func emitDelivery(t *testing.T, url string) (schema.Webhook, schema.WebhookDelivery) {
+	w := createWebhook(t, url, webhookService.EventReportStatusChanged)
+
+	webhookService.Emit(webhookService.EventReportStatusChanged, webhookService.ReportStatusChangedData{
+		PreviousStatus: model.ReportStatusPending,
+	})
+
+	webhookId := w.Id
+
+	r := webhook.GetDeliveryList(webhook.DeliveryQuery{WebhookId: &webhookId})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.WebhookDelivery, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	if !assert.Len(t, list, 1) {
+		t.FailNow()
+	}
+
+	return w, list[0]
+}
+
+func TestGetDelivery(t *testing.T) {
+	w, d := emitDelivery(t, "https://example.com/webhook")
+
+	defer webhook.DeleteWebhookById(w.Id)
+	defer webhook.DeleteDeliveryById(d.Id)
+
+	r := webhook.GetDelivery(d.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.WebhookDelivery{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	assert.Equal(t, w.Id, n.WebhookId)
+	assert.Equal(t, webhookService.EventReportStatusChanged, n.Event)
+	assert.Equal(t, int(model.WebhookDeliveryStatusPending), n.Status)
+
+	// 不存在的记录
+	r2 := webhook.GetDelivery("123123")
+
+	assert.Equal(t, exception.WebhookDeliveryNotExist.Code(), r2.Status)
+}
+
+func TestReplay(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	var secret string
+
+	// 本地的接收方, 签名错误时返回 401
+	received := make(chan string, 1)
+
+	webhookService.AllowPrivateAddress = true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		if !webhookService.Verify(secret, req.Header.Get(webhookService.HeaderTimestamp), body, req.Header.Get(webhookService.HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		received <- req.Header.Get(webhookService.HeaderDelivery)
+	}))
+
+	defer server.Close()
+
+	w, d := emitDelivery(t, server.URL)
+
+	secret = w.Secret
+
+	defer webhook.DeleteWebhookById(w.Id)
+	defer webhook.DeleteDeliveryById(d.Id)
+
+	r := webhook.Replay(controller.Context{Uid: adminInfo.Id}, d.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.WebhookDelivery{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	defer webhook.DeleteDeliveryById(n.Id)
+
+	assert.NotEqual(t, d.Id, n.Id)
+	assert.Equal(t, d.EventId, n.EventId)
+
+	_, err := webhookService.Deliver(database.Db, n.Id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, n.Id, <-received)
+}
+
+func TestReplayRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	w, d := emitDelivery(t, "https://example.com/webhook")
+
+	defer webhook.DeleteWebhookById(w.Id)
+	defer webhook.DeleteDeliveryById(d.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Put("/v1/webhook/delivery/"+d.Id+"/replay", nil, &header)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	n := schema.WebhookDelivery{}
+
+	assert.Nil(t, tester.Decode(res.Data, &n))
+
+	defer webhook.DeleteDeliveryById(n.Id)
+
+	assert.Equal(t, d.EventId, n.EventId)
+
+	// 发送记录列表
+	r2 := tester.HttpAdmin.Get("/v1/webhook/delivery?webhook_id="+w.Id, nil, &header)
+
+	res2 := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r2.Body.Bytes(), &res2))
+	assert.Equal(t, "", res2.Message)
+	assert.Equal(t, int64(2), res2.Meta.Total)
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+const ParamsWebhookIdName = "webhook_id"
+
+type Query struct {
+	schema.Query
+	Event   *string `json:"event" form:"event"`     // 订阅了该事件的 webhook
+	Enabled *bool   `json:"enabled" form:"enabled"` // 是否启用
+}
+
+func webhookToSchema(info model.Webhook) (data schema.Webhook) {
+	data.Id = info.Id
+	data.Url = info.Url
+	data.Events = append([]string{}, info.Events...)
+	data.Secret = info.Secret
+	data.Description = info.Description
+	data.Enabled = info.Enabled
+	data.CreatedAt = info.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = info.UpdatedAt.Format(time.RFC3339Nano)
+
+	return
+}
+
+// 获取 webhook 详情
+func Get(id string) (res schema.Response) {
+	var (
+		err  error
+		data schema.Webhook
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	webhookInfo := model.Webhook{Id: id}
+
+	if err = database.Db.First(&webhookInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookNotExist
+		}
+		return
+	}
+
+	data = webhookToSchema(webhookInfo)
+
+	return
+}
+
+// 获取 webhook 列表
+func GetList(input Query) (res schema.List) {
+	var (
+		err  error
+		data = make([]schema.Webhook, 0)
+		list = make([]model.Webhook, 0)
+		meta = &schema.Meta{}
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		helper.ResponseList(&res, data, meta, err)
+	}()
+
+	query := input.Query
+
+	query.Normalize()
+
+	db := database.Db.Model(model.Webhook{})
+
+	if input.Event != nil {
+		db = db.Where("? = ANY(events)", *input.Event)
+	}
+
+	if input.Enabled != nil {
+		db = db.Where("enabled = ?", *input.Enabled)
+	}
+
+	if err = query.Order(db.Limit(query.Limit).Offset(query.Limit * query.Page)).Find(&list).Error; err != nil {
+		return
+	}
+
+	var total int64
+
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+
+	for _, v := range list {
+		data = append(data, webhookToSchema(v))
+	}
+
+	meta.Total = total
+	meta.Num = len(data)
+	meta.Page = query.Page
+	meta.Limit = query.Limit
+	meta.Sort = query.Sort
+
+	return
+}
+
+func GetRouter(c *gin.Context) {
+	var (
+		err error
+		res = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	res = Get(c.Param(ParamsWebhookIdName))
+}
+
+func GetListRouter(c *gin.Context) {
+	var (
+		err   error
+		res   = schema.List{}
+		input Query
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindQuery(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = GetList(input)
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/webhook"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	webhookService "github.com/axetroy/go-server/core/service/webhook"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func createWebhook(t *testing.T, url string, events ...string) schema.Webhook {
+	adminInfo, _ := tester.LoginAdmin()
+
+	r := webhook.Create(controller.Context{
+		Uid: adminInfo.Id,
+	}, webhook.CreateParams{
+		Url:    url,
+		Events: events,
+	})
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Webhook{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	return n
+}
+
+func TestGet(t *testing.T) {
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserSignUp)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	r := webhook.Get(w.Id)
+
+	assert.Equal(t, "", r.Message)
+
+	n := schema.Webhook{}
+
+	assert.Nil(t, tester.Decode(r.Data, &n))
+
+	assert.Equal(t, w.Id, n.Id)
+	assert.Equal(t, w.Secret, n.Secret)
+
+	// 不存在的 webhook
+	r2 := webhook.Get("123123")
+
+	assert.Equal(t, exception.WebhookNotExist.Code(), r2.Status)
+}
+
+func TestGetList(t *testing.T) {
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserBindPhone)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	event := webhookService.EventUserBindPhone
+
+	r := webhook.GetList(webhook.Query{Event: &event})
+
+	assert.Equal(t, "", r.Message)
+
+	list := make([]schema.Webhook, 0)
+
+	assert.Nil(t, tester.Decode(r.Data, &list))
+
+	if assert.True(t, len(list) > 0) {
+		for _, v := range list {
+			assert.Contains(t, v.Events, event)
+		}
+	}
+}
+
+func TestGetListRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserSignUp)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	r := tester.HttpAdmin.Get("/v1/webhook", nil, &header)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	res := schema.List{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	list := make([]schema.Webhook, 0)
+
+	assert.Nil(t, tester.Decode(res.Data, &list))
+	assert.True(t, len(list) > 0)
+
+	r2 := tester.HttpAdmin.Get("/v1/webhook/w/"+w.Id, nil, &header)
+
+	assert.Equal(t, http.StatusOK, r2.Code)
+
+	res2 := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r2.Body.Bytes(), &res2))
+	assert.Equal(t, "", res2.Message)
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+type UpdateParams struct {
+	Url         *string  `json:"url" valid:"requrl~请输入正确的 URL"`                    // 接收事件的 URL
+	Events      []string `json:"events"`                                           // 订阅的事件
+	Secret      *string  `json:"secret" valid:"stringlength(8|64)~密钥长度为 8 到 64 位"` // 签名使用的密钥
+	Description *string  `json:"description"`                                      // 描述
+	Enabled     *bool    `json:"enabled"`                                          // 是否启用
+}
+
+func Update(c controller.Context, webhookId string, input UpdateParams) (res schema.Response) {
+	var (
+		err          error
+		data         schema.Webhook
+		tx           *gorm.DB
+		shouldUpdate bool
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+
+		if tx != nil {
+			if err != nil || !shouldUpdate {
+				_ = tx.Rollback().Error
+			} else {
+				err = tx.Commit().Error
+			}
+		}
+
+		helper.Response(&res, data, err)
+	}()
+
+	// 参数校验
+	if err = validator.ValidateStruct(input); err != nil {
+		return
+	}
+
+	tx = database.Db.Begin()
+
+	adminInfo := model.Admin{
+		Id: c.Uid,
+	}
+
+	if err = tx.First(&adminInfo).Error; err != nil {
+		// 没有找到管理员
+		if err == gorm.ErrRecordNotFound {
+			err = exception.AdminNotExist
+		}
+		return
+	}
+
+	if !adminInfo.IsSuper {
+		err = exception.AdminNotSuper
+		return
+	}
+
+	webhookInfo := model.Webhook{
+		Id: webhookId,
+	}
+
+	if err = tx.First(&webhookInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookNotExist
+		}
+		return
+	}
+
+	// 启用状态可能更新为 false, 所以使用 map 更新
+	updated := map[string]interface{}{}
+
+	if input.Url != nil {
+		updated["url"] = *input.Url
+	}
+
+	if input.Events != nil {
+		var events []string
+
+		if events, err = normalizeEvents(input.Events); err != nil {
+			return
+		}
+
+		updated["events"] = pq.StringArray(events)
+	}
+
+	if input.Secret != nil {
+		updated["secret"] = *input.Secret
+	}
+
+	if input.Description != nil {
+		updated["description"] = *input.Description
+	}
+
+	if input.Enabled != nil {
+		updated["enabled"] = *input.Enabled
+	}
+
+	if len(updated) == 0 {
+		data = webhookToSchema(webhookInfo)
+		return
+	}
+
+	shouldUpdate = true
+
+	if err = tx.Model(&webhookInfo).Updates(updated).Error; err != nil {
+		return
+	}
+
+	if err = tx.First(&webhookInfo).Error; err != nil {
+		return
+	}
+
+	data = webhookToSchema(webhookInfo)
+
+	return
+}
+
+func UpdateRouter(c *gin.Context) {
+	var (
+		input UpdateParams
+		err   error
+		res   = schema.Response{}
+	)
+
+	defer func() {
+		if err != nil {
+			res.Data = nil
+			res.Message = err.Error()
+		}
+		c.JSON(http.StatusOK, res)
+	}()
+
+	if err = c.ShouldBindJSON(&input); err != nil {
+		err = exception.InvalidParams
+		return
+	}
+
+	res = Update(controller.NewContext(c), c.Param(ParamsWebhookIdName), input)
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/webhook"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/token"
+	webhookService "github.com/axetroy/go-server/core/service/webhook"
+	"github.com/axetroy/go-server/tester"
+	"github.com/axetroy/mocker"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserSignUp)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	// 停用并修改订阅的事件
+	{
+		enabled := false
+
+		r := webhook.Update(controller.Context{
+			Uid: adminInfo.Id,
+		}, w.Id, webhook.UpdateParams{
+			Events:  []string{webhookService.EventTransferReceived},
+			Enabled: &enabled,
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		n := schema.Webhook{}
+
+		assert.Nil(t, tester.Decode(r.Data, &n))
+
+		assert.False(t, n.Enabled)
+		assert.Equal(t, []string{webhookService.EventTransferReceived}, n.Events)
+		assert.Equal(t, w.Url, n.Url)
+	}
+
+	// 不存在的事件
+	{
+		r := webhook.Update(controller.Context{
+			Uid: adminInfo.Id,
+		}, w.Id, webhook.UpdateParams{
+			Events: []string{"user.unknown"},
+		})
+
+		assert.Equal(t, exception.InvalidWebhookEvent.Error(), r.Message)
+	}
+
+	// 不存在的 webhook
+	{
+		url := "https://example.com"
+
+		r := webhook.Update(controller.Context{
+			Uid: adminInfo.Id,
+		}, "123123", webhook.UpdateParams{
+			Url: &url,
+		})
+
+		assert.Equal(t, exception.WebhookNotExist.Error(), r.Message)
+	}
+}
+
+func TestUpdateRouter(t *testing.T) {
+	adminInfo, _ := tester.LoginAdmin()
+
+	w := createWebhook(t, "https://example.com/webhook", webhookService.EventUserSignUp)
+
+	defer webhook.DeleteWebhookById(w.Id)
+
+	header := mocker.Header{
+		"Authorization": token.Prefix + " " + adminInfo.Token,
+	}
+
+	url := "https://example.com/another"
+
+	body, _ := json.Marshal(&webhook.UpdateParams{
+		Url: &url,
+	})
+
+	r := tester.HttpAdmin.Put("/v1/webhook/w/"+w.Id, body, &header)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	res := schema.Response{}
+
+	assert.Nil(t, json.Unmarshal(r.Body.Bytes(), &res))
+	assert.Equal(t, "", res.Message)
+
+	n := schema.Webhook{}
+
+	assert.Nil(t, tester.Decode(res.Data, &n))
+
+	assert.Equal(t, url, n.Url)
+	assert.True(t, n.Enabled)
+}
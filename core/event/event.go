@@ -0,0 +1,167 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package event
+
+import (
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/jinzhu/gorm"
+	"log"
+	"sync"
+)
+
+// 领域事件
+type Event interface {
+	Name() string // 事件名称, 订阅者按照名称订阅
+}
+
+// 同步的订阅者, 和发布者在同一个事务中运行, 返回错误则整个事务回滚
+type Handler func(tx *gorm.DB, e Event) error
+
+// 异步的订阅者, 在事务提交之后运行, 失败只记录日志, 不影响发布者
+type AsyncHandler func(e Event) error
+
+var (
+	mu            sync.RWMutex
+	handlers      = map[string][]Handler{}
+	asyncHandlers = map[string][]AsyncHandler{}
+	running       sync.WaitGroup
+)
+
+// 订阅事件, 在事务中同步运行. 按照订阅的顺序运行
+func Subscribe(name string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	handlers[name] = append(handlers[name], handler)
+}
+
+// 检查必需的事件是否都有同步的订阅者, 在进程启动时调用
+// 订阅者在 subscriber 包的 init 中注册, 进程没有引入该包时启动失败, 而不是注册出没有钱包的用户
+func Check() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, name := range Required {
+		if len(handlers[name]) == 0 {
+			return missingSubscriber(name)
+		}
+	}
+
+	return nil
+}
+
+func missingSubscriber(name string) error {
+	return fmt.Errorf("事件 %s 没有同步的订阅者, 请引入 core/subscriber 包", name)
+}
+
+func isRequired(name string) bool {
+	for _, v := range Required {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 订阅事件, 在事务提交之后异步运行
+func SubscribeAsync(name string, handler AsyncHandler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	asyncHandlers[name] = append(asyncHandlers[name], handler)
+}
+
+func recoverError(r interface{}) error {
+	switch t := r.(type) {
+	case string:
+		return errors.New(t)
+	case error:
+		return t
+	default:
+		return exception.Unknown
+	}
+}
+
+func runHandler(tx *gorm.DB, e Event, handler Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverError(r)
+		}
+	}()
+
+	return handler(tx, e)
+}
+
+func runAsyncHandler(e Event, handler AsyncHandler) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("事件 %s 的订阅者运行失败: %v\n", e.Name(), recoverError(r))
+		}
+	}()
+
+	if err := handler(e); err != nil {
+		log.Printf("事件 %s 的订阅者运行失败: %v\n", e.Name(), err)
+	}
+}
+
+// 一次事务中发布的事件
+// 发布时运行同步的订阅者, 事务提交之后调用 Commit 运行异步的订阅者, 事务回滚则丢弃
+type Batch struct {
+	events []Event
+}
+
+// 发布事件, 任何一个同步的订阅者返回错误时, 返回该错误, 发布者应该回滚事务
+func (b *Batch) Publish(tx *gorm.DB, e Event) error {
+	mu.RLock()
+	list := handlers[e.Name()]
+	mu.RUnlock()
+
+	// 没有经过启动检查的进程, 例如命令行工具, 发布时同样检查
+	if len(list) == 0 && isRequired(e.Name()) {
+		return missingSubscriber(e.Name())
+	}
+
+	for _, handler := range list {
+		if err := runHandler(tx, e, handler); err != nil {
+			return err
+		}
+	}
+
+	b.events = append(b.events, e)
+
+	return nil
+}
+
+// 事务提交之后调用, 异步运行已发布事件的订阅者
+func (b *Batch) Commit() {
+	events := b.events
+
+	b.events = nil
+
+	for _, e := range events {
+		mu.RLock()
+		list := asyncHandlers[e.Name()]
+		mu.RUnlock()
+
+		if len(list) == 0 {
+			continue
+		}
+
+		running.Add(1)
+
+		go func(e Event, list []AsyncHandler) {
+			defer running.Done()
+
+			for _, handler := range list {
+				runAsyncHandler(e, handler)
+			}
+		}(e, list)
+	}
+}
+
+// 等待正在运行的异步订阅者完成
+func Wait() {
+	running.Wait()
+}
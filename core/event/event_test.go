@@ -0,0 +1,143 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package event_test
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/event"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type testEvent struct {
+	name  string
+	value int
+}
+
+func (e testEvent) Name() string {
+	return e.name
+}
+
+func TestBatch(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, s)
+	}
+
+	event.Subscribe("test.batch", func(tx *gorm.DB, e event.Event) error {
+		record("sync-1")
+		return nil
+	})
+
+	event.Subscribe("test.batch", func(tx *gorm.DB, e event.Event) error {
+		record("sync-2")
+
+		if e.(testEvent).value < 0 {
+			return errors.New("invalid value")
+		}
+
+		return nil
+	})
+
+	event.SubscribeAsync("test.batch", func(e event.Event) error {
+		record("async")
+		return nil
+	})
+
+	// 异步的订阅者 panic 不影响其他订阅者
+	event.SubscribeAsync("test.batch", func(e event.Event) error {
+		panic("boom")
+	})
+
+	event.SubscribeAsync("test.batch", func(e event.Event) error {
+		record("async-after-panic")
+		return nil
+	})
+
+	batch := event.Batch{}
+
+	// 同步的订阅者按照订阅的顺序运行, 提交之前不会运行异步的订阅者
+	assert.Nil(t, batch.Publish(nil, testEvent{name: "test.batch", value: 1}))
+
+	event.Wait()
+
+	assert.Equal(t, []string{"sync-1", "sync-2"}, calls)
+
+	batch.Commit()
+
+	event.Wait()
+
+	assert.Equal(t, []string{"sync-1", "sync-2", "async", "async-after-panic"}, calls)
+
+	// 已经提交的事件不会重复运行
+	batch.Commit()
+
+	event.Wait()
+
+	assert.Len(t, calls, 4)
+}
+
+func TestBatchError(t *testing.T) {
+	asyncCalled := false
+
+	event.Subscribe("test.error", func(tx *gorm.DB, e event.Event) error {
+		return errors.New("rollback")
+	})
+
+	event.Subscribe("test.panic", func(tx *gorm.DB, e event.Event) error {
+		panic("boom")
+	})
+
+	event.SubscribeAsync("test.error", func(e event.Event) error {
+		asyncCalled = true
+		return nil
+	})
+
+	batch := event.Batch{}
+
+	// 同步的订阅者返回错误时, 事件不会在提交之后运行
+	err := batch.Publish(nil, testEvent{name: "test.error"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "rollback", err.Error())
+
+	// panic 转为错误
+	err = batch.Publish(nil, testEvent{name: "test.panic"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "boom", err.Error())
+
+	batch.Commit()
+
+	event.Wait()
+
+	assert.False(t, asyncCalled)
+
+	// 没有订阅者的事件
+	assert.Nil(t, batch.Publish(nil, testEvent{name: "test.nobody"}))
+
+	batch.Commit()
+}
+
+func TestCheck(t *testing.T) {
+	// 没有引入订阅者时, 启动检查和发布都会失败
+	assert.NotNil(t, event.Check())
+
+	batch := event.Batch{}
+
+	assert.NotNil(t, batch.Publish(nil, event.UserRegistered{}))
+
+	event.Subscribe(event.NameUserRegistered, func(tx *gorm.DB, e event.Event) error {
+		return nil
+	})
+
+	assert.Nil(t, event.Check())
+	assert.Nil(t, batch.Publish(nil, event.UserRegistered{}))
+}
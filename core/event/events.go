@@ -0,0 +1,118 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package event
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+)
+
+// 事件名称
+const (
+	NameUserRegistered      = "user.registered"
+	NamePhoneBound          = "user.phone_bound"
+	NamePasswordChanged     = "user.password_changed"
+	NameTransferCompleted   = "transfer.completed"
+	NameReportStatusChanged = "report.status_changed"
+	NameReportResolved      = "report.resolved"
+	NameNewsSaved           = "news.saved"
+	NameHelpSaved           = "help.saved"
+	NameNotificationSaved   = "notification.saved"
+)
+
+// 必须有同步订阅者的事件, 它们的订阅者负责创建必需的数据, 例如用户注册之后的钱包和邀请记录
+var Required = []string{NameUserRegistered}
+
+// 用户注册, 包括用户名/邮箱/手机/微信/第三方登陆注册以及管理员创建的用户
+type UserRegistered struct {
+	User       model.User // 已经创建的用户
+	InviteCode *string    // 注册时填写的邀请码
+}
+
+func (UserRegistered) Name() string {
+	return NameUserRegistered
+}
+
+// 用户绑定手机号
+type PhoneBound struct {
+	Uid   string // 用户ID
+	Phone string // 绑定的手机号
+}
+
+func (PhoneBound) Name() string {
+	return NamePhoneBound
+}
+
+// 修改登陆密码的方式
+type PasswordChangeSource string
+
+const (
+	PasswordChangedByUser  PasswordChangeSource = "user"  // 用户自己修改
+	PasswordChangedByAdmin PasswordChangeSource = "admin" // 管理员修改
+	PasswordChangedByReset PasswordChangeSource = "reset" // 通过重置码重置
+)
+
+// 用户的登陆密码已修改
+type PasswordChanged struct {
+	Uid    string               // 用户ID
+	Source PasswordChangeSource // 修改的方式
+}
+
+func (PasswordChanged) Name() string {
+	return NamePasswordChanged
+}
+
+// 转账完成
+type TransferCompleted struct {
+	Log          schema.TransferLog // 转账记录
+	FromUsername string             // 转账者的用户名
+}
+
+func (TransferCompleted) Name() string {
+	return NameTransferCompleted
+}
+
+// 反馈的状态改变
+type ReportStatusChanged struct {
+	Report         schema.Report      // 改变之后的反馈
+	PreviousStatus model.ReportStatus // 改变之前的状态
+}
+
+func (ReportStatusChanged) Name() string {
+	return NameReportStatusChanged
+}
+
+// 反馈已解决, 状态改变为已解决时和 ReportStatusChanged 一起发布
+type ReportResolved struct {
+	Report schema.Report // 已解决的反馈
+}
+
+func (ReportResolved) Name() string {
+	return NameReportResolved
+}
+
+// 新闻公告已创建或者修改
+type NewsSaved struct {
+	News model.News // 保存之后的新闻公告
+}
+
+func (NewsSaved) Name() string {
+	return NameNewsSaved
+}
+
+// 帮助文章已创建或者修改
+type HelpSaved struct {
+	Help model.Help // 保存之后的帮助文章
+}
+
+func (HelpSaved) Name() string {
+	return NameHelpSaved
+}
+
+// 系统通知已创建或者修改
+type NotificationSaved struct {
+	Notification model.Notification // 保存之后的系统通知
+}
+
+func (NotificationSaved) Name() string {
+	return NameNotificationSaved
+}
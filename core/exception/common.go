@@ -12,12 +12,16 @@ var (
 	InvalidInviteCode = New("无效的邀请码", 100005)
 	SendMsgFail       = New("发送短信失败", 101000)
 	SendEmailFail     = New("发送邮件失败", 101001)
+	SendMsgTooOften   = New("发送短信过于频繁, 请稍后再试", 101002)
 	UserNotLogin      = New("请先登陆", 999999)
 	InvalidAuth       = New("无效的身份认证方式", 999999)
 	InvalidToken      = New("无效的身份令牌", 999999)
 	TokenExpired      = New("身份令牌已过期", 999999)
 	EmptyList         = New("sql: no rows in result set", 0)
 
+	// 正文格式
+	InvalidContentFormat = New("不支持的内容格式", 0)
+
 	// 用户类
 	UserNotExist             = New("用户不存在", 200000)
 	UserExist                = New("用户已存在", 200001)
@@ -36,10 +40,18 @@ var (
 	RequirePayPassword       = New("请输入交易密码", 200014)
 	DuplicateBinding         = New("帐号重复绑定", 200015)
 	RenameUserNameFail       = New("无法重命名用户名", 200016)
+	InvalidNotifyChannel     = New("无效的通知渠道", 200017)
+	InvalidQuietHours        = New("免打扰时段的格式为 HH:MM", 200018)
 
 	// 钱包
-	NotEnoughBalance = New("钱包余额不足", 0)
-	InvalidWallet    = New("无效的钱包", 0)
+	NotEnoughBalance         = New("钱包余额不足", 0)
+	InvalidWallet            = New("无效的钱包", 0)
+	TransferNotExist         = New("转账记录不存在", 0)
+	TransferToSelf           = New("不能转账给自己", 0)
+	RecipientNotExist        = New("收款人不存在", 0)
+	RecipientAmbiguous       = New("匹配到多个收款人, 请指定收款人的类型", 0)
+	InvalidRecipientType     = New("无效的收款人类型", 0)
+	RecipientPreviewTooOften = New("查询收款人过于频繁, 请稍后再试", 0)
 
 	// 上传
 	RequireFile    = New("请上传文件", 0)
@@ -64,6 +76,9 @@ var (
 
 	// 帮助中心
 	HelpParentNotExist = New("父级不存在", 0)
+	HelpParentNotClass = New("只有分类可以作为父级", 0)
+	HelpMoveCycle      = New("不能移动到自己或者自己的子级下面", 0)
+	HelpHasChildren    = New("分类下还有内容, 不能修改为文章", 0)
 
 	// 邀请
 	InviteNotExist = New("邀请记录不存在", 0)
@@ -74,12 +89,48 @@ var (
 	RoleHadBeenUsed  = New("角色正在被使用，无法删除", 0)
 
 	// 系统通知
-	NotificationNotExist = New("系统通知不存在", 0)
+	NotificationNotExist        = New("系统通知不存在", 0)
+	NotificationInvalidTime     = New("过期时间必须晚于发布时间", 0)
+	NotificationInvalidLevel    = New("最低等级不能高于最高等级", 0)
+	NotificationInvalidRegister = New("注册时间的开始必须早于结束", 0)
 
 	// 用户消息
-	MessageNotExist = New("用户消息不存在", 0)
+	MessageNotExist        = New("用户消息不存在", 0)
+	MessageBatchNotExist   = New("群发任务不存在", 0)
+	MessageBatchNoTarget   = New("没有符合条件的用户", 0)
+	MessageBatchIsFinished = New("群发任务已经结束", 0)
+
+	// 邮件和短信模版
+	TemplateNotExist = New("模版不存在", 0)
+	TemplateExist    = New("模版已存在", 0)
+	InvalidTemplate  = New("模版格式错误或者使用了不存在的变量", 0)
+
+	// 邮件和短信的发送记录
+	DeliveryNotExist          = New("发送记录不存在", 0)
+	DeliveryCodeNotResendable = New("验证码不能重新发送, 请让用户重新获取", 0)
+	SMSLogNotExist            = New("短信记录不存在", 0)
+
+	// 定时任务
+	ScheduleTaskNotExist = New("定时任务不存在", 0)
+	ScheduleTaskRunning  = New("定时任务正在运行", 0)
+	ScheduleRunNotExist  = New("运行记录不存在", 0)
+
+	// webhook
+	WebhookNotExist         = New("webhook 不存在", 0)
+	WebhookDeliveryNotExist = New("webhook 发送记录不存在", 0)
+	InvalidWebhookEvent     = New("无效的事件类型", 0)
 
 	// 新闻资讯
-	NewsInvalidType = New("错误的文章类型", 0)
-	NewsNotExist    = New("文章不存在", 0)
+	NewsInvalidType        = New("错误的文章类型", 0)
+	NewsNotExist           = New("文章不存在", 0)
+	NewsInvalidStatus      = New("错误的文章状态", 0)
+	NewsInvalidPublishTime = New("错误的发布时间", 0)
+	NewsRevisionNotExist   = New("文章的历史版本不存在", 0)
+	NewsInvalidFeedFormat  = New("不支持的订阅格式", 0)
+
+	// 新闻资讯的评论
+	NewsCommentNotExist      = New("评论不存在", 0)
+	NewsCommentInvalidStatus = New("错误的评论状态", 0)
+	NewsCommentBanned        = New("你已被禁止评论", 0)
+	NewsCommentBanNotExist   = New("禁言记录不存在", 0)
 )
@@ -0,0 +1,46 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package helper
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/schema"
+	"strings"
+)
+
+// 生成联合多个分表的查询语句, 用于钱包/转账/流水这类按币种分表的数据
+// 每个表使用相同的查询条件, 返回的语句可以作为子查询使用
+func UnionSQL(tables []string, conditions []string, args []interface{}) (string, []interface{}) {
+	conditions = append(conditions, `("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00')`)
+
+	where := strings.Join(conditions, " AND ")
+
+	SQLs := make([]string, 0)
+	values := make([]interface{}, 0)
+
+	for _, tableName := range tables {
+		SQLs = append(SQLs, fmt.Sprintf(`SELECT * FROM "%s" WHERE %s`, tableName, where))
+		values = append(values, args...)
+	}
+
+	return strings.Join(SQLs, " UNION ALL "), values
+}
+
+// 根据查询参数生成排序语句, 只允许按照指定的字段排序
+func OrderSQL(query schema.Query, fields ...string) string {
+	orders := make([]string, 0)
+
+	for _, sort := range query.FormatSort() {
+		for _, field := range fields {
+			if sort.Field == field {
+				orders = append(orders, fmt.Sprintf(`"%s" %s`, sort.Field, sort.Order))
+				break
+			}
+		}
+	}
+
+	if len(orders) == 0 {
+		return `ORDER BY "created_at" DESC`
+	}
+
+	return "ORDER BY " + strings.Join(orders, ", ")
+}
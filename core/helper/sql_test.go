@@ -0,0 +1,22 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package helper_test
+
+import (
+	"github.com/axetroy/go-server/core/helper"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnionSQL(t *testing.T) {
+	sql, args := helper.UnionSQL([]string{"a", "b"}, []string{`"uid" = ?`}, []interface{}{"123"})
+
+	assert.Equal(t, `SELECT * FROM "a" WHERE "uid" = ? AND ("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00') UNION ALL SELECT * FROM "b" WHERE "uid" = ? AND ("deleted_at" IS NULL OR "deleted_at"='0001-01-01 00:00:00')`, sql)
+	assert.Equal(t, []interface{}{"123", "123"}, args)
+}
+
+func TestOrderSQL(t *testing.T) {
+	assert.Equal(t, `ORDER BY "amount" ASC, "created_at" DESC`, helper.OrderSQL(schema.Query{Sort: "amount,-created_at"}, "amount", "created_at"))
+	// 不允许的字段会被忽略
+	assert.Equal(t, `ORDER BY "created_at" DESC`, helper.OrderSQL(schema.Query{Sort: "password"}, "amount", "created_at"))
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"time"
+)
+
+// 队列中的一条消息
+type Message struct {
+	Body     []byte // 消息的内容
+	Attempts uint16 // 第几次处理这条消息, 从 1 开始
+	requeue  bool
+	delay    time.Duration
+}
+
+// 处理完成之后在 delay 之后重新投递, 不调用则表示处理完成
+func (m *Message) Requeue(delay time.Duration) {
+	m.requeue = true
+	m.delay = delay
+}
+
+// 处理消息, 处理完成之后才会确认这条消息
+type Handler func(msg *Message)
+
+// 订阅之后返回的句柄
+type Subscription interface {
+	Stop()                 // 停止接收新的消息
+	Done() <-chan struct{} // 正在处理的消息都完成之后关闭
+}
+
+// 消息队列的实现, 和 nsq 一样, 每个主题的消息会发送到它的每个频道, 同一个频道的消息只会被其中一个消费者处理
+type Backend interface {
+	Publish(topic Topic, body []byte) error
+	Subscribe(topic Topic, channel Chanel, concurrency int, handler Handler) (Subscription, error)
+}
+
+var (
+	// 默认使用的消息队列, 由配置 MSG_QUEUE_BACKEND 决定
+	DefaultBackend Backend
+
+	// MSG_QUEUE_BACKEND 为 memory 时使用的消息队列
+	Memory = NewMemoryBackend()
+)
+
+func init() {
+	var err error
+
+	if DefaultBackend, err = NewBackend(config.MessageQueue.Backend); err != nil {
+		panic(err)
+	}
+}
+
+// 根据名称创建消息队列
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case config.MessageQueueBackendNSQ:
+		return NewNSQBackend(Address, Config), nil
+	case config.MessageQueueBackendRedis:
+		return NewRedisBackend(), nil
+	case config.MessageQueueBackendMemory:
+		return Memory, nil
+	default:
+		return nil, fmt.Errorf("invalid message queue backend `%s`", name)
+	}
+}
+
+// 发布消息
+func Publish(topic Topic, body []byte) error {
+	return DefaultBackend.Publish(topic, body)
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 任务的内容, 以 JSON 的格式发布到对应的主题
+type Job interface {
+	Topic() Topic
+}
+
+// 任务的定义, 注册之后由消息队列进程统一消费
+type Definition struct {
+	Topic       Topic                                    // 主题
+	Channel     Chanel                                   // 频道
+	New         func() Job                               // 创建空的任务, 消息会解析到这里, 必须返回指针
+	Handler     func(ctx context.Context, job Job) error // 处理任务, 返回错误则稍后重试
+	Concurrency int                                      // 同时处理的任务数, 默认为 1
+	MaxAttempts uint16                                   // 最多处理的次数, 超过之后加入死信队列. 为 0 则不限制, 由任务自己决定何时放弃
+	Backoff     func(attempts uint16) time.Duration      // 第几次失败之后等待多久再重试, 默认为 DefaultBackoff
+}
+
+// 处理失败之后指定重试的时间
+type retryError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryError) Error() string {
+	return e.err.Error()
+}
+
+const (
+	DefaultBackoffDelay = time.Second * 5  // 第一次重试前等待的时间
+	MaxBackoffDelay     = time.Minute * 10 // 最长的重试等待时间, 不能超过 nsq 的 max-req-timeout
+)
+
+var (
+	registry = map[Topic]Definition{}
+	mu       sync.RWMutex
+)
+
+// 注册任务, 同一个主题只能注册一次
+func Register(def Definition) {
+	if def.Topic == "" || def.Channel == "" || def.New == nil || def.Handler == nil {
+		panic(fmt.Sprintf("invalid job definition for topic '%s'", def.Topic))
+	}
+
+	if def.Concurrency <= 0 {
+		def.Concurrency = 1
+	}
+
+	if def.Backoff == nil {
+		def.Backoff = DefaultBackoff
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[def.Topic]; ok {
+		panic(fmt.Sprintf("job '%s' has been registered", def.Topic))
+	}
+
+	registry[def.Topic] = def
+}
+
+// 获取已注册的任务, 按照主题排序
+func Definitions() []Definition {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Definition, 0, len(registry))
+
+	for _, def := range registry {
+		list = append(list, def)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Topic < list[j].Topic
+	})
+
+	return list
+}
+
+// 死信队列的主题, 多次处理仍然失败的任务会发布到这里
+func DeadLetterTopic(topic Topic) Topic {
+	return topic + "_dead"
+}
+
+// 默认的退避策略, 每次失败之后等待的时间翻倍
+func DefaultBackoff(attempts uint16) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := DefaultBackoffDelay
+
+	for i := uint16(1); i < attempts; i++ {
+		delay = delay * 2
+
+		if delay >= MaxBackoffDelay {
+			return MaxBackoffDelay
+		}
+	}
+
+	return delay
+}
+
+// 让任务在指定的时间之后重试, 而不是使用任务定义的退避策略
+func RetryAfter(err error, after time.Duration) error {
+	return &retryError{err: err, after: after}
+}
+
+// 把任务加入消息队列
+func Enqueue(ctx context.Context, job Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(job)
+
+	if err != nil {
+		return err
+	}
+
+	return Publish(job.Topic(), body)
+}
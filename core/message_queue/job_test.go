@@ -0,0 +1,220 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_test
+
+import (
+	"context"
+	"errors"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testJob struct {
+	Name string `json:"name"`
+}
+
+func (testJob) Topic() message_queue.Topic {
+	return "test_job"
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	assert.Equal(t, message_queue.DefaultBackoffDelay, message_queue.DefaultBackoff(0))
+	assert.Equal(t, message_queue.DefaultBackoffDelay, message_queue.DefaultBackoff(1))
+	assert.Equal(t, message_queue.DefaultBackoffDelay*4, message_queue.DefaultBackoff(3))
+	assert.Equal(t, message_queue.MaxBackoffDelay, message_queue.DefaultBackoff(100))
+}
+
+func TestRegister(t *testing.T) {
+	def := message_queue.Definition{
+		Topic:   "test_register",
+		Channel: "test_register",
+		New: func() message_queue.Job {
+			return &testJob{}
+		},
+		Handler: func(ctx context.Context, job message_queue.Job) error {
+			return nil
+		},
+	}
+
+	message_queue.Register(def)
+
+	found := false
+
+	for _, v := range message_queue.Definitions() {
+		if v.Topic == def.Topic {
+			found = true
+			assert.Equal(t, 1, v.Concurrency)
+			assert.NotNil(t, v.Backoff)
+		}
+	}
+
+	assert.True(t, found)
+
+	// 重复注册
+	assert.Panics(t, func() {
+		message_queue.Register(def)
+	})
+
+	// 缺少处理函数
+	assert.Panics(t, func() {
+		message_queue.Register(message_queue.Definition{Topic: "test_invalid", Channel: "test_invalid"})
+	})
+}
+
+func TestWorker(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts = map[string]int{}
+	)
+
+	fail := errors.New("fail")
+
+	message_queue.Register(message_queue.Definition{
+		Topic:   "test_job",
+		Channel: "test_job",
+		New: func() message_queue.Job {
+			return &testJob{}
+		},
+		Handler: func(ctx context.Context, job message_queue.Job) error {
+			name := job.(*testJob).Name
+
+			mu.Lock()
+			attempts[name]++
+			n := attempts[name]
+			mu.Unlock()
+
+			switch name {
+			case "fail":
+				return fail
+			case "retry":
+				// 第一次失败, 指定时间之后重试成功
+				if n == 1 {
+					return message_queue.RetryAfter(fail, time.Millisecond*10)
+				}
+				return nil
+			default:
+				return nil
+			}
+		},
+		MaxAttempts: 3,
+		Backoff: func(attempts uint16) time.Duration {
+			return time.Millisecond * 10
+		},
+	})
+
+	backend := message_queue.NewMemoryBackend()
+
+	defaultBackend := message_queue.DefaultBackend
+	message_queue.DefaultBackend = backend
+
+	defer func() {
+		message_queue.DefaultBackend = defaultBackend
+	}()
+
+	worker, err := message_queue.StartWorker()
+
+	assert.Nil(t, err)
+
+	for _, name := range []string{"ok", "fail", "retry"} {
+		assert.Nil(t, message_queue.Enqueue(context.Background(), testJob{Name: name}))
+	}
+
+	// 无法解析的消息直接加入死信队列
+	assert.Nil(t, message_queue.Publish("test_job", []byte("invalid")))
+
+	getMetrics := func() (m message_queue.Metrics) {
+		for _, v := range message_queue.GetMetrics() {
+			if v.Topic == "test_job" {
+				m = v
+			}
+		}
+		return
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+
+	for time.Now().Before(deadline) {
+		m := getMetrics()
+
+		if m.Processed == 2 && m.Dead == 2 {
+			break
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+	defer cancel()
+
+	worker.Stop(ctx)
+
+	m := getMetrics()
+
+	assert.Equal(t, int64(2), m.Processed)
+	assert.Equal(t, int64(5), m.Failed)
+	assert.Equal(t, int64(3), m.Retried)
+	assert.Equal(t, int64(2), m.Dead)
+	assert.Equal(t, int64(0), m.InFlight)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, 1, attempts["ok"])
+	assert.Equal(t, 3, attempts["fail"])
+	assert.Equal(t, 2, attempts["retry"])
+}
+
+func TestDeadLetterBackend(t *testing.T) {
+	backend := message_queue.NewMemoryBackend()
+	other := message_queue.NewMemoryBackend()
+
+	defaultBackend := message_queue.DefaultBackend
+	message_queue.DefaultBackend = other
+
+	defer func() {
+		message_queue.DefaultBackend = defaultBackend
+	}()
+
+	handler := message_queue.NewHandler(context.Background(), backend, message_queue.Definition{
+		Topic:   "test_dead_letter",
+		Channel: "test_dead_letter",
+		New: func() message_queue.Job {
+			return &testJob{}
+		},
+		Handler: func(ctx context.Context, job message_queue.Job) error {
+			return errors.New("fail")
+		},
+		MaxAttempts: 1,
+	})
+
+	handler(&message_queue.Message{Body: []byte(`{"name":"dead"}`), Attempts: 1})
+
+	// 死信发布到消费任务的消息队列, 而不是默认的消息队列
+	dead := make(chan message_queue.Message, 1)
+	lost := make(chan message_queue.Message, 1)
+
+	sub, err := backend.Subscribe(message_queue.DeadLetterTopic("test_dead_letter"), "test", 1, func(msg *message_queue.Message) {
+		dead <- *msg
+	})
+
+	assert.Nil(t, err)
+
+	defer sub.Stop()
+
+	sub2, err := other.Subscribe(message_queue.DeadLetterTopic("test_dead_letter"), "test", 1, func(msg *message_queue.Message) {
+		lost <- *msg
+	})
+
+	assert.Nil(t, err)
+
+	defer sub2.Stop()
+
+	assert.Equal(t, `{"name":"dead"}`, string(receive(t, dead).Body))
+
+	time.Sleep(time.Millisecond * 50)
+
+	assert.Len(t, lost, 0)
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	MemoryQueueSize      = 10000            // 每个频道最多缓存的消息数
+	MemoryRequeueTimeout = time.Second * 30 // 队列已满时, 重试的消息最多等待多久加入队列
+
+	memoryRequeueInterval = time.Millisecond * 10 // 队列已满时, 重试的消息隔多久再尝试加入队列
+)
+
+var ErrQueueFull = errors.New("message queue is full")
+
+// 保存在进程内的消息队列, 进程退出之后未处理的消息会丢失
+type MemoryBackend struct {
+	mu     sync.Mutex
+	topics map[Topic]*memoryTopic
+}
+
+type memoryTopic struct {
+	channels map[Chanel]chan *Message
+	backlog  [][]byte // 还没有频道订阅时发布的消息, 第一个频道订阅之后发送给它
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		topics: map[Topic]*memoryTopic{},
+	}
+}
+
+func (b *MemoryBackend) topic(topic Topic) *memoryTopic {
+	t, ok := b.topics[topic]
+
+	if !ok {
+		t = &memoryTopic{channels: map[Chanel]chan *Message{}}
+		b.topics[topic] = t
+	}
+
+	return t
+}
+
+func (b *MemoryBackend) Publish(topic Topic, body []byte) error {
+	if len(body) == 0 {
+		return errors.New("message can not be empty")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(topic)
+
+	if len(t.channels) == 0 {
+		if len(t.backlog) >= MemoryQueueSize {
+			return ErrQueueFull
+		}
+
+		t.backlog = append(t.backlog, body)
+
+		return nil
+	}
+
+	// 先确认每个频道都有空位再发送, 否则部分频道收到消息之后返回错误, 发布者重试时这些频道会收到重复的消息
+	// 所有写入频道的操作都持有锁, 所以检查之后空位不会被占用
+	for _, queue := range t.channels {
+		if len(queue) >= cap(queue) {
+			return ErrQueueFull
+		}
+	}
+
+	for _, queue := range t.channels {
+		queue <- &Message{Body: body}
+	}
+
+	return nil
+}
+
+// 尝试把重试的消息加入频道, 和发布消息一样持有锁
+func (b *MemoryBackend) offer(queue chan *Message, msg *Message) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case queue <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *MemoryBackend) Subscribe(topic Topic, channel Chanel, concurrency int, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+
+	t := b.topic(topic)
+
+	queue, ok := t.channels[channel]
+
+	if !ok {
+		queue = make(chan *Message, MemoryQueueSize)
+		t.channels[channel] = queue
+
+		for _, body := range t.backlog {
+			queue <- &Message{Body: body}
+		}
+
+		t.backlog = nil
+	}
+
+	b.mu.Unlock()
+
+	s := &memorySubscription{
+		backend: b,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				// 优先检查是否已经停止, 停止之后不再处理新的消息
+				select {
+				case <-s.stop:
+					return
+				default:
+				}
+
+				select {
+				case <-s.stop:
+					return
+				case msg := <-queue:
+					s.handle(queue, msg, handler)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(s.done)
+	}()
+
+	return s, nil
+}
+
+type memorySubscription struct {
+	backend  *MemoryBackend
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (s *memorySubscription) handle(queue chan *Message, msg *Message, handler Handler) {
+	m := &Message{Body: msg.Body, Attempts: msg.Attempts + 1}
+
+	handler(m)
+
+	if !m.requeue {
+		return
+	}
+
+	retry := &Message{Body: m.Body, Attempts: m.Attempts}
+
+	// 队列已满时等待空位, 超时之后才放弃, 并记录下来
+	time.AfterFunc(m.delay, func() {
+		deadline := time.Now().Add(MemoryRequeueTimeout)
+
+		for !s.backend.offer(queue, retry) {
+			if time.Now().After(deadline) {
+				log.Printf("进程内的消息队列已满, 丢弃了第 %d 次处理失败的消息: %s\n", retry.Attempts, retry.Body)
+				return
+			}
+
+			time.Sleep(memoryRequeueInterval)
+		}
+	})
+}
+
+func (s *memorySubscription) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *memorySubscription) Done() <-chan struct{} {
+	return s.done
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_test
+
+import (
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, c <-chan message_queue.Message) message_queue.Message {
+	select {
+	case msg := <-c:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+		return message_queue.Message{}
+	}
+}
+
+func TestMemoryBackend(t *testing.T) {
+	backend := message_queue.NewMemoryBackend()
+
+	// 订阅之前发布的消息会发送给第一个订阅的频道
+	assert.Nil(t, backend.Publish("test", []byte("before")))
+
+	// 不能发布空的消息
+	assert.NotNil(t, backend.Publish("test", nil))
+
+	a := make(chan message_queue.Message, 10)
+	b := make(chan message_queue.Message, 10)
+
+	subA, err := backend.Subscribe("test", "a", 2, func(msg *message_queue.Message) {
+		a <- *msg
+
+		// 第一次处理时要求重试
+		if string(msg.Body) == "retry" && msg.Attempts == 1 {
+			msg.Requeue(time.Millisecond * 10)
+		}
+	})
+
+	assert.Nil(t, err)
+
+	subB, err := backend.Subscribe("test", "b", 1, func(msg *message_queue.Message) {
+		b <- *msg
+	})
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "before", string(receive(t, a).Body))
+
+	// 每个频道都会收到消息
+	assert.Nil(t, backend.Publish("test", []byte("retry")))
+
+	msg := receive(t, a)
+	assert.Equal(t, "retry", string(msg.Body))
+	assert.Equal(t, uint16(1), msg.Attempts)
+
+	msg = receive(t, a)
+	assert.Equal(t, "retry", string(msg.Body))
+	assert.Equal(t, uint16(2), msg.Attempts)
+
+	msg = receive(t, b)
+	assert.Equal(t, "retry", string(msg.Body))
+	assert.Equal(t, uint16(1), msg.Attempts)
+
+	subA.Stop()
+	subB.Stop()
+
+	select {
+	case <-subA.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	select {
+	case <-subB.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	// 停止之后不再处理新的消息
+	assert.Nil(t, backend.Publish("test", []byte("after")))
+
+	time.Sleep(time.Millisecond * 50)
+
+	assert.Len(t, a, 0)
+	assert.Len(t, b, 0)
+}
+
+func TestMemoryBackendFull(t *testing.T) {
+	backend := message_queue.NewMemoryBackend()
+
+	release := make(chan struct{})
+
+	// 频道 b 阻塞, 直到队列占满
+	subB, err := backend.Subscribe("test_full", "b", 1, func(msg *message_queue.Message) {
+		<-release
+	})
+
+	assert.Nil(t, err)
+
+	for backend.Publish("test_full", []byte("message")) == nil {
+	}
+
+	// 频道 a 的队列是空的
+	a := make(chan message_queue.Message, 1)
+
+	subA, err := backend.Subscribe("test_full", "a", 1, func(msg *message_queue.Message) {
+		a <- *msg
+	})
+
+	assert.Nil(t, err)
+
+	defer func() {
+		subA.Stop()
+		subB.Stop()
+		close(release)
+	}()
+
+	// 有一个频道已满时, 其他频道也不会收到这条消息, 否则发布者重试时会收到重复的消息
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, message_queue.ErrQueueFull, backend.Publish("test_full", []byte("message")))
+	}
+
+	time.Sleep(time.Millisecond * 50)
+
+	assert.Len(t, a, 0)
+}
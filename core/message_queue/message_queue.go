@@ -2,14 +2,9 @@
 package message_queue
 
 import (
-	"encoding/json"
 	"github.com/axetroy/go-server/core/config"
-	"github.com/axetroy/go-server/core/service/email"
-	"github.com/axetroy/go-server/core/service/redis"
 	"github.com/nsqio/go-nsq"
-	"log"
 	"net"
-	"sync"
 	"time"
 )
 
@@ -17,62 +12,78 @@ type Topic string
 type Chanel string
 
 var (
-	TopicSendEmail  Topic       = "send_email"
-	ChanelSendEmail Chanel      = "send_email"
-	Address         string      // 消息队列地址
-	Config          *nsq.Config // 消息队列的配置
+	TopicDelivery          Topic       = "send_delivery" // 发送邮件和短信
+	ChanelDelivery         Chanel      = "send_delivery"
+	TopicDeliveryDead      Topic       = "send_delivery_dead" // 重试多次仍然失败的邮件和短信, 即死信队列
+	TopicSendMessageBatch  Topic       = "send_message_batch"
+	ChanelSendMessageBatch Chanel      = "send_message_batch"
+	TopicScheduleTrigger   Topic       = "schedule_trigger" // 管理员手动触发的定时任务
+	ChanelScheduleTrigger  Chanel      = "schedule_trigger"
+	TopicWebhook           Topic       = "send_webhook" // 发送 webhook
+	ChanelWebhook          Chanel      = "send_webhook"
+	TopicPushNotification  Topic       = "push_notification" // 把系统通知推送给在线的用户
+	ChanelPushNotification Chanel      = "push_notification"
+	Address                string      // 消息队列地址
+	Config                 *nsq.Config // 消息队列的配置
 )
 
-type SendActivationEmailBody struct {
-	Email string `json:"email"` // 要发送的邮箱
-	Code  string `json:"code"`  // 发送的激活码
+type DeliveryBody struct {
+	Id string `json:"id"` // 发送记录的 ID, 发送的内容保存在发送记录中
 }
 
-func init() {
-	host := config.MessageQueue.Host
-	port := config.MessageQueue.Port
-
-	Address = net.JoinHostPort(host, port)
+func (DeliveryBody) Topic() Topic {
+	return TopicDelivery
+}
 
-	Config = nsq.NewConfig()
-	Config.DialTimeout = time.Second * 5
-	Config.MsgTimeout = time.Second * 10
-	Config.ReadTimeout = time.Second * 15
-	Config.WriteTimeout = time.Second * 10
-	Config.HeartbeatInterval = time.Second * 10
+type SendMessageBatchBody struct {
+	Id string `json:"id"` // 群发任务的 ID, 每条消息只发送一批
 }
 
-func RunMessageQueueConsumer() (*nsq.Consumer, error) {
-	wg := &sync.WaitGroup{}
+func (SendMessageBatchBody) Topic() Topic {
+	return TopicSendMessageBatch
+}
 
-	wg.Add(1)
+type WebhookBody struct {
+	Id string `json:"id"` // webhook 发送记录的 ID
+}
 
-	c, err := CreateConsumer(TopicSendEmail, ChanelSendEmail, nsq.HandlerFunc(func(message *nsq.Message) error {
+func (WebhookBody) Topic() Topic {
+	return TopicWebhook
+}
 
-		body := SendActivationEmailBody{}
+type PushNotificationBody struct {
+	Id string `json:"id"` // 系统通知的 ID
+}
 
-		if err := json.Unmarshal(message.Body, &body); err != nil {
-			return err
-		}
+func (PushNotificationBody) Topic() Topic {
+	return TopicPushNotification
+}
 
-		mailer := email.NewMailer()
+type ScheduleTriggerBody struct {
+	Name string `json:"name"` // 定时任务的名称
+}
 
-		// 发送邮件
-		if err := mailer.SendActivationEmail(body.Email, body.Code); err != nil {
-			// 邮件没发出去的话，删除 redis 的 key
-			_ = redis.ClientActivationCode.Del(body.Code).Err()
-		}
+func (ScheduleTriggerBody) Topic() Topic {
+	return TopicScheduleTrigger
+}
 
-		log.Printf("发送验证码 %s 到 %s\n", body.Code, body.Email)
+func init() {
+	host := config.MessageQueue.Host
+	port := config.MessageQueue.Port
 
-		return nil
-	}))
+	Address = net.JoinHostPort(host, port)
 
-	if err != nil {
-		return c, err
-	}
+	Config = NewConfig()
+}
 
-	wg.Wait()
+// 创建默认的配置, 每个任务的消费者使用单独的配置, 互不影响
+func NewConfig() *nsq.Config {
+	c := nsq.NewConfig()
+	c.DialTimeout = time.Second * 5
+	c.MsgTimeout = time.Minute // 超过这个时间没有响应的消息会被重新投递, 处理中的消息会定时 Touch
+	c.ReadTimeout = time.Second * 15
+	c.WriteTimeout = time.Second * 10
+	c.HeartbeatInterval = time.Second * 10
 
-	return c, nil
+	return c
 }
@@ -0,0 +1,151 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"errors"
+	"github.com/nsqio/go-nsq"
+	"sync"
+	"time"
+)
+
+// 使用 nsq 的消息队列
+type NSQBackend struct {
+	address  string
+	config   *nsq.Config
+	producer *nsq.Producer
+	mu       sync.Mutex
+}
+
+// 创建 nsq 的消息队列, 第一次发布消息时才会连接 nsqd
+func NewNSQBackend(address string, config *nsq.Config) *NSQBackend {
+	return &NSQBackend{
+		address: address,
+		config:  config,
+	}
+}
+
+func (b *NSQBackend) getProducer() (*nsq.Producer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.producer != nil {
+		return b.producer, nil
+	}
+
+	p, err := nsq.NewProducer(b.address, b.config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.producer = p
+
+	return p, nil
+}
+
+func (b *NSQBackend) Publish(topic Topic, body []byte) (err error) {
+	var (
+		producer        *nsq.Producer
+		maxConnectTimes = 5
+		connectTimes    = 0
+	)
+
+	//不能发布空串，否则会导致 error
+	if len(body) == 0 {
+		err = errors.New("message can not be empty")
+		return
+	}
+
+	if producer, err = b.getProducer(); err != nil {
+		return
+	}
+
+	// 确保链接可用
+	for {
+		if producer.Ping() == nil {
+			break
+		}
+		if connectTimes >= maxConnectTimes {
+			err = errors.New("publish timeout")
+			return
+		}
+		connectTimes = connectTimes + 1
+	}
+
+	return producer.Publish(string(topic), body)
+}
+
+func (b *NSQBackend) Subscribe(topic Topic, channel Chanel, concurrency int, handler Handler) (Subscription, error) {
+	cfg := NewConfig()
+	cfg.MaxInFlight = concurrency
+	// 由任务自己决定重试的次数, nsq 不再丢弃多次失败的消息
+	cfg.MaxAttempts = 0
+
+	c, err := nsq.NewConsumer(string(topic), string(channel), cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddConcurrentHandlers(nsq.HandlerFunc(func(msg *nsq.Message) error {
+		m := &Message{Body: msg.Body, Attempts: msg.Attempts}
+
+		done := make(chan struct{})
+
+		go touch(msg, cfg.MsgTimeout/2, done)
+
+		handler(m)
+
+		close(done)
+
+		if m.requeue {
+			msg.DisableAutoResponse()
+			msg.RequeueWithoutBackoff(m.delay)
+		}
+
+		return nil
+	}), concurrency)
+
+	if err = c.ConnectToNSQD(b.address); err != nil {
+		c.Stop()
+		return nil, err
+	}
+
+	s := &nsqSubscription{consumer: c, done: make(chan struct{})}
+
+	go func() {
+		<-c.StopChan
+		close(s.done)
+	}()
+
+	return s, nil
+}
+
+// 处理时间较长的消息定时告诉 nsqd 还在处理, 否则超过 MsgTimeout 之后会被重新投递给其他消费者
+func touch(msg *nsq.Message, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			msg.Touch()
+		}
+	}
+}
+
+type nsqSubscription struct {
+	consumer *nsq.Consumer
+	done     chan struct{}
+}
+
+func (s *nsqSubscription) Stop() {
+	s.consumer.Stop()
+}
+
+func (s *nsqSubscription) Done() <-chan struct{} {
+	return s.done
+}
@@ -0,0 +1,324 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/util"
+	goRedis "github.com/go-redis/redis"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RedisStreamMaxLen       = 100000          // 每个主题大约保留的消息数, 超过之后删除最早的消息
+	RedisVisibilityTimeout  = time.Minute * 5 // 超过这个时间没有确认的消息, 会被其他消费者重新处理
+	redisBlockTimeout       = time.Second
+	redisPollInterval       = time.Second
+	redisReclaimEveryNPolls = 30
+)
+
+// 使用 Redis Streams 的消息队列, 主题对应一个 stream, 频道对应 stream 的消费组
+// 消息至少会被处理一次, 消费者崩溃时未确认的消息会在超时之后被其他消费者重新处理
+type RedisBackend struct {
+	client *goRedis.Client
+	prefix string
+}
+
+func NewRedisBackend() *RedisBackend {
+	return &RedisBackend{
+		client: redis.ClientQueue,
+		prefix: "queue:",
+	}
+}
+
+func (b *RedisBackend) stream(topic Topic) string {
+	return b.prefix + string(topic)
+}
+
+// 等待重试的消息, 存储结构 member: 消息 ID, score: 重试的时间
+func (b *RedisBackend) retryKey(topic Topic, channel Chanel) string {
+	return b.prefix + string(topic) + ":" + string(channel) + ":retry"
+}
+
+func (b *RedisBackend) Publish(topic Topic, body []byte) error {
+	if len(body) == 0 {
+		return errors.New("message can not be empty")
+	}
+
+	return b.client.XAdd(&goRedis.XAddArgs{
+		Stream:       b.stream(topic),
+		MaxLenApprox: RedisStreamMaxLen,
+		Values: map[string]interface{}{
+			"body": body,
+		},
+	}).Err()
+}
+
+func (b *RedisBackend) Subscribe(topic Topic, channel Chanel, concurrency int, handler Handler) (Subscription, error) {
+	stream := b.stream(topic)
+
+	if err := b.client.XGroupCreateMkStream(stream, string(channel), "0").Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	s := &redisSubscription{
+		client:   b.client,
+		stream:   stream,
+		group:    string(channel),
+		retryKey: b.retryKey(topic, channel),
+		consumer: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), util.GenerateId()),
+		handler:  handler,
+		tasks:    make(chan redisTask),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	var producers, workers sync.WaitGroup
+
+	producers.Add(2)
+
+	go func() {
+		defer producers.Done()
+		s.read(int64(concurrency))
+	}()
+
+	go func() {
+		defer producers.Done()
+		s.schedule()
+	}()
+
+	go func() {
+		producers.Wait()
+		close(s.tasks)
+	}()
+
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for task := range s.tasks {
+				s.process(task)
+			}
+		}()
+	}
+
+	go func() {
+		workers.Wait()
+		close(s.done)
+	}()
+
+	return s, nil
+}
+
+type redisTask struct {
+	id       string
+	body     []byte
+	attempts uint16
+}
+
+type redisSubscription struct {
+	client   *goRedis.Client
+	stream   string
+	group    string
+	retryKey string
+	consumer string
+	handler  Handler
+	tasks    chan redisTask
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (s *redisSubscription) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// 读取新的消息
+func (s *redisSubscription) read(count int64) {
+	for !s.stopped() {
+		streams, err := s.client.XReadGroup(&goRedis.XReadGroupArgs{
+			Group:    s.group,
+			Consumer: s.consumer,
+			Streams:  []string{s.stream, ">"},
+			Count:    count,
+			Block:    redisBlockTimeout,
+		}).Result()
+
+		if err != nil {
+			if err != goRedis.Nil {
+				log.Printf("读取消息队列 %s 失败: %v\n", s.stream, err)
+
+				select {
+				case <-s.stop:
+				case <-time.After(redisPollInterval):
+				}
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				s.tasks <- redisTask{id: msg.ID, body: bodyOf(msg), attempts: 1}
+			}
+		}
+	}
+}
+
+// 定时处理到期的重试, 并接管超时未确认的消息
+func (s *redisSubscription) schedule() {
+	ticker := time.NewTicker(redisPollInterval)
+
+	defer ticker.Stop()
+
+	polls := 0
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.retryDue()
+
+			polls++
+
+			if polls%redisReclaimEveryNPolls == 0 {
+				s.reclaim()
+			}
+		}
+	}
+}
+
+func (s *redisSubscription) retryDue() {
+	ids, err := s.client.ZRangeByScore(s.retryKey, goRedis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10),
+		Count: 100,
+	}).Result()
+
+	if err != nil {
+		log.Printf("读取消息队列 %s 的重试失败: %v\n", s.stream, err)
+		return
+	}
+
+	for _, id := range ids {
+		// 多个消费者同时处理时, 只有删除成功的消费者会重试这条消息
+		if s.client.ZRem(s.retryKey, id).Val() == 1 {
+			s.claim(id, 0)
+		}
+	}
+}
+
+func (s *redisSubscription) reclaim() {
+	pending, err := s.client.XPendingExt(&goRedis.XPendingExtArgs{
+		Stream: s.stream,
+		Group:  s.group,
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+	}).Result()
+
+	if err != nil {
+		log.Printf("读取消息队列 %s 未确认的消息失败: %v\n", s.stream, err)
+		return
+	}
+
+	for _, p := range pending {
+		if p.Idle < RedisVisibilityTimeout {
+			continue
+		}
+
+		// 等待重试的消息不需要接管
+		if s.client.ZScore(s.retryKey, p.Id).Err() == nil {
+			continue
+		}
+
+		s.claim(p.Id, RedisVisibilityTimeout)
+	}
+}
+
+// 接管一条消息并交给当前的消费者处理, minIdle 保证同一条消息只会被一个消费者接管
+func (s *redisSubscription) claim(id string, minIdle time.Duration) {
+	messages, err := s.client.XClaim(&goRedis.XClaimArgs{
+		Stream:   s.stream,
+		Group:    s.group,
+		Consumer: s.consumer,
+		MinIdle:  minIdle,
+		Messages: []string{id},
+	}).Result()
+
+	if err != nil {
+		log.Printf("接管消息 %s 失败: %v\n", id, err)
+		return
+	}
+
+	for _, msg := range messages {
+		attempts := uint16(1)
+
+		// 投递的次数即处理的次数
+		if pending, err := s.client.XPendingExt(&goRedis.XPendingExtArgs{
+			Stream: s.stream,
+			Group:  s.group,
+			Start:  msg.ID,
+			End:    msg.ID,
+			Count:  1,
+		}).Result(); err == nil && len(pending) > 0 {
+			attempts = uint16(pending[0].RetryCount)
+		}
+
+		s.tasks <- redisTask{id: msg.ID, body: bodyOf(msg), attempts: attempts}
+	}
+}
+
+func (s *redisSubscription) process(task redisTask) {
+	m := &Message{Body: task.body, Attempts: task.attempts}
+
+	s.handler(m)
+
+	var err error
+
+	if m.requeue {
+		err = s.client.ZAdd(s.retryKey, goRedis.Z{
+			Score:  float64(time.Now().Add(m.delay).UnixNano() / int64(time.Millisecond)),
+			Member: task.id,
+		}).Err()
+	} else {
+		err = s.client.XAck(s.stream, s.group, task.id).Err()
+	}
+
+	if err != nil {
+		log.Printf("确认消息 %s 失败: %v\n", task.id, err)
+	}
+}
+
+func (s *redisSubscription) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *redisSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func bodyOf(msg goRedis.XMessage) []byte {
+	if v, ok := msg.Values["body"].(string); ok {
+		return []byte(v)
+	}
+
+	return nil
+}
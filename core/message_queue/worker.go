@@ -0,0 +1,175 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// 任务的统计数据, 从进程启动开始计算
+type Metrics struct {
+	Topic     Topic `json:"topic"`     // 主题
+	Processed int64 `json:"processed"` // 处理成功的次数
+	Failed    int64 `json:"failed"`    // 处理失败的次数
+	Retried   int64 `json:"retried"`   // 重新投递的次数
+	Dead      int64 `json:"dead"`      // 加入死信队列的次数
+	InFlight  int64 `json:"in_flight"` // 正在处理的任务数
+}
+
+var (
+	metrics   = map[Topic]*Metrics{}
+	metricsMu sync.Mutex
+)
+
+func metricsOf(topic Topic) *Metrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	m, ok := metrics[topic]
+
+	if !ok {
+		m = &Metrics{Topic: topic}
+		metrics[topic] = m
+	}
+
+	return m
+}
+
+// 获取所有任务的统计数据
+func GetMetrics() []Metrics {
+	list := make([]Metrics, 0)
+
+	for _, def := range Definitions() {
+		m := metricsOf(def.Topic)
+
+		list = append(list, Metrics{
+			Topic:     def.Topic,
+			Processed: atomic.LoadInt64(&m.Processed),
+			Failed:    atomic.LoadInt64(&m.Failed),
+			Retried:   atomic.LoadInt64(&m.Retried),
+			Dead:      atomic.LoadInt64(&m.Dead),
+			InFlight:  atomic.LoadInt64(&m.InFlight),
+		})
+	}
+
+	return list
+}
+
+// 消费所有已注册的任务
+type Worker struct {
+	subscriptions []Subscription
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+// 为每个已注册的任务订阅默认的消息队列
+func StartWorker() (*Worker, error) {
+	return StartWorkerWithBackend(DefaultBackend)
+}
+
+// 为每个已注册的任务订阅指定的消息队列
+func StartWorkerWithBackend(backend Backend) (w *Worker, err error) {
+	w = &Worker{}
+
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+
+	defer func() {
+		if err != nil {
+			w.Stop(context.Background())
+		}
+	}()
+
+	for _, def := range Definitions() {
+		var s Subscription
+
+		if s, err = backend.Subscribe(def.Topic, def.Channel, def.Concurrency, NewHandler(w.ctx, backend, def)); err != nil {
+			return
+		}
+
+		w.subscriptions = append(w.subscriptions, s)
+	}
+
+	return
+}
+
+// 停止接收新的任务, 并等待正在处理的任务完成
+// ctx 结束时仍未完成的任务会收到取消的信号, 消息队列会在超时之后重新投递这些任务
+func (w *Worker) Stop(ctx context.Context) {
+	for _, s := range w.subscriptions {
+		s.Stop()
+	}
+
+	for _, s := range w.subscriptions {
+		select {
+		case <-s.Done():
+		case <-ctx.Done():
+			log.Println("等待任务完成超时")
+			w.cancel()
+			return
+		}
+	}
+
+	w.cancel()
+}
+
+// 创建任务的处理函数, 负责解析消息, 统计, 重试和加入死信队列
+// 死信发布到消费任务的消息队列, 而不是默认的消息队列
+func NewHandler(ctx context.Context, backend Backend, def Definition) Handler {
+	if def.Backoff == nil {
+		def.Backoff = DefaultBackoff
+	}
+
+	m := metricsOf(def.Topic)
+
+	return func(msg *Message) {
+		atomic.AddInt64(&m.InFlight, 1)
+		defer atomic.AddInt64(&m.InFlight, -1)
+
+		job := def.New()
+
+		if err := json.Unmarshal(msg.Body, job); err != nil {
+			// 无法解析的消息重试也没有意义
+			log.Printf("任务 %s 的消息无法解析: %v\n", def.Topic, err)
+			atomic.AddInt64(&m.Failed, 1)
+			deadLetter(backend, def, m, msg)
+			return
+		}
+
+		err := def.Handler(ctx, job)
+
+		if err == nil {
+			atomic.AddInt64(&m.Processed, 1)
+			return
+		}
+
+		atomic.AddInt64(&m.Failed, 1)
+
+		log.Printf("任务 %s 第 %d 次处理失败: %v\n", def.Topic, msg.Attempts, err)
+
+		if def.MaxAttempts > 0 && msg.Attempts >= def.MaxAttempts {
+			deadLetter(backend, def, m, msg)
+			return
+		}
+
+		delay := def.Backoff(msg.Attempts)
+
+		if e, ok := err.(*retryError); ok {
+			delay = e.after
+		}
+
+		atomic.AddInt64(&m.Retried, 1)
+
+		msg.Requeue(delay)
+	}
+}
+
+func deadLetter(backend Backend, def Definition, m *Metrics, msg *Message) {
+	atomic.AddInt64(&m.Dead, 1)
+
+	if err := backend.Publish(DeadLetterTopic(def.Topic), msg.Body); err != nil {
+		log.Printf("任务 %s 加入死信队列失败: %v\n", def.Topic, err)
+	}
+}
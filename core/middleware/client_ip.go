@@ -0,0 +1,101 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package middleware
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/gin-gonic/gin"
+	"net"
+	"strings"
+)
+
+// 可信的反向代理, 默认为空, 即不信任任何请求头中的 IP
+var TrustedProxies = ParseNetworks(config.Common.TrustedProxies)
+
+// 把 IP 或者 CIDR 解析成网段, 无效的值会被忽略
+func ParseNetworks(list []string) []*net.IPNet {
+	result := make([]*net.IPNet, 0)
+
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+
+		if v == "" {
+			continue
+		}
+
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+
+			if ip == nil {
+				continue
+			}
+
+			if ip.To4() != nil {
+				v = v + "/32"
+			} else {
+				v = v + "/128"
+			}
+		}
+
+		if _, network, err := net.ParseCIDR(v); err == nil {
+			result = append(result, network)
+		}
+	}
+
+	return result
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 客户端的 IP 地址
+// 请求头可以被客户端伪造, 只有直接连接的地址是可信的代理时才读取 X-Forwarded-For,
+// 并且从右往左跳过可信的代理, 取第一个不可信的地址
+func ClientIP(c *gin.Context) string {
+	remote, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+
+	if err != nil {
+		return ""
+	}
+
+	ip := net.ParseIP(remote)
+
+	if ip == nil || !isTrustedProxy(ip) {
+		return remote
+	}
+
+	forwarded := c.GetHeader("X-Forwarded-For")
+
+	if forwarded == "" {
+		if real := net.ParseIP(strings.TrimSpace(c.GetHeader("X-Real-Ip"))); real != nil {
+			return real.String()
+		}
+
+		return remote
+	}
+
+	list := strings.Split(forwarded, ",")
+
+	for i := len(list) - 1; i >= 0; i-- {
+		next := net.ParseIP(strings.TrimSpace(list[i]))
+
+		// 无效的地址之前的内容都不可信
+		if next == nil {
+			break
+		}
+
+		ip = next
+
+		if !isTrustedProxy(ip) {
+			break
+		}
+	}
+
+	return ip.String()
+}
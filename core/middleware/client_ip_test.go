@@ -0,0 +1,49 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package middleware_test
+
+import (
+	"github.com/axetroy/go-server/core/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func clientIP(remoteAddr string, headers map[string]string) string {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = remoteAddr
+
+	for k, v := range headers {
+		c.Request.Header.Set(k, v)
+	}
+
+	return middleware.ClientIP(c)
+}
+
+func TestClientIP(t *testing.T) {
+	defer func() {
+		middleware.TrustedProxies = middleware.ParseNetworks([]string{})
+	}()
+
+	// 没有可信的代理时忽略请求头
+	middleware.TrustedProxies = middleware.ParseNetworks([]string{})
+
+	assert.Equal(t, "1.2.3.4", clientIP("1.2.3.4:5678", map[string]string{"X-Forwarded-For": "8.8.8.8"}))
+	assert.Equal(t, "1.2.3.4", clientIP("1.2.3.4:5678", map[string]string{"X-Real-Ip": "8.8.8.8"}))
+
+	middleware.TrustedProxies = middleware.ParseNetworks([]string{"10.0.0.0/8", "127.0.0.1", "invalid"})
+
+	// 不是来自可信的代理
+	assert.Equal(t, "1.2.3.4", clientIP("1.2.3.4:5678", map[string]string{"X-Forwarded-For": "8.8.8.8"}))
+
+	// 来自可信的代理, 客户端自己添加的地址在最左边, 不会被采用
+	assert.Equal(t, "8.8.8.8", clientIP("127.0.0.1:5678", map[string]string{"X-Forwarded-For": "6.6.6.6, 8.8.8.8"}))
+	assert.Equal(t, "8.8.8.8", clientIP("127.0.0.1:5678", map[string]string{"X-Forwarded-For": "6.6.6.6, 8.8.8.8, 10.0.0.2"}))
+	assert.Equal(t, "8.8.8.8", clientIP("10.0.0.1:5678", map[string]string{"X-Real-Ip": "8.8.8.8"}))
+
+	// 无效的地址
+	assert.Equal(t, "127.0.0.1", clientIP("127.0.0.1:5678", map[string]string{"X-Forwarded-For": "8.8.8.8, unknown"}))
+	assert.Equal(t, "127.0.0.1", clientIP("127.0.0.1:5678", map[string]string{}))
+}
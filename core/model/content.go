@@ -0,0 +1,21 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+type ContentFormat string
+
+const (
+	ContentFormatMarkdown ContentFormat = "markdown" // Markdown
+	ContentFormatHTML     ContentFormat = "html"     // HTML, 展示之前会过滤掉不安全的标签和属性
+	ContentFormatPlain    ContentFormat = "plain"    // 纯文本
+)
+
+var ContentFormats = []ContentFormat{ContentFormatMarkdown, ContentFormatHTML, ContentFormatPlain}
+
+func IsValidContentFormat(f ContentFormat) bool {
+	for _, v := range ContentFormats {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type DeliveryStatus int
+
+const (
+	DeliveryStatusDead    DeliveryStatus = -1 // 重试多次之后仍然失败, 已加入死信队列
+	DeliveryStatusPending DeliveryStatus = 0  // 等待发送或者等待重试
+	DeliveryStatusSent    DeliveryStatus = 1  // 发送成功
+)
+
+// 邮件和短信的发送记录, 每一封邮件或者每一条短信都会记录, 由消息队列发送
+type Delivery struct {
+	Id        string         `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 记录ID
+	Kind      string         `gorm:"not null;index;type:varchar(32)" json:"kind"`                  // 发送的类型, 决定了 payload 的格式
+	Channel   string         `gorm:"not null;index;type:varchar(16)" json:"channel"`               // 渠道, email 或者 sms
+	Recipient string         `gorm:"not null;index;type:varchar(255)" json:"recipient"`            // 接收的邮箱或者手机号, 多个以 `,` 分隔
+	Payload   string         `gorm:"not null;type:text" json:"payload"`                            // 发送的内容, JSON 格式
+	Status    DeliveryStatus `gorm:"not null;index" json:"status"`                                 // 发送状态
+	Attempts  int            `gorm:"not null;default:0" json:"attempts"`                           // 已经尝试发送的次数
+	Error     string         `gorm:"not null;type:text" json:"error"`                              // 最后一次发送失败的原因
+	SentAt    *time.Time     `gorm:"null" json:"sent_at"`                                          // 发送成功的时间
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (d *Delivery) TableName() string {
+	return "delivery"
+}
+
+func (d *Delivery) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
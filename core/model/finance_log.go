@@ -4,6 +4,7 @@ package model
 import (
 	"github.com/axetroy/go-server/core/util"
 	"github.com/jinzhu/gorm"
+	"strings"
 	"time"
 )
 
@@ -33,6 +34,9 @@ type FinanceLog struct {
 	AfterFrozen     float64     `gorm:"not null" json:"after_frozen"`                                 // 这条流水后的冻结余额
 	Type            FinanceType `gorm:"not null" json:"status"`                                       // 流水类型
 	Note            *string     `gorm:"null;type:varchar(128)" json:"note"`                           // 流水备注
+	PrevHash        string      `gorm:"not null;default:'';type:varchar(64)" json:"prev_hash"`        // 上一条流水的哈希, 同一用户同一币种的流水组成一条哈希链
+	Hash            string      `gorm:"not null;default:'';type:varchar(64)" json:"hash"`             // 这条流水的哈希, 由流水内容和上一条流水的哈希签名而成
+	Seq             int64       `gorm:"not null;default:0;index" json:"seq"`                          // 在哈希链中的序号, 从 1 开始, 写入时在锁内递增, 不依赖服务器的时钟
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       *time.Time `sql:"index" json:"-"`
@@ -50,6 +54,40 @@ type FinanceLogCoin struct {
 	FinanceLog
 }
 
+// 计算流水的哈希
+// 创建时间由数据库写入, 精度与程序中的不一致, 所以不参与计算, 流水的先后顺序由 seq 和 prev_hash 保证
+func (log *FinanceLog) GenerateHash() (string, error) {
+	note := ""
+
+	if log.Note != nil {
+		note = *log.Note
+	}
+
+	raw := strings.Join([]string{
+		log.Id,
+		strings.ToUpper(log.Currency),
+		log.OrderId,
+		log.Uid,
+		util.FloatToStr(log.BeforeBalance),
+		util.FloatToStr(log.BalanceMutation),
+		util.FloatToStr(log.AfterBalance),
+		util.FloatToStr(log.BeforeFrozen),
+		util.FloatToStr(log.FrozenMutation),
+		util.FloatToStr(log.AfterFrozen),
+		string(log.Type),
+		note,
+		log.PrevHash,
+	}, "|")
+
+	return util.Signature(raw)
+}
+
+// 锁住某个用户某个币种的哈希链, 写入流水之前在事务中调用, 事务结束时自动释放
+// 用户的第一条流水之前没有可以锁的行, 所以使用 advisory lock
+func LockFinanceChain(tx *gorm.DB, currency string, uid string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "finance_log:"+strings.ToLower(currency)+":"+uid).Error
+}
+
 func (news *FinanceLog) BeforeCreate(scope *gorm.Scope) error {
 	return scope.SetColumn("id", util.GenerateId())
 }
@@ -0,0 +1,44 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 每日财务报表, 每个币种每天一条
+type FinanceReport struct {
+	Id             string  `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`                            // 报表ID
+	Date           string  `gorm:"not null;unique_index:idx_finance_report_date_currency;type:varchar(10)" json:"date"`     // 报表日期, 格式 2006-01-02
+	Currency       string  `gorm:"not null;unique_index:idx_finance_report_date_currency;type:varchar(16)" json:"currency"` // 币种
+	TotalBalance   float64 `gorm:"not null;type:numeric" json:"total_balance"`                                              // 生成报表时所有钱包的可用余额之和
+	TotalFrozen    float64 `gorm:"not null;type:numeric" json:"total_frozen"`                                               // 生成报表时所有钱包的冻结余额之和
+	WalletCount    int64   `gorm:"not null" json:"wallet_count"`                                                            // 钱包总数
+	NewWallets     int64   `gorm:"not null" json:"new_wallets"`                                                             // 当天新增的钱包数
+	TransferCount  int64   `gorm:"not null" json:"transfer_count"`                                                          // 当天的转账笔数
+	TransferVolume float64 `gorm:"not null;type:numeric" json:"transfer_volume"`                                            // 当天的转账总量
+	TopMovers      string  `gorm:"not null;type:text" json:"top_movers"`                                                    // 当天资金变动最大的用户, JSON 格式
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// 订阅每日财务报表的管理员
+type FinanceReportSubscription struct {
+	Id        string `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"` // 管理员ID
+	Email     string `gorm:"not null;type:varchar(255)" json:"email"`                      // 接收报表的邮箱
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (news *FinanceReport) TableName() string {
+	return "finance_report"
+}
+
+func (news *FinanceReport) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
+
+func (news *FinanceReportSubscription) TableName() string {
+	return "finance_report_subscription"
+}
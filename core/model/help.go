@@ -22,10 +22,13 @@ type Help struct {
 	Id        string         `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"` // 帮助文章ID
 	Title     string         `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 帮助文章标题
 	Content   string         `gorm:"not null;type:text" json:"content"`                            // 帮助文章内容
+	Format    ContentFormat  `gorm:"not null;default:'html';type:varchar(16)" json:"format"`       // 帮助文章内容的格式
+	Excerpt   string         `gorm:"not null;default:'';type:varchar(255)" json:"excerpt"`         // 帮助文章的纯文本摘要, 用于列表展示
 	Tags      pq.StringArray `gorm:"type:varchar(32)[]" json:"tags"`                               // 帮助文章的标签
 	Status    HelpStatus     `gorm:"not null;type:integer" json:"status"`                          // 帮助文章状态
 	Type      HelpType       `gorm:"not null;type:varchar(32)" json:"type"`                        // 帮助文章的类型
-	ParentId  *string        `gorm:"null;index;type:varchar(32)" json:"parent_id"`                 // 父级 ID，如果有的话                                    // 父级 ID
+	ParentId  *string        `gorm:"null;index;type:varchar(32)" json:"parent_id"`                 // 父级 ID，如果有的话, 只有分类可以作为父级
+	Sort      int            `gorm:"not null;default:0;index" json:"sort"`                         // 同级之间的排序, 越小的越靠前
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time `sql:"index"`
@@ -18,6 +18,8 @@ type Message struct {
 	Uid       string        `gorm:"not null;index;type:varchar(32)" json:"uid"`                   // 这条消息的所有者
 	Title     string        `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 消息标题
 	Content   string        `gorm:"not null;type:text" json:"content"`                            // 消息内容
+	Format    ContentFormat `gorm:"not null;default:'html';type:varchar(16)" json:"format"`       // 消息内容的格式
+	Excerpt   string        `gorm:"not null;default:'';type:varchar(255)" json:"excerpt"`         // 消息内容的纯文本摘要, 用于列表展示
 	Read      bool          `gorm:"not null" json:"read"`                                         // 是否已读
 	ReadAt    *time.Time    `json:"read_at"`                                                      // 已读时间
 	Status    MessageStatus `gorm:"not null" json:"status"`                                       // 消息状态
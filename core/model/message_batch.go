@@ -0,0 +1,57 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"time"
+)
+
+type MessageBatchStatus int
+
+const (
+	MessageBatchStatusCancelled MessageBatchStatus = -1 // 已取消
+	MessageBatchStatusPending   MessageBatchStatus = 0  // 等待发送
+	MessageBatchStatusRunning   MessageBatchStatus = 1  // 发送中
+	MessageBatchStatusFinished  MessageBatchStatus = 2  // 发送完成
+)
+
+// 群发个人消息的任务, 由消息队列按用户 ID 的顺序分批发送
+type MessageBatch struct {
+	Id      string             `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 任务ID
+	Author  string             `gorm:"not null;index;type:varchar(32)" json:"author"`                // 创建任务的管理员
+	Title   string             `gorm:"not null;type:varchar(32)" json:"title"`                       // 消息标题
+	Content string             `gorm:"not null;type:text" json:"content"`                            // 消息内容
+	Format  ContentFormat      `gorm:"not null;default:'html';type:varchar(16)" json:"format"`       // 消息内容的格式
+	Status  MessageBatchStatus `gorm:"not null;index" json:"status"`                                 // 任务状态
+
+	// 发送对象, 为空则不限制, 同时满足所有条件的用户才会收到消息
+	TargetRoles         pq.StringArray `gorm:"null;type:varchar(64)[]" json:"target_roles"` // 拥有其中任意一个角色的用户
+	TargetLevelMin      *int32         `gorm:"null" json:"target_level_min"`                // 用户的最低等级
+	TargetLevelMax      *int32         `gorm:"null" json:"target_level_max"`                // 用户的最高等级
+	TargetRegisterStart *time.Time     `gorm:"null" json:"target_register_start"`           // 在这之后注册的用户
+	TargetRegisterEnd   *time.Time     `gorm:"null" json:"target_register_end"`             // 在这之前注册的用户
+	TargetUsers         pq.StringArray `gorm:"null;type:varchar(32)[]" json:"target_users"` // 指定的用户, 例如从 CSV 导入的用户
+
+	Total      int64      `gorm:"not null;default:0" json:"total"`         // 创建任务时统计的发送对象数量
+	Invalid    int64      `gorm:"not null;default:0" json:"invalid"`       // 导入时无法识别的用户数量
+	Delivered  int64      `gorm:"not null;default:0" json:"delivered"`     // 已经发送的数量
+	Cursor     string     `gorm:"not null;type:varchar(32)" json:"cursor"` // 最后一个已发送的用户 ID
+	FinishedAt *time.Time `gorm:"null" json:"finished_at"`                 // 发送完成或者取消的时间
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time `sql:"index"`
+}
+
+func (m *MessageBatch) TableName() string {
+	return "message_batch"
+}
+
+func (m *MessageBatch) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 邮件和短信的模版, 同一个名称, 渠道和语言只有一个模版
+// 没有在数据库中的模版会使用内置的默认模版
+type MessageTemplate struct {
+	Id         string `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"`               // 模版ID
+	Name       string `gorm:"not null;unique_index:message_template_key;type:varchar(32)" json:"name"`    // 模版名称, 例如 activation
+	Channel    string `gorm:"not null;unique_index:message_template_key;type:varchar(16)" json:"channel"` // 渠道, email 或者 sms
+	Locale     string `gorm:"not null;unique_index:message_template_key;type:varchar(16)" json:"locale"`  // 语言, 例如 zh-CN
+	Subject    string `gorm:"not null;type:varchar(255)" json:"subject"`                                  // 邮件标题
+	Content    string `gorm:"not null;type:text" json:"content"`                                          // 模版内容, 邮件为 HTML, 短信为纯文本
+	Text       string `gorm:"not null;type:text" json:"text"`                                             // 邮件的纯文本内容
+	ExternalId string `gorm:"not null;type:varchar(64)" json:"external_id"`                               // 短信服务商的模版 ID, 为空则使用配置中的模版 ID
+	Version    int    `gorm:"not null" json:"version"`                                                    // 当前的版本号, 每次修改加 1
+	Author     string `gorm:"not null;type:varchar(32)" json:"author"`                                    // 最后修改的管理员
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// 模版的历史版本
+type MessageTemplateVersion struct {
+	Id         string `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 版本ID
+	TemplateId string `gorm:"not null;index;type:varchar(32)" json:"template_id"`           // 模版ID
+	Version    int    `gorm:"not null" json:"version"`                                      // 版本号
+	Subject    string `gorm:"not null;type:varchar(255)" json:"subject"`                    // 邮件标题
+	Content    string `gorm:"not null;type:text" json:"content"`                            // 模版内容
+	Text       string `gorm:"not null;type:text" json:"text"`                               // 邮件的纯文本内容
+	ExternalId string `gorm:"not null;type:varchar(64)" json:"external_id"`                 // 短信服务商的模版 ID
+	Author     string `gorm:"not null;type:varchar(32)" json:"author"`                      // 修改的管理员
+	CreatedAt  time.Time
+}
+
+func (m *MessageTemplate) TableName() string {
+	return "message_template"
+}
+
+func (m *MessageTemplate) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *MessageTemplateVersion) TableName() string {
+	return "message_template_version"
+}
+
+func (m *MessageTemplateVersion) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
@@ -15,12 +15,14 @@ const (
 	NewsTypeNews         NewsType = "news"         // 新闻资讯
 	NewsTypeAnnouncement NewsType = "announcement" // 官方公告
 
-	NewsStatusInActive NewsStatus = -1 // 未启用的状态
-	NewsStatusActive                   // 启用的状态
+	NewsStatusInActive NewsStatus = -1 // 已下线
+	NewsStatusDraft    NewsStatus = 0  // 草稿
+	NewsStatusActive   NewsStatus = 1  // 已发布, 到了发布时间之后用户才能看到
 )
 
 var (
-	NewsTypes = []NewsType{NewsTypeNews, NewsTypeAnnouncement}
+	NewsTypes    = []NewsType{NewsTypeNews, NewsTypeAnnouncement}
+	NewsStatuses = []NewsStatus{NewsStatusInActive, NewsStatusDraft, NewsStatusActive}
 )
 
 func IsValidNewsType(t NewsType) bool {
@@ -32,17 +34,35 @@ func IsValidNewsType(t NewsType) bool {
 	return false
 }
 
+func IsValidNewsStatus(s NewsStatus) bool {
+	for _, v := range NewsStatuses {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 type News struct {
-	Id        string         `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"` // 新闻公告类ID
-	Author    string         `gorm:"not null;index;type:varchar(32)" json:"author"`                // 公告的作者ID
-	Title     string         `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 公告标题
-	Content   string         `gorm:"not null;type:text" json:"content"`                            // 公告内容
-	Type      NewsType       `gorm:"not null;type:varchar(32)" json:"type"`                        // 公告类型
-	Tags      pq.StringArray `gorm:"type:varchar(32)[]" json:"tags"`                               // 公告的标签
-	Status    NewsStatus     `gorm:"not null;type:integer" json:"status"`                          // 公告状态
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time `sql:"index"`
+	Id          string         `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"` // 新闻公告类ID
+	Author      string         `gorm:"not null;index;type:varchar(32)" json:"author"`                // 公告的作者ID
+	Title       string         `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 公告标题
+	Content     string         `gorm:"not null;type:text" json:"content"`                            // 公告内容
+	Format      ContentFormat  `gorm:"not null;default:'html';type:varchar(16)" json:"format"`       // 公告内容的格式
+	Excerpt     string         `gorm:"not null;default:'';type:varchar(255)" json:"excerpt"`         // 公告内容的纯文本摘要, 用于列表展示
+	Type        NewsType       `gorm:"not null;type:varchar(32)" json:"type"`                        // 公告类型
+	Tags        pq.StringArray `gorm:"type:varchar(32)[]" json:"tags"`                               // 公告的标签
+	Status      NewsStatus     `gorm:"not null;type:integer" json:"status"`                          // 公告状态
+	PublishedAt *time.Time     `gorm:"index" json:"published_at"`                                    // 发布时间, 可以是将来的时间
+	Version     int            `gorm:"not null;default:0" json:"version"`                            // 当前的版本号, 每次修改加 1
+	Editor      string         `gorm:"not null;default:'';type:varchar(32)" json:"editor"`           // 最后修改的管理员
+	Views       int64          `gorm:"not null;default:0" json:"views"`                              // 浏览量, 同一个用户一段时间内只计算一次
+	Likes       int64          `gorm:"not null;default:0" json:"likes"`                              // 点赞数
+	Comments    int64          `gorm:"not null;default:0" json:"comments"`                           // 可见的评论和回复数
+	Popularity  int64          `gorm:"not null;default:0;index" json:"popularity"`                   // 热度, 由浏览量, 点赞数和评论数计算
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time `sql:"index"`
 }
 
 func (news *News) TableName() string {
@@ -52,3 +72,28 @@ func (news *News) TableName() string {
 func (news *News) BeforeCreate(scope *gorm.Scope) error {
 	return scope.SetColumn("id", util.GenerateId())
 }
+
+// 新闻公告的历史版本, 每次修改保存一个版本
+type NewsRevision struct {
+	Id          string         `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"`                          // 版本ID
+	NewsId      string         `gorm:"not null;index;unique_index:idx_news_revision_version;type:varchar(32)" json:"news_id"` // 新闻公告ID
+	Version     int            `gorm:"not null;unique_index:idx_news_revision_version" json:"version"`                        // 版本号
+	Title       string         `gorm:"not null;type:varchar(32)" json:"title"`                                                // 公告标题
+	Content     string         `gorm:"not null;type:text" json:"content"`                                                     // 公告内容
+	Format      ContentFormat  `gorm:"not null;default:'html';type:varchar(16)" json:"format"`                                // 公告内容的格式
+	Type        NewsType       `gorm:"not null;type:varchar(32)" json:"type"`                                                 // 公告类型
+	Tags        pq.StringArray `gorm:"type:varchar(32)[]" json:"tags"`                                                        // 公告的标签
+	Status      NewsStatus     `gorm:"not null;type:integer" json:"status"`                                                   // 公告状态
+	PublishedAt *time.Time     `json:"published_at"`                                                                          // 发布时间
+	Changes     pq.StringArray `gorm:"type:varchar(32)[]" json:"changes"`                                                     // 相对上一个版本修改的字段
+	Editor      string         `gorm:"not null;type:varchar(32)" json:"editor"`                                               // 修改的管理员
+	CreatedAt   time.Time
+}
+
+func (r *NewsRevision) TableName() string {
+	return "news_revision"
+}
+
+func (r *NewsRevision) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type NewsCommentStatus int
+
+const (
+	NewsCommentStatusHidden NewsCommentStatus = -1 // 被管理员隐藏
+	NewsCommentStatusActive NewsCommentStatus = 0  // 正常显示
+)
+
+func IsValidNewsCommentStatus(s NewsCommentStatus) bool {
+	return s == NewsCommentStatusHidden || s == NewsCommentStatusActive
+}
+
+// 新闻公告的评论, 回复只有一层, 回复的回复也属于同一条评论
+type NewsComment struct {
+	Id        string            `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 评论ID
+	NewsId    string            `gorm:"not null;index;type:varchar(32)" json:"news_id"`               // 新闻公告ID
+	Uid       string            `gorm:"not null;index;type:varchar(32)" json:"uid"`                   // 评论的用户
+	ParentId  *string           `gorm:"null;index;type:varchar(32)" json:"parent_id"`                 // 所属的评论, 为空则为评论, 否则为回复
+	ReplyUid  *string           `gorm:"null;type:varchar(32)" json:"reply_uid"`                       // 回复的用户
+	Content   string            `gorm:"not null;type:varchar(512)" json:"content"`                    // 评论内容, 纯文本
+	Status    NewsCommentStatus `gorm:"not null;default:0;index" json:"status"`                       // 评论状态
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time `sql:"index"`
+}
+
+func (c *NewsComment) TableName() string {
+	return "news_comment"
+}
+
+func (c *NewsComment) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
+
+// 用户点赞的新闻公告, 新闻公告 ID 和 UID 为联合主键
+type NewsLike struct {
+	NewsId    string `gorm:"primary_key;not null;type:varchar(32)" json:"news_id"`   // 新闻公告ID
+	Uid       string `gorm:"primary_key;not null;index;type:varchar(32)" json:"uid"` // 点赞的用户
+	CreatedAt time.Time
+}
+
+func (l *NewsLike) TableName() string {
+	return "news_like"
+}
+
+// 被禁止评论的用户
+type NewsCommentBan struct {
+	Uid       string     `gorm:"primary_key;not null;type:varchar(32)" json:"uid"` // 被禁言的用户
+	Admin     string     `gorm:"not null;type:varchar(32)" json:"admin"`           // 操作的管理员
+	Reason    string     `gorm:"not null;type:varchar(255)" json:"reason"`         // 禁言的原因
+	ExpiredAt *time.Time `gorm:"null" json:"expired_at"`                           // 解除的时间, 为空则永久禁言
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (b *NewsCommentBan) TableName() string {
+	return "news_comment_ban"
+}
+
+// 是否还在禁言中
+func (b *NewsCommentBan) IsActive(now time.Time) bool {
+	return b.ExpiredAt == nil || b.ExpiredAt.After(now)
+}
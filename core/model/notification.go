@@ -4,6 +4,7 @@ package model
 import (
 	"github.com/axetroy/go-server/core/util"
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"time"
 )
 
@@ -15,22 +16,37 @@ const (
 )
 
 type Notification struct {
-	Id        string             `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 通知ID
-	Author    string             `gorm:"not null;index;type:varchar(32)" json:"Author"`                // 发布这则公告的作者
-	Title     string             `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 公告标题
-	Content   string             `gorm:"not null;type:text" json:"content"`                            // 公告内容
-	Status    NotificationStatus `gorm:"not null" json:"status"`                                       // 公告状态
-	Note      *string            `gorm:"null;type:varchar(255)" json:"note"`                           // 这条通知的备注
+	Id      string             `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 通知ID
+	Author  string             `gorm:"not null;index;type:varchar(32)" json:"Author"`                // 发布这则公告的作者
+	Title   string             `gorm:"not null;index;type:varchar(32)" json:"title"`                 // 公告标题
+	Content string             `gorm:"not null;type:text" json:"content"`                            // 公告内容
+	Format  ContentFormat      `gorm:"not null;default:'html';type:varchar(16)" json:"format"`       // 公告内容的格式
+	Excerpt string             `gorm:"not null;default:'';type:varchar(255)" json:"excerpt"`         // 公告内容的纯文本摘要, 用于列表展示
+	Status  NotificationStatus `gorm:"not null" json:"status"`                                       // 公告状态
+	Note    *string            `gorm:"null;type:varchar(255)" json:"note"`                           // 这条通知的备注
+
+	// 推送对象, 为空则不限制, 同时满足所有条件的用户才能看到这条通知
+	TargetRoles         pq.StringArray `gorm:"null;type:varchar(64)[]" json:"target_roles"` // 拥有其中任意一个角色的用户
+	TargetLevelMin      *int32         `gorm:"null" json:"target_level_min"`                // 用户的最低等级
+	TargetLevelMax      *int32         `gorm:"null" json:"target_level_max"`                // 用户的最高等级
+	TargetRegisterStart *time.Time     `gorm:"null" json:"target_register_start"`           // 在这之后注册的用户
+	TargetRegisterEnd   *time.Time     `gorm:"null" json:"target_register_end"`             // 在这之前注册的用户
+	TargetUsers         pq.StringArray `gorm:"null;type:varchar(32)[]" json:"target_users"` // 指定的用户
+
+	PublishAt *time.Time `gorm:"null;index" json:"publish_at"`         // 发布时间, 为空则立即发布
+	ExpireAt  *time.Time `gorm:"null;index" json:"expire_at"`          // 过期时间, 为空则永不过期
+	Pushed    bool       `gorm:"not null;default:false" json:"pushed"` // 是否已经推送给在线的用户
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time `sql:"index"`
 }
 
 type NotificationMark struct {
-	Id           string       `gorm:"primary_key;not null;unique(uid);index;type:varchar(32)" json:"id"` // 通知ID, 通知 ID 和 UID 为联合唯一
-	Uid          string       `gorm:"not null;index;unique(id);type:varchar(32)" json:"uid"`             // 对应的用户ID, 联合通知ID唯一
-	Read         bool         `gorm:"not null" json:"read"`                                              // 是否已读
-	Notification Notification `gorm:"foreign_key:Id;association_foreign_key:Id" json:"notification"`     // 关联外键
+	Id           string       `gorm:"primary_key;not null;index;type:varchar(32)" json:"id"`         // 通知ID, 通知 ID 和 UID 为联合主键
+	Uid          string       `gorm:"primary_key;not null;index;type:varchar(32)" json:"uid"`        // 对应的用户ID, 通知 ID 和 UID 为联合主键
+	Read         bool         `gorm:"not null" json:"read"`                                          // 是否已读
+	Notification Notification `gorm:"foreign_key:Id;association_foreign_key:Id" json:"notification"` // 关联外键
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time `sql:"index"`
@@ -0,0 +1,23 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// 用户接收通知的偏好, 没有设置过的用户使用默认的偏好
+type NotificationPreference struct {
+	Uid          string         `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"uid"` // 用户ID
+	Transfer     pq.StringArray `gorm:"not null;type:varchar(16)[]" json:"transfer"`                   // 接收转账通知的渠道
+	Security     pq.StringArray `gorm:"not null;type:varchar(16)[]" json:"security"`                   // 接收账号安全通知的渠道
+	Announcement pq.StringArray `gorm:"not null;type:varchar(16)[]" json:"announcement"`               // 接收公告的渠道
+	QuietStart   *string        `gorm:"null;type:varchar(5)" json:"quiet_start"`                       // 免打扰的开始时间, 格式为 15:04
+	QuietEnd     *string        `gorm:"null;type:varchar(5)" json:"quiet_end"`                         // 免打扰的结束时间, 格式为 15:04, 早于开始时间则表示到第二天
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (p *NotificationPreference) TableName() string {
+	return "notification_preference"
+}
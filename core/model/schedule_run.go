@@ -0,0 +1,42 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type ScheduleRunStatus int
+
+const (
+	ScheduleRunStatusFail    ScheduleRunStatus = -1 // 运行失败
+	ScheduleRunStatusRunning ScheduleRunStatus = 0  // 正在运行
+	ScheduleRunStatusSuccess ScheduleRunStatus = 1  // 运行成功
+)
+
+// 定时任务的运行记录
+type ScheduleRun struct {
+	Id         string            `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 记录ID
+	Task       string            `gorm:"not null;index;type:varchar(64)" json:"task"`                  // 任务名称
+	Trigger    string            `gorm:"not null;index;type:varchar(16)" json:"trigger"`               // 触发的方式, schedule 为按计划运行, manual 为管理员手动触发
+	Instance   string            `gorm:"not null;type:varchar(128)" json:"instance"`                   // 运行任务的进程
+	Status     ScheduleRunStatus `gorm:"not null;index" json:"status"`                                 // 运行状态
+	Error      string            `gorm:"not null;type:text" json:"error"`                              // 失败的原因
+	Duration   int64             `gorm:"not null" json:"duration"`                                     // 运行的时间, 单位毫秒
+	StartedAt  time.Time         `gorm:"not null;index" json:"started_at"`                             // 开始的时间
+	FinishedAt *time.Time        `gorm:"null" json:"finished_at"`                                      // 结束的时间
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (s *ScheduleRun) TableName() string {
+	return "schedule_run"
+}
+
+func (s *ScheduleRun) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
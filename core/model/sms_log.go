@@ -0,0 +1,40 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type SMSLogStatus int
+
+const (
+	SMSLogStatusFail    SMSLogStatus = -1 // 服务商返回失败
+	SMSLogStatusSuccess SMSLogStatus = 1  // 服务商已接收
+)
+
+// 每一次调用短信服务商的记录
+type SMSLog struct {
+	Id         string       `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 记录ID
+	Provider   string       `gorm:"not null;index;type:varchar(16)" json:"provider"`              // 短信服务商
+	Phone      string       `gorm:"not null;index;type:varchar(16)" json:"phone"`                 // 接收的手机号
+	Template   string       `gorm:"not null;type:varchar(32)" json:"template"`                    // 模版名称
+	TemplateId string       `gorm:"not null;type:varchar(64)" json:"template_id"`                 // 服务商的模版 ID
+	Content    string       `gorm:"not null;type:text" json:"content"`                            // 使用本地模版渲染的内容, 和实际收到的内容可能不同
+	RequestId  string       `gorm:"not null;index;type:varchar(64)" json:"request_id"`            // 服务商返回的请求 ID, 用于向服务商查询发送状态
+	Status     SMSLogStatus `gorm:"not null;index" json:"status"`                                 // 发送状态
+	Error      string       `gorm:"not null;type:text" json:"error"`                              // 服务商返回的错误
+	CreatedAt  time.Time
+}
+
+func (s *SMSLog) TableName() string {
+	return "sms_log"
+}
+
+func (s *SMSLog) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
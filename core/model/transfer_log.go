@@ -41,8 +41,8 @@ type TransferLog struct {
 	Amount       string         `gorm:"not null;type:numeric" json:"amount"`                          // 转账数量
 	Status       TransferStatus `gorm:"not null" json:"status"`                                       // 转账状态
 	Note         *string        `gorm:"null;type:varchar(128)" json:"note"`                           // 转账备注
-	SnapshotFrom *string        `gorm:"null" json:"-"`                                                // 转账者的钱包快照
-	SnapshotTo   *string        `gorm:"null" json:"-"`                                                // 收款人的钱包快照
+	SnapshotFrom *string        `gorm:"null;type:text" json:"snapshot_from"`                          // 转账者的钱包快照
+	SnapshotTo   *string        `gorm:"null;type:text" json:"snapshot_to"`                            // 收款人的钱包快照
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time `sql:"index" json:"-"`
@@ -0,0 +1,29 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// 用户的收款人, 包含用户保存的收款人和最近转账过的联系人
+type TransferRecipient struct {
+	Id          string     `gorm:"primary_key;unique;not null;index;type:varchar(32)" json:"id"`                            // 数据ID
+	Uid         string     `gorm:"not null;index;unique_index:idx_transfer_recipient;type:varchar(32)" json:"uid"`          // 用户ID, 与收款人联合唯一
+	RecipientId string     `gorm:"not null;index;unique_index:idx_transfer_recipient;type:varchar(32)" json:"recipient_id"` // 收款人的用户ID
+	Recipient   User       `gorm:"foreignkey:RecipientId" json:"-"`                                                         // **外键**
+	Alias       *string    `gorm:"null;type:varchar(32)" json:"alias"`                                                      // 用户给收款人的备注名
+	Saved       bool       `gorm:"not null;index" json:"saved"`                                                             // 是否已保存到收款人列表, 未保存的只是最近的联系人
+	LastUsedAt  *time.Time `gorm:"null;index" json:"last_used_at"`                                                          // 最近一次转账给他的时间
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (news *TransferRecipient) TableName() string {
+	return "transfer_recipient"
+}
+
+func (news *TransferRecipient) BeforeCreate(scope *gorm.Scope) error {
+	return scope.SetColumn("id", util.GenerateId())
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package model
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"time"
+)
+
+// webhook 订阅, 订阅的事件发生时向 URL 发送 POST 请求
+type Webhook struct {
+	Id          string         `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 订阅ID
+	Url         string         `gorm:"not null;type:varchar(255)" json:"url"`                        // 接收事件的 URL
+	Events      pq.StringArray `gorm:"not null;type:varchar(64)[]" json:"events"`                    // 订阅的事件
+	Secret      string         `gorm:"not null;type:varchar(64)" json:"secret"`                      // 签名使用的密钥
+	Description string         `gorm:"not null;type:varchar(255)" json:"description"`                // 描述
+	Enabled     bool           `gorm:"not null;index" json:"enabled"`                                // 是否启用
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (w *Webhook) TableName() string {
+	return "webhook"
+}
+
+func (w *Webhook) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
+
+type WebhookDeliveryStatus int
+
+const (
+	WebhookDeliveryStatusDead    WebhookDeliveryStatus = -1 // 重试多次之后仍然失败
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = 0  // 等待发送或者等待重试
+	WebhookDeliveryStatusSuccess WebhookDeliveryStatus = 1  // 对方返回了 2xx
+)
+
+// webhook 的发送记录, 每个订阅的每一次事件都会记录
+type WebhookDelivery struct {
+	Id             string                `gorm:"primary_key;not null;unique;index;type:varchar(32)" json:"id"` // 记录ID
+	WebhookId      string                `gorm:"not null;index;type:varchar(32)" json:"webhook_id"`            // 订阅ID
+	Event          string                `gorm:"not null;index;type:varchar(64)" json:"event"`                 // 事件类型
+	EventId        string                `gorm:"not null;index;type:varchar(32)" json:"event_id"`              // 事件ID, 重放时不变, 接收方可以用来去重
+	Payload        string                `gorm:"not null;type:text" json:"payload"`                            // 发送的内容, JSON 格式
+	Status         WebhookDeliveryStatus `gorm:"not null;index" json:"status"`                                 // 发送状态
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`                           // 已经尝试发送的次数
+	ResponseStatus int                   `gorm:"not null;default:0" json:"response_status"`                    // 最后一次请求返回的状态码
+	ResponseBody   string                `gorm:"not null;type:text" json:"response_body"`                      // 最后一次请求返回的内容, 只保留开头的部分
+	Error          string                `gorm:"not null;type:text" json:"error"`                              // 最后一次失败的原因
+	Duration       int64                 `gorm:"not null;default:0" json:"duration"`                           // 最后一次请求的耗时, 单位毫秒
+	DeliveredAt    *time.Time            `gorm:"null" json:"delivered_at"`                                     // 发送成功的时间
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (w *WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+func (w *WebhookDelivery) BeforeCreate(scope *gorm.Scope) error {
+	if err := scope.SetColumn("id", util.GenerateId()); err != nil {
+		return err
+	}
+	return nil
+}
@@ -37,6 +37,10 @@ var (
 	AdminReportUpdate = New("report::update", "有权限修改反馈信息")
 	AdminReportDelete = New("report::delete", "有权限删除反馈信息")
 
+	AdminWalletGet   = New("wallet::get", "有权限查看用户钱包")
+	AdminTransferGet = New("transfer::get", "有权限查看转账记录")
+	AdminFinanceGet  = New("finance::get", "有权限查看财务流水")
+
 	// 管理员的所有权限
 	AdminList = []*Accession{
 		AdminAdminGet,
@@ -73,6 +77,10 @@ var (
 		AdminReportGet,
 		AdminReportUpdate,
 		AdminReportDelete,
+
+		AdminWalletGet,
+		AdminTransferGet,
+		AdminFinanceGet,
 	}
 
 	AdminMap = map[string]*Accession{}
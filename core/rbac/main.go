@@ -107,3 +107,53 @@ func Require(accesions ...accession.Accession) gin.HandlerFunc {
 		}
 	}
 }
+
+// 根据管理员权限鉴权的中间件, 超级管理员拥有所有权限
+func RequireAdmin(accesions ...accession.Accession) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			err error
+			uid = c.GetString("uid") // 这个中间件必须安排在JWT的中间件后面, 所以这里是拿的到 UID 的
+		)
+
+		defer func() {
+			if err != nil {
+				c.JSON(http.StatusOK, schema.Response{
+					Message: err.Error(),
+					Data:    nil,
+				})
+				c.Abort()
+			}
+		}()
+
+		if uid == "" {
+			err = exception.NoPermission
+			return
+		}
+
+		adminInfo := model.Admin{
+			Id: uid,
+		}
+
+		if err = database.Db.First(&adminInfo).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = exception.AdminNotExist
+			}
+			return
+		}
+
+		if adminInfo.IsSuper {
+			return
+		}
+
+		for _, a := range accesions {
+			for _, name := range adminInfo.Accession {
+				if name == a.Name {
+					return
+				}
+			}
+		}
+
+		err = exception.NoPermission
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type DeliveryPure struct {
+	Id        string `json:"id"`        // 记录ID
+	Kind      string `json:"kind"`      // 发送的类型
+	Channel   string `json:"channel"`   // 渠道, email 或者 sms
+	Recipient string `json:"recipient"` // 接收的邮箱或者手机号, 多个以 `,` 分隔
+	Payload   string `json:"payload"`   // 发送的内容, JSON 格式
+	Status    int    `json:"status"`    // 发送状态, -1 已加入死信队列, 0 等待发送, 1 发送成功
+	Attempts  int    `json:"attempts"`  // 已经尝试发送的次数
+	Error     string `json:"error"`     // 最后一次发送失败的原因
+}
+
+type Delivery struct {
+	DeliveryPure
+	SentAt    *string `json:"sent_at"`    // 发送成功的时间
+	CreatedAt string  `json:"created_at"` // 创建时间
+	UpdatedAt string  `json:"updated_at"` // 更新时间
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+import "github.com/axetroy/go-server/core/model"
+
+type FinanceLogPure struct {
+	Id              string            `json:"id"`               // 流水ID
+	Currency        string            `json:"currency"`         // 币种
+	OrderId         string            `json:"order_id"`         // 对应的订单ID
+	Uid             string            `json:"uid"`              // 对应的用户
+	BeforeBalance   string            `json:"before_balance"`   // 这条流水前的余额
+	BalanceMutation string            `json:"balance_mutation"` // 可用余额的变动
+	AfterBalance    string            `json:"after_balance"`    // 这条流水后的余额
+	BeforeFrozen    string            `json:"before_frozen"`    // 这条流水前的冻结余额
+	FrozenMutation  string            `json:"frozen_mutation"`  // 冻结余额的变动
+	AfterFrozen     string            `json:"after_frozen"`     // 这条流水后的冻结余额
+	Type            model.FinanceType `json:"type"`             // 流水类型
+	Note            *string           `json:"note"`             // 流水备注
+	PrevHash        string            `json:"prev_hash"`        // 上一条流水的哈希
+	Hash            string            `json:"hash"`             // 这条流水的哈希
+}
+
+type FinanceLog struct {
+	FinanceLogPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// 哈希链第一个断开的位置
+type FinanceChainBroken struct {
+	Id     string `json:"id"`     // 断开的流水 ID
+	Index  int    `json:"index"`  // 该流水在链中的位置, 从 0 开始
+	Reason string `json:"reason"` // 断开的原因
+	Expect string `json:"expect"` // 期望的哈希
+	Actual string `json:"actual"` // 实际记录的哈希
+}
+
+// 某个用户某个币种的流水哈希链的校验结果
+type FinanceChain struct {
+	Uid      string              `json:"uid"`      // 用户 ID
+	Currency string              `json:"currency"` // 币种
+	Total    int                 `json:"total"`    // 链上的流水数量
+	Valid    bool                `json:"valid"`    // 哈希链是否完整
+	Broken   *FinanceChainBroken `json:"broken"`   // 第一个断开的位置, 完整时为 null
+}
+
+// 当天资金变动最大的用户
+type FinanceReportMover struct {
+	Uid      string `json:"uid"`      // 用户ID
+	Mutation string `json:"mutation"` // 可用余额的净变动
+	Count    int64  `json:"count"`    // 流水笔数
+}
+
+type FinanceReportPure struct {
+	Date           string               `json:"date"`            // 报表日期
+	Currency       string               `json:"currency"`        // 币种
+	TotalBalance   string               `json:"total_balance"`   // 所有钱包的可用余额之和
+	TotalFrozen    string               `json:"total_frozen"`    // 所有钱包的冻结余额之和
+	WalletCount    int64                `json:"wallet_count"`    // 钱包总数
+	NewWallets     int64                `json:"new_wallets"`     // 当天新增的钱包数
+	TransferCount  int64                `json:"transfer_count"`  // 当天的转账笔数
+	TransferVolume string               `json:"transfer_volume"` // 当天的转账总量
+	TopMovers      []FinanceReportMover `json:"top_movers"`      // 当天资金变动最大的用户
+}
+
+type FinanceReport struct {
+	FinanceReportPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type FinanceReportSubscription struct {
+	Id        string `json:"id"`    // 管理员ID
+	Email     string `json:"email"` // 接收报表的邮箱
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
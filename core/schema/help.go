@@ -5,13 +5,16 @@ import (
 )
 
 type HelpPure struct {
-	Id       string           `json:"id"`        // 帮助文章ID
-	Title    string           `json:"title"`     // 帮助文章标题
-	Content  string           `json:"content"`   // 帮助文章内容
-	Tags     []string         `json:"tags"`      // 帮助文章的标签
-	Status   model.HelpStatus `json:"status"`    // 帮助文章状态
-	Type     model.HelpType   `json:"type"`      // 帮助文章的类型
-	ParentId *string          `json:"parent_id"` // 父级 ID，如果有的话
+	Id       string              `json:"id"`        // 帮助文章ID
+	Title    string              `json:"title"`     // 帮助文章标题
+	Content  string              `json:"content"`   // 帮助文章内容
+	Format   model.ContentFormat `json:"format"`    // 帮助文章内容的格式
+	Excerpt  string              `json:"excerpt"`   // 帮助文章的纯文本摘要
+	Tags     []string            `json:"tags"`      // 帮助文章的标签
+	Status   model.HelpStatus    `json:"status"`    // 帮助文章状态
+	Type     model.HelpType      `json:"type"`      // 帮助文章的类型
+	ParentId *string             `json:"parent_id"` // 父级 ID，如果有的话
+	Sort     int                 `json:"sort"`      // 同级之间的排序, 越小的越靠前
 }
 
 type Help struct {
@@ -19,3 +22,29 @@ type Help struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
+
+// 面包屑中的一级
+type HelpBreadcrumb struct {
+	Id    string         `json:"id"`    // 帮助文章ID
+	Title string         `json:"title"` // 帮助文章标题
+	Type  model.HelpType `json:"type"`  // 帮助文章的类型
+}
+
+// 帮助详情, 包括从根分类到父级的面包屑
+type HelpDetail struct {
+	Help
+	Html        string           `json:"html"`        // 渲染并过滤之后的内容
+	Breadcrumbs []HelpBreadcrumb `json:"breadcrumbs"` // 从根分类开始, 不包括自己
+}
+
+// 帮助中心的目录树, 不包括内容
+type HelpNode struct {
+	Id       string           `json:"id"`        // 帮助文章ID
+	Title    string           `json:"title"`     // 帮助文章标题
+	Tags     []string         `json:"tags"`      // 帮助文章的标签
+	Status   model.HelpStatus `json:"status"`    // 帮助文章状态
+	Type     model.HelpType   `json:"type"`      // 帮助文章的类型
+	ParentId *string          `json:"parent_id"` // 父级 ID
+	Sort     int              `json:"sort"`      // 同级之间的排序
+	Children []HelpNode       `json:"children"`  // 子级, 文章没有子级
+}
@@ -1,33 +1,69 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package schema
 
+import (
+	"github.com/axetroy/go-server/core/model"
+)
+
 type MessagePure struct {
-	Id      string  `json:"id"`      // 消息ID
-	Title   string  `json:"title"`   // 消息标题
-	Content string  `json:"content"` // 消息内容
-	Read    bool    `json:"read"`    // 用户是否已读
-	Note    *string `json:"note"`    // 备注
+	Id      string              `json:"id"`      // 消息ID
+	Title   string              `json:"title"`   // 消息标题
+	Content string              `json:"content"` // 消息内容
+	Format  model.ContentFormat `json:"format"`  // 消息内容的格式
+	Excerpt string              `json:"excerpt"` // 消息内容的纯文本摘要
+	Read    bool                `json:"read"`    // 用户是否已读
+	Note    *string             `json:"note"`    // 备注
 }
 
 type Message struct {
 	MessagePure
-	ReadAt    *string `json:"read"`       // 用户读取的时间
-	CreatedAt string  `json:"created_at"` // 创建时间
-	UpdatedAt string  `json:"updated_at"` // 更新时间
+	Html      string  `json:"html,omitempty"` // 渲染并过滤之后的内容, 只在详情中返回
+	ReadAt    *string `json:"read"`           // 用户读取的时间
+	CreatedAt string  `json:"created_at"`     // 创建时间
+	UpdatedAt string  `json:"updated_at"`     // 更新时间
 }
 
 type MessagePureAdmin struct {
-	Id      string  `json:"id"`      // 消息ID
-	Uid     string  `json:"uid"`     // 用户 UID
-	Title   string  `json:"title"`   // 消息标题
-	Content string  `json:"content"` // 消息内容
-	Read    bool    `json:"read"`    // 用户是否已读
-	Note    *string `json:"note"`    // 备注
+	Id      string              `json:"id"`      // 消息ID
+	Uid     string              `json:"uid"`     // 用户 UID
+	Title   string              `json:"title"`   // 消息标题
+	Content string              `json:"content"` // 消息内容
+	Format  model.ContentFormat `json:"format"`  // 消息内容的格式
+	Excerpt string              `json:"excerpt"` // 消息内容的纯文本摘要
+	Read    bool                `json:"read"`    // 用户是否已读
+	Note    *string             `json:"note"`    // 备注
 }
 
 type MessageAdmin struct {
 	MessagePureAdmin
-	ReadAt    *string `json:"read"`       // 用户读取的时间
-	CreatedAt string  `json:"created_at"` // 创建时间
-	UpdatedAt string  `json:"updated_at"` // 更新时间
+	Html      string  `json:"html,omitempty"` // 渲染并过滤之后的内容, 只在详情中返回
+	ReadAt    *string `json:"read"`           // 用户读取的时间
+	CreatedAt string  `json:"created_at"`     // 创建时间
+	UpdatedAt string  `json:"updated_at"`     // 更新时间
+}
+
+type MessageBatchPure struct {
+	Id             string              `json:"id"`               // 任务ID
+	Author         string              `json:"author"`           // 创建任务的管理员
+	Title          string              `json:"title"`            // 消息标题
+	Content        string              `json:"content"`          // 消息内容
+	Format         model.ContentFormat `json:"format"`           // 消息内容的格式
+	Status         int                 `json:"status"`           // 任务状态, -1 已取消, 0 等待发送, 1 发送中, 2 发送完成
+	TargetRoles    []string            `json:"target_roles"`     // 发送给拥有其中任意一个角色的用户
+	TargetLevelMin *int32              `json:"target_level_min"` // 发送给不低于该等级的用户
+	TargetLevelMax *int32              `json:"target_level_max"` // 发送给不高于该等级的用户
+	Total          int64               `json:"total"`            // 发送对象的数量
+	Invalid        int64               `json:"invalid"`          // 导入时无法识别的用户数量
+	Delivered      int64               `json:"delivered"`        // 已经发送的数量
+}
+
+type MessageBatch struct {
+	MessageBatchPure
+	TargetRegisterStart *string `json:"target_register_start"` // 发送给在这之后注册的用户
+	TargetRegisterEnd   *string `json:"target_register_end"`   // 发送给在这之前注册的用户
+	TargetUsers         int     `json:"target_users"`          // 指定的用户数量
+	Progress            float64 `json:"progress"`              // 发送进度, 0 - 1
+	FinishedAt          *string `json:"finished_at"`           // 发送完成或者取消的时间
+	CreatedAt           string  `json:"created_at"`            // 创建时间
+	UpdatedAt           string  `json:"updated_at"`            // 更新时间
 }
@@ -0,0 +1,37 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type MessageTemplatePure struct {
+	Id         string `json:"id"`          // 模版ID
+	Name       string `json:"name"`        // 模版名称
+	Channel    string `json:"channel"`     // 渠道, email 或者 sms
+	Locale     string `json:"locale"`      // 语言
+	Subject    string `json:"subject"`     // 邮件标题
+	Content    string `json:"content"`     // 模版内容
+	Text       string `json:"text"`        // 邮件的纯文本内容
+	ExternalId string `json:"external_id"` // 短信服务商的模版 ID
+	Version    int    `json:"version"`     // 当前的版本号
+	Author     string `json:"author"`      // 最后修改的管理员
+}
+
+type MessageTemplate struct {
+	MessageTemplatePure
+	CreatedAt string `json:"created_at"` // 创建时间
+	UpdatedAt string `json:"updated_at"` // 更新时间
+}
+
+type MessageTemplateVersionPure struct {
+	Id         string `json:"id"`          // 版本ID
+	TemplateId string `json:"template_id"` // 模版ID
+	Version    int    `json:"version"`     // 版本号
+	Subject    string `json:"subject"`     // 邮件标题
+	Content    string `json:"content"`     // 模版内容
+	Text       string `json:"text"`        // 邮件的纯文本内容
+	ExternalId string `json:"external_id"` // 短信服务商的模版 ID
+	Author     string `json:"author"`      // 修改的管理员
+}
+
+type MessageTemplateVersion struct {
+	MessageTemplateVersionPure
+	CreatedAt string `json:"created_at"` // 创建时间
+}
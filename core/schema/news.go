@@ -1,20 +1,107 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package schema
 
-import "github.com/axetroy/go-server/core/model"
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/util"
+)
 
 type NewsPure struct {
-	Id      string           `json:"id"`
-	Author  string           `json:"author"`
-	Title   string           `json:"title"`
-	Content string           `json:"content"`
-	Type    model.NewsType   `json:"type"`
-	Tags    []string         `json:"tags"`
-	Status  model.NewsStatus `json:"status"`
+	Id         string              `json:"id"`
+	Author     string              `json:"author"`
+	Title      string              `json:"title"`
+	Content    string              `json:"content"`
+	Format     model.ContentFormat `json:"format"`  // 内容的格式
+	Excerpt    string              `json:"excerpt"` // 内容的纯文本摘要
+	Type       model.NewsType      `json:"type"`
+	Tags       []string            `json:"tags"`
+	Status     model.NewsStatus    `json:"status"`
+	Version    int                 `json:"version"`    // 当前的版本号
+	Editor     string              `json:"editor"`     // 最后修改的管理员
+	Views      int64               `json:"views"`      // 浏览量
+	Likes      int64               `json:"likes"`      // 点赞数
+	Comments   int64               `json:"comments"`   // 可见的评论和回复数
+	Popularity int64               `json:"popularity"` // 热度
 }
 
 type News struct {
 	NewsPure
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Html        string  `json:"html,omitempty"` // 渲染并过滤之后的内容, 只在详情中返回
+	PublishedAt *string `json:"published_at"`   // 发布时间
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type NewsRevisionPure struct {
+	Id      string              `json:"id"`      // 版本ID
+	NewsId  string              `json:"news_id"` // 新闻公告ID
+	Version int                 `json:"version"` // 版本号
+	Title   string              `json:"title"`   // 公告标题
+	Content string              `json:"content"` // 公告内容
+	Format  model.ContentFormat `json:"format"`  // 内容的格式
+	Type    model.NewsType      `json:"type"`    // 公告类型
+	Tags    []string            `json:"tags"`    // 公告的标签
+	Status  model.NewsStatus    `json:"status"`  // 公告状态
+	Changes []string            `json:"changes"` // 相对上一个版本修改的字段
+	Editor  string              `json:"editor"`  // 修改的管理员
+}
+
+type NewsRevision struct {
+	NewsRevisionPure
+	Html        string  `json:"html,omitempty"` // 渲染并过滤之后的内容, 只在详情中返回
+	PublishedAt *string `json:"published_at"`   // 发布时间
+	CreatedAt   string  `json:"created_at"`     // 创建时间
+}
+
+// 两个版本之间的一个字段的变化
+type NewsFieldChange struct {
+	Field string      `json:"field"` // 字段名
+	From  interface{} `json:"from"`  // 旧的值
+	To    interface{} `json:"to"`    // 新的值
+}
+
+// 两个版本之间的差异
+type NewsDiff struct {
+	From    int               `json:"from"`    // 旧的版本号
+	To      int               `json:"to"`      // 新的版本号
+	Changes []NewsFieldChange `json:"changes"` // 除了内容之外修改的字段
+	Content []util.DiffLine   `json:"content"` // 内容逐行的差异
+}
+
+type NewsCommentPure struct {
+	Id       string                  `json:"id"`        // 评论ID
+	NewsId   string                  `json:"news_id"`   // 新闻公告ID
+	Uid      string                  `json:"uid"`       // 评论的用户
+	ParentId *string                 `json:"parent_id"` // 所属的评论, 为空则为评论, 否则为回复
+	ReplyUid *string                 `json:"reply_uid"` // 回复的用户
+	Content  string                  `json:"content"`   // 评论内容
+	Status   model.NewsCommentStatus `json:"status"`    // 评论状态, 0 正常, -1 已隐藏
+}
+
+type NewsComment struct {
+	NewsCommentPure
+	User      ProfilePublic `json:"user"`       // 评论的用户
+	Replies   int64         `json:"replies"`    // 可见的回复数量, 只有评论才有回复
+	CreatedAt string        `json:"created_at"` // 创建时间
+	UpdatedAt string        `json:"updated_at"` // 更新时间
+}
+
+// 用户对新闻公告的点赞状态
+type NewsLike struct {
+	NewsId string `json:"news_id"` // 新闻公告ID
+	Liked  bool   `json:"liked"`   // 是否已经点赞
+	Likes  int64  `json:"likes"`   // 点赞数
+}
+
+type NewsCommentBanPure struct {
+	Uid    string `json:"uid"`    // 被禁言的用户
+	Admin  string `json:"admin"`  // 操作的管理员
+	Reason string `json:"reason"` // 禁言的原因
+}
+
+type NewsCommentBan struct {
+	NewsCommentBanPure
+	ExpiredAt *string `json:"expired_at"` // 解除的时间, 为空则永久禁言
+	CreatedAt string  `json:"created_at"` // 创建时间
+	UpdatedAt string  `json:"updated_at"` // 更新时间
 }
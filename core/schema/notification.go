@@ -1,33 +1,63 @@
 // Copyright 2019 Axetroy. All rights reserved. MIT license.
 package schema
 
+import (
+	"github.com/axetroy/go-server/core/model"
+)
+
 // 普通会员获取的接口
 type NotificationPure struct {
-	Id      string  `json:"id"`
-	Title   string  `json:"title"`
-	Content string  `json:"content"`
-	Read    bool    `json:"read"`    // 用户是否已读
-	ReadAt  string  `json:"read_at"` // 用户读取的时间
-	Note    *string `json:"note"`
+	Id      string              `json:"id"`
+	Title   string              `json:"title"`
+	Content string              `json:"content"`
+	Format  model.ContentFormat `json:"format"`  // 内容的格式
+	Excerpt string              `json:"excerpt"` // 内容的纯文本摘要
+	Read    bool                `json:"read"`    // 用户是否已读
+	ReadAt  string              `json:"read_at"` // 用户读取的时间
+	Note    *string             `json:"note"`
 }
 
 type Notification struct {
 	NotificationPure
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Html      string  `json:"html,omitempty"` // 渲染并过滤之后的内容, 只在详情中返回
+	PublishAt string  `json:"publish_at"`     // 发布时间
+	ExpireAt  *string `json:"expire_at"`      // 过期时间, 为空则永不过期
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
 }
 
 // 这是管理员获取的接口
 type NotificationPureAdmin struct {
-	Id      string  `json:"id"`
-	Author  string  `json:"author"`
-	Title   string  `json:"title"`
-	Content string  `json:"content"`
-	Note    *string `json:"note"`
+	Id             string              `json:"id"`
+	Author         string              `json:"author"`
+	Title          string              `json:"title"`
+	Content        string              `json:"content"`
+	Format         model.ContentFormat `json:"format"`  // 内容的格式
+	Excerpt        string              `json:"excerpt"` // 内容的纯文本摘要
+	Note           *string             `json:"note"`
+	Status         int                 `json:"status"`           // 公告状态
+	TargetRoles    []string            `json:"target_roles"`     // 推送给拥有其中任意一个角色的用户
+	TargetLevelMin *int32              `json:"target_level_min"` // 推送给不低于该等级的用户
+	TargetLevelMax *int32              `json:"target_level_max"` // 推送给不高于该等级的用户
+	TargetUsers    []string            `json:"target_users"`     // 推送给指定的用户
+	Pushed         bool                `json:"pushed"`           // 是否已经推送给在线的用户
 }
 
 type NotificationAdmin struct {
 	NotificationPureAdmin
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Html                string  `json:"html,omitempty"`        // 渲染并过滤之后的内容, 只在详情中返回
+	TargetRegisterStart *string `json:"target_register_start"` // 推送给在这之后注册的用户
+	TargetRegisterEnd   *string `json:"target_register_end"`   // 推送给在这之前注册的用户
+	PublishAt           *string `json:"publish_at"`            // 发布时间, 为空则立即发布
+	ExpireAt            *string `json:"expire_at"`             // 过期时间, 为空则永不过期
+	CreatedAt           string  `json:"created_at"`
+	UpdatedAt           string  `json:"updated_at"`
+}
+
+// 系统通知的阅读统计
+type NotificationStats struct {
+	Id       string  `json:"id"`        // 通知ID
+	Target   int64   `json:"target"`    // 推送的用户数
+	Read     int64   `json:"read"`      // 已读的用户数
+	ReadRate float64 `json:"read_rate"` // 阅读率, 已读的用户数 / 推送的用户数
 }
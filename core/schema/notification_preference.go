@@ -0,0 +1,10 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type NotificationPreference struct {
+	Transfer     []string `json:"transfer"`     // 接收转账通知的渠道
+	Security     []string `json:"security"`     // 接收账号安全通知的渠道
+	Announcement []string `json:"announcement"` // 接收公告的渠道
+	QuietStart   *string  `json:"quiet_start"`  // 免打扰的开始时间, 格式为 HH:MM
+	QuietEnd     *string  `json:"quiet_end"`    // 免打扰的结束时间, 格式为 HH:MM
+}
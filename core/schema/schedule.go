@@ -0,0 +1,29 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type ScheduleTask struct {
+	Name        string       `json:"name"`        // 任务名称
+	Spec        string       `json:"spec"`        // cron 表达式
+	Description string       `json:"description"` // 任务的描述
+	Timeout     int64        `json:"timeout"`     // 最长运行时间, 单位秒
+	NextRunAt   *string      `json:"next_run_at"` // 下一次按计划运行的时间
+	LastRun     *ScheduleRun `json:"last_run"`    // 最近一次的运行记录
+}
+
+type ScheduleRunPure struct {
+	Id       string `json:"id"`       // 记录ID
+	Task     string `json:"task"`     // 任务名称
+	Trigger  string `json:"trigger"`  // 触发的方式, schedule 为按计划运行, manual 为管理员手动触发
+	Instance string `json:"instance"` // 运行任务的进程
+	Status   int    `json:"status"`   // 运行状态, -1 失败, 0 正在运行, 1 成功
+	Error    string `json:"error"`    // 失败的原因
+	Duration int64  `json:"duration"` // 运行的时间, 单位毫秒
+}
+
+type ScheduleRun struct {
+	ScheduleRunPure
+	StartedAt  string  `json:"started_at"`  // 开始的时间
+	FinishedAt *string `json:"finished_at"` // 结束的时间
+	CreatedAt  string  `json:"created_at"`  // 创建时间
+	UpdatedAt  string  `json:"updated_at"`  // 更新时间
+}
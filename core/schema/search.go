@@ -0,0 +1,12 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+// 搜索结果
+type SearchResult struct {
+	Type      string  `json:"type"`       // 内容的类型, news/help/notification
+	Id        string  `json:"id"`         // 内容的ID
+	Title     string  `json:"title"`      // 标题, 关键词用 <mark> 高亮, 其余部分已经转义
+	Snippet   string  `json:"snippet"`    // 内容的摘要, 关键词用 <mark> 高亮, 其余部分已经转义
+	Rank      float64 `json:"rank"`       // 相关度, 越大越相关
+	CreatedAt string  `json:"created_at"` // 创建时间
+}
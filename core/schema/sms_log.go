@@ -0,0 +1,19 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type SMSLogPure struct {
+	Id         string `json:"id"`          // 记录ID
+	Provider   string `json:"provider"`    // 短信服务商
+	Phone      string `json:"phone"`       // 接收的手机号
+	Template   string `json:"template"`    // 模版名称
+	TemplateId string `json:"template_id"` // 服务商的模版 ID
+	Content    string `json:"content"`     // 使用本地模版渲染的内容
+	RequestId  string `json:"request_id"`  // 服务商返回的请求 ID
+	Status     int    `json:"status"`      // 发送状态, -1 失败, 1 服务商已接收
+	Error      string `json:"error"`       // 服务商返回的错误
+}
+
+type SMSLog struct {
+	SMSLogPure
+	CreatedAt string `json:"created_at"` // 创建时间
+}
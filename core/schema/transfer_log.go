@@ -18,3 +18,54 @@ type TransferLog struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
+
+// 钱包在某一时刻的余额
+type TransferSnapshotWallet struct {
+	Balance string `json:"balance"` // 可用余额
+	Frozen  string `json:"frozen"`  // 冻结余额
+}
+
+// 转账时的钱包快照
+type TransferSnapshot struct {
+	TransferId string                 `json:"transfer_id"` // 转账ID
+	Uid        string                 `json:"uid"`         // 钱包所属的用户
+	Currency   string                 `json:"currency"`    // 币种
+	Before     TransferSnapshotWallet `json:"before"`      // 转账前的钱包
+	After      TransferSnapshotWallet `json:"after"`       // 转账后的钱包
+	Signature  string                 `json:"signature"`   // 快照的签名
+}
+
+// 钱包快照与财务流水的比对结果
+type TransferSnapshotCheck struct {
+	Uid          string  `json:"uid"`            // 用户ID
+	FinanceLogId *string `json:"finance_log_id"` // 对应的流水ID
+	Valid        bool    `json:"valid"`          // 快照是否可信, 且与流水一致
+	Reason       *string `json:"reason"`         // 不一致的原因
+}
+
+// 管理员查看的转账详情
+type TransferLogDetail struct {
+	TransferLog
+	SnapshotFrom *TransferSnapshot     `json:"snapshot_from"` // 转账者的钱包快照
+	SnapshotTo   *TransferSnapshot     `json:"snapshot_to"`   // 收款人的钱包快照
+	CheckFrom    TransferSnapshotCheck `json:"check_from"`    // 转账者的比对结果
+	CheckTo      TransferSnapshotCheck `json:"check_to"`      // 收款人的比对结果
+}
+
+// 转账前用于确认的收款人信息, 名称已打码
+type TransferRecipientPreview struct {
+	Id     string `json:"id,omitempty"` // 收款人的用户ID, 预览收款人时不返回
+	Name   string `json:"name"`         // 打码后的昵称/用户名
+	Avatar string `json:"avatar"`       // 收款人的头像
+}
+
+// 我的收款人
+type TransferRecipient struct {
+	Id         string                   `json:"id"`           // 数据ID
+	Recipient  TransferRecipientPreview `json:"recipient"`    // 收款人
+	Alias      *string                  `json:"alias"`        // 收款人的备注名
+	Saved      bool                     `json:"saved"`        // 是否已保存, 未保存的为最近的联系人
+	LastUsedAt *string                  `json:"last_used_at"` // 最近一次转账给他的时间
+	CreatedAt  string                   `json:"created_at"`
+	UpdatedAt  string                   `json:"updated_at"`
+}
@@ -0,0 +1,9 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+// 用户的未读数
+type Unread struct {
+	Message      int64 `json:"message"`      // 未读的个人消息数量
+	Notification int64 `json:"notification"` // 未读的系统通知数量
+	Total        int64 `json:"total"`        // 总的未读数量
+}
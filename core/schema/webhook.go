@@ -0,0 +1,38 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package schema
+
+type WebhookPure struct {
+	Id          string   `json:"id"`          // 订阅ID
+	Url         string   `json:"url"`         // 接收事件的 URL
+	Events      []string `json:"events"`      // 订阅的事件
+	Secret      string   `json:"secret"`      // 签名使用的密钥
+	Description string   `json:"description"` // 描述
+	Enabled     bool     `json:"enabled"`     // 是否启用
+}
+
+type Webhook struct {
+	WebhookPure
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type WebhookDeliveryPure struct {
+	Id             string `json:"id"`              // 记录ID
+	WebhookId      string `json:"webhook_id"`      // 订阅ID
+	Event          string `json:"event"`           // 事件类型
+	EventId        string `json:"event_id"`        // 事件ID, 重放时不变
+	Payload        string `json:"payload"`         // 发送的内容
+	Status         int    `json:"status"`          // 发送状态, -1 已放弃, 0 等待发送, 1 成功
+	Attempts       int    `json:"attempts"`        // 已经尝试发送的次数
+	ResponseStatus int    `json:"response_status"` // 最后一次请求返回的状态码
+	ResponseBody   string `json:"response_body"`   // 最后一次请求返回的内容
+	Error          string `json:"error"`           // 最后一次失败的原因
+	Duration       int64  `json:"duration"`        // 最后一次请求的耗时, 单位毫秒
+}
+
+type WebhookDelivery struct {
+	WebhookDeliveryPure
+	DeliveredAt *string `json:"delivered_at"` // 发送成功的时间
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
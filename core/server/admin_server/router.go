@@ -7,7 +7,9 @@ import (
 	"github.com/axetroy/go-server/core/controller/address"
 	"github.com/axetroy/go-server/core/controller/admin"
 	"github.com/axetroy/go-server/core/controller/banner"
+	"github.com/axetroy/go-server/core/controller/delivery"
 	"github.com/axetroy/go-server/core/controller/downloader"
+	"github.com/axetroy/go-server/core/controller/finance"
 	"github.com/axetroy/go-server/core/controller/help"
 	loginLog "github.com/axetroy/go-server/core/controller/logger/login"
 	"github.com/axetroy/go-server/core/controller/menu"
@@ -17,10 +19,19 @@ import (
 	"github.com/axetroy/go-server/core/controller/report"
 	"github.com/axetroy/go-server/core/controller/resource"
 	"github.com/axetroy/go-server/core/controller/role"
+	"github.com/axetroy/go-server/core/controller/schedule"
+	"github.com/axetroy/go-server/core/controller/search"
+	"github.com/axetroy/go-server/core/controller/sms"
 	"github.com/axetroy/go-server/core/controller/system"
+	"github.com/axetroy/go-server/core/controller/template"
+	"github.com/axetroy/go-server/core/controller/transfer"
 	"github.com/axetroy/go-server/core/controller/uploader"
 	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/controller/webhook"
 	"github.com/axetroy/go-server/core/middleware"
+	"github.com/axetroy/go-server/core/rbac"
+	"github.com/axetroy/go-server/core/rbac/accession"
 	"github.com/axetroy/go-server/core/schema"
 	"github.com/axetroy/go-server/core/service/dotenv"
 	"github.com/gin-gonic/gin"
@@ -110,11 +121,21 @@ func init() {
 		// 新闻咨询类
 		{
 			newsRouter := v1.Group("/news")
-			newsRouter.POST("", news.CreateRouter)              // 新建新闻公告
-			newsRouter.GET("", news.GetNewsListByUserRouter)    // 获取新闻列表
-			newsRouter.GET("/n/:news_id", news.GetNewsRouter)   // 获取新闻详情
-			newsRouter.PUT("/n/:news_id", news.UpdateRouter)    // 更新新闻公告
-			newsRouter.DELETE("/n/:news_id", news.DeleteRouter) // 删除新闻
+			newsRouter.POST("", news.CreateRouter)                                       // 新建新闻公告
+			newsRouter.GET("", news.GetNewsListRouter)                                   // 获取新闻列表
+			newsRouter.GET("/n/:news_id", news.GetNewsRouter)                            // 获取新闻详情
+			newsRouter.PUT("/n/:news_id", news.UpdateRouter)                             // 更新新闻公告
+			newsRouter.DELETE("/n/:news_id", news.DeleteRouter)                          // 删除新闻
+			newsRouter.GET("/n/:news_id/revision", news.GetRevisionListRouter)           // 获取新闻的历史版本
+			newsRouter.GET("/n/:news_id/revision/:version", news.GetRevisionRouter)      // 获取新闻的某个历史版本
+			newsRouter.PUT("/n/:news_id/revision/:version", news.RollbackRouter)         // 回滚到历史版本
+			newsRouter.GET("/n/:news_id/diff", news.DiffRevisionRouter)                  // 对比两个历史版本
+			newsRouter.GET("/comment", news.GetCommentListByAdminRouter)                 // 获取评论列表
+			newsRouter.PUT("/comment/c/:comment_id", news.UpdateCommentRouter)           // 隐藏或者恢复评论
+			newsRouter.DELETE("/comment/c/:comment_id", news.DeleteCommentByAdminRouter) // 删除评论
+			newsRouter.GET("/comment/ban", news.GetBanListRouter)                        // 获取禁言列表
+			newsRouter.POST("/comment/ban", news.BanRouter)                              // 禁止用户评论
+			newsRouter.DELETE("/comment/ban/:uid", news.UnbanRouter)                     // 解除禁言
 		}
 
 		// 系统通知
@@ -124,17 +145,80 @@ func init() {
 			notificationRouter.GET("", notification.GetNotificationListByAdminRouter) // 获取系统通知列表
 			notificationRouter.PUT("/n/:id", notification.UpdateRouter)               // 更新系统通知
 			notificationRouter.DELETE("/n/:id", notification.DeleteRouter)            // 删除系统通知
-			notificationRouter.GET("/n/:id", notification.GetRouter)                  // 获取单条系统通知
+			notificationRouter.GET("/n/:id", notification.GetByAdminRouter)           // 获取单条系统通知
+			notificationRouter.GET("/n/:id/stats", notification.GetStatsRouter)       // 获取系统通知的阅读统计
 		}
 
 		// 个人消息
 		{
 			messageRouter := v1.Group("/message")
-			messageRouter.POST("", message.CreateRouter)                        // 创建个人消息
-			messageRouter.GET("", message.GetMessageListByAdminRouter)          // 获取消息列表
-			messageRouter.GET("/m/:message_id", message.GetAdminRouter)         // 获取个人消息
-			messageRouter.PUT("/m/:message_id", message.UpdateRouter)           // 更新个人消息
-			messageRouter.DELETE("/m/:message_id", message.DeleteByAdminRouter) // 删除个人消息
+			messageRouter.POST("", message.CreateRouter)                            // 创建个人消息
+			messageRouter.GET("", message.GetMessageListByAdminRouter)              // 获取消息列表
+			messageRouter.GET("/m/:message_id", message.GetAdminRouter)             // 获取个人消息
+			messageRouter.PUT("/m/:message_id", message.UpdateRouter)               // 更新个人消息
+			messageRouter.DELETE("/m/:message_id", message.DeleteByAdminRouter)     // 删除个人消息
+			messageRouter.POST("/batch", message.CreateBatchRouter)                 // 创建群发任务
+			messageRouter.POST("/batch/csv", message.CreateBatchByCSVRouter)        // 导入 CSV 创建群发任务
+			messageRouter.GET("/batch", message.GetBatchListRouter)                 // 获取群发任务列表
+			messageRouter.GET("/batch/:batch_id", message.GetBatchRouter)           // 获取群发任务的进度
+			messageRouter.PUT("/batch/:batch_id/cancel", message.CancelBatchRouter) // 取消群发任务
+		}
+
+		// 邮件和短信模版
+		{
+			templateRouter := v1.Group("/template")
+			templateRouter.GET("", template.GetListRouter)                                        // 获取模版列表
+			templateRouter.POST("", template.CreateRouter)                                        // 创建模版
+			templateRouter.GET("/default", template.GetDefaultsRouter)                            // 获取内置的模版
+			templateRouter.POST("/preview", template.PreviewRouter)                               // 预览模版
+			templateRouter.GET("/t/:template_id", template.GetRouter)                             // 获取模版详情
+			templateRouter.PUT("/t/:template_id", template.UpdateRouter)                          // 更新模版
+			templateRouter.DELETE("/t/:template_id", template.DeleteRouter)                       // 删除模版, 之后使用内置的模版
+			templateRouter.GET("/t/:template_id/version", template.GetVersionListRouter)          // 获取模版的历史版本
+			templateRouter.PUT("/t/:template_id/version/:version", template.RestoreVersionRouter) // 恢复到历史版本
+		}
+
+		// 邮件和短信的发送记录
+		{
+			deliveryRouter := v1.Group("/delivery")
+			deliveryRouter.GET("", delivery.GetListRouter)                      // 获取发送记录列表
+			deliveryRouter.GET("/d/:delivery_id", delivery.GetRouter)           // 获取发送记录详情
+			deliveryRouter.PUT("/d/:delivery_id/resend", delivery.ResendRouter) // 重新发送
+		}
+
+		// 短信记录
+		{
+			smsRouter := v1.Group("/sms")
+			smsRouter.GET("/log", sms.GetLogListRouter)     // 获取短信记录列表
+			smsRouter.GET("/log/:log_id", sms.GetLogRouter) // 获取短信记录详情
+		}
+
+		// 定时任务
+		{
+			scheduleRouter := v1.Group("/schedule")
+			scheduleRouter.GET("", schedule.GetTaskListRouter)                  // 获取定时任务列表
+			scheduleRouter.PUT("/t/:task_name/trigger", schedule.TriggerRouter) // 手动触发定时任务
+			scheduleRouter.GET("/run", schedule.GetRunListRouter)               // 获取运行记录列表
+			scheduleRouter.GET("/run/:run_id", schedule.GetRunRouter)           // 获取运行记录详情
+		}
+
+		// webhook
+		{
+			webhookRouter := v1.Group("/webhook")
+			webhookRouter.GET("", webhook.GetListRouter)                             // 获取 webhook 列表
+			webhookRouter.POST("", webhook.CreateRouter)                             // 创建 webhook
+			webhookRouter.GET("/w/:webhook_id", webhook.GetRouter)                   // 获取 webhook 详情
+			webhookRouter.PUT("/w/:webhook_id", webhook.UpdateRouter)                // 更新 webhook
+			webhookRouter.DELETE("/w/:webhook_id", webhook.DeleteRouter)             // 删除 webhook
+			webhookRouter.GET("/delivery", webhook.GetDeliveryListRouter)            // 获取发送记录列表
+			webhookRouter.GET("/delivery/:delivery_id", webhook.GetDeliveryRouter)   // 获取发送记录详情
+			webhookRouter.PUT("/delivery/:delivery_id/replay", webhook.ReplayRouter) // 重放发送记录
+		}
+
+		// 全文搜索
+		{
+			searchRouter := v1.Group("/search")
+			searchRouter.PUT("/reindex", search.ReindexRouter) // 重建全文搜索的索引
 		}
 
 		// 用户反馈
@@ -154,6 +238,8 @@ func init() {
 			helpRouter.PUT("/h/:help_id", help.UpdateRouter)    // 更新帮助
 			helpRouter.GET("/h/:help_id", help.GetHelpRouter)   // 获取帮助详情
 			helpRouter.DELETE("/h/:help_id", help.DeleteRouter) // 删除帮助
+			helpRouter.GET("/tree", help.GetTreeRouter)         // 获取帮助中心的目录树
+			helpRouter.PUT("/h/:help_id/move", help.MoveRouter) // 移动帮助或者调整顺序
 		}
 
 		// Banner
@@ -176,6 +262,34 @@ func init() {
 			menuRouter.DELETE("/m/:menu_id", menu.DeleteRouter) // 删除菜单
 		}
 
+		// 钱包类
+		{
+			walletRouter := v1.Group("wallet")
+			walletRouter.Use(rbac.RequireAdmin(*accession.AdminWalletGet))
+			walletRouter.GET("", wallet.GetListByAdminRouter) // 获取钱包列表
+		}
+
+		// 转账类
+		{
+			transferRouter := v1.Group("transfer")
+			transferRouter.Use(rbac.RequireAdmin(*accession.AdminTransferGet))
+			transferRouter.GET("", transfer.GetHistoryByAdminRouter)               // 获取转账记录列表
+			transferRouter.GET("/t/:transfer_id", transfer.GetDetailByAdminRouter) // 获取转账详情
+		}
+
+		// 财务类
+		{
+			financeRouter := v1.Group("finance")
+			financeRouter.Use(rbac.RequireAdmin(*accession.AdminFinanceGet))
+			financeRouter.GET("/u/:user_id", finance.GetHistoryByAdminRouter)        // 获取用户的财务流水
+			financeRouter.GET("/u/:user_id/verify", finance.VerifyRouter)            // 校验用户的流水哈希链
+			financeRouter.GET("/report", finance.GetReportListRouter)                // 获取每日财务报表
+			financeRouter.GET("/report/export", finance.ExportReportRouter)          // 导出每日财务报表为 CSV
+			financeRouter.GET("/report/subscription", finance.GetSubscriptionRouter) // 获取我对财务报表的订阅
+			financeRouter.PUT("/report/subscription", finance.SubscribeRouter)       // 订阅每日财务报表
+			financeRouter.DELETE("/report/subscription", finance.UnsubscribeRouter)  // 取消订阅每日财务报表
+		}
+
 		// 日志
 		{
 			logRouter := v1.Group("log")
@@ -5,7 +5,10 @@ import (
 	"context"
 	"crypto/tls"
 	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/server/message_queue_server"
 	"github.com/axetroy/go-server/core/service/database"
+	_ "github.com/axetroy/go-server/core/subscriber"
 	"log"
 	"net/http"
 	"os"
@@ -25,6 +28,18 @@ func Serve() error {
 		MaxHeaderBytes: 1 << 20, // 10M
 	}
 
+	// 确认已经引入了事件的订阅者
+	if err := event.Check(); err != nil {
+		return err
+	}
+
+	// 使用进程内的消息队列时, 由当前进程消费任务
+	worker, err := message_queue_server.StartInProcessWorker()
+
+	if err != nil {
+		return err
+	}
+
 	log.Printf("Listen on:  %s\n", s.Addr)
 
 	go func() {
@@ -77,6 +92,13 @@ func Serve() error {
 		log.Fatal("Server Shutdown:", err)
 	}
 
+	// 等待事件的异步订阅者完成, 它们可能还会向消息队列发布任务
+	event.Wait()
+
+	if worker != nil {
+		worker.Stop(ctx)
+	}
+
 	// catching ctx.Done(). timeout of 5 seconds.
 	select {
 	case <-ctx.Done():
@@ -0,0 +1,42 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"log"
+)
+
+// 消费邮件和短信的发送任务, 发送失败则按照发送记录的尝试次数指数退避
+// 重试次数记录在发送记录中, 超过最大重试次数的记录由 dispatcher 加入死信队列, 这里不再限制次数
+func init() {
+	message_queue.Register(message_queue.Definition{
+		Topic:   message_queue.TopicDelivery,
+		Channel: message_queue.ChanelDelivery,
+		New: func() message_queue.Job {
+			return &message_queue.DeliveryBody{}
+		},
+		Handler:     handleDelivery,
+		Concurrency: 10,
+	})
+}
+
+func handleDelivery(_ context.Context, job message_queue.Job) error {
+	body := job.(*message_queue.DeliveryBody)
+
+	retryAfter, err := dispatcher.Deliver(database.Db, body.Id)
+
+	if err == nil {
+		return nil
+	}
+
+	if retryAfter > 0 {
+		return message_queue.RetryAfter(err, retryAfter)
+	}
+
+	log.Printf("发送记录 %s 发送失败, 不再重试: %v\n", body.Id, err)
+
+	return nil
+}
@@ -0,0 +1,17 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/message_queue"
+)
+
+// 使用进程内的消息队列时, 任务只能由发布任务的进程消费, 所以接口进程需要自己启动消费者
+// 其他的消息队列由消息队列进程消费, 返回 nil
+func StartInProcessWorker() (*message_queue.Worker, error) {
+	if config.MessageQueue.Backend != config.MessageQueueBackendMemory {
+		return nil, nil
+	}
+
+	return message_queue.StartWorker()
+}
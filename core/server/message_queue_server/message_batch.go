@@ -0,0 +1,40 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/service/database"
+)
+
+// 消费群发消息的任务, 每条队列消息只发送一批, 没有发送完则把下一批重新加入队列
+// 加入队列失败时返回错误, 由消息队列重新投递这一条消息, 已经发送的批次不会重复发送
+func init() {
+	message_queue.Register(message_queue.Definition{
+		Topic:   message_queue.TopicSendMessageBatch,
+		Channel: message_queue.ChanelSendMessageBatch,
+		New: func() message_queue.Job {
+			return &message_queue.SendMessageBatchBody{}
+		},
+		Handler:     handleMessageBatch,
+		Concurrency: 2,
+		MaxAttempts: 10,
+	})
+}
+
+func handleMessageBatch(ctx context.Context, job message_queue.Job) error {
+	body := job.(*message_queue.SendMessageBatchBody)
+
+	done, err := message.SendBatch(database.Db, body.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
+	}
+
+	return message_queue.Enqueue(ctx, body)
+}
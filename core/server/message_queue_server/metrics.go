@@ -0,0 +1,29 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"github.com/axetroy/go-server/core/message_queue"
+	"log"
+	"time"
+)
+
+// 统计数据输出的间隔
+const metricsInterval = time.Minute
+
+// 定时输出各个任务的统计数据
+func reportMetrics(quit <-chan struct{}) {
+	ticker := time.NewTicker(metricsInterval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			for _, m := range message_queue.GetMetrics() {
+				log.Printf("任务 %s: 成功 %d, 失败 %d, 重试 %d, 死信 %d, 处理中 %d\n", m.Topic, m.Processed, m.Failed, m.Retried, m.Dead, m.InFlight)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/controller/news"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"time"
+)
+
+// 每分钟把累加在 redis 中的文章浏览量和点赞数写入数据库, 并更新文章的热度
+func init() {
+	scheduler.Register(scheduler.Task{
+		Name:        "flush_news_counters",
+		Spec:        "* * * * *",
+		Description: "把文章的浏览量和点赞数写入数据库",
+		Timeout:     time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := news.FlushCounters(database.Db)
+
+			return err
+		},
+	})
+}
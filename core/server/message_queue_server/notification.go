@@ -0,0 +1,44 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/controller/notification"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"time"
+)
+
+// 每分钟检查一次定时发布的系统通知, 到了发布时间则加入推送的队列
+func init() {
+	scheduler.Register(scheduler.Task{
+		Name:        "publish_scheduled_notification",
+		Spec:        "* * * * *",
+		Description: "推送到了发布时间的系统通知",
+		Timeout:     time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := notification.PublishScheduled(database.Db)
+
+			return err
+		},
+	})
+
+	// 把系统通知推送给在线的用户
+	message_queue.Register(message_queue.Definition{
+		Topic:   message_queue.TopicPushNotification,
+		Channel: message_queue.ChanelPushNotification,
+		New: func() message_queue.Job {
+			return &message_queue.PushNotificationBody{}
+		},
+		Handler:     handlePushNotification,
+		Concurrency: 2,
+		MaxAttempts: 10,
+	})
+}
+
+func handlePushNotification(_ context.Context, job message_queue.Job) error {
+	body := job.(*message_queue.PushNotificationBody)
+
+	return notification.Push(database.Db, body.Id)
+}
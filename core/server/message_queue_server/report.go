@@ -0,0 +1,31 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/controller/finance"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"time"
+)
+
+// 每天凌晨生成前一天的财务报表, 并发送给订阅的管理员
+// 报表按日期和币种覆盖写入, 手动触发时重新生成也不会产生重复的数据
+func init() {
+	scheduler.Register(scheduler.Task{
+		Name:        "finance_daily_report",
+		Spec:        "5 0 * * *",
+		Description: "生成并发送前一天的财务报表",
+		Run: func(ctx context.Context) error {
+			date := time.Now().AddDate(0, 0, -1)
+
+			reports, err := finance.GenerateDailyReport(database.Db, date)
+
+			if err != nil {
+				return err
+			}
+
+			return finance.SendDailyReport(database.Db, date, reports)
+		},
+	})
+}
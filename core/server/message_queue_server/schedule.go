@@ -0,0 +1,55 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"log"
+	"time"
+)
+
+// 定时任务运行记录保留的天数
+const scheduleRunRetentionDays = 30
+
+func init() {
+	scheduler.Register(scheduler.Task{
+		Name:        "purge_schedule_run",
+		Spec:        "30 3 * * *",
+		Description: "删除 30 天之前的定时任务运行记录",
+		Run: func(ctx context.Context) error {
+			return database.Db.Where("started_at < ?", time.Now().AddDate(0, 0, -scheduleRunRetentionDays)).Delete(&model.ScheduleRun{}).Error
+		},
+	})
+
+	// 管理员手动触发的定时任务
+	message_queue.Register(message_queue.Definition{
+		Topic:   message_queue.TopicScheduleTrigger,
+		Channel: message_queue.ChanelScheduleTrigger,
+		New: func() message_queue.Job {
+			return &message_queue.ScheduleTriggerBody{}
+		},
+		Handler:     handleScheduleTrigger,
+		Concurrency: 2,
+	})
+}
+
+// 手动触发的任务失败之后不重试, 由管理员查看运行记录之后决定是否再次触发
+func handleScheduleTrigger(ctx context.Context, job message_queue.Job) error {
+	body := job.(*message_queue.ScheduleTriggerBody)
+
+	task, ok := scheduler.GetTask(body.Name)
+
+	if !ok {
+		log.Printf("定时任务 %s 不存在\n", body.Name)
+		return nil
+	}
+
+	if _, err := scheduler.Run(ctx, task, scheduler.TriggerManual); err != nil {
+		log.Printf("手动触发的定时任务 %s 运行失败: %v\n", task.Name, err)
+	}
+
+	return nil
+}
@@ -4,9 +4,11 @@ package message_queue_server
 import (
 	"context"
 	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/event"
 	"github.com/axetroy/go-server/core/message_queue"
 	"github.com/axetroy/go-server/core/service/database"
-	"github.com/nsqio/go-nsq"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	_ "github.com/axetroy/go-server/core/subscriber"
 	"log"
 	"os"
 	"os/signal"
@@ -15,22 +17,29 @@ import (
 )
 
 func Serve() error {
-	var (
-		c *nsq.Consumer
-	)
-
-	go func() {
-		if ctx, err := message_queue.RunMessageQueueConsumer(); err != nil {
-			log.Fatal(err)
-		} else {
-			c = ctx
-		}
-	}()
+	// 确认已经引入了事件的订阅者
+	if err := event.Check(); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+
+	// 消费所有已注册的任务
+	worker, err := message_queue.StartWorker()
+
+	if err != nil {
+		return err
+	}
+
+	go reportMetrics(stop)
+
+	// 按计划运行定时任务
+	schedule := scheduler.Start()
 
 	log.Println("Listening message queue")
 
 	// Wait for interrupt signal to gracefully shutdown the server with
-	// a timeout of 5 seconds.
+	// a timeout of 30 seconds.
 	quit := make(chan os.Signal)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
@@ -42,21 +51,18 @@ func Serve() error {
 
 	log.Println("Shutdown Server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 
 	defer cancel()
 
-	if c != nil {
-		c.Stop()
+	close(stop)
 
-		_ = c.DisconnectFromNSQD(message_queue.Address)
-	}
+	// 等待正在运行的定时任务和正在处理的任务完成, 最多等待 30 秒
+	schedule.Stop(ctx)
+	worker.Stop(ctx)
 
-	// catching ctx.Done(). timeout of 5 seconds.
-	select {
-	case <-ctx.Done():
-		log.Println("Timeout of 5 seconds.")
-	}
+	// 任务中发布的事件, 异步的订阅者可能还在运行, 关闭数据库之前等待它们完成
+	event.Wait()
 
 	_ = database.Db.Close()
 
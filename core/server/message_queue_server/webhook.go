@@ -0,0 +1,41 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_queue_server
+
+import (
+	"context"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/webhook"
+	"log"
+)
+
+// 消费 webhook 的发送任务, 和邮件短信一样, 重试次数记录在发送记录中
+func init() {
+	message_queue.Register(message_queue.Definition{
+		Topic:   message_queue.TopicWebhook,
+		Channel: message_queue.ChanelWebhook,
+		New: func() message_queue.Job {
+			return &message_queue.WebhookBody{}
+		},
+		Handler:     handleWebhook,
+		Concurrency: 10,
+	})
+}
+
+func handleWebhook(_ context.Context, job message_queue.Job) error {
+	body := job.(*message_queue.WebhookBody)
+
+	retryAfter, err := webhook.Deliver(database.Db, body.Id)
+
+	if err == nil {
+		return nil
+	}
+
+	if retryAfter > 0 {
+		return message_queue.RetryAfter(err, retryAfter)
+	}
+
+	log.Printf("webhook 发送记录 %s 发送失败, 不再重试: %v\n", body.Id, err)
+
+	return nil
+}
@@ -18,8 +18,11 @@ import (
 	"github.com/axetroy/go-server/core/controller/oauth2"
 	"github.com/axetroy/go-server/core/controller/report"
 	"github.com/axetroy/go-server/core/controller/resource"
+	"github.com/axetroy/go-server/core/controller/search"
 	"github.com/axetroy/go-server/core/controller/signature"
+	"github.com/axetroy/go-server/core/controller/stream"
 	"github.com/axetroy/go-server/core/controller/transfer"
+	"github.com/axetroy/go-server/core/controller/unread"
 	"github.com/axetroy/go-server/core/controller/uploader"
 	"github.com/axetroy/go-server/core/controller/user"
 	"github.com/axetroy/go-server/core/controller/wallet"
@@ -107,6 +110,8 @@ func init() {
 			userRouter.PUT("/password2/reset", rbac.Require(*accession.Password2Reset), user.ResetPayPasswordRouter)      // 重置交易密码
 			userRouter.POST("/password2/reset", rbac.Require(*accession.Password2Reset), user.SendResetPayPasswordRouter) // 发送重置交易密码的邮件/短信
 			userRouter.POST("/avatar", user.UploadAvatarRouter)                                                           // 上传用户头像
+			userRouter.GET("/preference", user.GetPreferenceRouter)                                                       // 获取接收通知的偏好
+			userRouter.PUT("/preference", user.UpdatePreferenceRouter)                                                    // 更新接收通知的偏好
 
 			// 验证码类
 			{
@@ -161,6 +166,10 @@ func init() {
 			transferRouter.GET("", transfer.GetHistoryRouter)                                                           // 获取我的转账记录
 			transferRouter.POST("", rbac.Require(*accession.DoTransfer), middleware.AuthPayPassword, transfer.ToRouter) // 转账给某人
 			transferRouter.GET("/t/:transfer_id", transfer.GetDetailRouter)                                             // 获取单条转账详情
+			transferRouter.GET("/preview", transfer.PreviewRouter)                                                      // 转账前预览收款人
+			transferRouter.GET("/recipient", transfer.GetRecipientListRouter)                                           // 获取我的收款人列表
+			transferRouter.POST("/recipient", transfer.SaveRecipientRouter)                                             // 保存收款人
+			transferRouter.DELETE("/recipient/r/:recipient_id", transfer.DeleteRecipientRouter)                         // 删除收款人
 		}
 
 		// 财务日志
@@ -173,8 +182,15 @@ func init() {
 		// 新闻咨询类
 		{
 			newsRouter := v1.Group("/news")
-			newsRouter.GET("", news.GetNewsListByUserRouter) // 获取新闻公告列表
-			newsRouter.GET("/n/:id", news.GetNewsRouter)     // 获取单个新闻公告详情
+			newsRouter.GET("", news.GetNewsListByUserRouter)                                          // 获取新闻公告列表
+			newsRouter.GET("/n/:news_id", news.GetNewsByUserRouter)                                   // 获取单个新闻公告详情
+			newsRouter.GET("/feed", news.GetFeedRouter)                                               // 新闻公告的订阅源, RSS/Atom/JSON Feed
+			newsRouter.GET("/n/:news_id/comment", news.GetCommentListRouter)                          // 获取新闻公告的评论
+			newsRouter.POST("/n/:news_id/comment", userAuthMiddleware, news.CreateCommentRouter)      // 发表评论或者回复
+			newsRouter.DELETE("/comment/c/:comment_id", userAuthMiddleware, news.DeleteCommentRouter) // 删除自己的评论
+			newsRouter.GET("/n/:news_id/like", userAuthMiddleware, news.GetLikeRouter)                // 获取点赞状态
+			newsRouter.POST("/n/:news_id/like", userAuthMiddleware, news.LikeRouter)                  // 点赞
+			newsRouter.DELETE("/n/:news_id/like", userAuthMiddleware, news.UnlikeRouter)              // 取消点赞
 		}
 
 		// 系统通知
@@ -182,6 +198,7 @@ func init() {
 			notificationRouter := v1.Group("/notification")
 			notificationRouter.Use(userAuthMiddleware)
 			notificationRouter.GET("", notification.GetNotificationListByUserRouter) // 获取系统通知列表
+			notificationRouter.PUT("/read", notification.ReadAllRouter)              // 标记所有通知为已读
 			notificationRouter.GET("/n/:id", notification.GetRouter)                 // 获取某一条系统通知详情
 			notificationRouter.PUT("/n/:id/read", notification.ReadRouter)           // 标记通知为已读
 		}
@@ -191,11 +208,28 @@ func init() {
 			messageRouter := v1.Group("/message")
 			messageRouter.Use(userAuthMiddleware)
 			messageRouter.GET("", message.GetMessageListByUserRouter)          // 获取我的消息列表
+			messageRouter.DELETE("", message.BatchDeleteByUserRouter)          // 批量删除消息
+			messageRouter.PUT("/read", message.ReadAllRouter)                  // 标记所有消息为已读
 			messageRouter.GET("/m/:message_id", message.GetRouter)             // 获取单个消息详情
 			messageRouter.PUT("/m/:message_id/read", message.ReadRouter)       // 标记消息为已读
 			messageRouter.DELETE("/m/:message_id", message.DeleteByUserRouter) // 删除消息
 		}
 
+		// 未读数
+		{
+			unreadRouter := v1.Group("/unread")
+			unreadRouter.Use(userAuthMiddleware)
+			unreadRouter.GET("", unread.GetRouter) // 获取未读的消息和通知数量
+		}
+
+		// 实时推送消息/通知/转账
+		{
+			streamRouter := v1.Group("/stream")
+			streamRouter.Use(userAuthMiddleware)
+			streamRouter.GET("/ws", stream.WebSocketRouter) // 通过 WebSocket 推送
+			streamRouter.GET("/sse", stream.SSERouter)      // 通过 Server-Sent Events 推送
+		}
+
 		// 用户反馈
 		{
 			reportRouter := v1.Group("/report")
@@ -211,6 +245,12 @@ func init() {
 			helpRouter := v1.Group("help")
 			helpRouter.GET("", help.GetHelpListRouter)        // 创建帮助列表
 			helpRouter.GET("/h/:help_id", help.GetHelpRouter) // 获取帮助详情
+			helpRouter.GET("/tree", help.GetTreeByUserRouter) // 获取帮助中心的目录树
+		}
+
+		// 全文搜索
+		{
+			v1.GET("/search", search.SearchRouter) // 搜索新闻公告, 帮助文章和系统通知
 		}
 
 		// Banner
@@ -0,0 +1,40 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package content
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 渲染结果的缓存时间
+var CacheExpiration = time.Hour * 24 * 7
+
+// 缓存的键, 除了版本号还包含正文的哈希, 即使版本号相同, 内容不同时也不会取到别的内容的缓存
+func CacheKey(kind string, id string, revision string, format model.ContentFormat, source string) string {
+	return strings.Join([]string{kind, id, revision, string(format), util.MD5(source)}, ":")
+}
+
+// 渲染正文并按版本缓存, 内容修改之后版本号变化, 旧版本的缓存等过期之后自动清除
+// 缓存不可用时直接渲染, 不影响正常返回
+func Cached(kind string, id string, revision string, format model.ContentFormat, source string) string {
+	key := CacheKey(kind, id, revision, format, source)
+
+	if raw, err := redis.ClientContent.Get(key).Result(); err == nil {
+		return raw
+	}
+
+	result := Render(format, source)
+
+	_ = redis.ClientContent.Set(key, result, CacheExpiration).Err()
+
+	return result
+}
+
+// 没有版本号的内容使用更新时间作为版本
+func RevisionOf(updatedAt time.Time) string {
+	return strconv.FormatInt(updatedAt.UnixNano(), 10)
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+// 文章/通知/消息正文的格式处理, 负责把正文渲染成安全的 HTML 以及生成纯文本摘要
+package content
+
+import (
+	"bytes"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/russross/blackfriday/v2"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 摘要的最大长度(字符数)
+const ExcerptLength = 200
+
+// 把正文渲染成可以直接展示的 HTML, 输出一定经过白名单过滤
+func Render(format model.ContentFormat, source string) string {
+	switch format {
+	case model.ContentFormatMarkdown:
+		return Sanitize(string(blackfriday.Run([]byte(source))))
+	case model.ContentFormatPlain:
+		return renderPlain(source)
+	default:
+		return Sanitize(source)
+	}
+}
+
+// 从正文中提取纯文本摘要, 超出 n 个字符的部分用省略号代替
+func Excerpt(format model.ContentFormat, source string, n int) string {
+	var text string
+
+	if format == model.ContentFormatPlain {
+		text = source
+	} else {
+		text = Text(Render(format, source))
+	}
+
+	return truncate(strings.Join(strings.Fields(text), " "), n)
+}
+
+// 纯文本按空行分段, 段内换行转成 <br>
+func renderPlain(source string) string {
+	var buf bytes.Buffer
+
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	for _, p := range strings.Split(source, "\n\n") {
+		p = strings.Trim(p, "\n")
+
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+
+		lines := strings.Split(p, "\n")
+
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+
+		buf.WriteString("<p>")
+		buf.WriteString(strings.Join(lines, "<br>"))
+		buf.WriteString("</p>")
+	}
+
+	return buf.String()
+}
+
+func truncate(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	runes := []rune(s)
+
+	return strings.TrimRightFunc(string(runes[:n]), unicode.IsSpace) + "…"
+}
+
+// 检查正文的格式, 没有指定时默认为 HTML
+func ParseFormat(f *model.ContentFormat) (model.ContentFormat, error) {
+	if f == nil || *f == "" {
+		return model.ContentFormatHTML, nil
+	}
+
+	if !model.IsValidContentFormat(*f) {
+		return "", exception.InvalidContentFormat
+	}
+
+	return *f, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package content_test
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		input  string
+		output string
+	}{
+		{`<p>hello <b>world</b></p>`, `<p>hello <b>world</b></p>`},
+		{`<p onclick="alert(1)">hi</p>`, `<p>hi</p>`},
+		{`<script>alert(1)</script>ok`, `ok`},
+		{`<style>p{}</style><iframe src="https://example.com"></iframe>ok`, `ok`},
+		{`<font color="red">red</font>`, `red`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{`<a href="JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"<a href=\"java\tscript:alert(1)\">x</a>", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{`<a href="https://example.com/?a=1&b=2" target="_blank">x</a>`, `<a href="https://example.com/?a=1&amp;b=2" rel="nofollow noopener noreferrer">x</a>`},
+		{`<a href="/help/1">x</a>`, `<a href="/help/1" rel="nofollow noopener noreferrer">x</a>`},
+		{`<img src="data:image/png;base64,xxx" alt="a">`, `<img alt="a">`},
+		{`<img src="https://example.com/a.png" onerror="alert(1)" width="100">`, `<img src="https://example.com/a.png" width="100">`},
+		{`<code class="language-go">x</code><code class="x y">y</code>`, `<code class="language-go">x</code><code>y</code>`},
+		{`<td colspan="javascript">x</td>`, `x`},
+		{`<!-- comment -->1 &lt; 2`, `1 &lt; 2`},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.output, content.Sanitize(c.input), c.input)
+	}
+}
+
+func TestRender(t *testing.T) {
+	// markdown 中的 HTML 也要经过过滤
+	html := content.Render(model.ContentFormatMarkdown, "# Title\n\n**bold** <script>alert(1)</script>\n\n[link](javascript:alert(1))")
+
+	assert.True(t, strings.Contains(html, "<h1>Title</h1>"))
+	assert.True(t, strings.Contains(html, "<strong>bold</strong>"))
+	assert.False(t, strings.Contains(html, "script"))
+	assert.False(t, strings.Contains(html, "javascript"))
+
+	// 纯文本会被转义
+	assert.Equal(t, "<p>a &lt;b&gt;<br>c</p><p>d</p>", content.Render(model.ContentFormatPlain, "a <b>\nc\n\n\nd"))
+
+	assert.Equal(t, "<p>hi</p>", content.Render(model.ContentFormatHTML, `<p style="color: red">hi</p>`))
+}
+
+func TestExcerpt(t *testing.T) {
+	assert.Equal(t, "Title bold text", content.Excerpt(model.ContentFormatMarkdown, "# Title\n\n**bold** text", 100))
+	assert.Equal(t, "hello world", content.Excerpt(model.ContentFormatHTML, "<p>hello</p><script>x</script><p>world</p>", 100))
+	assert.Equal(t, "<b> 你好", content.Excerpt(model.ContentFormatPlain, "<b>\n\n 你好 ", 100))
+	assert.Equal(t, "你好…", content.Excerpt(model.ContentFormatPlain, "你好 世界", 3))
+}
+
+func TestCacheKey(t *testing.T) {
+	a := content.CacheKey("news", "1", "2", model.ContentFormatMarkdown, "a")
+
+	assert.Equal(t, a, content.CacheKey("news", "1", "2", model.ContentFormatMarkdown, "a"))
+
+	// 版本号相同但内容不同时不能共用缓存
+	assert.NotEqual(t, a, content.CacheKey("news", "1", "2", model.ContentFormatMarkdown, "b"))
+	assert.NotEqual(t, a, content.CacheKey("news", "1", "2", model.ContentFormatHTML, "a"))
+}
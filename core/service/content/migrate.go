@@ -0,0 +1,59 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package content
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+const batchSize = 500
+
+// 有正文格式和摘要的表
+var Tables = []string{"news", "help", "notification", "message"}
+
+type row struct {
+	Id      string
+	Format  model.ContentFormat
+	Content string
+}
+
+// 为还没有摘要的旧数据生成摘要
+func Migrate(db *gorm.DB) error {
+	for _, t := range Tables {
+		if err := backfill(db, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func backfill(db *gorm.DB, t string) error {
+	last := ""
+
+	for {
+		rows := make([]row, 0)
+
+		if err := db.Table(t).Select("id, format, content").Where("id > ?", last).Where("excerpt = '' AND content <> ''").Order("id ASC").Limit(batchSize).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			excerpt := Excerpt(r.Format, r.Content, ExcerptLength)
+
+			if excerpt == "" {
+				continue
+			}
+
+			if err := db.Table(t).Where("id = ?", r.Id).UpdateColumn("excerpt", excerpt).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < batchSize {
+			return nil
+		}
+
+		last = rows[len(rows)-1].Id
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package content
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 允许保留的标签以及标签上允许的属性
+	allowedTags = map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+		"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "del": nil, "ins": nil,
+		"sub": nil, "sup": nil, "small": nil, "mark": nil, "abbr": nil,
+		"blockquote": nil, "pre": nil, "code": {"class"}, "kbd": nil,
+		"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+		"a":     {"href"},
+		"img":   {"src", "alt", "width", "height"},
+		"table": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil, "caption": nil,
+		"th": {"colspan", "rowspan", "align"}, "td": {"colspan", "rowspan", "align"},
+		"figure": nil, "figcaption": nil,
+	}
+
+	// 连同内容一起丢弃的标签
+	droppedTags = map[string]bool{
+		"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+		"object": true, "embed": true, "applet": true, "noscript": true, "template": true,
+		"textarea": true, "select": true, "button": true, "svg": true, "math": true,
+		"head": true, "title": true, "meta": true, "link": true, "base": true,
+	}
+
+	voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+	// 块级标签, 提取纯文本时在前后补空白
+	blockTags = map[string]bool{
+		"p": true, "br": true, "hr": true, "div": true, "li": true, "dt": true, "dd": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"blockquote": true, "pre": true, "tr": true, "td": true, "th": true, "figcaption": true,
+	}
+
+	codeClassReg = regexp.MustCompile(`^language-[\w-]+$`)
+	numberReg    = regexp.MustCompile(`^\d{1,4}%?$`)
+	alignValues  = map[string]bool{"left": true, "center": true, "right": true}
+)
+
+// 过滤 HTML, 只保留白名单内的标签和属性
+// 不在白名单内的标签会去掉标签本身而保留内容, 脚本类的标签连同内容一起丢弃
+func Sanitize(source string) string {
+	var buf bytes.Buffer
+
+	for _, n := range parse(source) {
+		sanitizeNode(&buf, n)
+	}
+
+	return buf.String()
+}
+
+// 提取 HTML 中的纯文本
+func Text(source string) string {
+	var buf bytes.Buffer
+
+	for _, n := range parse(source) {
+		textNode(&buf, n)
+	}
+
+	return buf.String()
+}
+
+func parse(source string) []*html.Node {
+	nodes, err := html.ParseFragment(strings.NewReader(source), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+
+	if err != nil {
+		return nil
+	}
+
+	return nodes
+}
+
+func sanitizeNode(buf *bytes.Buffer, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// 注释, doctype 等直接丢弃
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+
+	if droppedTags[tag] {
+		return
+	}
+
+	attrs, allowed := allowedTags[tag]
+
+	if allowed {
+		buf.WriteString("<" + tag)
+
+		for _, attr := range n.Attr {
+			if value, ok := sanitizeAttr(attr, attrs); ok {
+				buf.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+			}
+		}
+
+		if tag == "a" {
+			buf.WriteString(` rel="nofollow noopener noreferrer"`)
+		}
+
+		buf.WriteString(">")
+
+		if voidTags[tag] {
+			return
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(buf, c)
+	}
+
+	if allowed {
+		buf.WriteString("</" + tag + ">")
+	}
+}
+
+func sanitizeAttr(attr html.Attribute, allowed []string) (string, bool) {
+	if attr.Namespace != "" {
+		return "", false
+	}
+
+	key := strings.ToLower(attr.Key)
+
+	if key == "title" {
+		return attr.Val, true
+	}
+
+	for _, a := range allowed {
+		if a != key {
+			continue
+		}
+
+		value := strings.TrimSpace(attr.Val)
+
+		switch key {
+		case "href":
+			return value, isSafeURL(value, "http", "https", "mailto")
+		case "src":
+			return value, isSafeURL(value, "http", "https")
+		case "class":
+			return value, codeClassReg.MatchString(value)
+		case "alt":
+			return attr.Val, true
+		case "align":
+			return value, alignValues[strings.ToLower(value)]
+		default:
+			return value, numberReg.MatchString(value)
+		}
+	}
+
+	return "", false
+}
+
+// 链接只能是相对地址或者指定协议的地址
+func isSafeURL(value string, schemes ...string) bool {
+	if value == "" {
+		return false
+	}
+
+	// 浏览器会忽略协议中的空白和控制字符, 例如 "java\tscript:"
+	for _, r := range value {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+
+	u, err := url.Parse(value)
+
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" {
+		return !strings.Contains(strings.SplitN(value, "/", 2)[0], ":")
+	}
+
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func textNode(buf *bytes.Buffer, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+
+	if droppedTags[tag] {
+		return
+	}
+
+	if blockTags[tag] {
+		buf.WriteString(" ")
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		textNode(buf, c)
+	}
+
+	if blockTags[tag] {
+		buf.WriteString(" ")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+// 计数器, 增量先累加在 redis 的 hash 中, 再由定时任务批量写入数据库, 避免频繁地更新同一行
+package counter
+
+import (
+	"github.com/axetroy/go-server/core/service/redis"
+	"strconv"
+	"time"
+)
+
+// 正在写入数据库的增量
+func flushingKey(name string) string {
+	return name + ":flushing"
+}
+
+// 累加某个 ID 的增量
+func Incr(name string, id string, delta int64) error {
+	return redis.ClientCounter.HIncrBy(name, id, delta).Err()
+}
+
+// 只有第一次调用时返回 true, 用于去重, 例如同一个用户在一段时间内多次浏览只计算一次
+func Once(key string, ttl time.Duration) (bool, error) {
+	return redis.ClientCounter.SetNX(key, 1, ttl).Result()
+}
+
+// 还没有写入数据库的增量, 包括正在写入的部分, 获取失败时视为没有增量
+func Pending(name string, ids ...string) map[string]int64 {
+	result := map[string]int64{}
+
+	if len(ids) == 0 {
+		return result
+	}
+
+	for _, key := range []string{name, flushingKey(name)} {
+		values, err := redis.ClientCounter.HMGet(key, ids...).Result()
+
+		if err != nil {
+			continue
+		}
+
+		for i, v := range values {
+			if s, ok := v.(string); ok {
+				if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+					result[ids[i]] += n
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// 把累加的增量写入数据库, 返回写入的 ID 数量
+// 写入之前先把 hash 改名, 写入期间新的增量累加到新的 hash 中, 写入失败的部分留到下次继续写入
+// write 返回 nil 之后才会删除对应的增量, 所以 write 中的更新需要在一个事务中完成, 否则部分成功的写入会被重复累加
+func Flush(name string, write func(id string, delta int64) error) (count int, err error) {
+	key := flushingKey(name)
+
+	var exists int64
+
+	if exists, err = redis.ClientCounter.Exists(key).Result(); err != nil {
+		return
+	}
+
+	// 上次没有写完的先写完, 否则把当前累加的增量移过来
+	if exists == 0 {
+		if err = redis.ClientCounter.Rename(name, key).Err(); err != nil {
+			// 没有任何增量
+			if err.Error() == "ERR no such key" {
+				err = nil
+			}
+			return
+		}
+	}
+
+	var values map[string]string
+
+	if values, err = redis.ClientCounter.HGetAll(key).Result(); err != nil {
+		return
+	}
+
+	for id, raw := range values {
+		if delta, er := strconv.ParseInt(raw, 10, 64); er == nil && delta != 0 {
+			if err = write(id, delta); err != nil {
+				return
+			}
+
+			count++
+		}
+
+		if err = redis.ClientCounter.HDel(key, id).Err(); err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/axetroy/go-server/core/config"
 	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/content"
 	"github.com/axetroy/go-server/core/service/dotenv"
+	"github.com/axetroy/go-server/core/service/search"
 	"github.com/axetroy/go-server/core/util"
 	"github.com/jinzhu/gorm"
 	_ "github.com/lib/pq"
@@ -34,35 +36,95 @@ func init() {
 	if Config.Sync == "on" {
 		log.Println("正在同步数据库...")
 
+		backfillPushed := notificationPushedMissing(db)
+
+		// 历史版本的版本号改为唯一, 先处理重复的版本号
+		if err := migrateNewsRevisionVersion(db); err != nil {
+			panic(err)
+		}
+
 		// Migrate the schema
 		db.AutoMigrate(
-			new(model.Admin),            // 管理员表
-			new(model.News),             // 新闻公告
-			new(model.User),             // 用户表
-			new(model.Role),             // 角色表 - RBAC
-			new(model.WalletCny),        // 钱包 - CNY
-			new(model.WalletUsd),        // 钱包 - USD
-			new(model.WalletCoin),       // 钱包 - COIN
-			new(model.InviteHistory),    // 邀请表
-			new(model.LoginLog),         // 登陆成功表
-			new(model.TransferLogCny),   // 转账记录 - CNY
-			new(model.TransferLogUsd),   // 转账记录 - USD
-			new(model.TransferLogCoin),  // 转账记录 - COIN
-			new(model.FinanceLogCny),    // 流水列表 - CNY
-			new(model.FinanceLogUsd),    // 流水列表 - USD
-			new(model.FinanceLogCoin),   // 流水列表 - COIN
-			new(model.Notification),     // 系统消息
-			new(model.NotificationMark), // 系统消息的已读记录
-			new(model.Message),          // 个人消息
-			new(model.Address),          // 收货地址
-			new(model.Banner),           // Banner 表
-			new(model.Report),           // 反馈表
-			new(model.Menu),             // 后台管理员菜单
-			new(model.Help),             // 帮助中心
-			new(model.WechatOpenID),     // 微信 open_id 外键表
-			new(model.OAuth),            // oAuth2 表
+			new(model.Admin),                     // 管理员表
+			new(model.News),                      // 新闻公告
+			new(model.NewsRevision),              // 新闻公告的历史版本
+			new(model.NewsComment),               // 新闻公告的评论
+			new(model.NewsLike),                  // 新闻公告的点赞
+			new(model.NewsCommentBan),            // 被禁止评论的用户
+			new(model.User),                      // 用户表
+			new(model.Role),                      // 角色表 - RBAC
+			new(model.WalletCny),                 // 钱包 - CNY
+			new(model.WalletUsd),                 // 钱包 - USD
+			new(model.WalletCoin),                // 钱包 - COIN
+			new(model.InviteHistory),             // 邀请表
+			new(model.LoginLog),                  // 登陆成功表
+			new(model.TransferLogCny),            // 转账记录 - CNY
+			new(model.TransferLogUsd),            // 转账记录 - USD
+			new(model.TransferLogCoin),           // 转账记录 - COIN
+			new(model.TransferRecipient),         // 转账的收款人
+			new(model.FinanceLogCny),             // 流水列表 - CNY
+			new(model.FinanceLogUsd),             // 流水列表 - USD
+			new(model.FinanceLogCoin),            // 流水列表 - COIN
+			new(model.FinanceReport),             // 每日财务报表
+			new(model.FinanceReportSubscription), // 订阅财务报表的管理员
+			new(model.Notification),              // 系统消息
+			new(model.NotificationMark),          // 系统消息的已读记录
+			new(model.NotificationPreference),    // 用户接收通知的偏好
+			new(model.Message),                   // 个人消息
+			new(model.MessageBatch),              // 群发个人消息的任务
+			new(model.MessageTemplate),           // 邮件和短信的模版
+			new(model.MessageTemplateVersion),    // 邮件和短信模版的历史版本
+			new(model.Delivery),                  // 邮件和短信的发送记录
+			new(model.SMSLog),                    // 调用短信服务商的记录
+			new(model.ScheduleRun),               // 定时任务的运行记录
+			new(model.Webhook),                   // webhook 订阅
+			new(model.WebhookDelivery),           // webhook 的发送记录
+			new(model.Address),                   // 收货地址
+			new(model.Banner),                    // Banner 表
+			new(model.Report),                    // 反馈表
+			new(model.Menu),                      // 后台管理员菜单
+			new(model.Help),                      // 帮助中心
+			new(model.WechatOpenID),              // 微信 open_id 外键表
+			new(model.OAuth),                     // oAuth2 表
 		)
 
+		// 之前启用和未启用的状态值相同, 还没有版本号的文章都视为已发布, 发布时间为创建时间
+		db.Model(model.News{}).Where("version = 0 AND published_at IS NULL").UpdateColumns(map[string]interface{}{
+			"status":       model.NewsStatusActive,
+			"published_at": gorm.Expr("created_at"),
+		})
+
+		// 全文搜索的列和索引, gorm 不支持 GIN 索引, 所以单独创建
+		if err := search.Migrate(db); err != nil {
+			panic(err)
+		}
+
+		// 旧数据没有摘要, 按照正文生成
+		if err := content.Migrate(db); err != nil {
+			panic(err)
+		}
+
+		// 旧的流水没有哈希, 补上之后才能通过哈希链的校验
+		if err := migrateFinanceChain(db); err != nil {
+			panic(err)
+		}
+
+		// 已读记录改为通知 ID 和用户 ID 的联合主键
+		if err := migrateNotificationMarkKey(db); err != nil {
+			panic(err)
+		}
+
+		// 发送记录中不再保存验证码
+		if err := migrateDeliveryCode(db); err != nil {
+			panic(err)
+		}
+
+		if backfillPushed {
+			if err := migrateNotificationPushed(db); err != nil {
+				panic(err)
+			}
+		}
+
 		log.Println("数据库同步完成.")
 	}
 
@@ -0,0 +1,15 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package database
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+// 旧的验证码发送记录中保存了验证码, 管理员可以在发送记录中看到, 需要去掉, 可以重复执行
+func migrateDeliveryCode(db *gorm.DB) error {
+	return db.Model(&model.Delivery{}).
+		Where("kind IN (?)", []string{"email_code", "sms_code"}).
+		Where("payload LIKE ?", `%"code"%`).
+		UpdateColumn("payload", gorm.Expr("(payload::jsonb - 'code')::text")).Error
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package database
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+// 为加入哈希链之前的旧流水补上哈希和序号
+// 按照 created_at, id 的顺序把同一用户同一币种的流水串成链, 只处理还没有哈希或者序号的流水, 可以重复执行
+func migrateFinanceChain(db *gorm.DB) error {
+	for _, currency := range model.Wallets {
+		tableName := "finance_log_" + currency
+
+		if !db.HasTable(tableName) {
+			continue
+		}
+
+		var uids []string
+
+		if err := db.Table(tableName).Where("hash = '' OR seq = 0").Pluck("DISTINCT uid", &uids).Error; err != nil {
+			return err
+		}
+
+		for _, uid := range uids {
+			if err := migrateUserFinanceChain(db, tableName, currency, uid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func migrateUserFinanceChain(db *gorm.DB, tableName string, currency string, uid string) (err error) {
+	tx := db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	if err = model.LockFinanceChain(tx, currency, uid); err != nil {
+		return
+	}
+
+	list := make([]model.FinanceLog, 0)
+
+	// 软删除的流水也属于链的一部分, 没有序号的旧流水排在前面
+	if err = tx.Unscoped().Table(tableName).Where("uid = ?", uid).Order("seq ASC").Order("created_at ASC").Order("id ASC").Find(&list).Error; err != nil {
+		return
+	}
+
+	var (
+		prevHash    string // 上一条流水现在的哈希
+		oldPrevHash string // 上一条流水原来的哈希
+	)
+
+	for i, log := range list {
+		oldHash := log.Hash
+		seq := int64(i + 1)
+
+		// 旧流水没有哈希, 另外接在旧流水后面的流水因为上一条的哈希改变了也要重新计算
+		if log.Hash == "" || (log.PrevHash == oldPrevHash && oldPrevHash != prevHash) {
+			log.PrevHash = prevHash
+
+			if log.Hash, err = log.GenerateHash(); err != nil {
+				return
+			}
+
+			if err = tx.Table(tableName).Where("id = ?", log.Id).UpdateColumns(map[string]interface{}{
+				"prev_hash": log.PrevHash,
+				"hash":      log.Hash,
+			}).Error; err != nil {
+				return
+			}
+		}
+
+		if log.Seq != seq {
+			if err = tx.Table(tableName).Where("id = ?", log.Id).UpdateColumn("seq", seq).Error; err != nil {
+				return
+			}
+		}
+
+		oldPrevHash = oldHash
+		prevHash = log.Hash
+	}
+
+	return
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package database
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+// 以前并发修改文章时可能保存出版本号相同的历史版本, 建立 (news_id, version) 的唯一索引会失败
+// 需要在 AutoMigrate 之前按照版本号和创建时间重新编号, 文章的版本号改为最新的版本号, 可以重复执行
+func migrateNewsRevisionVersion(db *gorm.DB) (err error) {
+	if !db.HasTable(&model.NewsRevision{}) {
+		return nil
+	}
+
+	ids := make([]string, 0)
+
+	if err = db.Model(&model.NewsRevision{}).Group("news_id, version").Having("COUNT(*) > 1").Pluck("DISTINCT news_id", &ids).Error; err != nil {
+		return
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx := db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	if err = tx.Exec(`UPDATE news_revision AS r SET version = t.seq FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY news_id ORDER BY version, created_at, id) AS seq FROM news_revision WHERE news_id IN (?)
+	) AS t WHERE r.id = t.id`, ids).Error; err != nil {
+		return
+	}
+
+	err = tx.Exec("UPDATE news SET version = (SELECT MAX(version) FROM news_revision WHERE news_revision.news_id = news.id) WHERE id IN (?)", ids).Error
+
+	return
+}
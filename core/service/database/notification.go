@@ -0,0 +1,71 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package database
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+// 通知表是否还没有 pushed 列, 需要在 AutoMigrate 之前判断
+func notificationPushedMissing(db *gorm.DB) bool {
+	return db.HasTable(&model.Notification{}) && !db.Dialect().HasColumn("notification", "pushed")
+}
+
+// 新增的 pushed 列默认为 false, 已经发布的旧通知在创建时就推送过了, 标记为已推送, 否则定时任务会再推送一次
+func migrateNotificationPushed(db *gorm.DB) error {
+	return db.Unscoped().Model(&model.Notification{}).
+		Where("publish_at IS NULL OR publish_at <= ?", time.Now()).
+		UpdateColumn("pushed", true).Error
+}
+
+// 已读记录原来的主键只有通知 ID, 并且通知 ID 和用户 ID 各自唯一, 同一条通知只能有一个用户标记已读
+// AutoMigrate 不会修改已有的主键和约束, 需要删除旧的约束, 改为通知 ID 和用户 ID 的联合主键, 可以重复执行
+func migrateNotificationMarkKey(db *gorm.DB) (err error) {
+	if !db.HasTable(&model.NotificationMark{}) {
+		return nil
+	}
+
+	type constraint struct {
+		Name       string
+		Type       string
+		Definition string
+	}
+
+	list := make([]constraint, 0)
+
+	if err = db.Raw("SELECT conname AS name, contype::text AS type, pg_get_constraintdef(oid) AS definition FROM pg_constraint WHERE conrelid = 'notification_mark'::regclass AND contype IN ('p', 'u')").Scan(&list).Error; err != nil {
+		return
+	}
+
+	tx := db.Begin()
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback().Error
+		} else {
+			err = tx.Commit().Error
+		}
+	}()
+
+	hasKey := false
+
+	for _, c := range list {
+		if c.Type == "p" && c.Definition == "PRIMARY KEY (id, uid)" {
+			hasKey = true
+			continue
+		}
+
+		if err = tx.Exec(fmt.Sprintf(`ALTER TABLE notification_mark DROP CONSTRAINT "%s"`, strings.Replace(c.Name, `"`, `""`, -1))).Error; err != nil {
+			return
+		}
+	}
+
+	if !hasKey {
+		err = tx.Exec("ALTER TABLE notification_mark ADD PRIMARY KEY (id, uid)").Error
+	}
+
+	return
+}
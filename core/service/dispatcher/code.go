@@ -0,0 +1,94 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher
+
+import (
+	"errors"
+	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/telephone"
+)
+
+// 验证码的类型
+type CodeType string
+
+const (
+	CodeActivation         CodeType = "activation"           // 激活账号
+	CodeAuth               CodeType = "auth"                 // 身份验证
+	CodeRegister           CodeType = "register"             // 注册帐号, 邮件发送的是注册链接
+	CodeResetPassword      CodeType = "reset_password"       // 重置登陆密码
+	CodeResetTradePassword CodeType = "reset_trade_password" // 重置交易密码
+)
+
+var ErrInvalidCodeType = errors.New("invalid code type")
+
+func isValidCodeType(codeType CodeType) bool {
+	switch codeType {
+	case CodeActivation, CodeAuth, CodeRegister, CodeResetPassword, CodeResetTradePassword:
+		return true
+	default:
+		return false
+	}
+}
+
+// 发送验证码到指定的邮箱, 加入消息队列之后立即返回
+// 验证码是用户主动请求的, 并且接收的邮箱不一定已经绑定了用户, 所以不受通知偏好和免打扰的限制
+func SendEmailCode(to string, codeType CodeType, code string) error {
+	if !isValidCodeType(codeType) {
+		return ErrInvalidCodeType
+	}
+
+	_, err := enqueueCode(KindEmailCode, ChannelEmail, to, codeType, code)
+
+	return err
+}
+
+// 发送验证码到指定的手机号, 同样不受通知偏好和免打扰的限制
+func SendSMSCode(phone string, codeType CodeType, code string) error {
+	if !isValidCodeType(codeType) {
+		return ErrInvalidCodeType
+	}
+
+	_, err := enqueueCode(KindSMSCode, ChannelSMS, phone, codeType, code)
+
+	return err
+}
+
+// 发送每日财务报表给订阅的管理员, 管理员没有通知偏好
+func SendFinanceReport(to []string, date string, content string) error {
+	_, err := Enqueue(KindFinanceReport, ChannelEmail, to, FinanceReportPayload{Date: date, Content: content})
+
+	return err
+}
+
+func sendEmailCode(to string, codeType CodeType, code string) error {
+	mailer := email.NewMailer()
+
+	switch codeType {
+	case CodeActivation:
+		return mailer.SendActivationEmail(to, code)
+	case CodeAuth:
+		return mailer.SendAuthEmail(to, code)
+	case CodeRegister:
+		return mailer.SendRegisterEmail(to, code)
+	case CodeResetPassword:
+		return mailer.SendForgotPasswordEmail(to, code)
+	case CodeResetTradePassword:
+		return mailer.SendForgotTradePasswordEmail(to, code)
+	default:
+		return ErrInvalidCodeType
+	}
+}
+
+func sendSMSCode(phone string, codeType CodeType, code string) error {
+	client := telephone.GetClient()
+
+	switch codeType {
+	case CodeAuth, CodeActivation:
+		return client.SendAuthCode(phone, code)
+	case CodeRegister:
+		return client.SendRegisterCode(phone, code)
+	case CodeResetPassword, CodeResetTradePassword:
+		return client.SendResetPasswordCode(phone, code)
+	default:
+		return ErrInvalidCodeType
+	}
+}
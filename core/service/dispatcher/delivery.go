@@ -0,0 +1,292 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/email"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/service/telephone"
+	goRedis "github.com/go-redis/redis"
+	"github.com/jinzhu/gorm"
+	"log"
+	"strings"
+	"time"
+)
+
+// 发送的类型, 决定了发送记录中 payload 的格式
+type Kind string
+
+const (
+	KindEmailCode         Kind = "email_code"         // 邮件验证码, payload 为 CodePayload, 验证码保存在 redis 中
+	KindSMSCode           Kind = "sms_code"           // 短信验证码, payload 为 CodePayload, 验证码保存在 redis 中
+	KindEmailNotification Kind = "email_notification" // 通知邮件, payload 为 NotificationPayload
+	KindSMSNotification   Kind = "sms_notification"   // 通知短信, payload 为 NotificationPayload
+	KindFinanceReport     Kind = "finance_report"     // 每日财务报表, payload 为 FinanceReportPayload
+)
+
+const (
+	MaxAttempts = 5                // 最多尝试发送的次数, 超过则加入死信队列
+	RetryDelay  = time.Second * 10 // 第一次重试的间隔, 之后每次翻倍
+	CodeTTL     = time.Minute * 30 // 验证码在 redis 中保存的时间, 不短于验证码本身的有效期
+)
+
+var (
+	ErrInvalidKind = errors.New("invalid delivery kind")
+	ErrCodeExpired = errors.New("code has expired")
+)
+
+// 发送记录和管理员可以查看的内容中不包含验证码, 验证码以发送记录的 ID 保存在 redis 中, 发送时再取出
+type CodePayload struct {
+	Type CodeType `json:"type"` // 验证码的类型
+}
+
+func isCodeKind(kind Kind) bool {
+	return kind == KindEmailCode || kind == KindSMSCode
+}
+
+type NotificationPayload struct {
+	Title   string `json:"title"`   // 标题, 短信没有标题
+	Content string `json:"content"` // 内容
+}
+
+type FinanceReportPayload struct {
+	Date    string `json:"date"`    // 报表的日期
+	Content string `json:"content"` // 报表的 HTML 内容
+}
+
+// 第 attempts 次发送失败之后, 等待多久再重试
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return RetryDelay << uint(attempts-1)
+}
+
+// 从 redis 中取出等待发送的验证码, 过期之后不再发送
+func getCode(id string) (string, error) {
+	code, err := redis.ClientDeliveryCode.Get(id).Result()
+
+	if err == goRedis.Nil {
+		return "", ErrCodeExpired
+	}
+
+	return code, err
+}
+
+func publish(id string) error {
+	return message_queue.Enqueue(context.Background(), message_queue.DeliveryBody{Id: id})
+}
+
+// 记录要发送的邮件或者短信, 并加入消息队列, 不会等待发送完成
+// 加入队列失败时发送记录会保持等待发送的状态, 管理员可以在发送记录中重新发送
+func Enqueue(kind Kind, channel Channel, recipients []string, payload interface{}) (d model.Delivery, err error) {
+	var b []byte
+
+	if b, err = json.Marshal(payload); err != nil {
+		return
+	}
+
+	d = model.Delivery{
+		Kind:      string(kind),
+		Channel:   string(channel),
+		Recipient: strings.Join(recipients, ","),
+		Payload:   string(b),
+		Status:    model.DeliveryStatusPending,
+	}
+
+	if err = database.Db.Create(&d).Error; err != nil {
+		return
+	}
+
+	err = publish(d.Id)
+
+	return
+}
+
+// 记录要发送的验证码, 验证码不写入发送记录
+func enqueueCode(kind Kind, channel Channel, to string, codeType CodeType, code string) (d model.Delivery, err error) {
+	var b []byte
+
+	if b, err = json.Marshal(CodePayload{Type: codeType}); err != nil {
+		return
+	}
+
+	d = model.Delivery{
+		Kind:      string(kind),
+		Channel:   string(channel),
+		Recipient: to,
+		Payload:   string(b),
+		Status:    model.DeliveryStatusPending,
+	}
+
+	if err = database.Db.Create(&d).Error; err != nil {
+		return
+	}
+
+	if err = redis.ClientDeliveryCode.Set(d.Id, code, CodeTTL).Err(); err != nil {
+		return
+	}
+
+	err = publish(d.Id)
+
+	return
+}
+
+// 发送一条记录, 由消息队列的消费者调用
+// 发送失败时返回错误, retryAfter 大于 0 则应该在这之后重试, 否则已经加入死信队列
+// 已经发送成功或者已经放弃的记录会被忽略, 所以重复的消息不会重复发送
+func Deliver(db *gorm.DB, id string) (retryAfter time.Duration, err error) {
+	d := model.Delivery{Id: id}
+
+	if err = db.First(&d).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.DeliveryNotExist
+		}
+		return
+	}
+
+	if d.Status != model.DeliveryStatusPending {
+		return
+	}
+
+	sendErr := send(d)
+
+	d.Attempts = d.Attempts + 1
+
+	updates := map[string]interface{}{
+		"attempts": d.Attempts,
+	}
+
+	if sendErr == nil {
+		now := time.Now()
+		updates["status"] = model.DeliveryStatusSent
+		updates["sent_at"] = &now
+		updates["error"] = ""
+	} else {
+		updates["error"] = sendErr.Error()
+
+		// 验证码已经过期, 重试也没有意义
+		if d.Attempts >= MaxAttempts || sendErr == ErrCodeExpired {
+			updates["status"] = model.DeliveryStatusDead
+		} else {
+			retryAfter = Backoff(d.Attempts)
+		}
+	}
+
+	if err = db.Model(&d).Where("status = ?", model.DeliveryStatusPending).Updates(updates).Error; err != nil {
+		return
+	}
+
+	// 验证码已经发送, 不再保留
+	if sendErr == nil && isCodeKind(Kind(d.Kind)) {
+		_ = redis.ClientDeliveryCode.Del(d.Id).Err()
+	}
+
+	if sendErr != nil && retryAfter == 0 {
+		body, _ := json.Marshal(message_queue.DeliveryBody{Id: d.Id})
+
+		if er := message_queue.Publish(message_queue.TopicDeliveryDead, body); er != nil {
+			log.Printf("发送记录 %s 加入死信队列失败: %v\n", d.Id, er)
+		}
+	}
+
+	err = sendErr
+
+	return
+}
+
+// 重新发送一条记录, 不管之前是否发送成功
+// 验证码不会保存在发送记录中, 并且旧的验证码可能已经失效, 所以不能重新发送
+func Resend(db *gorm.DB, id string) (d model.Delivery, err error) {
+	d = model.Delivery{Id: id}
+
+	if err = db.First(&d).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.DeliveryNotExist
+		}
+		return
+	}
+
+	if isCodeKind(Kind(d.Kind)) {
+		err = exception.DeliveryCodeNotResendable
+		return
+	}
+
+	if err = db.Model(&d).Updates(map[string]interface{}{
+		"status":   model.DeliveryStatusPending,
+		"attempts": 0,
+		"sent_at":  nil,
+	}).Error; err != nil {
+		return
+	}
+
+	err = publish(d.Id)
+
+	return
+}
+
+func send(d model.Delivery) error {
+	switch Kind(d.Kind) {
+	case KindEmailCode:
+		p := CodePayload{}
+
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			return err
+		}
+
+		code, err := getCode(d.Id)
+
+		if err != nil {
+			return err
+		}
+
+		return sendEmailCode(d.Recipient, p.Type, code)
+	case KindSMSCode:
+		p := CodePayload{}
+
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			return err
+		}
+
+		code, err := getCode(d.Id)
+
+		if err != nil {
+			return err
+		}
+
+		return sendSMSCode(d.Recipient, p.Type, code)
+	case KindEmailNotification:
+		p := NotificationPayload{}
+
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			return err
+		}
+
+		return email.NewMailer().SendNotificationEmail(d.Recipient, p.Title, p.Content)
+	case KindSMSNotification:
+		p := NotificationPayload{}
+
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			return err
+		}
+
+		return telephone.GetClient().SendNotification(d.Recipient, p.Content)
+	case KindFinanceReport:
+		p := FinanceReportPayload{}
+
+		if err := json.Unmarshal([]byte(d.Payload), &p); err != nil {
+			return err
+		}
+
+		return email.NewMailer().SendFinanceReportEmail(strings.Split(d.Recipient, ","), p.Date, p.Content)
+	default:
+		return ErrInvalidKind
+	}
+}
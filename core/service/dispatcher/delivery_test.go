@@ -0,0 +1,103 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher_test
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, dispatcher.RetryDelay, dispatcher.Backoff(0))
+	assert.Equal(t, dispatcher.RetryDelay, dispatcher.Backoff(1))
+	assert.Equal(t, time.Second*20, dispatcher.Backoff(2))
+	assert.Equal(t, time.Second*80, dispatcher.Backoff(4))
+}
+
+func TestDeliver(t *testing.T) {
+	d := model.Delivery{
+		Kind:      "invalid",
+		Channel:   string(dispatcher.ChannelEmail),
+		Recipient: "test@example.com",
+		Payload:   "{}",
+		Status:    model.DeliveryStatusPending,
+	}
+
+	assert.Nil(t, database.Db.Create(&d).Error)
+
+	defer database.DeleteRowByTable("delivery", "id", d.Id)
+
+	// 发送失败, 等待重试
+	{
+		retryAfter, err := dispatcher.Deliver(database.Db, d.Id)
+
+		assert.Equal(t, dispatcher.ErrInvalidKind, err)
+		assert.Equal(t, dispatcher.Backoff(1), retryAfter)
+
+		info := model.Delivery{Id: d.Id}
+
+		assert.Nil(t, database.Db.First(&info).Error)
+		assert.Equal(t, model.DeliveryStatusPending, info.Status)
+		assert.Equal(t, 1, info.Attempts)
+		assert.Equal(t, dispatcher.ErrInvalidKind.Error(), info.Error)
+	}
+
+	// 达到最大的尝试次数, 不再重试
+	{
+		assert.Nil(t, database.Db.Model(&d).Update("attempts", dispatcher.MaxAttempts-1).Error)
+
+		retryAfter, err := dispatcher.Deliver(database.Db, d.Id)
+
+		assert.Equal(t, dispatcher.ErrInvalidKind, err)
+		assert.Equal(t, time.Duration(0), retryAfter)
+
+		info := model.Delivery{Id: d.Id}
+
+		assert.Nil(t, database.Db.First(&info).Error)
+		assert.Equal(t, model.DeliveryStatusDead, info.Status)
+	}
+
+	// 已经放弃的记录会被忽略
+	{
+		retryAfter, err := dispatcher.Deliver(database.Db, d.Id)
+
+		assert.Nil(t, err)
+		assert.Equal(t, time.Duration(0), retryAfter)
+	}
+
+	// 记录不存在
+	{
+		_, err := dispatcher.Deliver(database.Db, "123123")
+
+		assert.Equal(t, exception.DeliveryNotExist, err)
+	}
+}
+
+func TestDeliverExpiredCode(t *testing.T) {
+	d := model.Delivery{
+		Kind:      string(dispatcher.KindSMSCode),
+		Channel:   string(dispatcher.ChannelSMS),
+		Recipient: "13800000000",
+		Payload:   `{"type":"auth"}`,
+		Status:    model.DeliveryStatusPending,
+	}
+
+	assert.Nil(t, database.Db.Create(&d).Error)
+
+	defer database.DeleteRowByTable("delivery", "id", d.Id)
+
+	// redis 中没有验证码, 直接放弃, 不再重试
+	retryAfter, err := dispatcher.Deliver(database.Db, d.Id)
+
+	assert.Equal(t, dispatcher.ErrCodeExpired, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	info := model.Delivery{Id: d.Id}
+
+	assert.Nil(t, database.Db.First(&info).Error)
+	assert.Equal(t, model.DeliveryStatusDead, info.Status)
+}
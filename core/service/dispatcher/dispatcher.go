@@ -0,0 +1,157 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/jinzhu/gorm"
+	"github.com/mitchellh/mapstructure"
+	"log"
+	"time"
+)
+
+// 通知的类别, 用户可以为每个类别单独选择接收的渠道
+type Category string
+
+const (
+	CategoryTransfer     Category = "transfer"     // 转账
+	CategorySecurity     Category = "security"     // 账号安全
+	CategoryAnnouncement Category = "announcement" // 公告
+)
+
+// 接收通知的渠道
+type Channel string
+
+const (
+	ChannelInApp Channel = "in_app" // 站内信, 即个人消息
+	ChannelEmail Channel = "email"  // 邮件
+	ChannelSMS   Channel = "sms"    // 短信
+)
+
+var (
+	Categories = []Category{CategoryTransfer, CategorySecurity, CategoryAnnouncement}
+	Channels   = []Channel{ChannelInApp, ChannelEmail, ChannelSMS}
+)
+
+// 要发送给用户的通知
+type Notification struct {
+	Uid      string    // 接收通知的用户
+	Category Category  // 通知的类别
+	Title    string    // 标题, 作为站内信和邮件的标题, 不超过 32 个字符
+	Content  string    // 内容, 作为站内信, 邮件和短信的内容
+	Urgent   bool      // 紧急的通知不受免打扰时段的限制
+	Channels []Channel // 只通过这些渠道发送, 为空则不限制. 例如系统通知本身就是站内的, 只需要发送邮件和短信
+}
+
+func allowed(n Notification, channel Channel) bool {
+	if len(n.Channels) == 0 {
+		return true
+	}
+
+	for _, v := range n.Channels {
+		if v == channel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 按照用户的偏好, 把通知发送到开启的渠道, 返回发送的渠道
+// 站内信同步写入, 邮件和短信加入消息队列发送, 免打扰时段内不发送邮件和短信
+// 各个功能都应该通过这里通知用户, 而不是直接写入个人消息或者发送邮件和短信
+func Dispatch(n Notification) (channels []Channel, err error) {
+	channels = make([]Channel, 0)
+
+	userInfo := model.User{Id: n.Uid}
+
+	if err = database.Db.First(&userInfo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.UserNotExist
+		}
+		return
+	}
+
+	var preference model.NotificationPreference
+
+	if preference, err = GetPreference(database.Db, n.Uid); err != nil {
+		return
+	}
+
+	quiet := !n.Urgent && InQuietHours(preference, time.Now())
+
+	for _, channel := range channelsOf(preference, n.Category) {
+		if !allowed(n, channel) {
+			continue
+		}
+
+		switch channel {
+		case ChannelInApp:
+			if err = sendInApp(n); err != nil {
+				return
+			}
+		case ChannelEmail:
+			if quiet || userInfo.Email == nil {
+				continue
+			}
+
+			if _, er := Enqueue(KindEmailNotification, ChannelEmail, []string{*userInfo.Email}, NotificationPayload{Title: n.Title, Content: n.Content}); er != nil {
+				log.Printf("通知邮件加入队列失败: %v\n", er)
+				continue
+			}
+		case ChannelSMS:
+			if quiet || userInfo.Phone == nil {
+				continue
+			}
+
+			if _, er := Enqueue(KindSMSNotification, ChannelSMS, []string{*userInfo.Phone}, NotificationPayload{Content: n.Content}); er != nil {
+				log.Printf("通知短信加入队列失败: %v\n", er)
+				continue
+			}
+		default:
+			continue
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return
+}
+
+// 写入个人消息, 并推送给在线的用户
+func sendInApp(n Notification) (err error) {
+	// 通知的内容由模版生成, 按纯文本展示
+	messageInfo := model.Message{
+		Uid:     n.Uid,
+		Title:   n.Title,
+		Content: n.Content,
+		Format:  model.ContentFormatPlain,
+		Excerpt: content.Excerpt(model.ContentFormatPlain, n.Content, content.ExcerptLength),
+		Status:  model.MessageStatusActive,
+	}
+
+	if err = database.Db.Create(&messageInfo).Error; err != nil {
+		return
+	}
+
+	// 清除未读数的缓存, 推送失败不影响发送
+	_ = redis.ClientUnread.Del(n.Uid).Err()
+
+	data := schema.Message{}
+
+	if err = mapstructure.Decode(messageInfo, &data.MessagePure); err != nil {
+		return
+	}
+
+	data.CreatedAt = messageInfo.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = messageInfo.UpdatedAt.Format(time.RFC3339Nano)
+
+	_ = push.Publish(push.EventMessage, n.Uid, data)
+
+	return
+}
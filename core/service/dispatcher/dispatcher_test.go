@@ -0,0 +1,81 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher_test
+
+import (
+	"github.com/axetroy/go-server/core/controller"
+	"github.com/axetroy/go-server/core/controller/auth"
+	"github.com/axetroy/go-server/core/controller/message"
+	"github.com/axetroy/go-server/core/controller/user"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/tester"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDispatch(t *testing.T) {
+	userInfo, _ := tester.CreateUser()
+
+	defer auth.DeleteUserByUserName(userInfo.Username)
+
+	n := dispatcher.Notification{
+		Uid:      userInfo.Id,
+		Category: dispatcher.CategoryTransfer,
+		Title:    "test",
+		Content:  "test",
+	}
+
+	// 默认的偏好会发送站内信, 用户没有绑定邮箱, 不会发送邮件
+	{
+		channels, err := dispatcher.Dispatch(n)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []dispatcher.Channel{dispatcher.ChannelInApp}, channels)
+
+		count, err := message.CountUnread(database.Db, userInfo.Id)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	}
+
+	// 限制了渠道
+	{
+		n2 := n
+		n2.Channels = []dispatcher.Channel{dispatcher.ChannelEmail, dispatcher.ChannelSMS}
+
+		channels, err := dispatcher.Dispatch(n2)
+
+		assert.Nil(t, err)
+		assert.Len(t, channels, 0)
+	}
+
+	// 关闭了转账通知
+	{
+		r := user.UpdatePreference(controller.Context{Uid: userInfo.Id}, user.UpdatePreferenceParams{
+			Transfer: &[]string{},
+		})
+
+		assert.Equal(t, "", r.Message)
+
+		channels, err := dispatcher.Dispatch(n)
+
+		assert.Nil(t, err)
+		assert.Len(t, channels, 0)
+
+		count, err := message.CountUnread(database.Db, userInfo.Id)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+	}
+
+	// 用户不存在
+	{
+		n2 := n
+		n2.Uid = "123123"
+
+		_, err := dispatcher.Dispatch(n2)
+
+		assert.Equal(t, exception.UserNotExist, err)
+	}
+}
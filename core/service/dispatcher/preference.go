@@ -0,0 +1,130 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"time"
+)
+
+const QuietTimeFormat = "15:04" // 免打扰时段的时间格式
+
+var (
+	// 没有设置过偏好的用户使用的默认偏好
+	DefaultPreference = map[Category][]Channel{
+		CategoryTransfer:     {ChannelInApp, ChannelEmail},
+		CategorySecurity:     {ChannelInApp, ChannelEmail, ChannelSMS},
+		CategoryAnnouncement: {ChannelInApp},
+	}
+)
+
+// 获取用户接收通知的偏好, 没有设置过则返回默认的偏好
+func GetPreference(db *gorm.DB, uid string) (preference model.NotificationPreference, err error) {
+	if err = db.Where("uid = ?", uid).First(&preference).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+			preference = NewPreference(uid)
+		}
+		return
+	}
+
+	return
+}
+
+// 创建默认的偏好
+func NewPreference(uid string) model.NotificationPreference {
+	return model.NotificationPreference{
+		Uid:          uid,
+		Transfer:     toArray(DefaultPreference[CategoryTransfer]),
+		Security:     toArray(DefaultPreference[CategorySecurity]),
+		Announcement: toArray(DefaultPreference[CategoryAnnouncement]),
+	}
+}
+
+func toArray(channels []Channel) pq.StringArray {
+	result := pq.StringArray{}
+
+	for _, v := range channels {
+		result = append(result, string(v))
+	}
+
+	return result
+}
+
+// 获取某个类别开启的渠道
+func channelsOf(preference model.NotificationPreference, category Category) []Channel {
+	var list pq.StringArray
+
+	switch category {
+	case CategoryTransfer:
+		list = preference.Transfer
+	case CategorySecurity:
+		list = preference.Security
+	case CategoryAnnouncement:
+		list = preference.Announcement
+	}
+
+	result := make([]Channel, 0)
+
+	for _, v := range list {
+		result = append(result, Channel(v))
+	}
+
+	return result
+}
+
+// 当前是否处于免打扰时段, 结束时间早于开始时间则表示跨越了零点
+func InQuietHours(preference model.NotificationPreference, now time.Time) bool {
+	if preference.QuietStart == nil || preference.QuietEnd == nil {
+		return false
+	}
+
+	start, err := time.Parse(QuietTimeFormat, *preference.QuietStart)
+
+	if err != nil {
+		return false
+	}
+
+	end, err := time.Parse(QuietTimeFormat, *preference.QuietEnd)
+
+	if err != nil {
+		return false
+	}
+
+	current := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from == to {
+		return false
+	}
+
+	if from < to {
+		return current >= from && current < to
+	}
+
+	return current >= from || current < to
+}
+
+// 筛选在某个类别开启了其中任意一个渠道的用户, 用于给大量用户发送通知时排除不需要发送的用户
+// 没有设置过偏好的用户按默认的偏好处理
+func SubscriberScope(db *gorm.DB, category Category, channels ...Channel) *gorm.DB {
+	column := string(category)
+
+	subscribed := false
+
+	for _, v := range DefaultPreference[category] {
+		for _, channel := range channels {
+			if v == channel {
+				subscribed = true
+			}
+		}
+	}
+
+	if subscribed {
+		return db.Where("id NOT IN (SELECT uid FROM notification_preference WHERE NOT ("+column+" && ?::varchar[]))", toArray(channels))
+	}
+
+	return db.Where("id IN (SELECT uid FROM notification_preference WHERE "+column+" && ?::varchar[])", toArray(channels))
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package dispatcher_test
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInQuietHours(t *testing.T) {
+	at := func(s string) time.Time {
+		v, _ := time.Parse(dispatcher.QuietTimeFormat, s)
+		return v
+	}
+
+	preference := func(start string, end string) model.NotificationPreference {
+		return model.NotificationPreference{QuietStart: &start, QuietEnd: &end}
+	}
+
+	// 没有设置免打扰
+	assert.False(t, dispatcher.InQuietHours(model.NotificationPreference{}, at("23:00")))
+
+	// 当天的时段
+	assert.True(t, dispatcher.InQuietHours(preference("12:00", "14:00"), at("12:00")))
+	assert.True(t, dispatcher.InQuietHours(preference("12:00", "14:00"), at("13:59")))
+	assert.False(t, dispatcher.InQuietHours(preference("12:00", "14:00"), at("14:00")))
+	assert.False(t, dispatcher.InQuietHours(preference("12:00", "14:00"), at("11:59")))
+
+	// 跨越零点的时段
+	assert.True(t, dispatcher.InQuietHours(preference("22:00", "08:00"), at("23:30")))
+	assert.True(t, dispatcher.InQuietHours(preference("22:00", "08:00"), at("07:59")))
+	assert.False(t, dispatcher.InQuietHours(preference("22:00", "08:00"), at("08:00")))
+	assert.False(t, dispatcher.InQuietHours(preference("22:00", "08:00"), at("12:00")))
+
+	// 开始和结束相同
+	assert.False(t, dispatcher.InQuietHours(preference("22:00", "22:00"), at("22:00")))
+}
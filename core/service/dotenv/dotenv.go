@@ -134,6 +134,18 @@ func GetIntByDefault(key string, defaultValue int) int {
 	return result
 }
 
+func GetBoolByDefault(key string, defaultValue bool) bool {
+	val := GetByDefault(key, strconv.FormatBool(defaultValue))
+
+	result, err := strconv.ParseBool(val)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return result
+}
+
 func GetStrArrayByDefault(key string, defaultValue []string) []string {
 	val := GetByDefault(key, fmt.Sprintf("%s", strings.Join(defaultValue, ",")))
 
@@ -2,31 +2,22 @@
 package email
 
 import (
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/axetroy/go-server/core/config"
 	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/message_template"
 	"github.com/jordan-wright/email"
-	"net"
-	"net/smtp"
+	"html/template"
+	"log"
 	"net/textproto"
 )
 
-// TODO: 重构邮箱模版
-const (
-	prefix                      = "[GOTEST]: "
-	TemplateActivation          = `<a href="javascript: void 0">点击这里激活</a>或使用激活码: %v`
-	TemplateForgotPassword      = `<a href="javascript: void 0">点击连接重置密码</a>或使用重置码: %v`
-	TemplateForgotTradePassword = `<a href="javascript: void 0">点击连接重置交易密码</a>或使用重置码: %v`
-	TemplateAuth                = `正在验证您的身份，你的验证码是 %s`
-	TemplateRegistry            = `<a href="%s" href="target">点击注册您的帐号</a>`
-)
-
 var Config = config.SMTP
 
 type Mailer struct {
-	Auth *smtp.Auth
+	Transport Transport // 发送邮件的方式
+	Locale    string    // 邮件模版的语言, 为空则使用默认的语言
 }
 
 type Message struct {
@@ -44,16 +35,35 @@ type Message struct {
 }
 
 func NewMailer() *Mailer {
-	// Set up authentication information.
-	auth := smtp.PlainAuth(
-		"",
-		Config.Username,
-		Config.Password,
-		Config.Host,
-	)
 	return &Mailer{
-		Auth: &auth,
+		Transport: DefaultTransport,
+		Locale:    config.Common.Locale,
+	}
+}
+
+// 使用指定语言的模版
+func (e *Mailer) WithLocale(locale string) *Mailer {
+	if locale != "" {
+		e.Locale = locale
+	}
+
+	return e
+}
+
+// 使用模版发送邮件
+func (e *Mailer) SendTemplate(to []string, name string, data map[string]interface{}) (err error) {
+	var msg message_template.Message
+
+	if msg, err = message_template.Render(message_template.ChannelEmail, name, e.Locale, data); err != nil {
+		return
 	}
+
+	return e.Send(&Message{
+		To:      to,
+		Subject: msg.Subject,
+		Text:    []byte(msg.Text),
+		HTML:    []byte(msg.Content),
+	})
 }
 
 // 发送邮件
@@ -71,14 +81,9 @@ func (e *Mailer) Send(message *Message) (err error) {
 		Headers: textproto.MIMEHeader{},
 	}
 
-	var addr = net.JoinHostPort(Config.Host, Config.Port)
-
-	if err = msg.SendWithTLS(
-		addr,
-		*e.Auth, &tls.Config{
-			ServerName:         Config.Host,
-			InsecureSkipVerify: true,
-		}); err != nil {
+	if err = e.Transport.Send(msg); err != nil {
+		// 保留真实的错误, 便于排查 SMTP 的问题
+		log.Printf("发送邮件 `%s` 到 %v 失败: %v\n", message.Subject, message.To, err)
 		err = exception.SendEmailFail
 		return
 	}
@@ -87,71 +92,52 @@ func (e *Mailer) Send(message *Message) (err error) {
 }
 
 // 发送激活邮件
-func (e *Mailer) SendActivationEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "账号激活",
-		Text:    []byte("请点击连接激活您的账号"),
-		HTML:    []byte(fmt.Sprintf(TemplateActivation, code)),
-	}); err != nil {
-		return
-	}
-
-	return nil
+func (e *Mailer) SendActivationEmail(toEmail string, code string) error {
+	return e.SendTemplate([]string{toEmail}, message_template.NameActivation, map[string]interface{}{
+		"code": code,
+	})
 }
 
 // 发送认证邮件
-func (e *Mailer) SendAuthEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "邮箱认证",
-		Text:    []byte(fmt.Sprintf("您的验证码是: %s", code)),
-		HTML:    []byte(fmt.Sprintf(TemplateAuth, code)),
-	}); err != nil {
-		return
-	}
-
-	return nil
+func (e *Mailer) SendAuthEmail(toEmail string, code string) error {
+	return e.SendTemplate([]string{toEmail}, message_template.NameAuth, map[string]interface{}{
+		"code": code,
+	})
 }
 
 // 发送注册邮件
-func (e *Mailer) SendRegisterEmail(toEmail string, redirectURL string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "邮箱认证",
-		Text:    []byte(fmt.Sprintf("打开链接注册帐号: %s", redirectURL)),
-		HTML:    []byte(fmt.Sprintf(TemplateRegistry, redirectURL)),
-	}); err != nil {
-		return
-	}
-
-	return nil
+func (e *Mailer) SendRegisterEmail(toEmail string, redirectURL string) error {
+	return e.SendTemplate([]string{toEmail}, message_template.NameRegister, map[string]interface{}{
+		"url": redirectURL,
+	})
 }
 
 // 发送忘记密码邮件
-func (e *Mailer) SendForgotPasswordEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "忘记登陆密码",
-		Text:    []byte("请点击重置您的登陆密码"),
-		HTML:    []byte(fmt.Sprintf(TemplateForgotPassword, code)),
-	}); err != nil {
-		return
-	}
-
-	return nil
+func (e *Mailer) SendForgotPasswordEmail(toEmail string, code string) error {
+	return e.SendTemplate([]string{toEmail}, message_template.NameForgotPassword, map[string]interface{}{
+		"code": code,
+	})
 }
 
 // 发送忘记交易密码邮件
-func (e *Mailer) SendForgotTradePasswordEmail(toEmail string, code string) (err error) {
-	if err = e.Send(&Message{
-		To:      []string{toEmail},
-		Subject: prefix + "忘记交易密码",
-		Text:    []byte("请点击连接激活您的账号"),
-		HTML:    []byte(fmt.Sprintf(TemplateForgotTradePassword, code)),
-	}); err != nil {
-		return
-	}
+func (e *Mailer) SendForgotTradePasswordEmail(toEmail string, code string) error {
+	return e.SendTemplate([]string{toEmail}, message_template.NameForgotTradePassword, map[string]interface{}{
+		"code": code,
+	})
+}
 
-	return nil
+// 发送每日财务报表, 报表的内容是已经生成好的 HTML, 不会被转义
+func (e *Mailer) SendFinanceReportEmail(toEmail []string, date string, content string) error {
+	return e.SendTemplate(toEmail, message_template.NameFinanceReport, map[string]interface{}{
+		"date":    date,
+		"content": template.HTML(content),
+	})
+}
+
+// 发送通知邮件
+func (e *Mailer) SendNotificationEmail(toEmail string, title string, content string) error {
+	return e.SendTemplate([]string{toEmail}, message_template.NameNotification, map[string]interface{}{
+		"title":   title,
+		"content": content,
+	})
 }
@@ -0,0 +1,240 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/jordan-wright/email"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// 发送邮件的方式
+type Transport interface {
+	Send(msg *email.Email) error
+}
+
+var (
+	// 所有的 Mailer 默认使用的发送方式, 由配置 SMTP_TRANSPORT 决定
+	DefaultTransport Transport
+
+	// SMTP_TRANSPORT 为 memory 时使用的发送方式, 测试时可以从这里读取发送的邮件
+	Memory = NewMemoryTransport()
+)
+
+func init() {
+	var err error
+
+	if DefaultTransport, err = NewTransport(config.SMTP.Transport); err != nil {
+		panic(err)
+	}
+}
+
+// 根据名称创建发送方式
+func NewTransport(name string) (Transport, error) {
+	switch name {
+	case config.SMTPTransportSMTP:
+		return &SMTPTransport{
+			Host:               config.SMTP.Host,
+			Port:               config.SMTP.Port,
+			Security:           config.SMTP.Security,
+			InsecureSkipVerify: config.SMTP.InsecureSkipVerify,
+			Username:           config.SMTP.Username,
+			Password:           config.SMTP.Password,
+		}, nil
+	case config.SMTPTransportFile:
+		return &FileTransport{Dir: config.SMTP.MailDir}, nil
+	case config.SMTPTransportMemory:
+		return Memory, nil
+	default:
+		return nil, fmt.Errorf("invalid smtp transport `%s`", name)
+	}
+}
+
+// 通过 SMTP 服务器发送
+type SMTPTransport struct {
+	Host               string
+	Port               string
+	Security           string // tls, starttls 或者 none
+	InsecureSkipVerify bool   // 是否跳过证书校验
+	Username           string
+	Password           string
+}
+
+func (t *SMTPTransport) Send(msg *email.Email) error {
+	addr := net.JoinHostPort(t.Host, t.Port)
+
+	var auth smtp.Auth
+
+	if t.Username != "" {
+		auth = smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         t.Host,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	switch t.Security {
+	case config.SMTPSecurityTLS:
+		return msg.SendWithTLS(addr, auth, tlsConfig)
+	case config.SMTPSecuritySTARTTLS:
+		return t.sendWithStartTLS(addr, auth, tlsConfig, msg)
+	case config.SMTPSecurityNone:
+		return msg.Send(addr, auth)
+	default:
+		return fmt.Errorf("invalid smtp security `%s`", t.Security)
+	}
+}
+
+// 先建立明文连接, 服务器必须支持 STARTTLS, 否则不发送
+func (t *SMTPTransport) sendWithStartTLS(addr string, auth smtp.Auth, tlsConfig *tls.Config, msg *email.Email) error {
+	from, err := mail.ParseAddress(msg.From)
+
+	if err != nil {
+		return err
+	}
+
+	raw, err := msg.Bytes()
+
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.Dial(addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		return errors.New("smtp: server doesn't support STARTTLS")
+	}
+
+	if err = c.StartTLS(tlsConfig); err != nil {
+		return err
+	}
+
+	if auth != nil {
+		if err = c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(from.Address); err != nil {
+		return err
+	}
+
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, to := range list {
+			addr, err := mail.ParseAddress(to)
+
+			if err != nil {
+				return err
+			}
+
+			if err = c.Rcpt(addr.Address); err != nil {
+				return err
+			}
+		}
+	}
+
+	w, err := c.Data()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(raw); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// 以 maildir 的格式保存到本地目录, 每封邮件是 new 目录下的一个文件, 可以用邮件客户端直接打开
+type FileTransport struct {
+	Dir string
+}
+
+func (t *FileTransport) Send(msg *email.Email) error {
+	raw, err := msg.Bytes()
+
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(path.Join(t.Dir, dir), os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	hostname, _ := os.Hostname()
+
+	name := fmt.Sprintf("%d.%d.%s.eml", time.Now().UnixNano(), os.Getpid(), hostname)
+
+	// 先写入 tmp 目录再移动到 new 目录, 这样读取的时候不会读到写了一半的邮件
+	tmp := path.Join(t.Dir, "tmp", name)
+
+	if err := ioutil.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path.Join(t.Dir, "new", name))
+}
+
+// 保存在内存中, 用于测试
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []*email.Email
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{
+		messages: make([]*email.Email, 0),
+	}
+}
+
+func (t *MemoryTransport) Send(msg *email.Email) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, msg)
+
+	return nil
+}
+
+// 获取已经发送的邮件
+func (t *MemoryTransport) Messages() []*email.Email {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]*email.Email, len(t.messages))
+
+	copy(list, t.messages)
+
+	return list
+}
+
+// 清空已经发送的邮件
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = make([]*email.Email, 0)
+}
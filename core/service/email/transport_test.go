@@ -0,0 +1,89 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package email_test
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/email"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestMemoryTransport(t *testing.T) {
+	transport := email.NewMemoryTransport()
+
+	mailer := email.NewMailer()
+	mailer.Transport = transport
+
+	err := mailer.Send(&email.Message{
+		To:      []string{"test@example.com"},
+		Subject: "test",
+		Text:    []byte("hello"),
+	})
+
+	assert.Nil(t, err)
+
+	messages := transport.Messages()
+
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []string{"test@example.com"}, messages[0].To)
+	assert.Equal(t, "test", messages[0].Subject)
+	assert.Equal(t, "hello", string(messages[0].Text))
+
+	transport.Reset()
+
+	assert.Len(t, transport.Messages(), 0)
+}
+
+func TestFileTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+
+	assert.Nil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	mailer := email.NewMailer()
+	mailer.Transport = &email.FileTransport{Dir: dir}
+
+	assert.Nil(t, mailer.Send(&email.Message{
+		To:      []string{"test@example.com"},
+		Subject: "file transport",
+		Text:    []byte("hello"),
+	}))
+
+	files, err := ioutil.ReadDir(path.Join(dir, "new"))
+
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	b, err := ioutil.ReadFile(path.Join(dir, "new", files[0].Name()))
+
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(b), "Subject: file transport"))
+}
+
+func TestSMTPTransport(t *testing.T) {
+	// 连接失败时返回统一的错误, 真实的错误记录在日志中
+	mailer := email.NewMailer()
+	mailer.Transport = &email.SMTPTransport{
+		Host:     "127.0.0.1",
+		Port:     "1",
+		Security: config.SMTPSecuritySTARTTLS,
+	}
+
+	err := mailer.Send(&email.Message{
+		To:      []string{"test@example.com"},
+		Subject: "test",
+	})
+
+	assert.Equal(t, exception.SendEmailFail, err)
+
+	// 不支持的发送方式
+	_, err = email.NewTransport("invalid")
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,37 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package feed
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 根据请求的 If-None-Match 和 If-Modified-Since 判断客户端的缓存是否还有效
+// 同时带有两个头时以 If-None-Match 为准
+func NotModified(header http.Header, etag string, lastModified time.Time) bool {
+	if match := header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if since := header.Get("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+
+		if err != nil {
+			return false
+		}
+
+		// HTTP 的时间只精确到秒
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
@@ -0,0 +1,286 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+// 订阅源, 把文章列表输出为 RSS 2.0, Atom 和 JSON Feed 格式
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatRSS  Format = "rss"  // RSS 2.0
+	FormatAtom Format = "atom" // Atom 1.0
+	FormatJSON Format = "json" // JSON Feed 1.1
+)
+
+var Formats = []Format{FormatRSS, FormatAtom, FormatJSON}
+
+func IsValidFormat(f Format) bool {
+	for _, v := range Formats {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// 各个格式的 Content-Type
+func ContentType(f Format) string {
+	switch f {
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	default:
+		return "application/rss+xml; charset=utf-8"
+	}
+}
+
+type Feed struct {
+	Title       string    // 订阅源的标题
+	Description string    // 订阅源的描述
+	Link        string    // 网站的地址
+	FeedURL     string    // 订阅源自身的地址
+	Updated     time.Time // 最后更新的时间
+	Items       []Item
+}
+
+type Item struct {
+	Id         string    // 唯一标识, 不会随着内容修改而改变
+	Title      string    // 标题
+	Link       string    // 详情的地址
+	Summary    string    // 纯文本摘要
+	Content    string    // 过滤之后的 HTML 正文
+	Categories []string  // 分类或者标签
+	Published  time.Time // 发布时间
+	Updated    time.Time // 最后修改的时间
+}
+
+// 输出指定格式的订阅源
+func Render(f Format, feed Feed) ([]byte, error) {
+	switch f {
+	case FormatAtom:
+		return renderAtom(feed)
+	case FormatJSON:
+		return renderJSON(feed)
+	default:
+		return renderRSS(feed)
+	}
+}
+
+type rss struct {
+	XMLName          xml.Name   `xml:"rss"`
+	Version          string     `xml:"version,attr"`
+	AtomNamespace    string     `xml:"xmlns:atom,attr"`
+	ContentNamespace string     `xml:"xmlns:content,attr"`
+	Channel          rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        rssGuid  `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     rssCDATA `xml:"content:encoded"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+func renderRSS(feed Feed) ([]byte, error) {
+	r := rss{
+		Version:          "2.0",
+		AtomNamespace:    "http://www.w3.org/2005/Atom",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        feed.Link,
+			Description: feed.Description,
+			Self:        atomLink{Href: feed.FeedURL, Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]rssItem, 0, len(feed.Items)),
+		},
+	}
+
+	if !feed.Updated.IsZero() {
+		r.Channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range feed.Items {
+		r.Channel.Items = append(r.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Guid:        rssGuid{IsPermaLink: "false", Value: item.Id},
+			Description: item.Summary,
+			Content:     rssCDATA{Value: item.Content},
+			Categories:  item.Categories,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshalXML(r)
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	Id         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func renderAtom(feed Feed) ([]byte, error) {
+	a := atom{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   feed.Title,
+		Id:      feed.FeedURL,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.Link, Rel: "alternate"},
+			{Href: feed.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		// Atom 要求提供作者, 文章没有对外公开的作者, 使用订阅源的标题
+		Author:  atomAuthor{Name: feed.Title},
+		Entries: make([]atomEntry, 0, len(feed.Items)),
+	}
+
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			Title:      item.Title,
+			Id:         item.Id,
+			Link:       atomLink{Href: item.Link, Rel: "alternate"},
+			Published:  item.Published.UTC().Format(time.RFC3339),
+			Updated:    item.Updated.UTC().Format(time.RFC3339),
+			Summary:    atomText{Type: "text", Value: item.Summary},
+			Content:    atomText{Type: "html", Value: item.Content},
+			Categories: make([]atomCategory, 0, len(item.Categories)),
+		}
+
+		for _, c := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+
+		a.Entries = append(a.Entries, entry)
+	}
+
+	return marshalXML(a)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	b, err := xml.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	Id            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	Summary       string   `json:"summary"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+func renderJSON(feed Feed) ([]byte, error) {
+	f := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+
+	for _, item := range feed.Items {
+		f.Items = append(f.Items, jsonFeedItem{
+			Id:            item.Id,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			Summary:       item.Summary,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+			Tags:          item.Categories,
+		})
+	}
+
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+
+	// 正文是 HTML, 不需要把 < > & 转义成 \u003c 之类的形式
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(f); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
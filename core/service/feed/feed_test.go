@@ -0,0 +1,109 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package feed_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/axetroy/go-server/core/service/feed"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	published = time.Date(2019, 10, 1, 8, 0, 0, 0, time.UTC)
+	testFeed  = feed.Feed{
+		Title:   "官方公告",
+		Link:    "https://example.com",
+		FeedURL: "https://example.com/v1/news/feed",
+		Updated: published,
+		Items: []feed.Item{
+			{
+				Id:         "https://example.com/v1/news/n/1",
+				Title:      "a & b",
+				Link:       "https://example.com/v1/news/n/1",
+				Summary:    "hello",
+				Content:    "<p>hello</p>",
+				Categories: []string{"announcement", "tag"},
+				Published:  published,
+				Updated:    published,
+			},
+		},
+	}
+)
+
+func TestRenderRSS(t *testing.T) {
+	b, err := feed.Render(feed.FormatRSS, testFeed)
+
+	assert.Nil(t, err)
+
+	s := string(b)
+
+	assert.True(t, strings.HasPrefix(s, xml.Header))
+	assert.True(t, strings.Contains(s, `<title>a &amp; b</title>`))
+	assert.True(t, strings.Contains(s, `<content:encoded><![CDATA[<p>hello</p>]]></content:encoded>`))
+	assert.True(t, strings.Contains(s, `<pubDate>Tue, 01 Oct 2019 08:00:00 +0000</pubDate>`))
+	assert.True(t, strings.Contains(s, `<atom:link href="https://example.com/v1/news/feed" rel="self" type="application/rss+xml"></atom:link>`))
+
+	// 输出的是合法的 XML
+	assert.Nil(t, xml.Unmarshal(b, &struct{}{}))
+}
+
+func TestRenderAtom(t *testing.T) {
+	b, err := feed.Render(feed.FormatAtom, testFeed)
+
+	assert.Nil(t, err)
+
+	s := string(b)
+
+	assert.True(t, strings.Contains(s, `<feed xmlns="http://www.w3.org/2005/Atom">`))
+	assert.True(t, strings.Contains(s, `<updated>2019-10-01T08:00:00Z</updated>`))
+	assert.True(t, strings.Contains(s, `<content type="html">&lt;p&gt;hello&lt;/p&gt;</content>`))
+	assert.True(t, strings.Contains(s, `<category term="tag"></category>`))
+}
+
+func TestRenderJSON(t *testing.T) {
+	b, err := feed.Render(feed.FormatJSON, testFeed)
+
+	assert.Nil(t, err)
+
+	res := map[string]interface{}{}
+
+	assert.Nil(t, json.Unmarshal(b, &res))
+
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", res["version"])
+	assert.True(t, strings.Contains(string(b), `"content_html":"<p>hello</p>"`))
+
+	items := res["items"].([]interface{})
+
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "2019-10-01T08:00:00Z", items[0].(map[string]interface{})["date_published"])
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `"abc"`
+
+	cases := []struct {
+		header http.Header
+		result bool
+	}{
+		{http.Header{}, false},
+		{http.Header{"If-None-Match": {`"abc"`}}, true},
+		{http.Header{"If-None-Match": {`W/"abc"`}}, true},
+		{http.Header{"If-None-Match": {`"x", "abc"`}}, true},
+		{http.Header{"If-None-Match": {`*`}}, true},
+		{http.Header{"If-None-Match": {`"x"`}}, false},
+		{http.Header{"If-Modified-Since": {published.Format(http.TimeFormat)}}, true},
+		{http.Header{"If-Modified-Since": {published.Add(-time.Second).Format(http.TimeFormat)}}, false},
+		{http.Header{"If-Modified-Since": {"invalid"}}, false},
+		// 以 If-None-Match 为准
+		{http.Header{"If-None-Match": {`"x"`}, "If-Modified-Since": {published.Format(http.TimeFormat)}}, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.result, feed.NotModified(c.header, etag, published.Add(time.Millisecond*500)), c.header)
+	}
+}
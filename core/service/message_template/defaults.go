@@ -0,0 +1,210 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template
+
+// 渠道
+const (
+	ChannelEmail = "email" // 邮件
+	ChannelSMS   = "sms"   // 短信
+)
+
+// 模版名称
+const (
+	NameActivation          = "activation"            // 激活账号
+	NameAuth                = "auth"                  // 身份验证
+	NameRegister            = "register"              // 注册帐号
+	NameForgotPassword      = "forgot_password"       // 重置登陆密码
+	NameForgotTradePassword = "forgot_trade_password" // 重置交易密码
+	NameFinanceReport       = "finance_report"        // 每日财务报表
+	NameNotification        = "notification"          // 通知
+)
+
+// 所有的模版名称
+var Names = []string{NameActivation, NameAuth, NameRegister, NameForgotPassword, NameForgotTradePassword, NameFinanceReport, NameNotification}
+
+// 内置模版的默认语言
+const DefaultLocale = "zh-CN"
+
+// 内置的模版
+type Default struct {
+	Name      string            `json:"name"`      // 模版名称
+	Channel   string            `json:"channel"`   // 渠道
+	Locale    string            `json:"locale"`    // 语言
+	Subject   string            `json:"subject"`   // 邮件标题
+	Content   string            `json:"content"`   // 模版内容
+	Text      string            `json:"text"`      // 邮件的纯文本内容
+	Variables map[string]string `json:"variables"` // 模版可用的变量, 值为预览时使用的示例
+}
+
+var (
+	codeVariables = map[string]string{"code": "123456"}
+
+	// 没有在数据库中的模版使用这里的模版
+	Defaults = []Default{
+		{
+			Name:      NameActivation,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "账号激活",
+			Content:   `<p>欢迎注册, 您的激活码是: <strong>{{.code}}</strong></p>`,
+			Text:      `欢迎注册, 您的激活码是: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameActivation,
+			Channel:   ChannelEmail,
+			Locale:    "en-US",
+			Subject:   "Activate your account",
+			Content:   `<p>Welcome, your activation code is: <strong>{{.code}}</strong></p>`,
+			Text:      `Welcome, your activation code is: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameAuth,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "邮箱认证",
+			Content:   `<p>正在验证您的身份, 您的验证码是: <strong>{{.code}}</strong></p>`,
+			Text:      `正在验证您的身份, 您的验证码是: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameAuth,
+			Channel:   ChannelEmail,
+			Locale:    "en-US",
+			Subject:   "Verify your email",
+			Content:   `<p>Your verification code is: <strong>{{.code}}</strong></p>`,
+			Text:      `Your verification code is: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameRegister,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "注册帐号",
+			Content:   `<p><a href="{{.url}}" target="_blank">点击这里注册您的帐号</a></p><p>如果无法打开, 请复制链接到浏览器中访问: {{.url}}</p>`,
+			Text:      `打开链接注册帐号: {{.url}}`,
+			Variables: map[string]string{"url": "https://example.com/signup?code=123456"},
+		},
+		{
+			Name:      NameRegister,
+			Channel:   ChannelEmail,
+			Locale:    "en-US",
+			Subject:   "Create your account",
+			Content:   `<p><a href="{{.url}}" target="_blank">Click here to create your account</a></p><p>Or open this link in your browser: {{.url}}</p>`,
+			Text:      `Open the link to create your account: {{.url}}`,
+			Variables: map[string]string{"url": "https://example.com/signup?code=123456"},
+		},
+		{
+			Name:      NameForgotPassword,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "重置登陆密码",
+			Content:   `<p>您正在重置登陆密码, 重置码是: <strong>{{.code}}</strong></p><p>如果不是您本人的操作, 请忽略这封邮件</p>`,
+			Text:      `您正在重置登陆密码, 重置码是: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameForgotPassword,
+			Channel:   ChannelEmail,
+			Locale:    "en-US",
+			Subject:   "Reset your password",
+			Content:   `<p>Your password reset code is: <strong>{{.code}}</strong></p><p>If you did not request this, please ignore this email.</p>`,
+			Text:      `Your password reset code is: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameForgotTradePassword,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "重置交易密码",
+			Content:   `<p>您正在重置交易密码, 重置码是: <strong>{{.code}}</strong></p><p>如果不是您本人的操作, 请忽略这封邮件</p>`,
+			Text:      `您正在重置交易密码, 重置码是: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameForgotTradePassword,
+			Channel:   ChannelEmail,
+			Locale:    "en-US",
+			Subject:   "Reset your trade password",
+			Content:   `<p>Your trade password reset code is: <strong>{{.code}}</strong></p><p>If you did not request this, please ignore this email.</p>`,
+			Text:      `Your trade password reset code is: {{.code}}`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameFinanceReport,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "财务日报 {{.date}}",
+			Content:   `{{.content}}`,
+			Text:      `财务日报 {{.date}}`,
+			Variables: map[string]string{"date": "2019-01-01", "content": "<table></table>"},
+		},
+		{
+			Name:      NameNotification,
+			Channel:   ChannelEmail,
+			Locale:    "zh-CN",
+			Subject:   "{{.title}}",
+			Content:   `<p>{{.content}}</p>`,
+			Text:      `{{.content}}`,
+			Variables: map[string]string{"title": "标题", "content": "内容"},
+		},
+		{
+			Name:      NameAuth,
+			Channel:   ChannelSMS,
+			Locale:    "zh-CN",
+			Content:   `您的验证码是 {{.code}}, 请勿泄露给他人`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameRegister,
+			Channel:   ChannelSMS,
+			Locale:    "zh-CN",
+			Content:   `您的注册验证码是 {{.code}}, 请勿泄露给他人`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameForgotPassword,
+			Channel:   ChannelSMS,
+			Locale:    "zh-CN",
+			Content:   `您正在重置密码, 验证码是 {{.code}}, 请勿泄露给他人`,
+			Variables: codeVariables,
+		},
+		{
+			Name:      NameNotification,
+			Channel:   ChannelSMS,
+			Locale:    "zh-CN",
+			Content:   `{{.content}}`,
+			Variables: map[string]string{"content": "内容"},
+		},
+	}
+)
+
+// 获取内置的模版, 没有对应的语言则使用默认语言
+func GetDefault(channel string, name string, locale string) (Default, bool) {
+	for _, l := range []string{locale, DefaultLocale} {
+		for _, v := range Defaults {
+			if v.Channel == channel && v.Name == name && v.Locale == l {
+				return v, true
+			}
+		}
+	}
+
+	return Default{}, false
+}
+
+// 获取模版可用的变量和示例值, 优先使用相同渠道的内置模版, 同一个渠道的模版在各个语言中使用相同的变量
+func GetVariables(channel string, name string) map[string]string {
+	for _, v := range Defaults {
+		if v.Channel == channel && v.Name == name {
+			return v.Variables
+		}
+	}
+
+	for _, v := range Defaults {
+		if v.Name == name {
+			return v.Variables
+		}
+	}
+
+	return map[string]string{}
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template
+
+import (
+	"bytes"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/jinzhu/gorm"
+	htmlTemplate "html/template"
+	"text/template"
+)
+
+// 渲染之后的邮件或者短信
+type Message struct {
+	Subject    string `json:"subject"`     // 邮件标题
+	Content    string `json:"content"`     // 邮件的 HTML 内容, 或者短信的内容
+	Text       string `json:"text"`        // 邮件的纯文本内容
+	ExternalId string `json:"external_id"` // 短信服务商的模版 ID
+}
+
+func executeText(s string, data map[string]interface{}) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(s)
+
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func executeHTML(s string, data map[string]interface{}) (string, error) {
+	t, err := htmlTemplate.New("").Option("missingkey=error").Parse(s)
+
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// 渲染模版, 邮件的 HTML 内容中的变量会被转义, 使用了不存在的变量会返回错误
+func Execute(tpl model.MessageTemplate, data map[string]interface{}) (msg Message, err error) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	if msg.Subject, err = executeText(tpl.Subject, data); err != nil {
+		err = exception.InvalidTemplate
+		return
+	}
+
+	if msg.Text, err = executeText(tpl.Text, data); err != nil {
+		err = exception.InvalidTemplate
+		return
+	}
+
+	if tpl.Channel == ChannelEmail {
+		msg.Content, err = executeHTML(tpl.Content, data)
+	} else {
+		msg.Content, err = executeText(tpl.Content, data)
+	}
+
+	if err != nil {
+		err = exception.InvalidTemplate
+		return
+	}
+
+	msg.ExternalId = tpl.ExternalId
+
+	return
+}
+
+// 使用示例变量渲染模版, 用于保存前校验和预览, data 会覆盖示例变量
+func Preview(tpl model.MessageTemplate, data map[string]interface{}) (Message, error) {
+	variables := map[string]interface{}{}
+
+	for k, v := range GetVariables(tpl.Channel, tpl.Name) {
+		variables[k] = v
+	}
+
+	for k, v := range data {
+		variables[k] = v
+	}
+
+	return Execute(tpl, variables)
+}
+
+// 查找模版, 依次使用数据库中对应语言的模版, 数据库中默认语言的模版和内置的模版
+func Find(db *gorm.DB, channel string, name string, locale string) (tpl model.MessageTemplate, err error) {
+	if locale == "" {
+		locale = config.Common.Locale
+	}
+
+	for _, l := range []string{locale, config.Common.Locale} {
+		if err = db.Where("channel = ? AND name = ? AND locale = ?", channel, name, l).First(&tpl).Error; err == nil {
+			return
+		}
+
+		if err != gorm.ErrRecordNotFound {
+			return
+		}
+	}
+
+	d, ok := GetDefault(channel, name, locale)
+
+	if !ok {
+		err = exception.TemplateNotExist
+		return
+	}
+
+	err = nil
+
+	tpl = model.MessageTemplate{
+		Name:    d.Name,
+		Channel: d.Channel,
+		Locale:  d.Locale,
+		Subject: d.Subject,
+		Content: d.Content,
+		Text:    d.Text,
+	}
+
+	return
+}
+
+// 查找并渲染模版, 语言为空则使用默认的语言
+func Render(channel string, name string, locale string, data map[string]interface{}) (msg Message, err error) {
+	var tpl model.MessageTemplate
+
+	if tpl, err = Find(database.Db, channel, name, locale); err != nil {
+		return
+	}
+
+	return Execute(tpl, data)
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package message_template_test
+
+import (
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	tpl := model.MessageTemplate{
+		Channel: message_template.ChannelEmail,
+		Subject: "{{.title}}",
+		Content: `<a href="{{.url}}">{{.title}}</a>`,
+		Text:    "{{.title}}",
+	}
+
+	// 邮件的 HTML 内容会被转义, 标题和纯文本不会
+	msg, err := message_template.Execute(tpl, map[string]interface{}{"title": "<b>", "url": "https://example.com?a=1&b=2"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "<b>", msg.Subject)
+	assert.Equal(t, "<b>", msg.Text)
+	assert.Equal(t, `<a href="https://example.com?a=1&amp;b=2">&lt;b&gt;</a>`, msg.Content)
+
+	// 使用了不存在的变量
+	_, err = message_template.Execute(tpl, map[string]interface{}{"title": "title"})
+
+	assert.Equal(t, exception.InvalidTemplate, err)
+}
+
+func TestFind(t *testing.T) {
+	// 没有对应语言的模版则使用默认语言的内置模版
+	tpl, err := message_template.Find(database.Db, message_template.ChannelEmail, message_template.NameAuth, "ja-JP")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", tpl.Id)
+	assert.Equal(t, message_template.DefaultLocale, tpl.Locale)
+
+	// 有对应语言的内置模版
+	tpl, err = message_template.Find(database.Db, message_template.ChannelEmail, message_template.NameAuth, "en-US")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "en-US", tpl.Locale)
+
+	// 不存在的模版
+	_, err = message_template.Find(database.Db, message_template.ChannelSMS, message_template.NameFinanceReport, "")
+
+	assert.Equal(t, exception.TemplateNotExist, err)
+}
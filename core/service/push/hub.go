@@ -0,0 +1,138 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package push
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/service/redis"
+	goRedis "github.com/go-redis/redis"
+	"log"
+	"sync"
+)
+
+// 每个连接缓冲的事件数量, 超出则断开连接, 由客户端带上最后的事件 ID 重连补发
+var SubscriberBuffer = 64
+
+// 一个在线的连接
+type Subscriber struct {
+	Uid string
+	C   <-chan Event // 推送给这个连接的事件, 被关闭则说明连接需要断开
+
+	c chan Event
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = map[string]map[*Subscriber]struct{}{} // 用户 ID -> 该用户所有在线的连接
+	listenOnce  sync.Once
+)
+
+// 订阅某个用户的事件, 使用完后需要调用 Close
+// 第一次订阅时才开始监听 redis 的频道
+func Subscribe(uid string) *Subscriber {
+	listenOnce.Do(func() {
+		go listen(redis.Client.Subscribe(Channel))
+	})
+
+	c := make(chan Event, SubscriberBuffer)
+
+	s := &Subscriber{
+		Uid: uid,
+		C:   c,
+		c:   c,
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := subscribers[uid]; !ok {
+		subscribers[uid] = map[*Subscriber]struct{}{}
+	}
+
+	subscribers[uid][s] = struct{}{}
+
+	return s
+}
+
+// 取消订阅, 可以重复调用
+func (s *Subscriber) Close() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	s.remove()
+}
+
+// 必须在持有锁的情况下调用
+func (s *Subscriber) remove() {
+	set, ok := subscribers[s.Uid]
+
+	if !ok {
+		return
+	}
+
+	if _, ok := set[s]; !ok {
+		return
+	}
+
+	delete(set, s)
+
+	if len(set) == 0 {
+		delete(subscribers, s.Uid)
+	}
+
+	close(s.c)
+}
+
+// 当前实例在线的连接数
+func Online() (count int) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, set := range subscribers {
+		count = count + len(set)
+	}
+
+	return
+}
+
+// 把事件分发给当前实例上对应的连接
+func dispatch(event Event) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sets := make([]map[*Subscriber]struct{}, 0)
+
+	if event.Uid == "" {
+		for _, set := range subscribers {
+			sets = append(sets, set)
+		}
+	} else if set, ok := subscribers[event.Uid]; ok {
+		sets = append(sets, set)
+	}
+
+	for _, set := range sets {
+		for s := range set {
+			select {
+			case s.c <- event:
+			default:
+				// 客户端消费太慢, 断开连接
+				s.remove()
+			}
+		}
+	}
+}
+
+// 监听 redis 的频道, 其他实例发布的事件也会从这里收到
+func listen(pubsub *goRedis.PubSub) {
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		event := Event{}
+
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Println(err)
+			continue
+		}
+
+		dispatch(event)
+	}
+}
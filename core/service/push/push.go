@@ -0,0 +1,121 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package push
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/service/redis"
+	goRedis "github.com/go-redis/redis"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventMessage      EventType = "message"      // 收到新的个人消息
+	EventNotification EventType = "notification" // 发布了新的系统通知
+	EventTransfer     EventType = "transfer"     // 收到一笔转账
+	EventHeartbeat    EventType = "heartbeat"    // 心跳, 不会被记录和补发
+)
+
+var (
+	Channel           = "push"           // redis 发布订阅的频道, 所有 user_server 实例都订阅这个频道
+	HistoryLimit      = 100              // 每个用户保留最近的事件数量, 用于断线重连后补发
+	HistoryExpiration = time.Hour * 24   // 事件的保留时长
+	HeartbeatInterval = time.Second * 30 // 心跳间隔
+
+	keyEventId   = "push:id"                // 自增的事件 ID
+	keyHistory   = "push:history:"          // 用户的事件记录, 后面跟用户 ID
+	keyBroadcast = "push:history:broadcast" // 广播事件的记录
+)
+
+// 推送给客户端的事件
+type Event struct {
+	Id        int64           `json:"id"`            // 事件 ID, 全局递增, 用于断线重连
+	Type      EventType       `json:"type"`          // 事件类型
+	Uid       string          `json:"uid,omitempty"` // 接收事件的用户, 为空则为广播
+	Data      json.RawMessage `json:"data"`          // 事件的内容
+	CreatedAt string          `json:"created_at"`
+}
+
+func historyKey(uid string) string {
+	if uid == "" {
+		return keyBroadcast
+	}
+
+	return keyHistory + uid
+}
+
+// 发布一个事件, uid 为空则推送给所有在线的用户
+func Publish(eventType EventType, uid string, data interface{}) (err error) {
+	var (
+		raw []byte
+		id  int64
+	)
+
+	if raw, err = json.Marshal(data); err != nil {
+		return
+	}
+
+	if id, err = redis.Client.Incr(keyEventId).Result(); err != nil {
+		return
+	}
+
+	event := Event{
+		Id:        id,
+		Type:      eventType,
+		Uid:       uid,
+		Data:      raw,
+		CreatedAt: time.Now().Format(time.RFC3339Nano),
+	}
+
+	if raw, err = json.Marshal(event); err != nil {
+		return
+	}
+
+	key := historyKey(uid)
+
+	pipe := redis.Client.TxPipeline()
+
+	pipe.ZAdd(key, goRedis.Z{Score: float64(id), Member: raw})
+	pipe.ZRemRangeByRank(key, 0, int64(-HistoryLimit-1))
+	pipe.Expire(key, HistoryExpiration)
+	pipe.Publish(Channel, raw)
+
+	_, err = pipe.Exec()
+
+	return
+}
+
+// 获取某个用户在 lastEventId 之后的事件, 包括广播的事件, 按事件 ID 升序排列
+func History(uid string, lastEventId int64) (list []Event, err error) {
+	list = make([]Event, 0)
+
+	for _, key := range []string{historyKey(uid), keyBroadcast} {
+		var members []string
+
+		if members, err = redis.Client.ZRangeByScore(key, goRedis.ZRangeBy{
+			Min: "(" + strconv.FormatInt(lastEventId, 10),
+			Max: "+inf",
+		}).Result(); err != nil {
+			return
+		}
+
+		for _, member := range members {
+			event := Event{}
+
+			if err = json.Unmarshal([]byte(member), &event); err != nil {
+				return
+			}
+
+			list = append(list, event)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+
+	return
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package push_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/service/push"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPublish(t *testing.T) {
+	uid := util.GenerateId()
+	otherUid := util.GenerateId()
+
+	assert.Nil(t, push.Publish(push.EventMessage, uid, map[string]string{"title": "first"}))
+	assert.Nil(t, push.Publish(push.EventMessage, otherUid, map[string]string{"title": "other"}))
+	assert.Nil(t, push.Publish(push.EventTransfer, uid, map[string]string{"title": "second"}))
+
+	// 只包含自己的事件和广播的事件
+	list, err := push.History(uid, 0)
+
+	assert.Nil(t, err)
+
+	mine := make([]push.Event, 0)
+
+	for i, event := range list {
+		assert.NotEqual(t, otherUid, event.Uid)
+
+		if i > 0 {
+			assert.True(t, list[i-1].Id < event.Id)
+		}
+
+		if event.Uid == uid {
+			mine = append(mine, event)
+		}
+	}
+
+	assert.Len(t, mine, 2)
+	assert.Equal(t, push.EventMessage, mine[0].Type)
+	assert.Equal(t, push.EventTransfer, mine[1].Type)
+
+	data := map[string]string{}
+
+	assert.Nil(t, json.Unmarshal(mine[1].Data, &data))
+	assert.Equal(t, "second", data["title"])
+
+	// 从某个事件之后开始补发
+	list, err = push.History(uid, mine[0].Id)
+
+	assert.Nil(t, err)
+
+	for _, event := range list {
+		assert.True(t, event.Id > mine[0].Id)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	uid := util.GenerateId()
+
+	subscriber := push.Subscribe(uid)
+
+	defer subscriber.Close()
+
+	assert.Nil(t, push.Publish(push.EventMessage, uid, map[string]string{"title": "hello"}))
+
+	for {
+		select {
+		case event := <-subscriber.C:
+			// 忽略其他测试发布的广播
+			if event.Uid != uid {
+				continue
+			}
+
+			assert.Equal(t, push.EventMessage, event.Type)
+
+			subscriber.Close()
+
+			// 取消订阅之后, 通道会被关闭
+			for range subscriber.C {
+			}
+
+			// 可以重复取消订阅
+			subscriber.Close()
+			return
+		case <-time.After(time.Second * 5):
+			t.Fatal("timeout")
+		}
+	}
+}
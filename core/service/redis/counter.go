@@ -0,0 +1,22 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package redis
+
+import (
+	"github.com/go-redis/redis"
+	"time"
+)
+
+// 计数加 1, 没有过期时间时设置过期时间, 两步在一个脚本中执行
+// 即使之前设置过期时间失败, 下一次计数也会补上, 不会出现永不过期的计数
+var incrWindowScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// 固定时间窗口内的计数, 返回加 1 之后的值, 用于频率限制
+func IncrWindow(client *redis.Client, key string, window time.Duration) (int64, error) {
+	return incrWindowScript.Run(client, []string{key}, int64(window/time.Millisecond)).Int64()
+}
@@ -13,6 +13,13 @@ var (
 	ClientAuthPhoneCode  *redis.Client // 存储手机验证码，存储结构 key: 验证码, value: 手机号
 	ClientResetCode      *redis.Client // 存储重置密码的
 	ClientOAuthCode      *redis.Client // 存储 oAuth2 对应的激活码
+	ClientUnread         *redis.Client // 缓存用户的未读数，存储结构 key: 用户 ID, value: 未读数
+	ClientThrottle       *redis.Client // 发送短信的频率限制，存储结构 key: 手机号或者 IP, value: 发送次数
+	ClientQueue          *redis.Client // 使用 Redis Streams 的消息队列
+	ClientLock           *redis.Client // 分布式锁，存储结构 key: 锁的名称, value: 持有者的随机值
+	ClientContent        *redis.Client // 缓存正文渲染之后的 HTML，存储结构 key: 类型:ID:版本, value: HTML, 以及订阅源最后修改的时间
+	ClientCounter        *redis.Client // 还没有写入数据库的计数，存储结构 hash key: 计数名称, field: ID, value: 增量
+	ClientDeliveryCode   *redis.Client // 等待发送的验证码，存储结构 key: 发送记录 ID, value: 验证码
 	Config               = config.Redis
 )
 
@@ -59,4 +66,45 @@ func init() {
 		DB:       5,
 	})
 
+	ClientUnread = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       6,
+	})
+
+	ClientThrottle = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       7,
+	})
+
+	ClientQueue = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       8,
+	})
+
+	ClientLock = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       9,
+	})
+
+	ClientContent = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       10,
+	})
+
+	ClientCounter = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       11,
+	})
+
+	ClientDeliveryCode = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       12,
+	})
 }
@@ -0,0 +1,192 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 解析之后的 cron 表达式, 每个字段用一个位图表示允许的值
+type Schedule struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool // 日期是否为 *
+	dowStar bool // 星期是否为 *
+}
+
+type bounds struct {
+	min int
+	max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 7} // 0 和 7 都表示星期天
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// 解析 cron 表达式, 格式为 `分 时 日 月 星期`, 支持 *, 范围 a-b, 步长 /n 和列表 a,b
+// 也支持 @yearly, @monthly, @weekly, @daily 和 @hourly
+// 日期和星期都不为 * 时, 满足其中一个即可, 和标准的 cron 一致
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if v, ok := descriptors[spec]; ok {
+		spec = v
+	}
+
+	fields := strings.Fields(spec)
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression `%s`, expected 5 fields", spec)
+	}
+
+	var (
+		s   = &Schedule{}
+		err error
+	)
+
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+
+	// 7 也是星期天
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow | 1
+	}
+
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return s, nil
+}
+
+func parseField(field string, b bounds) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var (
+			start = b.min
+			end   = b.max
+			step  = 1
+		)
+
+		rangeAndStep := strings.SplitN(part, "/", 2)
+
+		if len(rangeAndStep) == 2 {
+			if step, err = strconv.Atoi(rangeAndStep[1]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step `%s`", part)
+			}
+		}
+
+		switch r := rangeAndStep[0]; {
+		case r == "*":
+		case strings.Contains(r, "-"):
+			lowAndHigh := strings.SplitN(r, "-", 2)
+
+			if start, err = strconv.Atoi(lowAndHigh[0]); err != nil {
+				return 0, fmt.Errorf("invalid range `%s`", part)
+			}
+
+			if end, err = strconv.Atoi(lowAndHigh[1]); err != nil {
+				return 0, fmt.Errorf("invalid range `%s`", part)
+			}
+		default:
+			if start, err = strconv.Atoi(r); err != nil {
+				return 0, fmt.Errorf("invalid value `%s`", part)
+			}
+
+			// 单个值带步长时表示从这个值开始到最大值
+			if len(rangeAndStep) == 1 {
+				end = start
+			}
+		}
+
+		if start < b.min || end > b.max || start > end {
+			return 0, fmt.Errorf("value `%s` out of range %d-%d", part, b.min, b.max)
+		}
+
+		for i := start; i <= end; i += step {
+			bits = bits | 1<<uint(i)
+		}
+	}
+
+	return
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// 获取 t 之后下一次运行的时间, 精确到分钟. 5 年内都不会运行则返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package scheduler_test
+
+import (
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"* * * * *", "*/5 * * * *", "0 0 1,15 * *", "30 9-18/3 * * 1-5", "0 0 * * 7", "@daily", "@hourly", "5/10 * * * *"} {
+		_, err := scheduler.Parse(spec)
+		assert.Nil(t, err, spec)
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "a * * * *", "5-1 * * * *", "@every"} {
+		_, err := scheduler.Parse(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	loc := time.UTC
+
+	// 2019-12-31 是星期二
+	now := time.Date(2019, 12, 31, 10, 7, 30, 0, loc)
+
+	cases := map[string]time.Time{
+		"* * * * *":    time.Date(2019, 12, 31, 10, 8, 0, 0, loc),
+		"*/15 * * * *": time.Date(2019, 12, 31, 10, 15, 0, 0, loc),
+		"5 0 * * *":    time.Date(2020, 1, 1, 0, 5, 0, 0, loc),
+		"0 9 * * 1":    time.Date(2020, 1, 6, 9, 0, 0, 0, loc),
+		"0 9 * * 7":    time.Date(2020, 1, 5, 9, 0, 0, 0, loc),
+		"0 0 29 2 *":   time.Date(2020, 2, 29, 0, 0, 0, 0, loc),
+		"@monthly":     time.Date(2020, 1, 1, 0, 0, 0, 0, loc),
+		"@yearly":      time.Date(2020, 1, 1, 0, 0, 0, 0, loc),
+		// 日期和星期都指定时满足其中一个即可
+		"0 12 15 * 3": time.Date(2020, 1, 1, 12, 0, 0, 0, loc),
+	}
+
+	for spec, expect := range cases {
+		s, err := scheduler.Parse(spec)
+
+		if !assert.Nil(t, err, spec) {
+			continue
+		}
+
+		assert.Equal(t, expect, s.Next(now), spec)
+	}
+
+	// 不存在的日期
+	s, err := scheduler.Parse("0 0 31 2 *")
+
+	assert.Nil(t, err)
+	assert.True(t, s.Next(now).IsZero())
+}
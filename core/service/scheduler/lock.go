@@ -0,0 +1,42 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package scheduler
+
+import (
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/util"
+	goRedis "github.com/go-redis/redis"
+	"time"
+)
+
+// 只有持有者才能释放锁, 避免锁过期之后释放了其他进程的锁
+var releaseScript = goRedis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+else
+	return 0
+end
+`)
+
+// 基于 Redis 的分布式锁
+type Lock struct {
+	key   string
+	token string
+}
+
+// 尝试获取锁, 锁已经被其他进程持有则返回 false. 锁在 ttl 之后自动过期, 防止进程崩溃之后无法释放
+func TryLock(key string, ttl time.Duration) (*Lock, bool, error) {
+	l := &Lock{key: key, token: util.GenerateId()}
+
+	ok, err := redis.ClientLock.SetNX(key, l.token, ttl).Result()
+
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	return l, true, nil
+}
+
+// 释放锁
+func (l *Lock) Release() error {
+	return releaseScript.Run(redis.ClientLock, []string{l.key}, l.token).Err()
+}
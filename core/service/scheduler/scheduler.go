@@ -0,0 +1,307 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/redis"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 触发的方式
+const (
+	TriggerSchedule = "schedule" // 按计划运行
+	TriggerManual   = "manual"   // 管理员手动触发
+)
+
+// 任务默认的最长运行时间
+const DefaultTimeout = time.Minute * 10
+
+// 定时任务
+type Task struct {
+	Name        string                          // 任务名称, 不能重复
+	Spec        string                          // cron 表达式, 使用进程所在的时区
+	Description string                          // 任务的描述
+	Timeout     time.Duration                   // 最长运行时间, 超时之后 ctx 会被取消, 默认为 DefaultTimeout
+	Run         func(ctx context.Context) error // 运行任务
+	schedule    *Schedule
+}
+
+// 获取 after 之后下一次运行的时间
+func (t Task) Next(after time.Time) time.Time {
+	return t.schedule.Next(after)
+}
+
+var (
+	registry = map[string]Task{}
+	mu       sync.RWMutex
+
+	// 当前的进程, 记录在运行记录中
+	instance = func() string {
+		hostname, _ := os.Hostname()
+		return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}()
+)
+
+// 注册定时任务, 表达式错误或者名称重复会 panic
+func Register(task Task) {
+	if task.Name == "" || task.Run == nil {
+		panic("invalid task")
+	}
+
+	schedule, err := Parse(task.Spec)
+
+	if err != nil {
+		panic(fmt.Sprintf("invalid spec for task '%s': %v", task.Name, err))
+	}
+
+	task.schedule = schedule
+
+	if task.Timeout <= 0 {
+		task.Timeout = DefaultTimeout
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[task.Name]; ok {
+		panic(fmt.Sprintf("task '%s' has been registered", task.Name))
+	}
+
+	registry[task.Name] = task
+}
+
+// 获取所有的定时任务, 按照名称排序
+func Tasks() []Task {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Task, 0, len(registry))
+
+	for _, task := range registry {
+		list = append(list, task)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// 获取定时任务
+func GetTask(name string) (Task, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	task, ok := registry[name]
+
+	return task, ok
+}
+
+func execute(ctx context.Context, task Task) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, task.Timeout)
+
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			switch t := r.(type) {
+			case string:
+				err = errors.New(t)
+			case error:
+				err = t
+			default:
+				err = exception.Unknown
+			}
+		}
+	}()
+
+	return task.Run(ctx)
+}
+
+// 运行一次任务并保存运行记录, 同一个任务在所有进程中同时只会运行一个
+// 任务正在运行时返回 exception.ScheduleTaskRunning, 任务失败时返回任务的错误
+func Run(ctx context.Context, task Task, trigger string) (run model.ScheduleRun, err error) {
+	lock, ok, err := TryLock("scheduler:running:"+task.Name, task.Timeout)
+
+	if err != nil {
+		return
+	}
+
+	if !ok {
+		err = exception.ScheduleTaskRunning
+		return
+	}
+
+	defer func() {
+		if er := lock.Release(); er != nil {
+			log.Printf("释放定时任务 %s 的锁失败: %v\n", task.Name, er)
+		}
+	}()
+
+	run = model.ScheduleRun{
+		Task:      task.Name,
+		Trigger:   trigger,
+		Instance:  instance,
+		Status:    model.ScheduleRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+
+	if err = database.Db.Create(&run).Error; err != nil {
+		return
+	}
+
+	runErr := execute(ctx, task)
+
+	finishedAt := time.Now()
+
+	run.FinishedAt = &finishedAt
+	run.Duration = int64(finishedAt.Sub(run.StartedAt) / time.Millisecond)
+	run.Status = model.ScheduleRunStatusSuccess
+
+	if runErr != nil {
+		run.Status = model.ScheduleRunStatusFail
+		run.Error = runErr.Error()
+	}
+
+	if err = database.Db.Model(&run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"error":       run.Error,
+		"duration":    run.Duration,
+		"finished_at": run.FinishedAt,
+	}).Error; err != nil {
+		return
+	}
+
+	err = runErr
+
+	return
+}
+
+// 按计划运行所有已注册的任务
+type Scheduler struct {
+	stop   chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// 开始按计划运行任务
+func Start() *Scheduler {
+	s := &Scheduler{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	go s.loop()
+
+	return s
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+
+	next := map[string]time.Time{}
+
+	for {
+		now := time.Now()
+
+		// 找到最近一次要运行的时间
+		earliest := now.Add(time.Minute)
+
+		for _, task := range Tasks() {
+			at, ok := next[task.Name]
+
+			if !ok {
+				at = task.Next(now)
+				next[task.Name] = at
+			}
+
+			if !at.IsZero() && at.Before(earliest) {
+				earliest = at
+			}
+		}
+
+		timer := time.NewTimer(time.Until(earliest))
+
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now = time.Now()
+
+		for _, task := range Tasks() {
+			at := next[task.Name]
+
+			if at.IsZero() || at.After(now) {
+				continue
+			}
+
+			next[task.Name] = task.Next(now)
+
+			s.wg.Add(1)
+
+			go func(task Task, at time.Time) {
+				defer s.wg.Done()
+				s.runScheduled(task, at)
+			}(task, at)
+		}
+	}
+}
+
+func (s *Scheduler) runScheduled(task Task, at time.Time) {
+	// 多个进程同时运行调度器时, 每一次计划的运行只由抢到的进程运行
+	key := fmt.Sprintf("scheduler:scheduled:%s:%d", task.Name, at.Unix())
+
+	ok, err := redis.ClientLock.SetNX(key, instance, time.Hour*24).Result()
+
+	if err != nil {
+		log.Printf("定时任务 %s 获取锁失败: %v\n", task.Name, err)
+		return
+	}
+
+	if !ok {
+		return
+	}
+
+	if _, err := Run(s.ctx, task, TriggerSchedule); err != nil {
+		log.Printf("定时任务 %s 运行失败: %v\n", task.Name, err)
+	}
+}
+
+// 停止调度, 并等待正在运行的任务完成. ctx 结束时仍未完成的任务会收到取消的信号
+func (s *Scheduler) Stop(ctx context.Context) {
+	close(s.stop)
+
+	<-s.done
+
+	finished := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		log.Println("等待定时任务完成超时")
+	}
+
+	s.cancel()
+}
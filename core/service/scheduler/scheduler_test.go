@@ -0,0 +1,134 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/scheduler"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	scheduler.Register(scheduler.Task{
+		Name: "test_register",
+		Spec: "@daily",
+		Run: func(ctx context.Context) error {
+			return nil
+		},
+	})
+
+	task, ok := scheduler.GetTask("test_register")
+
+	assert.True(t, ok)
+	assert.Equal(t, scheduler.DefaultTimeout, task.Timeout)
+	assert.False(t, task.Next(time.Now()).IsZero())
+
+	// 重复注册
+	assert.Panics(t, func() {
+		scheduler.Register(task)
+	})
+
+	// 错误的表达式
+	assert.Panics(t, func() {
+		scheduler.Register(scheduler.Task{
+			Name: "test_invalid",
+			Spec: "* * *",
+			Run: func(ctx context.Context) error {
+				return nil
+			},
+		})
+	})
+}
+
+func TestRun(t *testing.T) {
+	var (
+		fail    = errors.New("fail")
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	scheduler.Register(scheduler.Task{
+		Name: "test_run",
+		Spec: "@daily",
+		Run: func(ctx context.Context) error {
+			return nil
+		},
+	})
+
+	scheduler.Register(scheduler.Task{
+		Name: "test_run_fail",
+		Spec: "@daily",
+		Run: func(ctx context.Context) error {
+			return fail
+		},
+	})
+
+	scheduler.Register(scheduler.Task{
+		Name: "test_run_slow",
+		Spec: "@daily",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+
+	defer database.DeleteRowByTable("schedule_run", "task", "test_run")
+	defer database.DeleteRowByTable("schedule_run", "task", "test_run_fail")
+	defer database.DeleteRowByTable("schedule_run", "task", "test_run_slow")
+
+	// 运行成功
+	{
+		task, _ := scheduler.GetTask("test_run")
+
+		run, err := scheduler.Run(context.Background(), task, scheduler.TriggerManual)
+
+		assert.Nil(t, err)
+		assert.Equal(t, model.ScheduleRunStatusSuccess, run.Status)
+		assert.Equal(t, scheduler.TriggerManual, run.Trigger)
+		assert.NotNil(t, run.FinishedAt)
+
+		saved := model.ScheduleRun{Id: run.Id}
+
+		assert.Nil(t, database.Db.First(&saved).Error)
+		assert.Equal(t, model.ScheduleRunStatusSuccess, saved.Status)
+	}
+
+	// 运行失败
+	{
+		task, _ := scheduler.GetTask("test_run_fail")
+
+		run, err := scheduler.Run(context.Background(), task, scheduler.TriggerSchedule)
+
+		assert.Equal(t, fail, err)
+		assert.Equal(t, model.ScheduleRunStatusFail, run.Status)
+		assert.Equal(t, fail.Error(), run.Error)
+	}
+
+	// 同一个任务同时只能运行一个
+	{
+		task, _ := scheduler.GetTask("test_run_slow")
+
+		done := make(chan error)
+
+		go func() {
+			_, err := scheduler.Run(context.Background(), task, scheduler.TriggerManual)
+			done <- err
+		}()
+
+		<-started
+
+		_, err := scheduler.Run(context.Background(), task, scheduler.TriggerManual)
+
+		assert.Equal(t, exception.ScheduleTaskRunning, err)
+
+		close(release)
+
+		assert.Nil(t, <-done)
+	}
+}
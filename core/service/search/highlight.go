@@ -0,0 +1,113 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	HighlightStart = "<mark>"  // 高亮的开始标签
+	HighlightEnd   = "</mark>" // 高亮的结束标签
+	SnippetLength  = 120       // 摘要的长度, 单位是字符
+	snippetBefore  = 20        // 摘要中第一个关键词之前保留的字符数
+)
+
+// 标记文本中出现关键词的位置, 不区分大小写
+func mark(runes []rune, keywords []string) []bool {
+	marked := make([]bool, len(runes))
+	lower := make([]rune, len(runes))
+
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	for _, keyword := range keywords {
+		k := []rune(keyword)
+
+		if len(k) == 0 {
+			continue
+		}
+
+		for i := 0; i+len(k) <= len(lower); i++ {
+			if string(lower[i:i+len(k)]) == keyword {
+				for j := i; j < i+len(k); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	return marked
+}
+
+// 转义 HTML, 并用 <mark> 包裹关键词
+func render(runes []rune, marked []bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(runes); {
+		j := i
+
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+
+		text := html.EscapeString(string(runes[i:j]))
+
+		if marked[i] {
+			b.WriteString(HighlightStart + text + HighlightEnd)
+		} else {
+			b.WriteString(text)
+		}
+
+		i = j
+	}
+
+	return b.String()
+}
+
+// 高亮整段文本中的关键词, 用于标题
+func Highlight(text string, keywords []string) string {
+	runes := []rune(text)
+
+	return render(runes, mark(runes, keywords))
+}
+
+// 截取第一个关键词附近的一段文本作为摘要, 并高亮其中的关键词
+// 内容中没有关键词时截取开头的一段
+func Snippet(text string, keywords []string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	marked := mark(runes, keywords)
+
+	start := 0
+
+	for i, m := range marked {
+		if m {
+			start = i - snippetBefore
+			break
+		}
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + SnippetLength
+
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	snippet := render(runes[start:end], marked[start:end])
+
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+
+	if end < len(runes) {
+		snippet = snippet + "..."
+	}
+
+	return snippet
+}
@@ -0,0 +1,259 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/content"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"unicode"
+)
+
+// 可以搜索的内容类型, 同时也是对应的表名
+const (
+	TypeNews         = "news"         // 新闻公告
+	TypeHelp         = "help"         // 帮助文章
+	TypeNotification = "notification" // 系统通知
+)
+
+var Types = []string{TypeNews, TypeHelp, TypeNotification}
+
+const (
+	Column      = "search_vector" // 保存分词结果的列
+	maxPosition = 16383           // tsvector 中位置的最大值
+	batchSize   = 500             // 重建索引时每批处理的数量
+)
+
+func IsValidType(t string) bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Postgres 自带的分词器不能切分中文, 所以在这里分词, 直接生成 tsvector 和 tsquery, 不再经过数据库的分词器
+// 英文和数字按照单词切分并转为小写, 中日韩文字按照相邻的两个字切分 (二元分词), 例如 "帮助中心" -> 帮助 助中 中心
+func Tokenize(text string) []string {
+	return tokenize(text, true)
+}
+
+// 分词, unigram 为 true 时中日韩文字额外输出单个字, 使得搜索单个字时也能匹配
+func tokenize(text string, unigram bool) []string {
+	var (
+		tokens = make([]string, 0)
+		word   = make([]rune, 0)
+		cjk    = make([]rune, 0)
+	)
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i < len(cjk)-1; i++ {
+				if unigram {
+					tokens = append(tokens, string(cjk[i]))
+				}
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+			if unigram {
+				tokens = append(tokens, string(cjk[len(cjk)-1]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// 词只包含字母和数字, 不需要转义
+func quote(token string) string {
+	return "'" + token + "'"
+}
+
+// 生成 tsvector 的文本, 标题的权重为 A, 内容的权重为 B
+func Vector(title string, content string) string {
+	var (
+		b        strings.Builder
+		position = 0
+	)
+
+	write := func(text string, weight string) {
+		for _, token := range Tokenize(text) {
+			if position < maxPosition {
+				position++
+			}
+
+			if b.Len() > 0 {
+				b.WriteString(" ")
+			}
+
+			b.WriteString(fmt.Sprintf("%s:%d%s", quote(token), position, weight))
+		}
+	}
+
+	write(title, "A")
+	write(content, "B")
+
+	return b.String()
+}
+
+// 生成 tsquery 的文本, 所有的词都要匹配, 英文和数字按照前缀匹配
+// 没有可以搜索的词时返回空字符串
+func Query(q string) string {
+	tokens := Keywords(q)
+
+	for i, token := range tokens {
+		if isCJK([]rune(token)[0]) {
+			tokens[i] = quote(token)
+		} else {
+			tokens[i] = quote(token) + ":*"
+		}
+	}
+
+	return strings.Join(tokens, " & ")
+}
+
+// 搜索的关键词, 去掉重复的词
+func Keywords(q string) []string {
+	var (
+		result = make([]string, 0)
+		exist  = map[string]bool{}
+	)
+
+	for _, token := range tokenize(q, false) {
+		if exist[token] {
+			continue
+		}
+		exist[token] = true
+		result = append(result, token)
+	}
+
+	return result
+}
+
+// 更新一条记录的索引, 正文按照格式渲染之后只索引其中的纯文本, 标签和属性不参与搜索
+func Index(db *gorm.DB, t string, id string, title string, format model.ContentFormat, source string) error {
+	return db.Table(t).Where("id = ?", id).UpdateColumn(Column, gorm.Expr("?::tsvector", Vector(title, Text(format, source)))).Error
+}
+
+// 按照数据库中最新的内容更新一条记录的索引, 记录不存在时忽略
+func Refresh(db *gorm.DB, t string, id string) error {
+	rows := make([]row, 0)
+
+	if err := db.Table(t).Select("id, title, format, content").Where("id = ?", id).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		if err := Index(db, t, r.Id, r.Title, r.Format, r.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 正文渲染之后的纯文本, 用于建立索引和生成摘要
+func Text(format model.ContentFormat, source string) string {
+	if format == model.ContentFormatPlain {
+		return source
+	}
+
+	return content.Text(content.Render(format, source))
+}
+
+// 添加索引的列和 GIN 索引, 并为还没有索引的记录建立索引, 在同步数据库时调用
+func Migrate(db *gorm.DB) error {
+	for _, t := range Types {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s tsvector", t, Column)).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s USING GIN (%s)", t, Column, t, Column)).Error; err != nil {
+			return err
+		}
+
+		if _, err := reindex(db, t, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 重建某个类型的所有索引, 分词的规则改变之后调用, 返回处理的数量
+func Reindex(db *gorm.DB, t string) (int, error) {
+	return reindex(db, t, false)
+}
+
+type row struct {
+	Id      string
+	Title   string
+	Format  model.ContentFormat
+	Content string
+}
+
+func reindex(db *gorm.DB, t string, onlyMissing bool) (count int, err error) {
+	last := ""
+
+	for {
+		rows := make([]row, 0)
+
+		query := db.Table(t).Select("id, title, format, content").Where("id > ?", last)
+
+		if onlyMissing {
+			query = query.Where(Column + " IS NULL")
+		}
+
+		if err = query.Order("id ASC").Limit(batchSize).Scan(&rows).Error; err != nil {
+			return
+		}
+
+		for _, r := range rows {
+			if err = Index(db, t, r.Id, r.Title, r.Format, r.Content); err != nil {
+				return
+			}
+		}
+
+		count = count + len(rows)
+
+		if len(rows) < batchSize {
+			return
+		}
+
+		last = rows[len(rows)-1].Id
+	}
+}
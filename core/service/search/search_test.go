@@ -0,0 +1,52 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package search_test
+
+import (
+	"github.com/axetroy/go-server/core/service/search"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{}, search.Tokenize(""))
+	assert.Equal(t, []string{"hello", "world", "2019"}, search.Tokenize("Hello, World! 2019"))
+	assert.Equal(t, []string{"帮"}, search.Tokenize("帮"))
+	assert.Equal(t, []string{"帮", "帮助", "助", "助中", "中", "中心", "心"}, search.Tokenize("帮助中心"))
+	assert.Equal(t, []string{"如", "如何", "何", "何重", "重", "重置", "置", "password"}, search.Tokenize("如何重置password"))
+}
+
+func TestKeywords(t *testing.T) {
+	assert.Equal(t, []string{}, search.Keywords("  ,.  "))
+	assert.Equal(t, []string{"帮助", "助中", "中心", "api"}, search.Keywords("帮助中心 API api"))
+	assert.Equal(t, []string{"帮"}, search.Keywords("帮"))
+}
+
+func TestVector(t *testing.T) {
+	assert.Equal(t, "'go':1A 'server':2A '文':3B '文档':4B '档':5B", search.Vector("Go-Server", "文档"))
+	assert.Equal(t, "", search.Vector("", ""))
+}
+
+func TestQuery(t *testing.T) {
+	assert.Equal(t, "", search.Query("!!!"))
+	assert.Equal(t, "'重置' & '置密' & '密码'", search.Query("重置密码"))
+	assert.Equal(t, "'重置' & '置密' & '密码' & 'api':*", search.Query("重置密码 API"))
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "如何<mark>重置密码</mark>", search.Highlight("如何重置密码", search.Keywords("重置密码")))
+	assert.Equal(t, "<mark>Go</mark> &lt;server&gt;", search.Highlight("Go <server>", search.Keywords("go")))
+	assert.Equal(t, "no match", search.Highlight("no match", search.Keywords("重置")))
+}
+
+func TestSnippet(t *testing.T) {
+	content := strings.Repeat("a", 100) + "重置密码" + strings.Repeat("b", 200)
+
+	snippet := search.Snippet(content, search.Keywords("重置密码"))
+
+	assert.True(t, strings.HasPrefix(snippet, "..."+strings.Repeat("a", 20)+"<mark>重置密码</mark>"))
+	assert.True(t, strings.HasSuffix(snippet, "b..."))
+
+	// 没有关键词时截取开头
+	assert.Equal(t, "short text", search.Snippet("short\n text", search.Keywords("重置")))
+}
@@ -3,9 +3,10 @@ package telephone
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/dysmsapi"
 	"github.com/axetroy/go-server/core/config"
-	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/message_template"
 )
 
 func NewAliyun() *Aliyun {
@@ -17,6 +18,10 @@ func NewAliyun() *Aliyun {
 type Aliyun struct {
 }
 
+func (c *Aliyun) getProvider() provider {
+	return providerAliyun
+}
+
 func (c *Aliyun) getAuthTemplateID() string {
 	return config.Telephone.Aliyun.TemplateCodeAuth
 }
@@ -29,11 +34,15 @@ func (c *Aliyun) getRegisterTemplateID() string {
 	return config.Telephone.Aliyun.TemplateCodeRegister
 }
 
-func (c *Aliyun) send(phone string, templateID string, templateMap map[string]string) error {
+func (c *Aliyun) getNotificationTemplateID() string {
+	return config.Telephone.Aliyun.TemplateCodeNotification
+}
+
+func (c *Aliyun) send(phone string, templateID string, templateMap map[string]string) (string, error) {
 	aliClient, err := dysmsapi.NewClientWithAccessKey("cn-hangzhou", config.Telephone.Aliyun.AccessKeyId, config.Telephone.Aliyun.AccessSecret)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	request := dysmsapi.CreateSendSmsRequest()
@@ -46,34 +55,51 @@ func (c *Aliyun) send(phone string, templateID string, templateMap map[string]st
 	b, err := json.Marshal(templateMap)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	request.TemplateParam = string(b)
 
 	res, err := aliClient.SendSms(request)
 
-	if err != nil || !res.IsSuccess() {
-		return exception.SendMsgFail
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	// 发送回执 ID 可以用于查询发送状态, 没有回执 ID 时使用请求 ID
+	requestId := res.BizId
+
+	if requestId == "" {
+		requestId = res.RequestId
+	}
+
+	if !res.IsSuccess() || res.Code != "OK" {
+		return requestId, fmt.Errorf("aliyun: %s %s", res.Code, res.Message)
+	}
+
+	return requestId, nil
 }
 
 func (c *Aliyun) SendAuthCode(phone string, code string) error {
-	return c.send(phone, c.getAuthTemplateID(), map[string]string{
+	return deliver(c, phone, message_template.NameAuth, c.getAuthTemplateID(), map[string]string{
 		"code": code,
 	})
 }
 
 func (c *Aliyun) SendResetPasswordCode(phone string, code string) error {
-	return c.send(phone, c.getResetPasswordTemplateID(), map[string]string{
+	return deliver(c, phone, message_template.NameForgotPassword, c.getResetPasswordTemplateID(), map[string]string{
 		"code": code,
 	})
 }
 
 func (c *Aliyun) SendRegisterCode(phone string, code string) error {
-	return c.send(phone, c.getRegisterTemplateID(), map[string]string{
+	return deliver(c, phone, message_template.NameRegister, c.getRegisterTemplateID(), map[string]string{
 		"code": code,
 	})
 }
+
+func (c *Aliyun) SendNotification(phone string, content string) error {
+	return deliver(c, phone, message_template.NameNotification, c.getNotificationTemplateID(), map[string]string{
+		"content": content,
+	})
+}
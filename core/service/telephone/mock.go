@@ -0,0 +1,132 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package telephone
+
+import (
+	"github.com/axetroy/go-server/core/service/message_template"
+	"github.com/axetroy/go-server/core/util"
+	"sync"
+	"time"
+)
+
+// 内存中最多保留的短信数量, 超过之后丢弃最早的
+var MockMessageLimit = 1000
+
+// 不会真正发送的短信服务商, 用于本地开发和测试
+// 发送的短信会保存在内存中, 同时和其他服务商一样写入短信记录, 管理员可以在短信记录中查看
+type Mock struct {
+	mu       sync.Mutex
+	messages []MockMessage
+}
+
+type MockMessage struct {
+	Phone       string            `json:"phone"`        // 接收的手机号
+	TemplateID  string            `json:"template_id"`  // 服务商的模版 ID
+	TemplateMap map[string]string `json:"template_map"` // 模版参数
+	RequestId   string            `json:"request_id"`   // 请求 ID
+	CreatedAt   time.Time         `json:"created_at"`   // 发送时间
+}
+
+func NewMock() *Mock {
+	return &Mock{
+		messages: make([]MockMessage, 0),
+	}
+}
+
+func (c *Mock) getProvider() provider {
+	return providerMock
+}
+
+func (c *Mock) getAuthTemplateID() string {
+	return message_template.NameAuth
+}
+
+func (c *Mock) getResetPasswordTemplateID() string {
+	return message_template.NameForgotPassword
+}
+
+func (c *Mock) getRegisterTemplateID() string {
+	return message_template.NameRegister
+}
+
+func (c *Mock) getNotificationTemplateID() string {
+	return message_template.NameNotification
+}
+
+func (c *Mock) send(phone string, templateID string, templateMap map[string]string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := MockMessage{
+		Phone:       phone,
+		TemplateID:  templateID,
+		TemplateMap: templateMap,
+		RequestId:   "mock-" + util.GenerateId(),
+		CreatedAt:   time.Now(),
+	}
+
+	if len(c.messages) >= MockMessageLimit {
+		c.messages = c.messages[len(c.messages)-MockMessageLimit+1:]
+	}
+
+	c.messages = append(c.messages, msg)
+
+	return msg.RequestId, nil
+}
+
+// 获取已经发送的短信
+func (c *Mock) Messages() []MockMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := make([]MockMessage, len(c.messages))
+
+	copy(list, c.messages)
+
+	return list
+}
+
+// 获取最后一条发送到这个手机号的短信
+func (c *Mock) Last(phone string) (MockMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if c.messages[i].Phone == phone {
+			return c.messages[i], true
+		}
+	}
+
+	return MockMessage{}, false
+}
+
+// 清空已经发送的短信
+func (c *Mock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = make([]MockMessage, 0)
+}
+
+func (c *Mock) SendAuthCode(phone string, code string) error {
+	return deliver(c, phone, message_template.NameAuth, c.getAuthTemplateID(), map[string]string{
+		"code": code,
+	})
+}
+
+func (c *Mock) SendResetPasswordCode(phone string, code string) error {
+	return deliver(c, phone, message_template.NameForgotPassword, c.getResetPasswordTemplateID(), map[string]string{
+		"code": code,
+	})
+}
+
+func (c *Mock) SendRegisterCode(phone string, code string) error {
+	return deliver(c, phone, message_template.NameRegister, c.getRegisterTemplateID(), map[string]string{
+		"code": code,
+	})
+}
+
+func (c *Mock) SendNotification(phone string, content string) error {
+	return deliver(c, phone, message_template.NameNotification, c.getNotificationTemplateID(), map[string]string{
+		"content": content,
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package telephone_test
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/telephone"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	client := telephone.NewMock()
+
+	phone := "13800000000"
+
+	assert.Nil(t, client.SendAuthCode(phone, "123456"))
+
+	msg, ok := client.Last(phone)
+
+	assert.True(t, ok)
+	assert.Equal(t, "123456", msg.TemplateMap["code"])
+	assert.Len(t, client.Messages(), 1)
+
+	// 同时写入了短信记录
+	smsLog := model.SMSLog{}
+
+	assert.Nil(t, database.Db.Where("request_id = ?", msg.RequestId).First(&smsLog).Error)
+
+	defer database.DeleteRowByTable("sms_log", "id", smsLog.Id)
+
+	assert.Equal(t, "mock", smsLog.Provider)
+	assert.Equal(t, phone, smsLog.Phone)
+	assert.Equal(t, model.SMSLogStatusSuccess, smsLog.Status)
+	// 记录中不保存验证码
+	assert.Equal(t, "您的验证码是 "+telephone.MaskedValue+", 请勿泄露给他人", smsLog.Content)
+
+	client.Reset()
+
+	_, ok = client.Last(phone)
+
+	assert.False(t, ok)
+}
+
+func TestMockLimit(t *testing.T) {
+	client := telephone.NewMock()
+
+	phone := "13800000000"
+
+	limit := telephone.MockMessageLimit
+
+	telephone.MockMessageLimit = 3
+
+	defer func() {
+		telephone.MockMessageLimit = limit
+	}()
+
+	defer database.Db.Where("phone = ? AND provider = ?", phone, "mock").Delete(&model.SMSLog{})
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, client.SendNotification(phone, "test"))
+	}
+
+	// 只保留最近的短信
+	assert.Len(t, client.Messages(), 3)
+}
@@ -4,26 +4,37 @@ package telephone
 import (
 	"fmt"
 	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/message_template"
 	"log"
 )
 
 type provider string
 
+// 短信记录中代替模版参数的内容
+const MaskedValue = "******"
+
 var (
 	client          *Telephone             // 发送短信的客户端
 	providerAliyun  provider   = "aliyun"  // 阿里云
 	providerTencent provider   = "tencent" // 腾讯云
+	providerMock    provider   = "mock"    // 不发送短信, 只记录下来, 用于本地开发和测试
 )
 
 // 邮箱提供这应提供的对象
 type Telephone interface {
-	getAuthTemplateID() string                                                 // 身份验证的模版 ID
-	getResetPasswordTemplateID() string                                        // 重置密码的模版 ID
-	getRegisterTemplateID() string                                             // 注册帐号的模版 ID
-	send(phone string, templateID string, templateMap map[string]string) error // 发送验证码
-	SendRegisterCode(phone string, code string) error                          // 发送注册验证码
-	SendAuthCode(phone string, code string) error                              // 发送身份验证码
-	SendResetPasswordCode(phone string, code string) error                     // 发送重置密码验证码
+	getProvider() provider                                                                             // 服务商的名称
+	getAuthTemplateID() string                                                                         // 身份验证的模版 ID
+	getResetPasswordTemplateID() string                                                                // 重置密码的模版 ID
+	getRegisterTemplateID() string                                                                     // 注册帐号的模版 ID
+	getNotificationTemplateID() string                                                                 // 通知的模版 ID
+	send(phone string, templateID string, templateMap map[string]string) (requestId string, err error) // 调用服务商发送短信, 返回服务商的请求 ID
+	SendRegisterCode(phone string, code string) error                                                  // 发送注册验证码
+	SendAuthCode(phone string, code string) error                                                      // 发送身份验证码
+	SendResetPasswordCode(phone string, code string) error                                             // 发送重置密码验证码
+	SendNotification(phone string, content string) error                                               // 发送通知
 }
 
 func init() {
@@ -34,6 +45,9 @@ func init() {
 	case providerTencent:
 		initClient(NewTencent())
 		break
+	case providerMock:
+		initClient(NewMock())
+		break
 	default:
 		log.Fatal(fmt.Sprintf(`Invalid telephone provider "%s"`, config.Telephone.Provider))
 	}
@@ -46,3 +60,66 @@ func initClient(s Telephone) {
 func GetClient() Telephone {
 	return *client
 }
+
+// 获取短信模版对应的服务商模版 ID, 模版中没有设置则使用配置中的模版 ID
+func templateID(name string, fallback string) string {
+	tpl, err := message_template.Find(database.Db, message_template.ChannelSMS, name, "")
+
+	if err != nil || tpl.ExternalId == "" {
+		return fallback
+	}
+
+	return tpl.ExternalId
+}
+
+// 使用模版发送短信, 并记录到短信记录中
+// 服务商返回的错误只记录下来, 返回给调用者的是统一的错误
+func deliver(c Telephone, phone string, name string, fallbackTemplateID string, templateMap map[string]string) error {
+	id := templateID(name, fallbackTemplateID)
+
+	requestId, err := c.send(phone, id, templateMap)
+
+	smsLog := model.SMSLog{
+		Provider:   string(c.getProvider()),
+		Phone:      phone,
+		Template:   name,
+		TemplateId: id,
+		Content:    render(name, templateMap),
+		RequestId:  requestId,
+		Status:     model.SMSLogStatusSuccess,
+	}
+
+	if err != nil {
+		smsLog.Status = model.SMSLogStatusFail
+		smsLog.Error = err.Error()
+	}
+
+	if er := database.Db.Create(&smsLog).Error; er != nil {
+		log.Printf("保存短信记录失败: %v\n", er)
+	}
+
+	if err != nil {
+		log.Printf("发送短信到 %s 失败: %v\n", phone, err)
+		return exception.SendMsgFail
+	}
+
+	return nil
+}
+
+// 使用本地的模版渲染短信的内容, 仅用于记录
+// 模版参数中可能有验证码, 记录中的参数都会被隐藏
+func render(name string, templateMap map[string]string) string {
+	data := map[string]interface{}{}
+
+	for k := range templateMap {
+		data[k] = MaskedValue
+	}
+
+	msg, err := message_template.Render(message_template.ChannelSMS, name, "", data)
+
+	if err != nil {
+		return ""
+	}
+
+	return msg.Content
+}
@@ -7,7 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/axetroy/go-server/core/config"
-	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/message_template"
 	"github.com/axetroy/go-server/core/util"
 	"io/ioutil"
 	"net/http"
@@ -49,6 +49,10 @@ type tencentCloudResponse struct {
 	Sid    *string `json:"sid"`    // 本次发送标识 ID，标识一次短信下发记录
 }
 
+func (c *Tencent) getProvider() provider {
+	return providerTencent
+}
+
 func (c *Tencent) getAuthTemplateID() string {
 	return config.Telephone.Tencent.TemplateCodeAuth
 }
@@ -61,11 +65,15 @@ func (c *Tencent) getRegisterTemplateID() string {
 	return config.Telephone.Tencent.TemplateCodeRegister
 }
 
-func (c *Tencent) send(phone string, templateID string, templateMap map[string]string) error {
+func (c *Tencent) getNotificationTemplateID() string {
+	return config.Telephone.Tencent.TemplateCodeNotification
+}
+
+func (c *Tencent) send(phone string, templateID string, templateMap map[string]string) (string, error) {
 	tplId, err := strconv.Atoi(templateID)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	appKey := config.Telephone.Tencent.AppKey
@@ -78,13 +86,20 @@ func (c *Tencent) send(phone string, templateID string, templateMap map[string]s
 	_, err = h.Write([]byte(fmt.Sprintf("appkey=%s&random=%s&time=%d&mobile=%s", appKey, randomStr, unixTIme, phone)))
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	sig := h.Sum(nil)
 
+	// 腾讯云的模版参数是按顺序的数组, 目前的模版都只有一个参数
+	templateParams := make([]string, 0)
+
+	for _, v := range templateMap {
+		templateParams = append(templateParams, v)
+	}
+
 	params := tencentCloudParams{
-		Params: []string{},
+		Params: templateParams,
 		Sig:    string(sig),
 		Sign:   config.Telephone.Tencent.Sign,
 		Tel: tencentTel{
@@ -98,51 +113,69 @@ func (c *Tencent) send(phone string, templateID string, templateMap map[string]s
 	b, err := json.Marshal(params)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	body := bytes.NewReader(b)
 
 	r, err := http.Post(fmt.Sprintf("https://yun.tim.qq.com/v5/tlssmssvr/sendsms?sdkappid=%s&random=%s", appKey, randomStr), "application/json", body)
 
-	if err != nil || r.StatusCode != http.StatusOK {
-		return exception.SendMsgFail
+	if err != nil {
+		return "", err
+	}
+
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("tencent: http status %d", r.StatusCode)
 	}
 
 	resBytes, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	res := tencentCloudResponse{}
 
 	if err := json.Unmarshal(resBytes, &res); err != nil {
-		return err
+		return "", err
+	}
+
+	requestId := ""
+
+	if res.Sid != nil {
+		requestId = *res.Sid
 	}
 
 	// 非 0 表示失败
 	if res.Result != 0 {
-		return exception.SendMsgFail
+		return requestId, fmt.Errorf("tencent: %d %s", res.Result, res.ErrMsg)
 	}
 
-	return nil
+	return requestId, nil
 }
 
 func (c *Tencent) SendAuthCode(phone string, code string) error {
-	return c.send(phone, c.getAuthTemplateID(), map[string]string{
+	return deliver(c, phone, message_template.NameAuth, c.getAuthTemplateID(), map[string]string{
 		"code": code,
 	})
 }
 
 func (c *Tencent) SendResetPasswordCode(phone string, code string) error {
-	return c.send(phone, c.getResetPasswordTemplateID(), map[string]string{
+	return deliver(c, phone, message_template.NameForgotPassword, c.getResetPasswordTemplateID(), map[string]string{
 		"code": code,
 	})
 }
 
 func (c *Tencent) SendRegisterCode(phone string, code string) error {
-	return c.send(phone, c.getRegisterTemplateID(), map[string]string{
+	return deliver(c, phone, message_template.NameRegister, c.getRegisterTemplateID(), map[string]string{
 		"code": code,
 	})
 }
+
+func (c *Tencent) SendNotification(phone string, content string) error {
+	return deliver(c, phone, message_template.NameNotification, c.getNotificationTemplateID(), map[string]string{
+		"content": content,
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package telephone
+
+import (
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/redis"
+	"time"
+)
+
+// 发送验证码之前检查发送频率, 超过限制返回 exception.SendMsgTooOften
+// 同一个 IP 每小时最多发送 config.Telephone.IPLimit 次, 同一个手机号在 config.Telephone.Cooldown 秒内只能发送一次
+// IP 为空时不检查 IP 的限制, 例如用户已经登陆的情况
+func Throttle(phone string, ip string) error {
+	key := "phone:" + phone
+	cooldown := time.Duration(config.Telephone.Cooldown) * time.Second
+
+	// 先检查手机号的冷却时间, 冷却中的请求不占用 IP 的次数
+	if cooldown > 0 {
+		exist, err := redis.ClientThrottle.Exists(key).Result()
+
+		if err != nil {
+			return err
+		}
+
+		if exist > 0 {
+			return exception.SendMsgTooOften
+		}
+	}
+
+	if ip != "" && config.Telephone.IPLimit > 0 {
+		// 第一次发送时开始计时
+		count, err := redis.IncrWindow(redis.ClientThrottle, "ip:"+ip, time.Hour)
+
+		if err != nil {
+			return err
+		}
+
+		if count > int64(config.Telephone.IPLimit) {
+			return exception.SendMsgTooOften
+		}
+	}
+
+	// 同时发送的请求只有一个能设置成功
+	if cooldown > 0 {
+		ok, err := redis.ClientThrottle.SetNX(key, 1, cooldown).Result()
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return exception.SendMsgTooOften
+		}
+	}
+
+	return nil
+}
+
+// 清除手机号的冷却时间, 例如验证码没有发送成功
+func ResetThrottle(phone string) {
+	_ = redis.ClientThrottle.Del("phone:" + phone).Err()
+}
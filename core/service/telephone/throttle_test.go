@@ -0,0 +1,53 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package telephone_test
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/config"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/service/redis"
+	"github.com/axetroy/go-server/core/service/telephone"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestThrottle(t *testing.T) {
+	phone := "13800000001"
+	ip := "10.0.0.1"
+
+	defer redis.ClientThrottle.Del("phone:"+phone, "ip:"+ip)
+
+	// 同一个手机号在冷却时间内只能发送一次
+	assert.Nil(t, telephone.Throttle(phone, ""))
+	assert.Equal(t, exception.SendMsgTooOften, telephone.Throttle(phone, ""))
+
+	// 清除冷却时间之后可以再次发送
+	telephone.ResetThrottle(phone)
+
+	assert.Nil(t, telephone.Throttle(phone, ""))
+
+	telephone.ResetThrottle(phone)
+
+	// 同一个 IP 超过了每小时的次数
+	for i := 0; i < config.Telephone.IPLimit; i++ {
+		p := fmt.Sprintf("1390000%04d", i)
+
+		assert.Nil(t, telephone.Throttle(p, ip))
+
+		telephone.ResetThrottle(p)
+	}
+
+	assert.Equal(t, exception.SendMsgTooOften, telephone.Throttle(phone, ip))
+
+	// 超过 IP 的限制不会占用手机号的冷却时间
+	exist, err := redis.ClientThrottle.Exists("phone:" + phone).Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), exist)
+
+	// IP 的计数有过期时间
+	ttl, err := redis.ClientThrottle.TTL("ip:" + ip).Result()
+
+	assert.Nil(t, err)
+	assert.True(t, ttl > 0)
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var (
+	// 是否允许请求内网地址, 只应该在测试中开启
+	AllowPrivateAddress = false
+
+	ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+	// 不允许请求的网段, 防止通过 webhook 访问内网的服务和云服务器的元数据
+	forbiddenNetworks = parseCIDRs(
+		"0.0.0.0/8",      // 本网络
+		"10.0.0.0/8",     // 私有网络
+		"100.64.0.0/10",  // 运营商级 NAT, 阿里云的元数据服务也在这里
+		"127.0.0.0/8",    // 本机
+		"169.254.0.0/16", // 链路本地, 包括 169.254.169.254 的元数据服务
+		"172.16.0.0/12",  // 私有网络
+		"192.0.0.0/24",   // IETF 协议分配
+		"192.168.0.0/16", // 私有网络
+		"198.18.0.0/15",  // 基准测试
+		"224.0.0.0/4",    // 组播
+		"240.0.0.0/4",    // 保留, 包括广播地址
+		"::/128",         // 未指定地址
+		"::1/128",        // 本机
+		"fc00::/7",       // 唯一本地地址
+		"fe80::/10",      // 链路本地
+		"ff00::/8",       // 组播
+	)
+)
+
+func parseCIDRs(list ...string) []*net.IPNet {
+	result := make([]*net.IPNet, 0, len(list))
+
+	for _, s := range list {
+		_, n, err := net.ParseCIDR(s)
+
+		if err != nil {
+			panic(err)
+		}
+
+		result = append(result, n)
+	}
+
+	return result
+}
+
+// 是否为不允许请求的地址
+func IsForbiddenIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, n := range forbiddenNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 在建立连接之前检查解析之后的 IP, 这样域名解析到内网地址或者 DNS 重绑定都会被拒绝
+func control(_ string, address string, _ syscall.RawConn) error {
+	if AllowPrivateAddress {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil || IsForbiddenIP(ip) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   Timeout,
+		KeepAlive: time.Second * 30,
+		Control:   control,
+	}
+
+	return &http.Client{
+		Timeout: Timeout,
+		Transport: &http.Transport{
+			// 不使用环境变量中的代理, 否则检查的是代理的地址
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       time.Second * 90,
+			TLSHandshakeTimeout:   time.Second * 10,
+			ExpectContinueTimeout: time.Second,
+		},
+		// 不跟随重定向, 否则可以通过重定向访问内网地址, 3xx 视为发送失败
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
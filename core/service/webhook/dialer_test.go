@@ -0,0 +1,32 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"github.com/axetroy/go-server/core/service/webhook"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestIsForbiddenIP(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.100.100.200":  true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"::ffff:127.0.0.1": true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"8.8.8.8":          false,
+		"172.32.0.1":       false,
+		"2001:4860::8888":  false,
+	}
+
+	for ip, forbidden := range cases {
+		assert.Equal(t, forbidden, webhook.IsForbiddenIP(net.ParseIP(ip)), ip)
+	}
+}
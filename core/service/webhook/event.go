@@ -0,0 +1,48 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/schema"
+)
+
+// 可以订阅的事件
+const (
+	EventUserSignUp          = "user.signup"           // 用户注册, 数据为 schema.Profile
+	EventUserBindPhone       = "user.bind_phone"       // 用户绑定手机号, 数据为 BindPhoneData
+	EventTransferSent        = "transfer.sent"         // 用户转出, 数据为 schema.TransferLog
+	EventTransferReceived    = "transfer.received"     // 用户收到转账, 数据为 schema.TransferLog
+	EventReportStatusChanged = "report.status_changed" // 反馈的状态改变, 数据为 ReportStatusChangedData
+)
+
+// 所有的事件
+var Events = []string{
+	EventUserSignUp,
+	EventUserBindPhone,
+	EventTransferSent,
+	EventTransferReceived,
+	EventReportStatusChanged,
+}
+
+// 用户绑定手机号的数据
+type BindPhoneData struct {
+	Uid   string `json:"uid"`   // 用户ID
+	Phone string `json:"phone"` // 绑定的手机号
+}
+
+// 反馈的状态改变的数据
+type ReportStatusChangedData struct {
+	schema.Report
+	PreviousStatus model.ReportStatus `json:"previous_status"` // 改变之前的状态
+}
+
+// 是否是可以订阅的事件
+func IsValidEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
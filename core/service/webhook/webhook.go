@@ -0,0 +1,281 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/message_queue"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/util"
+	"github.com/jinzhu/gorm"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Webhook-Event"     // 事件类型
+	HeaderDelivery  = "X-Webhook-Delivery"  // 发送记录的 ID
+	HeaderTimestamp = "X-Webhook-Timestamp" // 发送的时间, Unix 时间戳, 单位秒
+	HeaderSignature = "X-Webhook-Signature" // 签名, 见 Sign
+)
+
+const (
+	MaxAttempts     = 6                // 最多尝试的次数
+	RetryDelay      = time.Second * 30 // 第一次重试前等待的时间, 之后每次翻倍
+	Timeout         = time.Second * 10 // 每次请求的超时时间
+	maxResponseBody = 1024             // 发送记录中保存的返回内容的长度
+)
+
+// 发送的内容
+type Envelope struct {
+	Id        string      `json:"id"`         // 事件ID
+	Event     string      `json:"event"`      // 事件类型
+	CreatedAt string      `json:"created_at"` // 事件发生的时间
+	Data      interface{} `json:"data"`       // 事件的数据, 格式由事件类型决定
+}
+
+var client = newClient()
+
+// 签名的内容为 `时间戳.请求体`, 使用订阅的密钥计算 HMAC-SHA256, 和 util.Signature 一样以十六进制表示
+// 接收方应该校验签名, 并拒绝时间戳相差太久的请求, 防止重放
+func Sign(secret string, timestamp string, body []byte) (string, error) {
+	return util.SignatureWithKey(secret, timestamp+"."+string(body))
+}
+
+// 校验签名, 用于接收方
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	expected, err := Sign(secret, timestamp, body)
+
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// 第几次失败之后等待多久再重试
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return RetryDelay << uint(attempts-1)
+}
+
+func publish(id string) error {
+	return message_queue.Enqueue(context.Background(), message_queue.WebhookBody{Id: id})
+}
+
+// 事件发生之后调用, 为每个订阅了该事件并且启用的 webhook 创建发送记录, 并加入消息队列
+// 发送失败不影响业务, 所以这里只记录日志, 不返回错误
+func Emit(event string, data interface{}) {
+	if err := emit(database.Db, event, data); err != nil {
+		log.Printf("webhook 事件 %s 发送失败: %v\n", event, err)
+	}
+}
+
+func emit(db *gorm.DB, event string, data interface{}) (err error) {
+	if !IsValidEvent(event) {
+		return exception.InvalidWebhookEvent
+	}
+
+	list := make([]model.Webhook, 0)
+
+	if err = db.Where("enabled = ? AND ? = ANY(events)", true, event).Find(&list).Error; err != nil {
+		return
+	}
+
+	if len(list) == 0 {
+		return
+	}
+
+	envelope := Envelope{
+		Id:        util.GenerateId(),
+		Event:     event,
+		CreatedAt: time.Now().Format(time.RFC3339Nano),
+		Data:      data,
+	}
+
+	var payload []byte
+
+	if payload, err = json.Marshal(envelope); err != nil {
+		return
+	}
+
+	for _, w := range list {
+		d := model.WebhookDelivery{
+			WebhookId: w.Id,
+			Event:     event,
+			EventId:   envelope.Id,
+			Payload:   string(payload),
+			Status:    model.WebhookDeliveryStatusPending,
+		}
+
+		if err = db.Create(&d).Error; err != nil {
+			return
+		}
+
+		// 加入队列失败时记录会保持等待发送的状态, 管理员可以重放
+		if er := publish(d.Id); er != nil {
+			log.Printf("webhook 发送记录 %s 加入队列失败: %v\n", d.Id, er)
+		}
+	}
+
+	return
+}
+
+// 发送一条记录, 由消息队列的消费者调用
+// 发送失败时返回错误, retryAfter 大于 0 则应该在这之后重试, 否则已经放弃
+// 已经发送成功或者已经放弃的记录会被忽略, 所以重复的消息不会重复发送
+func Deliver(db *gorm.DB, id string) (retryAfter time.Duration, err error) {
+	d := model.WebhookDelivery{Id: id}
+
+	if err = db.First(&d).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookDeliveryNotExist
+		}
+		return
+	}
+
+	if d.Status != model.WebhookDeliveryStatusPending {
+		return
+	}
+
+	w := model.Webhook{Id: d.WebhookId}
+
+	if err = db.First(&w).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookNotExist
+		}
+		return
+	}
+
+	start := time.Now()
+
+	status, body, sendErr := send(w, d)
+
+	d.Attempts = d.Attempts + 1
+
+	updates := map[string]interface{}{
+		"attempts":        d.Attempts,
+		"response_status": status,
+		"response_body":   body,
+		"duration":        int64(time.Since(start) / time.Millisecond),
+	}
+
+	if sendErr == nil {
+		now := time.Now()
+		updates["status"] = model.WebhookDeliveryStatusSuccess
+		updates["delivered_at"] = &now
+		updates["error"] = ""
+	} else {
+		updates["error"] = sendErr.Error()
+
+		if d.Attempts >= MaxAttempts {
+			updates["status"] = model.WebhookDeliveryStatusDead
+		} else {
+			retryAfter = Backoff(d.Attempts)
+		}
+	}
+
+	if err = db.Model(&d).Where("status = ?", model.WebhookDeliveryStatusPending).Updates(updates).Error; err != nil {
+		return
+	}
+
+	err = sendErr
+
+	return
+}
+
+func send(w model.Webhook, d model.WebhookDelivery) (status int, body string, err error) {
+	var (
+		req       *http.Request
+		res       *http.Response
+		signature string
+		timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+		payload   = []byte(d.Payload)
+	)
+
+	if signature, err = Sign(w.Secret, timestamp, payload); err != nil {
+		return
+	}
+
+	if req, err = http.NewRequest(http.MethodPost, w.Url, bytes.NewReader(payload)); err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, d.Id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, signature)
+
+	if res, err = client.Do(req); err != nil {
+		return
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+
+	status = res.StatusCode
+	// 返回的内容会保存到数据库中, 去掉无效的 UTF-8 和数据库不支持的 \x00
+	body = strings.Replace(strings.ToValidUTF8(string(b), "\uFFFD"), "\x00", "", -1)
+
+	if status < 200 || status >= 300 {
+		err = errors.New(fmt.Sprintf("unexpected status code %d", status))
+	}
+
+	return
+}
+
+// 重放一条记录, 创建一条新的发送记录, 事件ID和内容不变
+func Replay(db *gorm.DB, id string) (d model.WebhookDelivery, err error) {
+	origin := model.WebhookDelivery{Id: id}
+
+	if err = db.First(&origin).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookDeliveryNotExist
+		}
+		return
+	}
+
+	w := model.Webhook{Id: origin.WebhookId}
+
+	if err = db.First(&w).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.WebhookNotExist
+		}
+		return
+	}
+
+	d = model.WebhookDelivery{
+		WebhookId: origin.WebhookId,
+		Event:     origin.Event,
+		EventId:   origin.EventId,
+		Payload:   origin.Payload,
+		Status:    model.WebhookDeliveryStatusPending,
+	}
+
+	if err = db.Create(&d).Error; err != nil {
+		return
+	}
+
+	err = publish(d.Id)
+
+	return
+}
@@ -0,0 +1,227 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package webhook_test
+
+import (
+	"encoding/json"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/webhook"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-webhook-secret"
+
+// 本地的接收方, 校验签名和时间戳, 并记录收到的内容
+type receiver struct {
+	sync.Mutex
+	server   *httptest.Server
+	status   int
+	received []webhook.Envelope
+	headers  []http.Header
+}
+
+func newReceiver(status int) *receiver {
+	r := &receiver{status: status}
+
+	// 接收方在本机
+	webhook.AllowPrivateAddress = true
+
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		timestamp := req.Header.Get(webhook.HeaderTimestamp)
+
+		if !webhook.Verify(testSecret, timestamp, body, req.Header.Get(webhook.HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// 拒绝时间相差超过 5 分钟的请求
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+
+		if err != nil || time.Since(time.Unix(unix, 0)) > time.Minute*5 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		envelope := webhook.Envelope{}
+
+		_ = json.Unmarshal(body, &envelope)
+
+		r.Lock()
+		r.received = append(r.received, envelope)
+		r.headers = append(r.headers, req.Header)
+		r.Unlock()
+
+		w.WriteHeader(r.status)
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	return r
+}
+
+func createWebhook(t *testing.T, url string, events ...string) model.Webhook {
+	w := model.Webhook{
+		Url:     url,
+		Events:  events,
+		Secret:  testSecret,
+		Enabled: true,
+	}
+
+	assert.Nil(t, database.Db.Create(&w).Error)
+
+	return w
+}
+
+func deleteWebhook(id string) {
+	database.DeleteRowByTable("webhook_delivery", "webhook_id", id)
+	database.DeleteRowByTable("webhook", "id", id)
+}
+
+func findDeliveries(t *testing.T, webhookId string) []model.WebhookDelivery {
+	list := make([]model.WebhookDelivery, 0)
+
+	assert.Nil(t, database.Db.Where("webhook_id = ?", webhookId).Order("created_at asc").Find(&list).Error)
+
+	return list
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+
+	signature, err := webhook.Sign(testSecret, "1570000000", body)
+
+	assert.Nil(t, err)
+	assert.Len(t, signature, 64)
+
+	assert.True(t, webhook.Verify(testSecret, "1570000000", body, signature))
+	assert.False(t, webhook.Verify("another-secret", "1570000000", body, signature))
+	assert.False(t, webhook.Verify(testSecret, "1570000001", body, signature))
+	assert.False(t, webhook.Verify(testSecret, "1570000000", []byte(`{"id":"2"}`), signature))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, webhook.RetryDelay, webhook.Backoff(0))
+	assert.Equal(t, webhook.RetryDelay, webhook.Backoff(1))
+	assert.Equal(t, webhook.RetryDelay*4, webhook.Backoff(3))
+}
+
+func TestDeliver(t *testing.T) {
+	r := newReceiver(http.StatusOK)
+
+	defer r.server.Close()
+
+	w := createWebhook(t, r.server.URL, webhook.EventUserBindPhone)
+
+	defer deleteWebhook(w.Id)
+
+	// 没有订阅的事件不会发送
+	webhook.Emit(webhook.EventUserSignUp, map[string]string{})
+
+	assert.Len(t, findDeliveries(t, w.Id), 0)
+
+	webhook.Emit(webhook.EventUserBindPhone, webhook.BindPhoneData{Uid: "123", Phone: "13800000000"})
+
+	list := findDeliveries(t, w.Id)
+
+	if !assert.Len(t, list, 1) {
+		return
+	}
+
+	d := list[0]
+
+	assert.Equal(t, model.WebhookDeliveryStatusPending, d.Status)
+
+	retryAfter, err := webhook.Deliver(database.Db, d.Id)
+
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	assert.Nil(t, database.Db.First(&d).Error)
+	assert.Equal(t, model.WebhookDeliveryStatusSuccess, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusOK, d.ResponseStatus)
+	assert.Equal(t, "ok", d.ResponseBody)
+	assert.NotNil(t, d.DeliveredAt)
+
+	if assert.Len(t, r.received, 1) {
+		assert.Equal(t, d.EventId, r.received[0].Id)
+		assert.Equal(t, webhook.EventUserBindPhone, r.received[0].Event)
+		assert.Equal(t, map[string]interface{}{"uid": "123", "phone": "13800000000"}, r.received[0].Data)
+		assert.Equal(t, d.Id, r.headers[0].Get(webhook.HeaderDelivery))
+		assert.Equal(t, webhook.EventUserBindPhone, r.headers[0].Get(webhook.HeaderEvent))
+	}
+
+	// 已经发送成功的记录不会重复发送
+	_, err = webhook.Deliver(database.Db, d.Id)
+
+	assert.Nil(t, err)
+	assert.Len(t, r.received, 1)
+
+	// 重放会创建新的记录, 事件ID不变
+	replay, err := webhook.Replay(database.Db, d.Id)
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, d.Id, replay.Id)
+	assert.Equal(t, d.EventId, replay.EventId)
+	assert.Equal(t, d.Payload, replay.Payload)
+
+	_, err = webhook.Deliver(database.Db, replay.Id)
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, r.received, 2) {
+		assert.Equal(t, d.EventId, r.received[1].Id)
+	}
+}
+
+func TestDeliverRetry(t *testing.T) {
+	r := newReceiver(http.StatusInternalServerError)
+
+	defer r.server.Close()
+
+	w := createWebhook(t, r.server.URL, webhook.EventTransferSent)
+
+	defer deleteWebhook(w.Id)
+
+	webhook.Emit(webhook.EventTransferSent, map[string]string{"id": "123"})
+
+	list := findDeliveries(t, w.Id)
+
+	if !assert.Len(t, list, 1) {
+		return
+	}
+
+	d := list[0]
+
+	// 失败之后等待重试
+	retryAfter, err := webhook.Deliver(database.Db, d.Id)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, webhook.Backoff(1), retryAfter)
+
+	assert.Nil(t, database.Db.First(&d).Error)
+	assert.Equal(t, model.WebhookDeliveryStatusPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, d.ResponseStatus)
+	assert.NotEqual(t, "", d.Error)
+
+	// 最后一次仍然失败则放弃
+	assert.Nil(t, database.Db.Model(&d).Update("attempts", webhook.MaxAttempts-1).Error)
+
+	retryAfter, err = webhook.Deliver(database.Db, d.Id)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	assert.Nil(t, database.Db.First(&d).Error)
+	assert.Equal(t, model.WebhookDeliveryStatusDead, d.Status)
+	assert.Equal(t, webhook.MaxAttempts, d.Attempts)
+}
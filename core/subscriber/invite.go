@@ -0,0 +1,44 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package subscriber
+
+import (
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/exception"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+func init() {
+	event.Subscribe(event.NameUserRegistered, createInviteHistory)
+}
+
+// 用户使用邀请码注册时, 写入邀请列表中. 邀请码无效则注册失败
+func createInviteHistory(tx *gorm.DB, e event.Event) error {
+	registered := e.(event.UserRegistered)
+
+	if registered.InviteCode == nil || len(*registered.InviteCode) == 0 {
+		return nil
+	}
+
+	inviter := model.User{
+		InviteCode: *registered.InviteCode,
+	}
+
+	if err := tx.Where(&inviter).Find(&inviter).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = exception.InvalidInviteCode
+		}
+		return err
+	}
+
+	if inviter.Id == "" {
+		return nil
+	}
+
+	return tx.Create(&model.InviteHistory{
+		Inviter:       inviter.Id,
+		Invitee:       registered.User.Id,
+		Status:        model.StatusInviteRegistered,
+		RewardSettled: false,
+	}).Error
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package subscriber
+
+import (
+	"fmt"
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/service/dispatcher"
+	"github.com/axetroy/go-server/core/service/push"
+	"strings"
+)
+
+func init() {
+	event.SubscribeAsync(event.NameTransferCompleted, notifyTransfer)
+	event.SubscribeAsync(event.NamePasswordChanged, notifyPasswordChanged)
+}
+
+// 通知收款人
+func notifyTransfer(e event.Event) error {
+	completed := e.(event.TransferCompleted)
+
+	log := completed.Log
+
+	_ = push.Publish(push.EventTransfer, log.To, log)
+
+	_, err := dispatcher.Dispatch(dispatcher.Notification{
+		Uid:      log.To,
+		Category: dispatcher.CategoryTransfer,
+		Title:    "收到转账",
+		Content:  fmt.Sprintf("收到来自 %s 的转账 %s %s", completed.FromUsername, log.Amount, strings.ToUpper(log.Currency)),
+	})
+
+	return err
+}
+
+var passwordChangedTitles = map[event.PasswordChangeSource]string{
+	event.PasswordChangedByUser:  "登陆密码已修改",
+	event.PasswordChangedByAdmin: "登陆密码已被管理员修改",
+	event.PasswordChangedByReset: "登陆密码已重置",
+}
+
+// 通知用户账号安全的变动, 不受免打扰时段的限制
+func notifyPasswordChanged(e event.Event) error {
+	changed := e.(event.PasswordChanged)
+
+	title := passwordChangedTitles[changed.Source]
+
+	_, err := dispatcher.Dispatch(dispatcher.Notification{
+		Uid:      changed.Uid,
+		Category: dispatcher.CategorySecurity,
+		Title:    title,
+		Content:  title + ", 如果不是您本人的操作, 请立即修改密码并联系客服",
+		Urgent:   true,
+	})
+
+	return err
+}
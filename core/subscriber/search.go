@@ -0,0 +1,28 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package subscriber
+
+import (
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/service/database"
+	"github.com/axetroy/go-server/core/service/search"
+)
+
+func init() {
+	event.SubscribeAsync(event.NameNewsSaved, indexNews)
+	event.SubscribeAsync(event.NameHelpSaved, indexHelp)
+	event.SubscribeAsync(event.NameNotificationSaved, indexNotification)
+}
+
+// 在事务提交之后更新全文搜索的索引, 索引失败只记录日志, 不影响保存
+// 按照数据库中最新的内容建立索引, 避免并发保存时旧的事件覆盖新的索引
+func indexNews(e event.Event) error {
+	return search.Refresh(database.Db, search.TypeNews, e.(event.NewsSaved).News.Id)
+}
+
+func indexHelp(e event.Event) error {
+	return search.Refresh(database.Db, search.TypeHelp, e.(event.HelpSaved).Help.Id)
+}
+
+func indexNotification(e event.Event) error {
+	return search.Refresh(database.Db, search.TypeNotification, e.(event.NotificationSaved).Notification.Id)
+}
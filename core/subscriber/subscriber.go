@@ -0,0 +1,5 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+
+// 领域事件的订阅者, 在 init 中订阅事件
+// 发布事件的进程都需要引入该包, 否则事件发布之后没有订阅者处理
+package subscriber
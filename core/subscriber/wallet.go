@@ -0,0 +1,31 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package subscriber
+
+import (
+	"github.com/axetroy/go-server/core/controller/wallet"
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/model"
+	"github.com/jinzhu/gorm"
+)
+
+func init() {
+	event.Subscribe(event.NameUserRegistered, createWallets)
+}
+
+// 创建用户对应的钱包账号
+func createWallets(tx *gorm.DB, e event.Event) error {
+	registered := e.(event.UserRegistered)
+
+	for _, walletName := range model.Wallets {
+		if err := tx.Table(wallet.GetTableName(walletName)).Create(&model.Wallet{
+			Id:       registered.User.Id,
+			Currency: walletName,
+			Balance:  0,
+			Frozen:   0,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package subscriber
+
+import (
+	"github.com/axetroy/go-server/core/event"
+	"github.com/axetroy/go-server/core/schema"
+	"github.com/axetroy/go-server/core/service/webhook"
+	"github.com/mitchellh/mapstructure"
+	"time"
+)
+
+func init() {
+	event.SubscribeAsync(event.NameUserRegistered, emitUserSignUp)
+	event.SubscribeAsync(event.NamePhoneBound, emitBindPhone)
+	event.SubscribeAsync(event.NameTransferCompleted, emitTransfer)
+	event.SubscribeAsync(event.NameReportStatusChanged, emitReportStatusChanged)
+}
+
+func emitUserSignUp(e event.Event) error {
+	userInfo := e.(event.UserRegistered).User
+
+	data := schema.Profile{}
+
+	if err := mapstructure.Decode(userInfo, &data.ProfilePure); err != nil {
+		return err
+	}
+
+	data.PayPassword = userInfo.PayPassword != nil && len(*userInfo.PayPassword) != 0
+	data.CreatedAt = userInfo.CreatedAt.Format(time.RFC3339Nano)
+	data.UpdatedAt = userInfo.UpdatedAt.Format(time.RFC3339Nano)
+
+	webhook.Emit(webhook.EventUserSignUp, data)
+
+	return nil
+}
+
+func emitBindPhone(e event.Event) error {
+	bound := e.(event.PhoneBound)
+
+	webhook.Emit(webhook.EventUserBindPhone, webhook.BindPhoneData{
+		Uid:   bound.Uid,
+		Phone: bound.Phone,
+	})
+
+	return nil
+}
+
+func emitTransfer(e event.Event) error {
+	log := e.(event.TransferCompleted).Log
+
+	webhook.Emit(webhook.EventTransferSent, log)
+	webhook.Emit(webhook.EventTransferReceived, log)
+
+	return nil
+}
+
+func emitReportStatusChanged(e event.Event) error {
+	changed := e.(event.ReportStatusChanged)
+
+	webhook.Emit(webhook.EventReportStatusChanged, webhook.ReportStatusChangedData{
+		Report:         changed.Report,
+		PreviousStatus: changed.PreviousStatus,
+	})
+
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util
+
+import "strings"
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "=" // 没有改变的行
+	DiffInsert DiffOp = "+" // 新增的行
+	DiffDelete DiffOp = "-" // 删除的行
+)
+
+type DiffLine struct {
+	Op   DiffOp `json:"op"`   // 操作
+	Text string `json:"text"` // 行的内容
+}
+
+// 逐行对比两段文本, 返回从 a 变成 b 的最短编辑过程, 使用 Myers 算法
+func DiffLines(a string, b string) []DiffLine {
+	return diff(splitLines(a), splitLines(b))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	return strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+}
+
+func diff(a []string, b []string) []DiffLine {
+	return diffRange(a, b, make([]DiffLine, 0, len(a)+len(b)))
+}
+
+// 去掉相同的开头和结尾之后, 找到最短编辑路径中间的一个点, 分成两半递归对比
+// 只需要 O(n+m) 的内存, 不需要记录每一步的状态来回溯
+func diffRange(a []string, b []string, result []DiffLine) []DiffLine {
+	prefix := 0
+
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		result = append(result, DiffLine{Op: DiffEqual, Text: a[prefix]})
+		prefix++
+	}
+
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if len(a) > 0 && len(b) > 0 {
+		if x, y, ok := middle(a, b); ok {
+			result = diffRange(a[:x], b[:y], result)
+			result = diffRange(a[x:], b[y:], result)
+		} else {
+			result = appendLines(result, DiffDelete, a)
+			result = appendLines(result, DiffInsert, b)
+		}
+	} else {
+		result = appendLines(result, DiffDelete, a)
+		result = appendLines(result, DiffInsert, b)
+	}
+
+	return appendLines(result, DiffEqual, common)
+}
+
+func appendLines(result []DiffLine, op DiffOp, lines []string) []DiffLine {
+	for _, l := range lines {
+		result = append(result, DiffLine{Op: op, Text: l})
+	}
+
+	return result
+}
+
+// 从两端同时按照 Myers 算法搜索, 两个方向的路径重叠时返回重叠的点, 这个点在最短编辑路径上
+// 没有任何相同的行时返回 false
+func middle(a []string, b []string) (int, int, bool) {
+	var (
+		n      = len(a)
+		m      = len(b)
+		maxD   = (n + m + 1) / 2
+		offset = maxD
+		size   = 2*maxD + 2
+		v1     = make([]int, size) // 正向每条对角线能到达的最远的 x
+		v2     = make([]int, size) // 反向每条对角线能到达的最远的 x, 从末尾开始计算
+		delta  = n - m
+		front  = delta%2 != 0 // 总的编辑次数为奇数时, 在正向搜索中检查重叠
+	)
+
+	for i := range v1 {
+		v1[i] = -1
+		v2[i] = -1
+	}
+
+	v1[offset+1] = 0
+	v2[offset+1] = 0
+
+	// 超出范围的对角线不再搜索
+	var k1start, k1end, k2start, k2end int
+
+	for d := 0; d < maxD; d++ {
+		for k1 := -d + k1start; k1 <= d-k1end; k1 += 2 {
+			i := offset + k1
+
+			var x1 int
+
+			if k1 == -d || (k1 != d && v1[i-1] < v1[i+1]) {
+				x1 = v1[i+1]
+			} else {
+				x1 = v1[i-1] + 1
+			}
+
+			y1 := x1 - k1
+
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+
+			v1[i] = x1
+
+			if x1 > n {
+				k1end += 2
+			} else if y1 > m {
+				k1start += 2
+			} else if front {
+				j := offset + delta - k1
+
+				if j >= 0 && j < size && v2[j] != -1 && x1 >= n-v2[j] {
+					return x1, y1, true
+				}
+			}
+		}
+
+		for k2 := -d + k2start; k2 <= d-k2end; k2 += 2 {
+			i := offset + k2
+
+			var x2 int
+
+			if k2 == -d || (k2 != d && v2[i-1] < v2[i+1]) {
+				x2 = v2[i+1]
+			} else {
+				x2 = v2[i-1] + 1
+			}
+
+			y2 := x2 - k2
+
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+
+			v2[i] = x2
+
+			if x2 > n {
+				k2end += 2
+			} else if y2 > m {
+				k2start += 2
+			} else if !front {
+				j := offset + delta - k2
+
+				if j >= 0 && j < size && v1[j] != -1 {
+					x1 := v1[j]
+					y1 := x1 - (j - offset)
+
+					if x1 >= n-x2 {
+						return x1, y1, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util_test
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, []util.DiffLine{}, util.DiffLines("", ""))
+
+	assert.Equal(t, []util.DiffLine{
+		{Op: util.DiffInsert, Text: "a"},
+		{Op: util.DiffInsert, Text: "b"},
+	}, util.DiffLines("", "a\nb"))
+
+	assert.Equal(t, []util.DiffLine{
+		{Op: util.DiffDelete, Text: "a"},
+	}, util.DiffLines("a", ""))
+
+	assert.Equal(t, []util.DiffLine{
+		{Op: util.DiffEqual, Text: "a"},
+		{Op: util.DiffDelete, Text: "b"},
+		{Op: util.DiffInsert, Text: "x"},
+		{Op: util.DiffEqual, Text: "c"},
+		{Op: util.DiffInsert, Text: "d"},
+	}, util.DiffLines("a\nb\nc", "a\r\nx\r\nc\r\nd"))
+
+	// 把差异中的旧行和新行分别拼起来, 应该得到原来的两段文本
+	a := []string{"A", "B", "C", "A", "B", "B", "A"}
+	b := []string{"C", "B", "A", "B", "A", "C"}
+
+	var oldLines, newLines []string
+	edits := 0
+
+	for _, l := range util.DiffLines(join(a), join(b)) {
+		switch l.Op {
+		case util.DiffEqual:
+			oldLines = append(oldLines, l.Text)
+			newLines = append(newLines, l.Text)
+		case util.DiffDelete:
+			oldLines = append(oldLines, l.Text)
+			edits++
+		case util.DiffInsert:
+			newLines = append(newLines, l.Text)
+			edits++
+		}
+	}
+
+	assert.Equal(t, a, oldLines)
+	assert.Equal(t, b, newLines)
+	assert.Equal(t, 5, edits)
+}
+
+// 随机生成的文本, 编辑次数应该和最长公共子序列计算出的最少次数相同
+func TestDiffLinesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	random := func() []string {
+		lines := make([]string, r.Intn(30))
+
+		for i := range lines {
+			lines[i] = string(rune('A' + r.Intn(4)))
+		}
+
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+
+		var oldLines, newLines []string
+		edits := 0
+
+		for _, l := range util.DiffLines(join(a), join(b)) {
+			switch l.Op {
+			case util.DiffEqual:
+				oldLines = append(oldLines, l.Text)
+				newLines = append(newLines, l.Text)
+			case util.DiffDelete:
+				oldLines = append(oldLines, l.Text)
+				edits++
+			case util.DiffInsert:
+				newLines = append(newLines, l.Text)
+				edits++
+			}
+		}
+
+		// 空行拼接之后无法区分, 只比较有内容的情况
+		if len(a) > 0 {
+			assert.Equal(t, a, oldLines)
+		}
+
+		if len(b) > 0 {
+			assert.Equal(t, b, newLines)
+		}
+
+		assert.Equal(t, len(a)+len(b)-2*lcs(a, b), edits)
+	}
+}
+
+// 很长的文本只需要线性的内存
+func TestDiffLinesLarge(t *testing.T) {
+	a := make([]string, 100000)
+	b := make([]string, 0, len(a))
+
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+
+		if i%1000 != 0 {
+			b = append(b, a[i])
+		}
+	}
+
+	edits := 0
+
+	for _, l := range util.DiffLines(join(a), join(b)) {
+		if l.Op != util.DiffEqual {
+			edits++
+		}
+	}
+
+	assert.Equal(t, len(a)-len(b), edits)
+}
+
+func lcs(a []string, b []string) int {
+	dp := make([][]int, len(a)+1)
+
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				dp[i][j] = dp[i-1][j-1] + 1
+			} else if dp[i-1][j] > dp[i][j-1] {
+				dp[i][j] = dp[i-1][j]
+			} else {
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+
+	return dp[len(a)][len(b)]
+}
+
+func join(lines []string) string {
+	return strings.Join(lines, "\n")
+}
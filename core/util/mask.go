@@ -0,0 +1,21 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util
+
+import "strings"
+
+// 对名称进行打码, 只保留首尾各一个字符, 用于向他人展示用户的名称
+// 例如: 张三 -> 张*, 张小三 -> 张*三, axetroy -> a*****y
+func MaskName(name string) string {
+	runes := []rune(name)
+
+	switch len(runes) {
+	case 0:
+		return ""
+	case 1:
+		return "*"
+	case 2:
+		return string(runes[0]) + "*"
+	default:
+		return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+	}
+}
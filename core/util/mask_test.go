@@ -0,0 +1,16 @@
+// Copyright 2019 Axetroy. All rights reserved. MIT license.
+package util_test
+
+import (
+	"github.com/axetroy/go-server/core/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "", util.MaskName(""))
+	assert.Equal(t, "*", util.MaskName("a"))
+	assert.Equal(t, "张*", util.MaskName("张三"))
+	assert.Equal(t, "张*三", util.MaskName("张小三"))
+	assert.Equal(t, "a*****y", util.MaskName("axetroy"))
+}
@@ -13,6 +13,11 @@ var (
 )
 
 func Signature(input string) (string, error) {
+	return SignatureWithKey(key, input)
+}
+
+// 使用指定的密钥签名, 例如 webhook 使用订阅自己的密钥
+func SignatureWithKey(key string, input string) (string, error) {
 	h := hmac.New(sha256.New, []byte(key))
 
 	if _, err := io.WriteString(h, input); err != nil {
@@ -36,3 +36,21 @@ func TestSignature(t *testing.T) {
 		})
 	}
 }
+
+func TestSignatureWithKey(t *testing.T) {
+	got, err := util.SignatureWithKey("secret", "123")
+
+	if err != nil {
+		t.Errorf("SignatureWithKey() error = %v", err)
+		return
+	}
+
+	// 相同的内容使用不同的密钥, 签名不同
+	if other, _ := util.SignatureWithKey("another", "123"); other == got {
+		t.Errorf("SignatureWithKey() should depend on the key")
+	}
+
+	if len(got) != 64 {
+		t.Errorf("SignatureWithKey() got = %v, want a sha256 hex digest", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/axetroy/go-server/core/controller/admin"
 	"github.com/axetroy/go-server/core/controller/auth"
 	"github.com/axetroy/go-server/core/schema"
+	_ "github.com/axetroy/go-server/core/subscriber"
 	"github.com/axetroy/go-server/core/util"
 )
 